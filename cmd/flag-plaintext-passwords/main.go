@@ -0,0 +1,79 @@
+// Command flag-plaintext-passwords finds user accounts whose passwords are still stored in plaintext,
+// from before passwords were hashed. Each is replaced by its argon2id hash and the account is flagged
+// so the user must choose a new password before logging in: an admin issues a reset token through
+// POST /api/v1/users/:id/password-reset and the user redeems it at POST /api/v1/auth/password/reset.
+// Accounts whose users log in before it runs are upgraded at login and need no reset.
+//
+// Usage:
+//
+//	go run ./cmd/flag-plaintext-passwords [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"rota-api/config"
+	"rota-api/passhash"
+	"rota-api/repositories"
+	"rota-api/utils"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "list the accounts without changing them")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	passhash.Configure(passhash.Params{
+		Memory:      uint32(cfg.Password.Memory),
+		Iterations:  uint32(cfg.Password.Iterations),
+		Parallelism: uint8(cfg.Password.Parallelism),
+	})
+
+	db, err := utils.InitDB(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	userRepo := repositories.NewUserRepository(db)
+
+	ctx := context.Background()
+	users, err := userRepo.FindAll(ctx)
+	if err != nil {
+		log.Fatalf("Failed to list users: %v", err)
+	}
+
+	flagged := 0
+	for _, user := range users {
+		if user.Password == nil || passhash.IsHashed(*user.Password) {
+			continue
+		}
+		if *dryRun {
+			log.Printf("Plaintext password: user %d (%s)", user.ID, user.Email)
+			flagged++
+			continue
+		}
+
+		hashed, err := passhash.Hash(*user.Password)
+		if err != nil {
+			log.Fatalf("Failed to hash password of user %d: %v", user.ID, err)
+		}
+		changed, err := userRepo.RequirePasswordReset(ctx, user.ID, *user.Password, hashed)
+		if err != nil {
+			log.Fatalf("Failed to flag user %d: %v", user.ID, err)
+		}
+		if changed {
+			log.Printf("Password reset required: user %d (%s)", user.ID, user.Email)
+			flagged++
+		}
+	}
+
+	if *dryRun {
+		log.Printf("%d of %d accounts hold plaintext passwords, nothing changed (dry run)", flagged, len(users))
+		return
+	}
+	log.Printf("%d of %d accounts flagged for a password reset", flagged, len(users))
+}
@@ -0,0 +1,74 @@
+// Command issue-mfa-enrollment-token issues a single-use MFA enrollment token for an account, for when no
+// admin can issue one through POST /api/v1/users/:id/mfa-enrollment, such as the first admin of a new
+// deployment. The user enrolls their authenticator with it at POST /api/v1/auth/mfa/enroll.
+//
+// Usage:
+//
+//	go run ./cmd/issue-mfa-enrollment-token -email admin@example.com
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"rota-api/config"
+	"rota-api/repositories"
+	"rota-api/services"
+	"rota-api/utils"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func main() {
+	email := flag.String("email", "", "email of the account to enroll")
+	flag.Parse()
+	if *email == "" {
+		log.Fatal("-email is required")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	db, err := utils.InitDB(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", cfg.Redis.Host, cfg.Redis.Port),
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	defer redisClient.Close()
+
+	ctx := context.Background()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		log.Fatalf("Failed to connect to Redis: %v", err)
+	}
+
+	userRepo := repositories.NewUserRepository(db)
+	authService := services.NewAuthService(
+		userRepo,
+		repositories.NewMFARecoveryCodeRepository(db),
+		repositories.NewRedisRepository(redisClient),
+		services.AuthConfig{
+			JWTSecret: cfg.JWT.Secret,
+			MFA:       services.MFAPolicy{EnrollmentTTL: cfg.MFA.EnrollmentTTL},
+		},
+	)
+
+	user, err := userRepo.FindByEmail(ctx, *email)
+	if err != nil {
+		log.Fatalf("Failed to find user %s: %v", *email, err)
+	}
+	token, expiresAt, err := authService.IssueMFAEnrollmentToken(ctx, user.ID)
+	if err != nil {
+		log.Fatalf("Failed to issue enrollment token for user %d: %v", user.ID, err)
+	}
+
+	log.Printf("MFA enrollment token for user %d (%s), valid until %s", user.ID, user.Email, expiresAt.Format("2006-01-02 15:04 MST"))
+	fmt.Println(token)
+}
@@ -5,11 +5,18 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// RatePolicy allows Limit requests per Window, written as "limit/window" (e.g. "10/1m")
+type RatePolicy struct {
+	Limit  int
+	Window time.Duration
+}
+
 // Config holds all configuration for the application
 type Config struct {
 	Environment     string
@@ -24,6 +31,15 @@ type Config struct {
 	DBPassword string
 	DBName     string
 
+	// Agency clock: schedules are read and written in this timezone, and a service day
+	// runs from ServiceDayStart to ServiceDayStart the next morning. Migration 021 backfills
+	// service dates of existing trips with the defaults; it cannot read these settings
+	Agency struct {
+		Timezone        string `env:"AGENCY_TIMEZONE" envDefault:"Asia/Bangkok"`
+		Location        *time.Location
+		ServiceDayStart time.Duration `env:"SERVICE_DAY_START" envDefault:"3h"`
+	}
+
 	Redis struct {
 		Host     string `env:"REDIS_HOST" envDefault:"localhost"`
 		Port     string `env:"REDIS_PORT" envDefault:"6379"`
@@ -36,6 +52,65 @@ type Config struct {
 		AccessTokenTTL  time.Duration `env:"JWT_ACCESS_TOKEN_TTL" envDefault:"24h"`
 		RefreshTokenTTL time.Duration `env:"JWT_REFRESH_TOKEN_TTL" envDefault:"168h"` // 7 days
 	}
+
+	Roster struct {
+		MinRestBetweenTrips time.Duration `env:"DRIVER_MIN_REST" envDefault:"30m"`
+		MaxDailyDuty        time.Duration `env:"DRIVER_MAX_DAILY_DUTY" envDefault:"8h"`
+	}
+	Dispatch struct {
+		VehicleTurnaround time.Duration `env:"VEHICLE_TURNAROUND" envDefault:"15m"`
+	}
+	Cache struct {
+		TTL     time.Duration `env:"CACHE_TTL" envDefault:"5m"`
+		LRUSize int           `env:"CACHE_LRU_SIZE" envDefault:"1000"`
+	}
+	Display struct {
+		RefreshInterval time.Duration `env:"DISPLAY_REFRESH_INTERVAL" envDefault:"15s"`
+	}
+	RateLimit struct {
+		API          RatePolicy `env:"RATE_LIMIT_API_IP" envDefault:"600/1m"`
+		LoginIP      RatePolicy `env:"RATE_LIMIT_LOGIN_IP" envDefault:"10/1m"`
+		LoginAccount RatePolicy `env:"RATE_LIMIT_LOGIN_ACCOUNT" envDefault:"5/15m"`
+		RegisterIP   RatePolicy `env:"RATE_LIMIT_REGISTER_IP" envDefault:"5/1h"`
+		RefreshIP    RatePolicy `env:"RATE_LIMIT_REFRESH_IP" envDefault:"30/1m"`
+	}
+	// Reverse proxies (comma-separated IPs or CIDR ranges, e.g. the Kong network) whose X-Forwarded-For
+	// header is trusted for the client IP. Without any, the connecting address is the client IP
+	Proxy struct {
+		TrustedProxies []string `env:"TRUSTED_PROXIES" envDefault:""`
+	}
+	Idempotency struct {
+		TTL time.Duration `env:"IDEMPOTENCY_TTL" envDefault:"24h"`
+	}
+	Lockout struct {
+		MaxFailures  int           `env:"LOGIN_LOCKOUT_MAX_FAILURES" envDefault:"5"`
+		BaseDuration time.Duration `env:"LOGIN_LOCKOUT_BASE" envDefault:"1m"`
+		MaxDuration  time.Duration `env:"LOGIN_LOCKOUT_MAX" envDefault:"1h"`
+	}
+	// Multi-factor authentication: accounts with RequiredRoles (comma-separated, or "none") must use MFA
+	// to act with their role. A login waiting for its second factor is valid for ChallengeTTL, and an
+	// enrollment token an admin issues to such an account for EnrollmentTTL
+	MFA struct {
+		RequiredRoles []string      `env:"MFA_REQUIRED_ROLES" envDefault:"admin,staff"`
+		Issuer        string        `env:"MFA_ISSUER" envDefault:"Rota"`
+		ChallengeTTL  time.Duration `env:"MFA_CHALLENGE_TTL" envDefault:"5m"`
+		EnrollmentTTL time.Duration `env:"MFA_ENROLLMENT_TTL" envDefault:"24h"`
+	}
+	// Argon2id cost of new password hashes; Memory is in KiB. Existing hashes are upgraded at login.
+	// A password reset token an admin issues is valid for ResetTokenTTL
+	Password struct {
+		Memory        int           `env:"PASSWORD_ARGON2_MEMORY" envDefault:"65536"`
+		Iterations    int           `env:"PASSWORD_ARGON2_ITERATIONS" envDefault:"3"`
+		Parallelism   int           `env:"PASSWORD_ARGON2_PARALLELISM" envDefault:"4"`
+		ResetTokenTTL time.Duration `env:"PASSWORD_RESET_TOKEN_TTL" envDefault:"24h"`
+	}
+	// Admin reports: departures later than LateThreshold count as late, and a vehicle in service for
+	// VehicleHoursPerDay a day is fully utilized. UseSnapshot reads trips from the trip_actuals view
+	Reports struct {
+		LateThreshold      time.Duration `env:"REPORT_LATE_THRESHOLD" envDefault:"5m"`
+		VehicleHoursPerDay time.Duration `env:"REPORT_VEHICLE_HOURS_PER_DAY" envDefault:"18h"`
+		UseSnapshot        bool          `env:"REPORTS_USE_SNAPSHOT" envDefault:"false"`
+	}
 }
 
 // LoadConfig loads configuration from environment variables
@@ -69,6 +144,18 @@ func LoadConfig() (*Config, error) {
 		DBName:     getEnv("DB_NAME", "rota_dev"),
 	}
 
+	// Load agency clock
+	cfg.Agency.Timezone = getEnv("AGENCY_TIMEZONE", "Asia/Bangkok")
+	location, err := time.LoadLocation(cfg.Agency.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AGENCY_TIMEZONE %q: %v", cfg.Agency.Timezone, err)
+	}
+	cfg.Agency.Location = location
+	cfg.Agency.ServiceDayStart, err = time.ParseDuration(getEnv("SERVICE_DAY_START", "3h"))
+	if err != nil || cfg.Agency.ServiceDayStart < 0 || cfg.Agency.ServiceDayStart >= 24*time.Hour {
+		return nil, fmt.Errorf("invalid SERVICE_DAY_START %q: use a duration from 0h to below 24h", getEnv("SERVICE_DAY_START", "3h"))
+	}
+
 	// Load Redis configuration
 	cfg.Redis.Host = getEnv("REDIS_HOST", "localhost")
 	cfg.Redis.Port = getEnv("REDIS_PORT", "6379")
@@ -80,6 +167,85 @@ func LoadConfig() (*Config, error) {
 	cfg.JWT.AccessTokenTTL, _ = time.ParseDuration(getEnv("JWT_ACCESS_TOKEN_TTL", "24h"))
 	cfg.JWT.RefreshTokenTTL, _ = time.ParseDuration(getEnv("JWT_REFRESH_TOKEN_TTL", "168h"))
 
+	// Load driver roster rules
+	cfg.Roster.MinRestBetweenTrips, _ = time.ParseDuration(getEnv("DRIVER_MIN_REST", "30m"))
+	cfg.Roster.MaxDailyDuty, _ = time.ParseDuration(getEnv("DRIVER_MAX_DAILY_DUTY", "8h"))
+	cfg.Dispatch.VehicleTurnaround, _ = time.ParseDuration(getEnv("VEHICLE_TURNAROUND", "15m"))
+
+	// Load read-through cache settings
+	cfg.Cache.TTL, _ = time.ParseDuration(getEnv("CACHE_TTL", "5m"))
+	cfg.Cache.LRUSize = getEnvAsInt("CACHE_LRU_SIZE", 1000)
+
+	// Load station signage display settings
+	cfg.Display.RefreshInterval, _ = time.ParseDuration(getEnv("DISPLAY_REFRESH_INTERVAL", "15s"))
+	if cfg.Display.RefreshInterval <= 0 {
+		cfg.Display.RefreshInterval = 15 * time.Second
+	}
+
+	// Load rate limit policies
+	cfg.RateLimit.API = getEnvAsRatePolicy("RATE_LIMIT_API_IP", "600/1m")
+	cfg.RateLimit.LoginIP = getEnvAsRatePolicy("RATE_LIMIT_LOGIN_IP", "10/1m")
+	cfg.RateLimit.LoginAccount = getEnvAsRatePolicy("RATE_LIMIT_LOGIN_ACCOUNT", "5/15m")
+	cfg.RateLimit.RegisterIP = getEnvAsRatePolicy("RATE_LIMIT_REGISTER_IP", "5/1h")
+	cfg.RateLimit.RefreshIP = getEnvAsRatePolicy("RATE_LIMIT_REFRESH_IP", "30/1m")
+
+	// Load trusted reverse proxies
+	for _, proxy := range strings.Split(getEnv("TRUSTED_PROXIES", ""), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			cfg.Proxy.TrustedProxies = append(cfg.Proxy.TrustedProxies, proxy)
+		}
+	}
+
+	// Load how long responses are kept for replay to retries with the same Idempotency-Key
+	cfg.Idempotency.TTL, _ = time.ParseDuration(getEnv("IDEMPOTENCY_TTL", "24h"))
+	if cfg.Idempotency.TTL <= 0 {
+		cfg.Idempotency.TTL = 24 * time.Hour
+	}
+
+	// Load login lockout policy
+	cfg.Lockout.MaxFailures = getEnvAsInt("LOGIN_LOCKOUT_MAX_FAILURES", 5)
+	cfg.Lockout.BaseDuration, _ = time.ParseDuration(getEnv("LOGIN_LOCKOUT_BASE", "1m"))
+	cfg.Lockout.MaxDuration, _ = time.ParseDuration(getEnv("LOGIN_LOCKOUT_MAX", "1h"))
+
+	// Load password hashing cost
+	cfg.Password.Memory = getEnvAsInt("PASSWORD_ARGON2_MEMORY", 65536)
+	cfg.Password.Iterations = getEnvAsInt("PASSWORD_ARGON2_ITERATIONS", 3)
+	cfg.Password.Parallelism = getEnvAsInt("PASSWORD_ARGON2_PARALLELISM", 4)
+	if cfg.Password.Memory < 8*1024 || cfg.Password.Iterations < 1 || cfg.Password.Parallelism < 1 || cfg.Password.Parallelism > 255 {
+		return nil, fmt.Errorf("invalid password hashing cost: PASSWORD_ARGON2_MEMORY must be at least 8192 (KiB), PASSWORD_ARGON2_ITERATIONS at least 1 and PASSWORD_ARGON2_PARALLELISM from 1 to 255")
+	}
+	cfg.Password.ResetTokenTTL, _ = time.ParseDuration(getEnv("PASSWORD_RESET_TOKEN_TTL", "24h"))
+	if cfg.Password.ResetTokenTTL <= 0 {
+		cfg.Password.ResetTokenTTL = 24 * time.Hour
+	}
+
+	// Load multi-factor authentication policy
+	for _, role := range strings.Split(getEnv("MFA_REQUIRED_ROLES", "admin,staff"), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			cfg.MFA.RequiredRoles = append(cfg.MFA.RequiredRoles, role)
+		}
+	}
+	cfg.MFA.Issuer = getEnv("MFA_ISSUER", "Rota")
+	cfg.MFA.ChallengeTTL, _ = time.ParseDuration(getEnv("MFA_CHALLENGE_TTL", "5m"))
+	if cfg.MFA.ChallengeTTL <= 0 {
+		cfg.MFA.ChallengeTTL = 5 * time.Minute
+	}
+	cfg.MFA.EnrollmentTTL, _ = time.ParseDuration(getEnv("MFA_ENROLLMENT_TTL", "24h"))
+	if cfg.MFA.EnrollmentTTL <= 0 {
+		cfg.MFA.EnrollmentTTL = 24 * time.Hour
+	}
+
+	// Load report settings
+	cfg.Reports.LateThreshold, _ = time.ParseDuration(getEnv("REPORT_LATE_THRESHOLD", "5m"))
+	if cfg.Reports.LateThreshold < 0 {
+		cfg.Reports.LateThreshold = 5 * time.Minute
+	}
+	cfg.Reports.VehicleHoursPerDay, _ = time.ParseDuration(getEnv("REPORT_VEHICLE_HOURS_PER_DAY", "18h"))
+	if cfg.Reports.VehicleHoursPerDay <= 0 || cfg.Reports.VehicleHoursPerDay > 24*time.Hour {
+		cfg.Reports.VehicleHoursPerDay = 18 * time.Hour
+	}
+	cfg.Reports.UseSnapshot, _ = strconv.ParseBool(getEnv("REPORTS_USE_SNAPSHOT", "false"))
+
 	return cfg, nil
 }
 
@@ -100,3 +266,30 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// Helper function to get an environment variable as a "limit/window" rate policy or a default value.
+// A limit of 0 disables the policy
+func getEnvAsRatePolicy(key, defaultValue string) RatePolicy {
+	if policy, err := parseRatePolicy(getEnv(key, defaultValue)); err == nil {
+		return policy
+	}
+	log.Printf("Invalid %s, using %s", key, defaultValue)
+	policy, _ := parseRatePolicy(defaultValue)
+	return policy
+}
+
+func parseRatePolicy(value string) (RatePolicy, error) {
+	limitStr, windowStr, ok := strings.Cut(value, "/")
+	if !ok {
+		return RatePolicy{}, fmt.Errorf("expected limit/window, got %q", value)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+	if err != nil || limit < 0 {
+		return RatePolicy{}, fmt.Errorf("invalid limit %q", limitStr)
+	}
+	window, err := time.ParseDuration(strings.TrimSpace(windowStr))
+	if err != nil || window <= 0 {
+		return RatePolicy{}, fmt.Errorf("invalid window %q", windowStr)
+	}
+	return RatePolicy{Limit: limit, Window: window}, nil
+}
@@ -0,0 +1,51 @@
+package dto
+
+// RegisterRequest represents the request body for user registration
+type RegisterRequest struct {
+	Username *string `json:"username,omitempty" validate:"omitempty,min=3,max=50,alphanum"`
+	Email    string  `json:"email" validate:"required,email,max=100"`
+	Password string  `json:"password" validate:"required,min=8,max=72"`
+}
+
+// LoginRequest represents the request body for user login
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// RefreshTokenRequest represents the request body for token refresh
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// ResetPasswordRequest represents the request body for setting a new password with a reset token from an admin
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required,max=128"`
+	NewPassword string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+// MFAEnrollRequest represents the request body for starting MFA enrolment. Accounts whose role requires
+// MFA give the enrollment token an administrator issued them
+type MFAEnrollRequest struct {
+	EnrollmentToken string `json:"enrollment_token" validate:"omitempty,max=128"`
+}
+
+// MFACodeRequest represents a request confirmed with a code from the authenticator app
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required,numeric,len=6"`
+}
+
+// MFAVerifyRequest represents the second step of a login with MFA, with an authenticator code or a recovery code
+type MFAVerifyRequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,numeric,len=6"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code,omitempty,max=32"`
+}
+
+// MFADisableRequest represents the request body for turning MFA off, confirmed with the password, or a
+// recent login for accounts without one, and an authenticator code or a recovery code
+type MFADisableRequest struct {
+	Password     string `json:"password" validate:"omitempty,max=72"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,numeric,len=6"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code,omitempty,max=32"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+import (
+	"rota-api/models"
+)
+
+// DisplayThemeRequest is the body of setting a station's signage theme. Omitted colours and scale use the defaults
+type DisplayThemeRequest struct {
+	BackgroundColor string  `json:"background_color" validate:"omitempty,hexcolor"`
+	TextColor       string  `json:"text_color" validate:"omitempty,hexcolor"`
+	AccentColor     string  `json:"accent_color" validate:"omitempty,hexcolor"`
+	LogoURL         string  `json:"logo_url" validate:"omitempty,url,max=500"`
+	FontScale       float64 `json:"font_scale" validate:"omitempty,gte=0.5,lte=3"`
+}
+
+// ToModel builds the theme of stationID the request describes
+func (r DisplayThemeRequest) ToModel(stationID uint) models.StationDisplayTheme {
+	return models.StationDisplayTheme{
+		StationID:       stationID,
+		BackgroundColor: r.BackgroundColor,
+		TextColor:       r.TextColor,
+		AccentColor:     r.AccentColor,
+		LogoURL:         r.LogoURL,
+		FontScale:       r.FontScale,
+	}
+}
@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"time"
+
+	"rota-api/models"
+)
+
+// CreateDriverRequest is the body of creating a driver
+type CreateDriverRequest struct {
+	Name          string    `json:"name" validate:"required,max=100"`
+	LicenseNumber string    `json:"license_number" validate:"required,max=50"`
+	LicenseExpiry time.Time `json:"license_expiry" validate:"required"`
+	Phone         string    `json:"phone" validate:"omitempty,max=50"`
+	Email         string    `json:"email" validate:"omitempty,email,max=100"`
+	Active        *bool     `json:"active"`
+}
+
+// ToModel builds the driver the request describes. Drivers are active unless the request says otherwise
+func (r CreateDriverRequest) ToModel() models.Driver {
+	driver := models.Driver{
+		Name:          r.Name,
+		LicenseNumber: r.LicenseNumber,
+		LicenseExpiry: r.LicenseExpiry,
+		Phone:         r.Phone,
+		Email:         r.Email,
+		Active:        true,
+	}
+	if r.Active != nil {
+		driver.Active = *r.Active
+	}
+	return driver
+}
+
+// UpdateDriverRequest is the body of updating a driver. Omitted fields keep their current values
+type UpdateDriverRequest struct {
+	Name          string     `json:"name" validate:"omitempty,max=100"`
+	LicenseNumber string     `json:"license_number" validate:"omitempty,max=50"`
+	LicenseExpiry *time.Time `json:"license_expiry"`
+	Phone         string     `json:"phone" validate:"omitempty,max=50"`
+	Email         string     `json:"email" validate:"omitempty,email,max=100"`
+	Active        *bool      `json:"active"`
+}
+
+// Apply copies the fields given in the request onto driver
+func (r UpdateDriverRequest) Apply(driver *models.Driver) {
+	if r.Name != "" {
+		driver.Name = r.Name
+	}
+	if r.LicenseNumber != "" {
+		driver.LicenseNumber = r.LicenseNumber
+	}
+	if r.LicenseExpiry != nil {
+		driver.LicenseExpiry = *r.LicenseExpiry
+	}
+	if r.Phone != "" {
+		driver.Phone = r.Phone
+	}
+	if r.Email != "" {
+		driver.Email = r.Email
+	}
+	if r.Active != nil {
+		driver.Active = *r.Active
+	}
+}
+
+// AssignDriverRequest is the body of rostering a driver onto a trip
+type AssignDriverRequest struct {
+	DriverID uint `json:"driver_id" validate:"required"`
+}
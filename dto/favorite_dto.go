@@ -2,6 +2,8 @@ package dto
 
 import (
 	"time"
+
+	"rota-api/models"
 )
 
 // FavoriteStationResponse represents the simplified favorite station data sent to clients
@@ -11,3 +13,17 @@ type FavoriteStationResponse struct {
 	Name      string    `json:"name"`
 	Location  string    `json:"location"`
 }
+
+// FavoriteRequest is the body of saving a station as a user's favorite
+type FavoriteRequest struct {
+	UserID    uint `json:"user_id" validate:"required"`
+	StationID uint `json:"station_id" validate:"required"`
+}
+
+// ToModel builds the favorite the request describes
+func (r FavoriteRequest) ToModel() models.Favorite {
+	return models.Favorite{
+		UserID:    r.UserID,
+		StationID: r.StationID,
+	}
+}
@@ -0,0 +1,168 @@
+package dto
+
+import (
+	"time"
+
+	"rota-api/models"
+)
+
+// The rows below are the columns of the spreadsheets admin data is imported from and exported to,
+// named by their JSON names. Exports write the id of each record; imports match stations, vehicles
+// and staff by their natural key instead, and schedules by id when one is given
+
+// StationRow is one station in a spreadsheet, matched to stored stations by name
+type StationRow struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name" validate:"required,max=100"`
+	NameEN   string `json:"name_en" validate:"omitempty,max=100"`
+	Location string `json:"location" validate:"omitempty,max=255"`
+	Detail   string `json:"detail" validate:"omitempty,max=1000"`
+}
+
+// StationRowOf describes station as a row
+func StationRowOf(station *models.Station) StationRow {
+	return StationRow{
+		ID:       station.ID,
+		Name:     station.Name,
+		NameEN:   station.NameEN,
+		Location: station.Location,
+		Detail:   station.Detail,
+	}
+}
+
+// Apply copies the row onto station
+func (r StationRow) Apply(station *models.Station) {
+	station.Name = r.Name
+	station.NameEN = r.NameEN
+	station.Location = r.Location
+	station.Detail = r.Detail
+}
+
+// VehicleRow is one vehicle in a spreadsheet, matched to stored vehicles by licence plate
+type VehicleRow struct {
+	ID           uint   `json:"id"`
+	LicensePlate string `json:"license_plate" validate:"required,max=20,thai_plate"`
+	Capacity     int    `json:"capacity" validate:"gt=0,lte=200"`
+	DriverName   string `json:"driver_name" validate:"omitempty,max=100"`
+	RouteID      uint   `json:"route_id" validate:"required"`
+	Odometer     int    `json:"odometer" validate:"gte=0"`
+}
+
+// VehicleRowOf describes vehicle as a row
+func VehicleRowOf(vehicle *models.Vehicle) VehicleRow {
+	return VehicleRow{
+		ID:           vehicle.ID,
+		LicensePlate: vehicle.LicensePlate,
+		Capacity:     vehicle.Capacity,
+		DriverName:   vehicle.DriverName,
+		RouteID:      vehicle.RouteID,
+		Odometer:     vehicle.Odometer,
+	}
+}
+
+// Apply copies the row onto vehicle
+func (r VehicleRow) Apply(vehicle *models.Vehicle) {
+	vehicle.LicensePlate = r.LicensePlate
+	vehicle.Capacity = r.Capacity
+	vehicle.DriverName = r.DriverName
+	vehicle.RouteID = r.RouteID
+	vehicle.Odometer = r.Odometer
+}
+
+// StaffRow is one staff account in a spreadsheet, matched to stored accounts by email. The password
+// is required for new accounts only; exports leave it empty
+type StaffRow struct {
+	ID        uint   `json:"id"`
+	Username  string `json:"username" validate:"required,min=3,max=50,alphanum"`
+	Password  string `json:"password" validate:"omitempty,min=8,max=72"`
+	Email     string `json:"email" validate:"required,email,max=100"`
+	Name      string `json:"name" validate:"required,max=255"`
+	Position  string `json:"position" validate:"omitempty,max=255"`
+	Phone     string `json:"phone" validate:"omitempty,max=50"`
+	StationID uint   `json:"station_id" validate:"required"`
+}
+
+// StaffRowOf describes staff as a row
+func StaffRowOf(staff *models.Staff) StaffRow {
+	return StaffRow{
+		ID:        staff.ID,
+		Username:  staff.Username,
+		Email:     staff.Email,
+		Name:      staff.Name,
+		Position:  staff.Position,
+		Phone:     staff.Phone,
+		StationID: staff.StationID,
+	}
+}
+
+// Apply copies the row onto staff. The password is left to the caller, which hashes it
+func (r StaffRow) Apply(staff *models.Staff) {
+	staff.Username = r.Username
+	staff.Email = r.Email
+	staff.Name = r.Name
+	staff.Position = r.Position
+	staff.Phone = r.Phone
+	staff.StationID = r.StationID
+}
+
+// ScheduleRow is one schedule in a spreadsheet, matched to stored schedules by id, or else by route,
+// station, service date and round. Times are given as in CreateScheduleRequest; local times take precedence
+type ScheduleRow struct {
+	ID             uint       `json:"id"`
+	RouteID        uint       `json:"route_id" validate:"required"`
+	VehicleID      uint       `json:"vehicle_id" validate:"required"`
+	DriverID       *uint      `json:"driver_id" validate:"omitempty,gt=0"`
+	StationID      uint       `json:"station_id" validate:"required"`
+	Round          int        `json:"round" validate:"gte=0"`
+	ServiceDate    string     `json:"service_date" validate:"required_with=DepartureLocal ArrivalLocal,omitempty,datetime=2006-01-02"`
+	DepartureLocal string     `json:"departure_local" validate:"omitempty,local_time"`
+	ArrivalLocal   string     `json:"arrival_local" validate:"omitempty,local_time,after=DepartureLocal"`
+	DepartureTime  *time.Time `json:"departure_time" validate:"required_without=DepartureLocal"`
+	ArrivalTime    *time.Time `json:"arrival_time" validate:"required_without=ArrivalLocal,omitempty,after=DepartureTime"`
+	Status         string     `json:"status" validate:"omitempty,oneof=scheduled delayed cancelled completed"`
+	Platform       string     `json:"platform" validate:"omitempty,max=10"`
+}
+
+// ScheduleRowOf describes schedule as a row
+func ScheduleRowOf(schedule *models.Schedule) ScheduleRow {
+	departure, arrival := schedule.DepartureTime, schedule.ArrivalTime
+	return ScheduleRow{
+		ID:             schedule.ID,
+		RouteID:        schedule.RouteID,
+		VehicleID:      schedule.VehicleID,
+		DriverID:       schedule.DriverID,
+		StationID:      schedule.StationID,
+		Round:          schedule.Round,
+		ServiceDate:    string(schedule.ServiceDate),
+		DepartureLocal: schedule.DepartureLocal,
+		ArrivalLocal:   schedule.ArrivalLocal,
+		DepartureTime:  &departure,
+		ArrivalTime:    &arrival,
+		Status:         schedule.Status,
+		Platform:       schedule.Platform,
+	}
+}
+
+// Apply copies the row onto schedule. Times the row leaves out are cleared, so they are resolved
+// from the ones it gives; a row without a status keeps the schedule's
+func (r ScheduleRow) Apply(schedule *models.Schedule) {
+	schedule.RouteID = r.RouteID
+	schedule.VehicleID = r.VehicleID
+	schedule.DriverID = r.DriverID
+	schedule.StationID = r.StationID
+	schedule.Round = r.Round
+	schedule.ServiceDate = models.Date(r.ServiceDate)
+	schedule.DepartureLocal = r.DepartureLocal
+	schedule.ArrivalLocal = r.ArrivalLocal
+	schedule.DepartureTime, schedule.ArrivalTime = time.Time{}, time.Time{}
+	if r.DepartureTime != nil {
+		schedule.DepartureTime = *r.DepartureTime
+	}
+	if r.ArrivalTime != nil {
+		schedule.ArrivalTime = *r.ArrivalTime
+	}
+	if r.Status != "" {
+		schedule.Status = r.Status
+	}
+	schedule.Platform = r.Platform
+}
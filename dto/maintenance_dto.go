@@ -0,0 +1,78 @@
+package dto
+
+import (
+	"time"
+
+	"rota-api/models"
+)
+
+// MaintenanceRecordRequest is the body of recording maintenance carried out on a vehicle
+type MaintenanceRecordRequest struct {
+	Type        string    `json:"type" validate:"required,max=50"`
+	Description string    `json:"description" validate:"omitempty,max=1000"`
+	Odometer    int       `json:"odometer" validate:"gte=0"`
+	Cost        float64   `json:"cost" validate:"gte=0"`
+	PerformedAt time.Time `json:"performed_at" validate:"required"`
+}
+
+// ToModel builds the maintenance record of vehicleID the request describes
+func (r MaintenanceRecordRequest) ToModel(vehicleID uint) models.MaintenanceRecord {
+	return models.MaintenanceRecord{
+		VehicleID:   vehicleID,
+		Type:        r.Type,
+		Description: r.Description,
+		Odometer:    r.Odometer,
+		Cost:        r.Cost,
+		PerformedAt: r.PerformedAt,
+	}
+}
+
+// InspectionRequest is the body of scheduling an inspection, due by date, by odometer reading or both
+type InspectionRequest struct {
+	Type        string     `json:"type" validate:"required,max=50"`
+	DueDate     *time.Time `json:"due_date" validate:"required_without=DueOdometer"`
+	DueOdometer *int       `json:"due_odometer" validate:"omitempty,gte=0"`
+	Notes       string     `json:"notes" validate:"omitempty,max=1000"`
+}
+
+// ToModel builds the inspection of vehicleID the request describes
+func (r InspectionRequest) ToModel(vehicleID uint) models.VehicleInspection {
+	return models.VehicleInspection{
+		VehicleID:   vehicleID,
+		Type:        r.Type,
+		DueDate:     r.DueDate,
+		DueOdometer: r.DueOdometer,
+		Notes:       r.Notes,
+	}
+}
+
+// CompleteInspectionRequest is the body of recording the result of an inspection
+type CompleteInspectionRequest struct {
+	Passed bool   `json:"passed"`
+	Notes  string `json:"notes" validate:"omitempty,max=1000"`
+}
+
+// OutOfServiceWindowRequest is the body of taking a vehicle out of service.
+// Without end_time the vehicle stays out of service until the window is closed
+type OutOfServiceWindowRequest struct {
+	StartTime           time.Time  `json:"start_time" validate:"required"`
+	EndTime             *time.Time `json:"end_time" validate:"omitempty,after=StartTime"`
+	Reason              string     `json:"reason" validate:"required,max=255"`
+	MaintenanceRecordID *uint      `json:"maintenance_record_id" validate:"omitempty,gt=0"`
+}
+
+// ToModel builds the out-of-service window of vehicleID the request describes
+func (r OutOfServiceWindowRequest) ToModel(vehicleID uint) models.OutOfServiceWindow {
+	return models.OutOfServiceWindow{
+		VehicleID:           vehicleID,
+		StartTime:           r.StartTime,
+		EndTime:             r.EndTime,
+		Reason:              r.Reason,
+		MaintenanceRecordID: r.MaintenanceRecordID,
+	}
+}
+
+// CloseOutOfServiceWindowRequest is the body of returning a vehicle to service, now unless end_time is given
+type CloseOutOfServiceWindowRequest struct {
+	EndTime *time.Time `json:"end_time"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"rota-api/models"
+)
+
+// CreateRouteRequest is the body of creating a route
+type CreateRouteRequest struct {
+	StartStationID uint                `json:"start_station_id" validate:"required"`
+	EndStationID   uint                `json:"end_station_id" validate:"required,nefield=StartStationID"`
+	Distance       float64             `json:"distance" validate:"gt=0"`
+	Duration       string              `json:"duration" validate:"required,max=10,duration"`
+	Description    string              `json:"description" validate:"omitempty,max=1000"`
+	Translations   models.Translations `json:"translations"`
+}
+
+// ToModel builds the route the request describes
+func (r CreateRouteRequest) ToModel() models.Route {
+	return models.Route{
+		StartStationID: r.StartStationID,
+		EndStationID:   r.EndStationID,
+		Distance:       r.Distance,
+		Duration:       r.Duration,
+		Description:    r.Description,
+		Translations:   r.Translations,
+	}
+}
+
+// UpdateRouteRequest is the body of updating a route. Omitted fields keep their current values
+type UpdateRouteRequest struct {
+	StartStationID uint                `json:"start_station_id"`
+	EndStationID   uint                `json:"end_station_id" validate:"omitempty,nefield=StartStationID"`
+	Distance       float64             `json:"distance" validate:"gte=0"`
+	Duration       string              `json:"duration" validate:"omitempty,max=10,duration"`
+	Description    string              `json:"description" validate:"omitempty,max=1000"`
+	Translations   models.Translations `json:"translations"`
+}
+
+// ToModel builds the changes the request describes
+func (r UpdateRouteRequest) ToModel() models.Route {
+	return CreateRouteRequest(r).ToModel()
+}
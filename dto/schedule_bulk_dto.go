@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"time"
+
+	"rota-api/models"
+)
+
+// BulkShiftRequest is the body of shifting the schedules matching criteria by an offset,
+// e.g. "15m" to run them later or "-1h" to run them earlier
+type BulkShiftRequest struct {
+	Criteria models.ScheduleCriteria `json:"criteria"`
+	Offset   string                  `json:"offset" validate:"required,max=20,offset"`
+	Preview  bool                    `json:"preview"`
+}
+
+// OffsetDuration returns the validated offset
+func (r BulkShiftRequest) OffsetDuration() time.Duration {
+	d, _ := time.ParseDuration(r.Offset)
+	return d
+}
+
+// BulkCancelRequest is the body of cancelling the schedules matching criteria. The reason
+// is recorded in the log of each schedule on behalf of the signed-in staff member
+type BulkCancelRequest struct {
+	Criteria models.ScheduleCriteria `json:"criteria"`
+	Reason   string                  `json:"reason" validate:"required,max=500"`
+	Preview  bool                    `json:"preview"`
+}
+
+// BulkCopyRequest is the body of copying the schedules of a service date, optionally narrowed
+// by criteria, to other service dates at the same local times
+type BulkCopyRequest struct {
+	SourceDate  models.Date             `json:"source_date" validate:"required,datetime=2006-01-02"`
+	Criteria    models.ScheduleCriteria `json:"criteria"`
+	TargetDates []models.Date           `json:"target_dates" validate:"required,min=1,max=31,dive,datetime=2006-01-02"`
+	Preview     bool                    `json:"preview"`
+}
@@ -0,0 +1,69 @@
+package dto
+
+import (
+	"time"
+
+	"rota-api/models"
+)
+
+// CreateScheduleRequest is the body of creating a schedule. Times are given either as instants
+// (departure_time, arrival_time) or as local times on a service date (service_date, departure_local,
+// arrival_local), where local times past 24:00 belong to trips that run after midnight
+type CreateScheduleRequest struct {
+	RouteID        uint       `json:"route_id" validate:"required"`
+	VehicleID      uint       `json:"vehicle_id" validate:"required"`
+	DriverID       *uint      `json:"driver_id" validate:"omitempty,gt=0"`
+	StationID      uint       `json:"station_id" validate:"required"`
+	Round          int        `json:"round" validate:"gte=0"`
+	ServiceDate    string     `json:"service_date" validate:"required_with=DepartureLocal ArrivalLocal,omitempty,datetime=2006-01-02"`
+	DepartureTime  *time.Time `json:"departure_time" validate:"required_without=DepartureLocal"`
+	ArrivalTime    *time.Time `json:"arrival_time" validate:"required_without=ArrivalLocal,omitempty,after=DepartureTime"`
+	DepartureLocal string     `json:"departure_local" validate:"omitempty,local_time"`
+	ArrivalLocal   string     `json:"arrival_local" validate:"omitempty,local_time,after=DepartureLocal"`
+	Status         string     `json:"status" validate:"omitempty,oneof=scheduled delayed cancelled completed"`
+	Platform       string     `json:"platform" validate:"omitempty,max=10"`
+}
+
+// ToModel builds the schedule the request describes
+func (r CreateScheduleRequest) ToModel() models.Schedule {
+	schedule := models.Schedule{
+		RouteID:        r.RouteID,
+		VehicleID:      r.VehicleID,
+		DriverID:       r.DriverID,
+		StationID:      r.StationID,
+		Round:          r.Round,
+		ServiceDate:    models.Date(r.ServiceDate),
+		DepartureLocal: r.DepartureLocal,
+		ArrivalLocal:   r.ArrivalLocal,
+		Status:         r.Status,
+		Platform:       r.Platform,
+	}
+	if r.DepartureTime != nil {
+		schedule.DepartureTime = *r.DepartureTime
+	}
+	if r.ArrivalTime != nil {
+		schedule.ArrivalTime = *r.ArrivalTime
+	}
+	return schedule
+}
+
+// UpdateScheduleRequest is the body of updating a schedule. Omitted fields keep their current values
+type UpdateScheduleRequest struct {
+	RouteID        uint       `json:"route_id"`
+	VehicleID      uint       `json:"vehicle_id"`
+	DriverID       *uint      `json:"driver_id" validate:"omitempty,gt=0"`
+	StationID      uint       `json:"station_id"`
+	Round          int        `json:"round" validate:"gte=0"`
+	ServiceDate    string     `json:"service_date" validate:"omitempty,datetime=2006-01-02"`
+	DepartureTime  *time.Time `json:"departure_time"`
+	ArrivalTime    *time.Time `json:"arrival_time" validate:"omitempty,after=DepartureTime"`
+	DepartureLocal string     `json:"departure_local" validate:"omitempty,local_time"`
+	ArrivalLocal   string     `json:"arrival_local" validate:"omitempty,local_time,after=DepartureLocal"`
+	Status         string     `json:"status" validate:"omitempty,oneof=scheduled delayed cancelled completed"`
+	Platform       string     `json:"platform" validate:"omitempty,max=10"`
+}
+
+// ToModel builds the changes the request describes
+func (r UpdateScheduleRequest) ToModel() models.Schedule {
+	return CreateScheduleRequest(r).ToModel()
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	"rota-api/models"
+)
+
+// CreateScheduleLogRequest is the body of logging a change to a trip
+type CreateScheduleLogRequest struct {
+	ScheduleID        uint       `json:"schedule_id" validate:"required"`
+	StaffID           uint       `json:"staff_id" validate:"required"`
+	ChangeDescription string     `json:"change_description" validate:"required,max=1000"`
+	ActualDeparture   *time.Time `json:"actual_departure"`
+	ActualArrival     *time.Time `json:"actual_arrival" validate:"omitempty,after=ActualDeparture"`
+	Status            string     `json:"status" validate:"omitempty,oneof=scheduled delayed cancelled completed"`
+	Notes             string     `json:"notes" validate:"omitempty,max=1000"`
+}
+
+// ToModel builds the schedule log the request describes
+func (r CreateScheduleLogRequest) ToModel() models.ScheduleLog {
+	return models.ScheduleLog{
+		ScheduleID:        r.ScheduleID,
+		StaffID:           r.StaffID,
+		ChangeDescription: r.ChangeDescription,
+		ActualDeparture:   r.ActualDeparture,
+		ActualArrival:     r.ActualArrival,
+		Status:            r.Status,
+		Notes:             r.Notes,
+	}
+}
+
+// UpdateScheduleLogRequest is the body of updating a schedule log. Omitted fields keep their current values
+type UpdateScheduleLogRequest struct {
+	ScheduleID        uint       `json:"schedule_id"`
+	StaffID           uint       `json:"staff_id"`
+	ChangeDescription string     `json:"change_description" validate:"omitempty,max=1000"`
+	ActualDeparture   *time.Time `json:"actual_departure"`
+	ActualArrival     *time.Time `json:"actual_arrival" validate:"omitempty,after=ActualDeparture"`
+	Status            string     `json:"status" validate:"omitempty,oneof=scheduled delayed cancelled completed"`
+	Notes             string     `json:"notes" validate:"omitempty,max=1000"`
+}
+
+// ToModel builds the changes the request describes
+func (r UpdateScheduleLogRequest) ToModel() models.ScheduleLog {
+	return CreateScheduleLogRequest(r).ToModel()
+}
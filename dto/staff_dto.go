@@ -0,0 +1,55 @@
+package dto
+
+import (
+	"rota-api/models"
+)
+
+// CreateStaffRequest is the body of creating a staff account
+type CreateStaffRequest struct {
+	Username  string `json:"username" validate:"required,min=3,max=50,alphanum"`
+	Password  string `json:"password" validate:"required,min=8,max=72"`
+	Email     string `json:"email" validate:"required,email,max=100"`
+	Name      string `json:"name" validate:"required,max=255"`
+	Position  string `json:"position" validate:"omitempty,max=255"`
+	Phone     string `json:"phone" validate:"omitempty,max=50"`
+	StationID uint   `json:"station_id" validate:"required"`
+}
+
+// ToModel builds the staff account the request describes
+func (r CreateStaffRequest) ToModel() models.Staff {
+	return models.Staff{
+		Username:  r.Username,
+		Password:  r.Password,
+		Email:     r.Email,
+		Name:      r.Name,
+		Position:  r.Position,
+		Phone:     r.Phone,
+		StationID: r.StationID,
+	}
+}
+
+// UpdateStaffRequest is the body of updating a staff account. Omitted fields keep their current values
+type UpdateStaffRequest struct {
+	Username  string `json:"username" validate:"omitempty,min=3,max=50,alphanum"`
+	Password  string `json:"password" validate:"omitempty,min=8,max=72"`
+	Email     string `json:"email" validate:"omitempty,email,max=100"`
+	Name      string `json:"name" validate:"omitempty,max=255"`
+	Position  string `json:"position" validate:"omitempty,max=255"`
+	Phone     string `json:"phone" validate:"omitempty,max=50"`
+	StationID uint   `json:"station_id"`
+}
+
+// ToModel builds the changes the request describes
+func (r UpdateStaffRequest) ToModel() models.Staff {
+	return CreateStaffRequest(r).ToModel()
+}
+
+// StaffRequest holds the fields of a complete staff account, which a patched account must still satisfy
+type StaffRequest struct {
+	Username  string `json:"username" validate:"required,min=3,max=50,alphanum"`
+	Email     string `json:"email" validate:"required,email,max=100"`
+	Name      string `json:"name" validate:"required,max=255"`
+	Position  string `json:"position" validate:"omitempty,max=255"`
+	Phone     string `json:"phone" validate:"omitempty,max=50"`
+	StationID uint   `json:"station_id" validate:"required"`
+}
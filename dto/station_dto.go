@@ -0,0 +1,25 @@
+package dto
+
+import (
+	"rota-api/models"
+)
+
+// StationRequest is the body of creating or replacing a station
+type StationRequest struct {
+	Name         string              `json:"name" validate:"required,max=100"`
+	NameEN       string              `json:"name_en" validate:"omitempty,max=100"`
+	Location     string              `json:"location" validate:"omitempty,max=255"`
+	Detail       string              `json:"detail" validate:"omitempty,max=1000"`
+	Translations models.Translations `json:"translations"`
+}
+
+// ToModel builds the station the request describes
+func (r StationRequest) ToModel() models.Station {
+	return models.Station{
+		Name:         r.Name,
+		NameEN:       r.NameEN,
+		Location:     r.Location,
+		Detail:       r.Detail,
+		Translations: r.Translations,
+	}
+}
@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"time"
+
+	"rota-api/models"
+)
+
+// SaveTripRequest is the body of saving a trip to the signed-in user's history: either the schedule
+// travelled on, or the route and when it was travelled
+type SaveTripRequest struct {
+	ScheduleID  *uint      `json:"schedule_id" validate:"omitempty,gt=0"`
+	RouteID     uint       `json:"route_id" validate:"required_without=ScheduleID"`
+	TravelledAt *time.Time `json:"travelled_at" validate:"required_without=ScheduleID"`
+	Notes       string     `json:"notes" validate:"omitempty,max=255"`
+}
+
+// ToModel builds the trip the request describes for the user
+func (r SaveTripRequest) ToModel(userID uint) models.Trip {
+	trip := models.Trip{
+		UserID:     userID,
+		ScheduleID: r.ScheduleID,
+		RouteID:    r.RouteID,
+		Notes:      r.Notes,
+	}
+	if r.TravelledAt != nil {
+		trip.TravelledAt = *r.TravelledAt
+	}
+	return trip
+}
@@ -0,0 +1,76 @@
+package dto
+
+import (
+	"rota-api/models"
+)
+
+// CreateUserRequest is the body of an admin creating a user account
+type CreateUserRequest struct {
+	Username string          `json:"username" validate:"omitempty,min=3,max=50,alphanum"`
+	Email    string          `json:"email" validate:"required,email,max=100"`
+	Password string          `json:"password" validate:"required,min=8,max=72"`
+	Role     models.UserRole `json:"role" validate:"omitempty,oneof=user staff admin"`
+	Name     string          `json:"name" validate:"omitempty,max=100"`
+}
+
+// ToModel builds the user the request describes, with the user role unless another is given
+func (r CreateUserRequest) ToModel() models.User {
+	password := r.Password
+	user := models.User{
+		Email:    r.Email,
+		Username: &r.Username,
+		Password: &password,
+		Role:     r.Role,
+	}
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	return user
+}
+
+// UpdateUserRequest is the body of updating a user. Omitted fields keep their current values.
+// Users update their own accounts with it, so it has no role: roles change only through UpdateUserRoleRequest
+type UpdateUserRequest struct {
+	Email          string  `json:"email" validate:"omitempty,email,max=100"`
+	Username       *string `json:"username" validate:"omitempty,min=3,max=50,alphanum"`
+	ProfilePicture *string `json:"profilePicture" validate:"omitempty,max=2048"`
+}
+
+// Apply copies the provided fields onto user
+func (r UpdateUserRequest) Apply(user *models.User) {
+	if r.Email != "" {
+		user.Email = r.Email
+	}
+	if r.Username != nil {
+		user.Username = r.Username
+	}
+	if r.ProfilePicture != nil {
+		user.ProfilePicture = r.ProfilePicture
+	}
+}
+
+// UpdateUserRoleRequest is the body of changing a user's role
+type UpdateUserRoleRequest struct {
+	Role models.UserRole `json:"role" validate:"required,oneof=user staff admin"`
+}
+
+// UserRequest holds the fields of a complete user account, which a patched account must still satisfy
+type UserRequest struct {
+	Email          string          `json:"email" validate:"required,email,max=100"`
+	Role           models.UserRole `json:"role" validate:"required,oneof=user staff admin"`
+	Username       *string         `json:"username" validate:"omitempty,min=3,max=50,alphanum"`
+	ProfilePicture *string         `json:"profilePicture" validate:"omitempty,max=2048"`
+}
+
+// ChangePasswordRequest is the body of a user changing their own password. The current password
+// may only be left out by accounts that have none yet, which must have logged in moments ago
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"omitempty,max=72"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72,nefield=CurrentPassword"`
+}
+
+// DeleteAccountRequest is the body of a user deleting their own account, confirmed with their password,
+// or by a recent login for accounts without one
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"omitempty,max=72"`
+}
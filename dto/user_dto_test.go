@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+
+	"rota-api/models"
+)
+
+func TestUpdateUserRequestIgnoresRole(t *testing.T) {
+	var req UpdateUserRequest
+	if err := json.Unmarshal([]byte(`{"email":"rider@example.com","role":"admin"}`), &req); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if err := Validate(&req); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	user := models.User{Email: "old@example.com", Role: models.RoleUser}
+	req.Apply(&user)
+
+	if user.Role != models.RoleUser {
+		t.Errorf("role = %q, want %q: updating an account must not change its role", user.Role, models.RoleUser)
+	}
+	if user.Email != "rider@example.com" {
+		t.Errorf("email = %q, want the requested email", user.Email)
+	}
+}
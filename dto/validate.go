@@ -0,0 +1,92 @@
+package dto
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"rota-api/utils/servicetime"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// thaiPlatePattern matches Thai licence plates: an optional digit and one or two consonants, or the two digits
+// of a commercial plate, then up to four digits, e.g. "กท-1234", "1กข 234" or "10-1234", optionally followed
+// by the province, e.g. "กท-1234 กรุงเทพมหานคร"
+var thaiPlatePattern = regexp.MustCompile(`^(?:[0-9]?[ก-ฮ]{1,2}|[0-9]{2})[ -]?[0-9]{1,4}(?: [\p{Thai} ]+)?$`)
+
+// validate is shared by every request: validator caches struct metadata, so one instance serves all handlers
+var validate = newValidator()
+
+// newValidator creates a validator that reports fields by their JSON names and knows the API's custom rules:
+//
+//	thai_plate   Thai licence plate, see thaiPlatePattern
+//	duration     Go duration greater than zero, e.g. "30m" or "1h45m"
+//	offset       Go duration other than zero, e.g. "15m" or "-1h"
+//	local_time   local time on a service date, past 24:00 for trips after midnight, e.g. "25:10"
+//	after=Field  time or local time later than Field's, skipped when either is empty
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	_ = v.RegisterValidation("thai_plate", func(fl validator.FieldLevel) bool {
+		return thaiPlatePattern.MatchString(strings.TrimSpace(fl.Field().String()))
+	})
+	_ = v.RegisterValidation("duration", func(fl validator.FieldLevel) bool {
+		d, err := time.ParseDuration(fl.Field().String())
+		return err == nil && d > 0
+	})
+	_ = v.RegisterValidation("offset", func(fl validator.FieldLevel) bool {
+		d, err := time.ParseDuration(fl.Field().String())
+		return err == nil && d != 0
+	})
+	_ = v.RegisterValidation("local_time", func(fl validator.FieldLevel) bool {
+		_, err := servicetime.ParseLocalTime(fl.Field().String())
+		return err == nil
+	})
+	_ = v.RegisterValidation("after", func(fl validator.FieldLevel) bool {
+		other, _, _, found := fl.GetStructFieldOKAdvanced2(fl.Parent(), fl.Param())
+		if !found {
+			return true
+		}
+		later, ok := orderOf(fl.Field())
+		earlier, otherOK := orderOf(other)
+		return !ok || !otherOK || later > earlier
+	}, true)
+	return v
+}
+
+// orderOf reads a time, a pointer to one or a local time such as "25:10" as a value that orders them.
+// ok is false when the field is empty, so rules comparing it are skipped
+func orderOf(field reflect.Value) (int64, bool) {
+	for field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return 0, false
+		}
+		field = field.Elem()
+	}
+	switch value := field.Interface().(type) {
+	case time.Time:
+		return value.UnixNano(), !value.IsZero()
+	case string:
+		offset, err := servicetime.ParseLocalTime(value)
+		return int64(offset), err == nil
+	}
+	return 0, false
+}
+
+// Validate checks a request against its validate tags. Failures are returned as
+// validator.ValidationErrors, which the error handler reports field by field
+func Validate(req interface{}) error {
+	return validate.Struct(req)
+}
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"rota-api/models"
+)
+
+// CreateVehicleRequest is the body of creating a vehicle
+type CreateVehicleRequest struct {
+	LicensePlate string `json:"license_plate" validate:"required,max=20,thai_plate"`
+	Capacity     int    `json:"capacity" validate:"gt=0,lte=200"`
+	DriverName   string `json:"driver_name" validate:"omitempty,max=100"`
+	RouteID      uint   `json:"route_id" validate:"required"`
+	Odometer     int    `json:"odometer" validate:"gte=0"`
+}
+
+// ToModel builds the vehicle the request describes
+func (r CreateVehicleRequest) ToModel() models.Vehicle {
+	return models.Vehicle{
+		LicensePlate: r.LicensePlate,
+		Capacity:     r.Capacity,
+		DriverName:   r.DriverName,
+		RouteID:      r.RouteID,
+		Odometer:     r.Odometer,
+	}
+}
+
+// UpdateVehicleRequest is the body of updating a vehicle. Omitted fields keep their current values
+type UpdateVehicleRequest struct {
+	LicensePlate string `json:"license_plate" validate:"omitempty,max=20,thai_plate"`
+	Capacity     int    `json:"capacity" validate:"gte=0,lte=200"`
+	DriverName   string `json:"driver_name" validate:"omitempty,max=100"`
+	RouteID      uint   `json:"route_id"`
+	Odometer     int    `json:"odometer" validate:"gte=0"`
+}
+
+// ToModel builds the changes the request describes
+func (r UpdateVehicleRequest) ToModel() models.Vehicle {
+	return CreateVehicleRequest(r).ToModel()
+}
@@ -8,3 +8,25 @@ const (
 	USERNAME_EXISTS       = "AUTH_005"
 	TOKEN_EXPIRED         = "AUTH_006"
 )
+
+const (
+	ACCOUNT_LOCKED          = "AUTH_007"
+	MISSING_TOKEN           = "AUTH_008"
+	ADMIN_REQUIRED          = "AUTH_009"
+	TOKEN_GENERATION_FAILED = "AUTH_010"
+	LOGIN_METHOD_MISMATCH   = "AUTH_011"
+	TOKEN_REVOKED           = "AUTH_012"
+)
+
+const (
+	PASSWORD_INCORRECT      = "AUTH_013"
+	PASSWORD_RESET_REQUIRED = "AUTH_014"
+	MFA_REQUIRED            = "AUTH_015"
+	MFA_INVALID_CODE        = "AUTH_016"
+	MFA_ALREADY_ENABLED     = "AUTH_017"
+	MFA_NOT_ENABLED         = "AUTH_018"
+	MFA_NOT_ENROLLED        = "AUTH_019"
+	RESET_TOKEN_INVALID     = "AUTH_020"
+	REAUTH_REQUIRED         = "AUTH_021"
+	MFA_ENROLLMENT_DENIED   = "AUTH_022"
+)
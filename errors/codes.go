@@ -0,0 +1,110 @@
+package errors
+
+// Error codes are part of the API contract: clients branch on them, so a code is never reused or renumbered.
+// Codes are grouped by resource; the COMMON group covers errors any endpoint can return
+const (
+	BAD_REQUEST          = "COMMON_001"
+	INVALID_REQUEST_BODY = "COMMON_002"
+	INVALID_ID           = "COMMON_003"
+	INVALID_PARAMETER    = "COMMON_004"
+	VALIDATION_FAILED    = "COMMON_005"
+	NOT_FOUND            = "COMMON_006"
+	CONFLICT             = "COMMON_007"
+	UNAUTHORIZED         = "COMMON_008"
+	FORBIDDEN            = "COMMON_009"
+	RATE_LIMITED         = "COMMON_010"
+	INTERNAL_ERROR       = "COMMON_011"
+	METHOD_NOT_ALLOWED   = "COMMON_012"
+	PAYLOAD_TOO_LARGE    = "COMMON_013"
+	INVALID_SORT         = "COMMON_014"
+	INVALID_CURSOR       = "COMMON_015"
+	INVALID_PAGE         = "COMMON_016"
+	INVALID_FILTER       = "COMMON_017"
+	INVALID_TRANSLATION  = "COMMON_018"
+	INVALID_DATE         = "COMMON_019"
+	INVALID_TIME         = "COMMON_020"
+	UNSUPPORTED_MEDIA    = "COMMON_021"
+	INVALID_PATCH        = "COMMON_022"
+	PATCH_TEST_FAILED    = "COMMON_023"
+	FIELD_NOT_WRITABLE   = "COMMON_024"
+	PRECONDITION_FAILED  = "COMMON_025"
+	PRECONDITION_NEEDED  = "COMMON_026"
+	INVALID_IDEMPOTENCY  = "COMMON_027"
+	IDEMPOTENCY_REUSED   = "COMMON_028"
+	IDEMPOTENCY_PENDING  = "COMMON_029"
+)
+
+const (
+	USER_NOT_FOUND     = "USER_001"
+	USER_INVALID_ROLE  = "USER_002"
+	USER_FORBIDDEN     = "USER_003"
+	STATION_NOT_FOUND  = "STATION_001"
+	ROUTE_NOT_FOUND    = "ROUTE_001"
+	STAFF_NOT_FOUND    = "STAFF_001"
+	STAFF_EXISTS       = "STAFF_002"
+	STAFF_NOT_LINKED   = "STAFF_003"
+	FAVORITE_NOT_FOUND = "FAVORITE_001"
+	FAVORITE_EXISTS    = "FAVORITE_002"
+	FAVORITE_FORBIDDEN = "FAVORITE_003"
+)
+
+const (
+	TRIP_NOT_FOUND        = "TRIP_001"
+	TRIP_EXISTS           = "TRIP_002"
+	TRIP_FORBIDDEN        = "TRIP_003"
+	TRIP_IN_FUTURE        = "TRIP_004"
+	TRIP_INVALID_ROUTE    = "TRIP_005"
+	TRIP_INVALID_SCHEDULE = "TRIP_006"
+)
+
+const (
+	VEHICLE_NOT_FOUND      = "VEHICLE_001"
+	VEHICLE_OUT_OF_SERVICE = "VEHICLE_002"
+	VEHICLE_EXISTS         = "VEHICLE_003"
+)
+
+const (
+	SCHEDULE_NOT_FOUND     = "SCHEDULE_001"
+	SCHEDULE_CONFLICT      = "SCHEDULE_002"
+	SCHEDULE_OVERLAP       = "SCHEDULE_003"
+	SCHEDULE_INVALID_TIMES = "SCHEDULE_004"
+	SCHEDULE_NO_CRITERIA   = "SCHEDULE_005"
+	SCHEDULE_BULK_TOO_MANY = "SCHEDULE_006"
+	SCHEDULE_LOG_NOT_FOUND = "SCHEDULE_LOG_001"
+)
+
+const (
+	DRIVER_NOT_FOUND           = "DRIVER_001"
+	DRIVER_EXISTS              = "DRIVER_002"
+	DRIVER_INACTIVE            = "DRIVER_003"
+	DRIVER_LICENSE_EXPIRED     = "DRIVER_004"
+	DRIVER_DOUBLE_BOOKED       = "DRIVER_005"
+	DRIVER_INSUFFICIENT_REST   = "DRIVER_006"
+	DRIVER_DUTY_HOURS_EXCEEDED = "DRIVER_007"
+)
+
+const (
+	MAINTENANCE_NOT_FOUND          = "MAINTENANCE_001"
+	MAINTENANCE_INVALID_WINDOW     = "MAINTENANCE_002"
+	MAINTENANCE_INVALID_INSPECTION = "MAINTENANCE_003"
+	MAINTENANCE_WINDOW_NOT_FOUND   = "MAINTENANCE_004"
+	INSPECTION_NOT_FOUND           = "MAINTENANCE_005"
+	MAINTENANCE_TRIPS_SCHEDULED    = "MAINTENANCE_006"
+)
+
+const (
+	BOARD_INVALID_CURSOR   = "BOARD_001"
+	BOARD_INVALID_TYPE     = "BOARD_002"
+	DISPLAY_INVALID_THEME  = "DISPLAY_001"
+	SEARCH_QUERY_REQUIRED  = "SEARCH_001"
+	SEARCH_QUERY_TOO_LONG  = "SEARCH_002"
+	RANKING_INVALID_PERIOD = "RANKING_001"
+	IMPORT_INVALID_FILE    = "IMPORT_001"
+	IMPORT_INVALID_MAPPING = "IMPORT_002"
+	IMPORT_INVALID_ROWS    = "IMPORT_003"
+	IMPORT_TOO_MANY_ROWS   = "IMPORT_004"
+	EXPORT_INVALID_FORMAT  = "EXPORT_001"
+	EXPORT_TOO_MANY_ROWS   = "EXPORT_002"
+	REPORT_INVALID_RANGE   = "REPORT_001"
+	REPORT_INVALID_GROUP   = "REPORT_002"
+)
@@ -1,20 +1,34 @@
 package errors
 
-import "fmt"
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
 
-// Error represents a custom error type
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Error represents a custom error type: a domain error with a stable code and the HTTP status it maps to
 type Error struct {
 	Code    string
 	Message string
 	Err     error
+	// Status is the HTTP status the error is reported with; 0 means 500
+	Status int
+	// Fields holds per-field messages of validation errors
+	Fields map[string]string
+	// Extensions are extra members of the problem document, such as the trips a schedule conflicts with
+	Extensions map[string]interface{}
 }
 
 // Error implements the error interface
 func (e *Error) Error() string {
 	if e.Err != nil {
-		return fmt.Sprintf("%s: %s (cause: %v)", e.Code, e.Message, e.Err)
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
 	}
-	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	return e.Message
 }
 
 // Unwrap implements the unwrap interface
@@ -22,6 +36,48 @@ func (e *Error) Unwrap() error {
 	return e.Err
 }
 
+// Is matches errors with the same code, so copies made by Wrap and With still match their sentinel
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code && t.Status == e.Status && t.Message == e.Message
+}
+
+// HTTPStatus returns the status the error is reported with
+func (e *Error) HTTPStatus() int {
+	if e.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.Status
+}
+
+// Wrap returns a copy of the error caused by err
+func (e *Error) Wrap(err error) *Error {
+	copied := e.clone()
+	copied.Err = err
+	return copied
+}
+
+// With returns a copy of the error carrying an extra problem member
+func (e *Error) With(key string, value interface{}) *Error {
+	copied := e.clone()
+	copied.Extensions = make(map[string]interface{}, len(e.Extensions)+1)
+	for k, v := range e.Extensions {
+		copied.Extensions[k] = v
+	}
+	copied.Extensions[key] = value
+	return copied
+}
+
+func (e *Error) clone() *Error {
+	copied := *e
+	return &copied
+}
+
+// Extender is implemented by errors that add members to the problem document they are reported as
+type Extender interface {
+	ProblemExtensions() map[string]interface{}
+}
+
 // NewError creates a new error
 func NewError(code string, message string, err error) *Error {
 	return &Error{
@@ -30,3 +86,144 @@ func NewError(code string, message string, err error) *Error {
 		Err:     err,
 	}
 }
+
+// New creates an error reported with the given HTTP status
+func New(status int, code, message string) *Error {
+	return &Error{Code: code, Message: message, Status: status}
+}
+
+// BadRequest creates a 400 error for malformed input
+func BadRequest(code, message string) *Error {
+	return New(http.StatusBadRequest, code, message)
+}
+
+// Unauthorized creates a 401 error for missing or invalid credentials
+func Unauthorized(code, message string) *Error {
+	return New(http.StatusUnauthorized, code, message)
+}
+
+// Forbidden creates a 403 error for callers not allowed to do something
+func Forbidden(code, message string) *Error {
+	return New(http.StatusForbidden, code, message)
+}
+
+// NotFound creates a 404 error for a missing resource
+func NotFound(code, message string) *Error {
+	return New(http.StatusNotFound, code, message)
+}
+
+// Conflict creates a 409 error for requests that clash with the current state
+func Conflict(code, message string) *Error {
+	return New(http.StatusConflict, code, message)
+}
+
+// Validation creates a 422 error listing the problem with each field
+func Validation(fields map[string]string) *Error {
+	e := New(http.StatusUnprocessableEntity, VALIDATION_FAILED, "One or more validation errors occurred")
+	e.Fields = fields
+	return e
+}
+
+// TooManyRequests creates a 429 error for throttled callers
+func TooManyRequests(code, message string) *Error {
+	return New(http.StatusTooManyRequests, code, message)
+}
+
+// Internal creates a 500 error. The message is shown to clients; err is only logged
+func Internal(message string, err error) *Error {
+	return &Error{Code: INTERNAL_ERROR, Message: message, Err: err, Status: http.StatusInternalServerError}
+}
+
+// NotFoundIf turns an error meaning the record does not exist into a not-found error for a resource,
+// and returns any other error unchanged
+func NotFoundIf(err error, code, message string) error {
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return NotFound(code, message).Wrap(err)
+	}
+	return err
+}
+
+// From resolves any error into the domain error it is reported as. Errors that are not
+// domain errors, not-found errors, validation errors or Fiber errors become internal errors
+func From(err error) *Error {
+	var domainErr *Error
+	if stderrors.As(err, &domainErr) {
+		resolved := domainErr.clone()
+		// Keep the context added by wrapping, e.g. "invalid translation: unsupported locale"
+		if err != error(domainErr) && resolved.HTTPStatus() < http.StatusInternalServerError {
+			resolved.Message = err.Error()
+		}
+		resolved.Err = err
+
+		var extender Extender
+		if stderrors.As(err, &extender) {
+			for key, value := range extender.ProblemExtensions() {
+				resolved = resolved.With(key, value)
+			}
+		}
+		return resolved
+	}
+
+	var validationErrs validator.ValidationErrors
+	if stderrors.As(err, &validationErrs) {
+		return ValidationFields(validationErrs)
+	}
+
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return NotFound(NOT_FOUND, "Resource not found").Wrap(err)
+	}
+
+	var fiberErr *fiber.Error
+	if stderrors.As(err, &fiberErr) {
+		return New(fiberErr.Code, codeForStatus(fiberErr.Code), fiberErr.Message)
+	}
+
+	return Internal("Internal Server Error", err)
+}
+
+// ValidationFields reports validator errors by the JSON name of each failing field
+func ValidationFields(errs validator.ValidationErrors) *Error {
+	fields := make(map[string]string, len(errs))
+	for _, e := range errs {
+		message := e.Tag()
+		if e.Param() != "" {
+			message += "=" + e.Param()
+		}
+		fields[e.Field()] = message
+	}
+	return Validation(fields)
+}
+
+// codeForStatus picks the generic code for errors raised by Fiber itself
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return BAD_REQUEST
+	case http.StatusUnauthorized:
+		return UNAUTHORIZED
+	case http.StatusForbidden:
+		return FORBIDDEN
+	case http.StatusNotFound:
+		return NOT_FOUND
+	case http.StatusMethodNotAllowed:
+		return METHOD_NOT_ALLOWED
+	case http.StatusConflict:
+		return CONFLICT
+	case http.StatusRequestEntityTooLarge:
+		return PAYLOAD_TOO_LARGE
+	case http.StatusUnsupportedMediaType:
+		return UNSUPPORTED_MEDIA
+	case http.StatusPreconditionFailed:
+		return PRECONDITION_FAILED
+	case http.StatusPreconditionRequired:
+		return PRECONDITION_NEEDED
+	case http.StatusUnprocessableEntity:
+		return VALIDATION_FAILED
+	case http.StatusTooManyRequests:
+		return RATE_LIMITED
+	}
+	if status < http.StatusInternalServerError {
+		return BAD_REQUEST
+	}
+	return INTERNAL_ERROR
+}
@@ -0,0 +1,231 @@
+// Package filter implements the small query language accepted by the `filter` parameter of
+// list endpoints, e.g. `capacity>=40 and route_id in (1,2)`. Filters are parsed into an
+// expression tree and translated to parameterised SQL against a per-resource field allowlist
+package filter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	apperrors "rota-api/errors"
+)
+
+// ErrInvalidFilter is wrapped by every parse and validation error
+var ErrInvalidFilter = apperrors.BadRequest(apperrors.INVALID_FILTER, "invalid filter")
+
+// FieldType tells which operators a field supports and how its values are parsed
+type FieldType int
+
+const (
+	String FieldType = iota
+	Int
+	Float
+	Bool
+	Time
+)
+
+// Field is a column clients may filter on
+type Field struct {
+	Column string
+	Type   FieldType
+}
+
+// Fields is the allowlist of filterable fields of a resource, keyed by the name used in filters
+type Fields map[string]Field
+
+// Apply parses input and adds it to query as a WHERE condition. An empty input leaves query unchanged
+func Apply(query *gorm.DB, input string, fields Fields) (*gorm.DB, error) {
+	if strings.TrimSpace(input) == "" {
+		return query, nil
+	}
+
+	expr, err := Parse(input)
+	if err != nil {
+		return nil, err
+	}
+
+	condition, err := fields.Translate(expr)
+	if err != nil {
+		return nil, err
+	}
+	return query.Where(condition), nil
+}
+
+// Translate checks expr against the allowlist and converts it to a SQL expression with bound values
+func (f Fields) Translate(expr Node) (clause.Expr, error) {
+	var b strings.Builder
+	var vars []interface{}
+	if err := f.translate(expr, &b, &vars); err != nil {
+		return clause.Expr{}, err
+	}
+	return clause.Expr{SQL: b.String(), Vars: vars}, nil
+}
+
+func (f Fields) translate(expr Node, b *strings.Builder, vars *[]interface{}) error {
+	switch n := expr.(type) {
+	case Logical:
+		b.WriteString("(")
+		if err := f.translate(n.Left, b, vars); err != nil {
+			return err
+		}
+		b.WriteString(" " + strings.ToUpper(n.Op) + " ")
+		if err := f.translate(n.Right, b, vars); err != nil {
+			return err
+		}
+		b.WriteString(")")
+
+	case Not:
+		b.WriteString("NOT (")
+		if err := f.translate(n.Expr, b, vars); err != nil {
+			return err
+		}
+		b.WriteString(")")
+
+	case NullCheck:
+		field, err := f.lookup(n.Field, n.Pos)
+		if err != nil {
+			return err
+		}
+		if n.Negate {
+			b.WriteString(field.Column + " IS NOT NULL")
+		} else {
+			b.WriteString(field.Column + " IS NULL")
+		}
+
+	case Comparison:
+		field, err := f.lookup(n.Field, n.Pos)
+		if err != nil {
+			return err
+		}
+		if err := checkOperator(n, field); err != nil {
+			return err
+		}
+
+		values := make([]interface{}, 0, len(n.Values))
+		for _, literal := range n.Values {
+			value, err := convert(literal, n.Field, field.Type)
+			if err != nil {
+				return err
+			}
+			values = append(values, value)
+		}
+
+		switch n.Op {
+		case "in":
+			b.WriteString(field.Column + " IN ?")
+			*vars = append(*vars, values)
+		case "~":
+			b.WriteString(field.Column + " ILIKE ?")
+			*vars = append(*vars, "%"+escapeLike(values[0].(string))+"%")
+		default:
+			b.WriteString(field.Column + " " + sqlOperator(n.Op) + " ?")
+			*vars = append(*vars, values[0])
+		}
+
+	default:
+		return fmt.Errorf("%w: unsupported expression %T", ErrInvalidFilter, expr)
+	}
+	return nil
+}
+
+func (f Fields) lookup(name string, pos int) (Field, error) {
+	field, ok := f[name]
+	if !ok {
+		return Field{}, syntaxError(pos, "unknown field %q, filterable fields are: %s", name, strings.Join(f.names(), ", "))
+	}
+	return field, nil
+}
+
+func (f Fields) names() []string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func checkOperator(n Comparison, field Field) error {
+	switch n.Op {
+	case "=", "!=", "in":
+		return nil
+	case ">", ">=", "<", "<=":
+		if field.Type == String || field.Type == Bool {
+			return syntaxError(n.Pos, "operator %q is not supported for %q, use =, != or ~", n.Op, n.Field)
+		}
+		return nil
+	case "~":
+		if field.Type != String {
+			return syntaxError(n.Pos, "operator \"~\" only applies to text fields, not %q", n.Field)
+		}
+		return nil
+	}
+	return syntaxError(n.Pos, "unknown operator %q", n.Op)
+}
+
+func sqlOperator(op string) string {
+	if op == "!=" {
+		return "<>"
+	}
+	return op
+}
+
+// convert parses a literal as the field type so the database never sees a mistyped value
+func convert(literal Literal, name string, fieldType FieldType) (interface{}, error) {
+	invalid := func(expected string) error {
+		return syntaxError(literal.Pos, "%q expects %s, got %q", name, expected, literal.Text)
+	}
+
+	switch fieldType {
+	case Int:
+		if literal.Kind != NumberLiteral {
+			return nil, invalid("an integer")
+		}
+		value, err := strconv.ParseInt(literal.Text, 10, 64)
+		if err != nil {
+			return nil, invalid("an integer")
+		}
+		return value, nil
+	case Float:
+		if literal.Kind != NumberLiteral {
+			return nil, invalid("a number")
+		}
+		value, err := strconv.ParseFloat(literal.Text, 64)
+		if err != nil {
+			return nil, invalid("a number")
+		}
+		return value, nil
+	case Bool:
+		if literal.Kind != BoolLiteral {
+			return nil, invalid("true or false")
+		}
+		return literal.Text == "true", nil
+	case Time:
+		if literal.Kind != StringLiteral {
+			return nil, invalid("a quoted date or RFC3339 time")
+		}
+		if value, err := time.Parse(time.RFC3339, literal.Text); err == nil {
+			return value, nil
+		}
+		if value, err := time.Parse("2006-01-02", literal.Text); err == nil {
+			return value, nil
+		}
+		return nil, invalid("a quoted date (2006-01-02) or RFC3339 time")
+	default:
+		if literal.Kind != StringLiteral {
+			return nil, invalid("a quoted text value")
+		}
+		return literal.Text, nil
+	}
+}
+
+// escapeLike escapes LIKE wildcards so "~" matches the text literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
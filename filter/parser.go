@@ -0,0 +1,350 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Limits that keep a filter cheap to parse and to run
+const (
+	MaxLength     = 1000
+	MaxConditions = 20
+	maxDepth      = 10
+)
+
+// Node is a parsed filter expression
+type Node interface {
+	node()
+}
+
+// Logical joins two expressions with AND or OR
+type Logical struct {
+	Op          string // "and" or "or"
+	Left, Right Node
+}
+
+// Not negates an expression
+type Not struct {
+	Expr Node
+}
+
+// Comparison compares a field with one value, or with a list of values for "in"
+type Comparison struct {
+	Field  string
+	Op     string // =, !=, >, >=, <, <=, ~, in
+	Values []Literal
+	Pos    int
+}
+
+// NullCheck tests a field with "is null" or "is not null"
+type NullCheck struct {
+	Field  string
+	Negate bool
+	Pos    int
+}
+
+func (Logical) node()    {}
+func (Not) node()        {}
+func (Comparison) node() {}
+func (NullCheck) node()  {}
+
+// LiteralKind tells how a literal was written
+type LiteralKind int
+
+const (
+	NumberLiteral LiteralKind = iota
+	StringLiteral
+	BoolLiteral
+)
+
+// Literal is a value as written in the filter, converted to the field type on translation
+type Literal struct {
+	Kind LiteralKind
+	Text string
+	Pos  int
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOperator
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// Parse turns a filter string into an expression tree. It only checks syntax;
+// fields and values are checked against an allowlist when the tree is translated
+func Parse(input string) (Node, error) {
+	if len(input) > MaxLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrInvalidFilter, MaxLength)
+	}
+
+	tokens, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, syntaxError(tok.pos, "unexpected %q", tok.text)
+	}
+	return expr, nil
+}
+
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case r == '\'' || r == '"':
+			start := i
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, syntaxError(start, "unterminated string")
+				}
+				if runes[i] == r {
+					// A doubled quote stands for the quote itself
+					if i+1 < len(runes) && runes[i+1] == r {
+						b.WriteRune(r)
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{tokString, b.String(), start})
+		case r == '-' || unicode.IsDigit(r):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			text := string(runes[start:i])
+			if text == "-" {
+				return nil, syntaxError(start, "expected a number after \"-\"")
+			}
+			tokens = append(tokens, token{tokNumber, text, start})
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokIdent, string(runes[start:i]), start})
+		case strings.ContainsRune("=!<>~", r):
+			start := i
+			i++
+			if i < len(runes) && runes[i] == '=' && r != '=' && r != '~' {
+				i++
+			}
+			text := string(runes[start:i])
+			if text == "!" {
+				return nil, syntaxError(start, "expected \"!=\"")
+			}
+			tokens = append(tokens, token{tokOperator, text, start})
+		default:
+			return nil, syntaxError(i, "unexpected character %q", r)
+		}
+	}
+
+	return append(tokens, token{tokEOF, "end of filter", len(runes)}), nil
+}
+
+type parser struct {
+	tokens     []token
+	pos        int
+	conditions int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// keyword reports whether the next token is the given case-insensitive keyword, consuming it if so
+func (p *parser) keyword(word string) bool {
+	if tok := p.peek(); tok.kind == tokIdent && strings.EqualFold(tok.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseOr(depth int) (Node, error) {
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = Logical{Op: "or", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd(depth int) (Node, error) {
+	left, err := p.parseUnary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseUnary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = Logical{Op: "and", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary(depth int) (Node, error) {
+	if depth > maxDepth {
+		return nil, syntaxError(p.peek().pos, "nested deeper than %d levels", maxDepth)
+	}
+
+	if p.keyword("not") {
+		expr, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return Not{Expr: expr}, nil
+	}
+
+	if tok := p.peek(); tok.kind == tokLParen {
+		p.next()
+		expr, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, syntaxError(closing.pos, "expected \")\" to close \"(\" at position %d, got %q", tok.pos+1, closing.text)
+		}
+		return expr, nil
+	}
+
+	return p.parseCondition()
+}
+
+func (p *parser) parseCondition() (Node, error) {
+	field := p.next()
+	if field.kind != tokIdent || isKeyword(field.text) {
+		return nil, syntaxError(field.pos, "expected a field name, got %q", field.text)
+	}
+
+	p.conditions++
+	if p.conditions > MaxConditions {
+		return nil, syntaxError(field.pos, "more than %d conditions", MaxConditions)
+	}
+
+	if p.keyword("is") {
+		negate := p.keyword("not")
+		if !p.keyword("null") {
+			tok := p.peek()
+			return nil, syntaxError(tok.pos, "expected \"null\" after \"is\", got %q", tok.text)
+		}
+		return NullCheck{Field: field.text, Negate: negate, Pos: field.pos}, nil
+	}
+
+	if p.keyword("in") {
+		if open := p.next(); open.kind != tokLParen {
+			return nil, syntaxError(open.pos, "expected \"(\" after \"in\", got %q", open.text)
+		}
+		var values []Literal
+		for {
+			value, err := p.parseLiteral()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+
+			tok := p.next()
+			if tok.kind == tokRParen {
+				break
+			}
+			if tok.kind != tokComma {
+				return nil, syntaxError(tok.pos, "expected \",\" or \")\" in list, got %q", tok.text)
+			}
+		}
+		return Comparison{Field: field.text, Op: "in", Values: values, Pos: field.pos}, nil
+	}
+
+	op := p.next()
+	if op.kind != tokOperator {
+		return nil, syntaxError(op.pos, "expected an operator after %q, got %q", field.text, op.text)
+	}
+	value, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+	return Comparison{Field: field.text, Op: op.text, Values: []Literal{value}, Pos: field.pos}, nil
+}
+
+func (p *parser) parseLiteral() (Literal, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokNumber:
+		return Literal{Kind: NumberLiteral, Text: tok.text, Pos: tok.pos}, nil
+	case tok.kind == tokString:
+		return Literal{Kind: StringLiteral, Text: tok.text, Pos: tok.pos}, nil
+	case tok.kind == tokIdent && (strings.EqualFold(tok.text, "true") || strings.EqualFold(tok.text, "false")):
+		return Literal{Kind: BoolLiteral, Text: strings.ToLower(tok.text), Pos: tok.pos}, nil
+	default:
+		return Literal{}, syntaxError(tok.pos, "expected a value, got %q (quote text values)", tok.text)
+	}
+}
+
+func isKeyword(word string) bool {
+	switch strings.ToLower(word) {
+	case "and", "or", "not", "in", "is", "null", "true", "false":
+		return true
+	}
+	return false
+}
+
+// syntaxError reports a problem at a 0-based rune offset, shown to clients as a 1-based position
+func syntaxError(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("%w at position %d: %s", ErrInvalidFilter, pos+1, fmt.Sprintf(format, args...))
+}
@@ -1,53 +1,49 @@
 package handler
 
 import (
+	"errors"
+	"math"
+	"strconv"
+
+	"rota-api/dto"
+	apperrors "rota-api/errors"
 	"rota-api/models"
 	"rota-api/response"
 	"rota-api/services"
 	"time"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
 
 // AuthHandler handles HTTP requests related to authentication
 type AuthHandler struct {
 	authService services.AuthService
-	validate    *validator.Validate
 }
 
 // NewAuthHandler creates a new instance of AuthHandler
 func NewAuthHandler(authService services.AuthService) *AuthHandler {
 	return &AuthHandler{
 		authService: authService,
-		validate:    validator.New(),
 	}
 }
 
-// RegisterRequest represents the request body for user registration
-type RegisterRequest struct {
-	Username *string `json:"username,omitempty" validate:"omitempty,min=3,max=50,alphanum"`
-	Email    string  `json:"email" validate:"required,email,max=100"`
-	Password string  `json:"password" validate:"required,min=8"`
-}
-
-// LoginRequest represents the request body for user login
-type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
-}
-
-// RefreshTokenRequest represents the request body for token refresh
-type RefreshTokenRequest struct {
-	RefreshToken string `json:"refresh_token" validate:"required"`
-}
-
 // AuthResponse represents the authentication response
 type AuthResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token,omitempty"`
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int    `json:"expires_in"`
+	// MFAEnrollmentRequired tells users whose role requires MFA that they act as plain users until they enable it
+	MFAEnrollmentRequired bool `json:"mfa_enrollment_required,omitempty"`
+	// RecoveryCodes are returned once, when MFA is activated
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// MFAChallengeResponse is the login response of accounts with MFA, whose MFAToken is exchanged for
+// an AuthResponse with a code from the authenticator app or a recovery code
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
 }
 
 // ErrorResponse represents an error response
@@ -63,27 +59,17 @@ type ErrorResponse struct {
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Param request body RegisterRequest true "User registration details"
+// @Param request body dto.RegisterRequest true "User registration details"
 // @Success 201 {object} response.SuccessResponse{data=AuthResponse}
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 409 {object} response.ErrorResponse
+// @Failure 400 {object} response.ProblemDetails
+// @Failure 409 {object} response.ProblemDetails
 // @Router /auth/register [post]
 func (h *AuthHandler) Register(c *fiber.Ctx) error {
-	var req RegisterRequest
-
-	// Parse request body
-	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body")
-	}
+	var req dto.RegisterRequest
 
-	// Validate request
-	if err := h.validate.Struct(req); err != nil {
-		return response.ValidationError(c, err)
-	}
-
-	// Check if the required fields are provided
-	if req.Email == "" || req.Password == "" {
-		return response.BadRequest(c, "Email and password are required")
+	// Parse and validate request body
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
 	// Create user model
@@ -101,43 +87,39 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	// Check if the user already exists by email
 	existingUser, _ := h.authService.FindUserByEmail(c.Context(), req.Email)
 	if existingUser != nil {
-		return response.Conflict(c, "Email already exists")
+		return services.ErrEmailExists
 	}
 
 	// Check if username is provided and if it already exists
 	if req.Username != nil && *req.Username != "" {
 		existingUser, _ := h.authService.FindUserByUsername(c.Context(), *req.Username)
 		if existingUser != nil {
-			return response.Conflict(c, "Username already exists")
+			return services.ErrUsernameExists
 		}
 	}
 
-	// ไม่ต้องเข้ารหัสรหัสผ่านเพื่อให้การทดสอบทำได้ง่ายขึ้น
-	// hashedPassword, err := models.HashPassword(req.Password)
-	// if err != nil {
-	// 	return response.InternalServerError(c, "Failed to process registration")
-	// }
-	// user.Password = &hashedPassword
-	
-	// ใช้รหัสผ่านแบบไม่เข้ารหัส
-	password := req.Password
-	user.Password = &password
+	// เข้ารหัสรหัสผ่านก่อนบันทึก
+	hashedPassword, err := h.authService.HashPassword(req.Password)
+	if err != nil {
+		return apperrors.Internal("Failed to register user", err)
+	}
+	user.Password = &hashedPassword
 
 	// Save the user directly to the database
 	if err := h.authService.CreateUser(c.Context(), user); err != nil {
-		return response.InternalServerError(c, "Failed to register user")
+		return apperrors.Internal("Failed to register user", err)
 	}
 
 	// Generate JWT token
 	token, err := h.authService.GenerateAccessToken(user)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to generate token")
+		return services.ErrTokenGeneration.Wrap(err)
 	}
 
 	// Generate refresh token
 	refreshToken, err := h.authService.GenerateRefreshToken()
 	if err != nil {
-		return response.InternalServerError(c, "Failed to generate refresh token")
+		return services.ErrTokenGeneration.Wrap(err)
 	}
 
 	// Clear sensitive data
@@ -158,52 +140,73 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Param request body LoginRequest true "User credentials"
+// @Param request body dto.LoginRequest true "User credentials"
 // @Success 200 {object} response.SuccessResponse{data=AuthResponse}
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 401 {object} response.ErrorResponse
-// @Failure 429 {object} response.ErrorResponse
+// @Failure 400 {object} response.ProblemDetails
+// @Failure 401 {object} response.ProblemDetails
+// @Failure 429 {object} response.ProblemDetails
 // @Router /auth/login [post]
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
-	var req LoginRequest
+	var req dto.LoginRequest
 
-	// Parse request body
-	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body")
+	// Parse and validate request body
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
-	// Validate request
-	if err := h.validate.Struct(req); err != nil {
-		return response.ValidationError(c, err)
+	// Authenticate user
+	user, _, err := h.authService.Login(c, req.Email, req.Password)
+	if err != nil {
+		var locked *services.AccountLockedError
+		if errors.As(err, &locked) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(locked.RetryAfter().Seconds()))))
+			return err
+		}
+		if errors.Is(err, services.ErrPasswordResetRequired) {
+			return err
+		}
+		return apperrors.Unauthorized(apperrors.INVALID_CREDENTIALS, "Invalid email or password")
 	}
 
-	// Check rate limiting (pseudo-code, implement your rate limiter)
-	// if rateLimited := checkRateLimit(c.IP()); rateLimited {
-	// 	return response.TooManyRequests(c, "Too many login attempts. Please try again later.")
-	// }
+	// Accounts with MFA get a challenge instead, exchanged for tokens at /auth/mfa/verify
+	if user.MFAEnabled {
+		challenge, err := h.authService.GenerateMFAChallenge(user)
+		if err != nil {
+			return services.ErrTokenGeneration.Wrap(err)
+		}
+		return response.Success(c, MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    challenge,
+		}, "Multi-factor authentication required")
+	}
 
+	return sendTokens(c, h.authService, user, "Login successful")
+}
 
-	// Authenticate user
-	user, token, err := h.authService.Login(c, req.Email, req.Password)
+// sendTokens issues an access and a refresh token to a user who has completed login
+func sendTokens(c *fiber.Ctx, authService services.AuthService, user *models.User, message string) error {
+	tokens, err := issueTokens(authService, user)
 	if err != nil {
-		// Log failed login attempt
-		// incrementFailedAttempts(c.IP())
-		return response.Unauthorized(c, "Invalid email or password")
+		return err
 	}
+	return response.Success(c, tokens, message)
+}
 
+// issueTokens generates the tokens of a user who has completed login
+func issueTokens(authService services.AuthService, user *models.User) (*AuthResponse, error) {
 	// Generate refresh token with expiration
-	expiresIn := int(h.authService.GetJWTExpiration().Seconds())
+	expiresIn := int(authService.GetJWTExpiration().Seconds())
 	
 	// Generate access token with expiration claim
-	token, err = h.authService.GenerateAccessToken(user)
+	token, err := authService.GenerateAccessToken(user)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to generate access token")
+		return nil, services.ErrTokenGeneration.Wrap(err)
 	}
 
 	// Generate refresh token
-	refreshToken, err := h.authService.GenerateRefreshToken()
+	refreshToken, err := authService.GenerateRefreshToken()
 	if err != nil {
-		return response.InternalServerError(c, "Failed to generate refresh token")
+		return nil, services.ErrTokenGeneration.Wrap(err)
 	}
 
 	// TODO: Store refresh token in database with user ID and expiration
@@ -214,13 +217,13 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	// Clear sensitive data
 	user.Password = nil
 
-	// Return tokens
-	return response.Success(c, AuthResponse{
-		AccessToken:  token,
-		RefreshToken: refreshToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    expiresIn,
-	}, "Login successful")
+	return &AuthResponse{
+		AccessToken:           token,
+		RefreshToken:          refreshToken,
+		TokenType:             "Bearer",
+		ExpiresIn:             expiresIn,
+		MFAEnrollmentRequired: !user.MFAEnabled && authService.MFARequired(user.Role),
+	}, nil
 }
 
 // GetCurrentUser returns the current authenticated user's profile
@@ -230,19 +233,19 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 // @Security BearerAuth
 // @Produce json
 // @Success 200 {object} response.SuccessResponse{data=models.User}
-// @Failure 401 {object} response.ErrorResponse
+// @Failure 401 {object} response.ProblemDetails
 // @Router /auth/me [get]
 func (h *AuthHandler) GetCurrentUser(c *fiber.Ctx) error {
 	// Get user ID from context (set by auth middleware)
 	userID, ok := c.Locals("userID").(int)
 	if !ok || userID == 0 {
-		return response.Unauthorized(c, "Unauthorized")
+		return apperrors.Unauthorized(apperrors.UNAUTHORIZED, "Unauthorized")
 	}
 
 	// Get user from database
 	user, err := h.authService.GetUserByID(c.Context(), userID)
 	if err != nil {
-		return response.NotFound(c, "User not found")
+		return err
 	}
 
 	// Clear sensitive data
@@ -257,22 +260,17 @@ func (h *AuthHandler) GetCurrentUser(c *fiber.Ctx) error {
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Param request body RefreshTokenRequest true "Refresh token"
+// @Param request body dto.RefreshTokenRequest true "Refresh token"
 // @Success 200 {object} response.SuccessResponse{data=AuthResponse}
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 401 {object} response.ErrorResponse
+// @Failure 400 {object} response.ProblemDetails
+// @Failure 401 {object} response.ProblemDetails
 // @Router /auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
-	var req RefreshTokenRequest
+	var req dto.RefreshTokenRequest
 
-	// Parse request body
-	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body")
-	}
-
-	// Validate request
-	if err := h.validate.Struct(req); err != nil {
-		return response.ValidationError(c, err)
+	// Parse and validate request body
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
 
 	// Check if refresh token is valid and not revoked
@@ -292,14 +290,14 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 	// Generate new access token
 	// token, err := h.authService.GenerateAccessToken(user)
 	// if err != nil {
-	// 	return response.InternalServerError(c, "Failed to generate access token")
+	// 	return services.ErrTokenGeneration.Wrap(err)
 	// }
 
 
 	// Generate new refresh token (optional: implement refresh token rotation)
 	// newRefreshToken, err := h.authService.GenerateRefreshToken()
 	// if err != nil {
-	// 	return response.InternalServerError(c, "Failed to generate refresh token")
+	// 	return services.ErrTokenGeneration.Wrap(err)
 	// }
 
 
@@ -332,19 +330,19 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 // @Security BearerAuth
 // @Produce json
 // @Success 204
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 401 {object} response.ErrorResponse
+// @Failure 400 {object} response.ProblemDetails
+// @Failure 401 {object} response.ProblemDetails
 // @Router /auth/logout [post]
 func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 	// Get token from Authorization header
 	authHeader := c.Get("Authorization")
 	if authHeader == "" {
-		return response.BadRequest(c, "Missing authorization header")
+		return apperrors.Unauthorized(apperrors.MISSING_TOKEN, "Missing authorization header")
 	}
 
 	// Check if token starts with "Bearer "
 	if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
-		return response.BadRequest(c, "Invalid authorization header format")
+		return apperrors.Unauthorized(apperrors.INVALID_TOKEN, "Invalid authorization header format")
 	}
 
 	// Extract token (without "Bearer " prefix)
@@ -363,7 +361,7 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 
 	// Invalidate the access token
 	if err := h.authService.Logout(token); err != nil {
-		return response.InternalServerError(c, "Failed to invalidate access token")
+		return apperrors.Internal("Failed to invalidate access token", err)
 	}
 
 	// If refresh token is provided, invalidate it as well
@@ -378,6 +376,31 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
+// ResetPassword sets a new password with a single-use reset token an admin issued for the account
+// @Summary Reset password
+// @Description Replace the password of an account with a reset token from an admin, required before accounts flagged for a reset can log in
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} response.SuccessResponse
+// @Failure 400 {object} response.ProblemDetails
+// @Failure 401 {object} response.ProblemDetails
+// @Failure 429 {object} response.ProblemDetails
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var req dto.ResetPasswordRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.authService.ResetPassword(c.Context(), req.Token, req.NewPassword); err != nil {
+		return err
+	}
+
+	return response.Success(c, nil, "Password reset successfully, please log in with the new password")
+}
+
 /* TODO: Implement Google OAuth handlers in the future
 func (h *AuthHandler) GoogleLogin(c *fiber.Ctx) error {
 {{ ... }}
@@ -389,16 +412,12 @@ func (h *AuthHandler) GoogleLogin(c *fiber.Ctx) error {
 func (h *AuthHandler) GoogleCallback(c *fiber.Ctx) error {
 	code := c.Query("code")
 	if code == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "code is required",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_PARAMETER, "code is required")
 	}
 
 	token, err := h.authService.GoogleCallback(c.Context(), code)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.JSON(fiber.Map{
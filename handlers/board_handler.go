@@ -0,0 +1,88 @@
+package handler
+
+import (
+	apperrors "rota-api/errors"
+	"rota-api/services"
+	"rota-api/utils/servicetime"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Board paging limits
+const (
+	defaultBoardLimit  = 10
+	maxBoardLimit      = 50
+	defaultBoardWindow = 3 * time.Hour
+	maxBoardWindow     = 24 * time.Hour
+)
+
+type BoardHandler struct {
+	boardService services.BoardService
+}
+
+func NewBoardHandler(boardService services.BoardService) *BoardHandler {
+	return &BoardHandler{
+		boardService: boardService,
+	}
+}
+
+// parseBoardRequest reads the board query parameters:
+// type (departures|arrivals), at (RFC3339, default now), window (e.g. 90m, default 3h),
+// limit (default 10) and cursor (from a previous page)
+func parseBoardRequest(c *fiber.Ctx) (services.BoardRequest, error) {
+	stationID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return services.BoardRequest{}, apperrors.BadRequest(apperrors.INVALID_ID, "Invalid station ID")
+	}
+
+	at, err := parseTimeParam(c.Query("at"))
+	if err != nil {
+		return services.BoardRequest{}, apperrors.BadRequest(apperrors.INVALID_TIME, "Invalid at format. Use ISO8601 (e.g. 2025-06-02T08:00:00Z)")
+	}
+	if at == nil {
+		now := servicetime.Now()
+		at = &now
+	}
+
+	window := defaultBoardWindow
+	if param := c.Query("window"); param != "" {
+		window, err = time.ParseDuration(param)
+		if err != nil || window <= 0 || window > maxBoardWindow {
+			return services.BoardRequest{}, apperrors.BadRequest(apperrors.INVALID_PARAMETER, "Invalid window. Use a duration up to 24h (e.g. 90m, 3h)")
+		}
+	}
+
+	limit := c.QueryInt("limit", defaultBoardLimit)
+	if limit < 1 || limit > maxBoardLimit {
+		return services.BoardRequest{}, apperrors.BadRequest(apperrors.INVALID_PARAMETER, "limit must be between 1 and 50")
+	}
+
+	return services.BoardRequest{
+		StationID: uint(stationID),
+		Type:      c.Query("type"),
+		At:        *at,
+		Window:    window,
+		Limit:     limit,
+		Cursor:    c.Query("cursor"),
+	}, nil
+}
+
+// GetStationBoard returns the next departures (or arrivals) at a station with live status,
+// platform and delay, paged forward and backward in time with next_cursor/prev_cursor
+func (h *BoardHandler) GetStationBoard(c *fiber.Ctx) error {
+	req, err := parseBoardRequest(c)
+	if err != nil {
+		return err
+	}
+
+	board, err := h.boardService.GetStationBoard(c.Context(), req)
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.STATION_NOT_FOUND, "Station not found")
+	}
+
+	return c.JSON(fiber.Map{
+		"board": board,
+	})
+}
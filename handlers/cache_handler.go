@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"rota-api/repositories"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CacheHandler struct {
+	cacheRepo repositories.CacheRepository
+}
+
+func NewCacheHandler(cacheRepo repositories.CacheRepository) *CacheHandler {
+	return &CacheHandler{
+		cacheRepo: cacheRepo,
+	}
+}
+
+// GetCacheStats returns the read-through cache hit/miss counters since startup
+func (h *CacheHandler) GetCacheStats(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"cache": h.cacheRepo.Stats(),
+	})
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	apperrors "rota-api/errors"
+	"rota-api/services"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ConflictHandler struct {
+	conflictService services.ConflictService
+}
+
+func NewConflictHandler(conflictService services.ConflictService) *ConflictHandler {
+	return &ConflictHandler{
+		conflictService: conflictService,
+	}
+}
+
+// ValidateConflicts reports every vehicle and driver conflict among the trips departing
+// between `from` and `to` (RFC3339). `from` defaults to now and `to` to seven days later
+func (h *ConflictHandler) ValidateConflicts(c *fiber.Ctx) error {
+	from, err := parseTimeParam(c.Query("from"))
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_TIME, "Invalid from format. Use ISO8601 (e.g. 2025-06-02T08:00:00Z)")
+	}
+	to, err := parseTimeParam(c.Query("to"))
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_TIME, "Invalid to format. Use ISO8601 (e.g. 2025-06-02T08:00:00Z)")
+	}
+
+	now := time.Now()
+	if from == nil {
+		from = &now
+	}
+	if to == nil {
+		end := from.AddDate(0, 0, 7)
+		to = &end
+	}
+	if !to.After(*from) {
+		return apperrors.BadRequest(apperrors.INVALID_PARAMETER, "to must be after from")
+	}
+
+	report, err := h.conflictService.ValidateRange(c.Context(), *from, *to)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"report": report,
+	})
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"html/template"
+	"log"
+	"rota-api/dto"
+	apperrors "rota-api/errors"
+	"rota-api/i18n"
+	"rota-api/models"
+	"rota-api/services"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+//go:embed templates/station_display.html
+var displayTemplates embed.FS
+
+var stationDisplayTemplate = template.Must(template.ParseFS(displayTemplates, "templates/station_display.html"))
+
+type DisplayHandler struct {
+	displayService  services.DisplayService
+	refreshInterval time.Duration
+}
+
+func NewDisplayHandler(displayService services.DisplayService, refreshInterval time.Duration) *DisplayHandler {
+	return &DisplayHandler{
+		displayService:  displayService,
+		refreshInterval: refreshInterval,
+	}
+}
+
+// RenderStationDisplay serves the full-screen signage board of a station as HTML
+func (h *DisplayHandler) RenderStationDisplay(c *fiber.Ctx) error {
+	stationID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid station ID")
+	}
+
+	display, err := h.displayService.GetStationDisplay(c.Context(), uint(stationID))
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.STATION_NOT_FOUND, "Station not found")
+	}
+
+	var page bytes.Buffer
+	if err := stationDisplayTemplate.Execute(&page, struct {
+		*models.StationDisplay
+		EventsURL string
+	}{display, fmt.Sprintf("/display/stations/%d/events?lang=%s", stationID, display.Locale)}); err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Type("html", "utf-8")
+	return c.Send(page.Bytes())
+}
+
+// StreamStationDisplay is a Server-Sent Events stream telling a signage board to reload.
+// It sends a `hello` event with the current fingerprint on connect, then a `refresh`
+// event whenever the station's schedules, statuses or theme change
+func (h *DisplayHandler) StreamStationDisplay(c *fiber.Ctx) error {
+	stationID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid station ID")
+	}
+
+	display, err := h.displayService.GetStationDisplay(c.Context(), uint(stationID))
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.STATION_NOT_FOUND, "Station not found")
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	displayService := h.displayService
+	interval := h.refreshInterval
+	// The request context is gone once streaming starts, so keep the negotiated language for the polls
+	locale := i18n.FromContext(c.Context())
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		fingerprint := display.Fingerprint
+		fmt.Fprintf(w, "retry: 5000\nevent: hello\ndata: %s\n\n", fingerprint)
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(i18n.WithLocale(context.Background(), locale), interval)
+			current, err := displayService.GetStationDisplay(ctx, uint(stationID))
+			cancel()
+
+			switch {
+			case err != nil:
+				log.Printf("Display stream for station %d: %v", stationID, err)
+				fmt.Fprint(w, ": keep-alive\n\n")
+			case current.Fingerprint != fingerprint:
+				fingerprint = current.Fingerprint
+				fmt.Fprintf(w, "event: refresh\ndata: %s\n\n", fingerprint)
+			default:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+
+			// A failed flush means the display has disconnected
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func (h *DisplayHandler) GetDisplayTheme(c *fiber.Ctx) error {
+	stationID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid station ID")
+	}
+
+	theme, err := h.displayService.GetTheme(c.Context(), uint(stationID))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"theme": theme,
+	})
+}
+
+func (h *DisplayHandler) UpdateDisplayTheme(c *fiber.Ctx) error {
+	stationID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid station ID")
+	}
+
+	var req dto.DisplayThemeRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	theme := req.ToModel(uint(stationID))
+	if err := h.displayService.UpdateTheme(c.Context(), &theme); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Display theme updated successfully",
+		"theme":   theme,
+	})
+}
+
+func (h *DisplayHandler) ResetDisplayTheme(c *fiber.Ctx) error {
+	stationID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid station ID")
+	}
+
+	if err := h.displayService.ResetTheme(c.Context(), uint(stationID)); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Display theme reset to default",
+	})
+}
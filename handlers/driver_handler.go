@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"rota-api/dto"
+	apperrors "rota-api/errors"
+	"rota-api/models"
+	"rota-api/pagination"
+	"rota-api/patch"
+	"rota-api/services"
+	"rota-api/utils/servicetime"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DriverHandler struct {
+	driverService services.DriverService
+}
+
+func NewDriverHandler(driverService services.DriverService) *DriverHandler {
+	return &DriverHandler{
+		driverService: driverService,
+	}
+}
+
+// parseRosterDate parses the `date` query parameter as a service date (YYYY-MM-DD), defaulting to today's
+func parseRosterDate(c *fiber.Ctx) (time.Time, error) {
+	date := c.Query("date")
+	if date == "" {
+		return servicetime.ServiceDate(time.Now()), nil
+	}
+	return servicetime.ParseDate(date)
+}
+
+func (h *DriverHandler) GetDriverByID(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid driver ID")
+	}
+
+	driver, err := h.driverService.GetDriverByID(c.Context(), uint(id))
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.DRIVER_NOT_FOUND, "Driver not found")
+	}
+
+	return sendVersioned(c, driver.Version, fiber.Map{
+		"driver": driver,
+	})
+}
+
+func (h *DriverHandler) GetAllDrivers(c *fiber.Ctx) error {
+	params, err := pagination.ParseParams(c)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.driverService.GetAllDrivers(c.Context(), params)
+	if err != nil {
+		return err
+	}
+
+	pagination.SetLinks(c, &result)
+	return c.JSON(pageBody("drivers", result))
+}
+
+func (h *DriverHandler) CreateDriver(c *fiber.Ctx) error {
+	var req dto.CreateDriverRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	driver := req.ToModel()
+
+	if err := h.driverService.CreateDriver(c.Context(), &driver); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Driver created successfully",
+		"driver":  driver,
+	})
+}
+
+func (h *DriverHandler) UpdateDriver(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid driver ID")
+	}
+
+	driver, err := h.driverService.GetDriverByID(c.Context(), uint(id))
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.DRIVER_NOT_FOUND, "Driver not found")
+	}
+	if err := checkIfMatch(c, driver.Version); err != nil {
+		return err
+	}
+
+	var req dto.UpdateDriverRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	req.Apply(driver)
+
+	if err := h.driverService.UpdateDriver(c.Context(), driver); err != nil {
+		return err
+	}
+
+	setVersion(c, driver.Version)
+	return c.JSON(fiber.Map{
+		"message": "Driver updated successfully",
+		"driver":  driver,
+	})
+}
+
+// driverWritable lists the driver fields each role may change with PATCH
+var driverWritable = patch.Policy{
+	models.RoleAdmin: {"name", "license_number", "license_expiry", "phone", "email", "active"},
+}
+
+// PatchDriver applies a JSON Merge Patch or JSON Patch to a driver. Members set to null are cleared
+func (h *DriverHandler) PatchDriver(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid driver ID")
+	}
+
+	var req dto.CreateDriverRequest
+	driver, err := h.driverService.PatchDriver(c.Context(), uint(id), patchWith[models.Driver](c, driverWritable, &req))
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.DRIVER_NOT_FOUND, "Driver not found")
+	}
+
+	setVersion(c, driver.Version)
+	return c.JSON(fiber.Map{
+		"message": "Driver updated successfully",
+		"driver":  driver,
+	})
+}
+
+func (h *DriverHandler) DeleteDriver(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid driver ID")
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.driverService.DeleteDriver(c.Context(), uint(id), version); err != nil {
+		return apperrors.NotFoundIf(err, apperrors.DRIVER_NOT_FOUND, "Driver not found")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Driver deleted successfully",
+	})
+}
+
+// GetRoster returns every rostered driver's trips, rest periods and rule violations for a day
+func (h *DriverHandler) GetRoster(c *fiber.Ctx) error {
+	date, err := parseRosterDate(c)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_DATE, "Invalid date format. Use YYYY-MM-DD")
+	}
+
+	roster, err := h.driverService.GetRoster(c.Context(), date)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"roster": roster,
+	})
+}
+
+// GetDriverRoster returns a single driver's duty for a day
+func (h *DriverHandler) GetDriverRoster(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid driver ID")
+	}
+
+	date, err := parseRosterDate(c)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_DATE, "Invalid date format. Use YYYY-MM-DD")
+	}
+
+	duty, err := h.driverService.GetDriverDuty(c.Context(), uint(id), date)
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.DRIVER_NOT_FOUND, "Driver not found")
+	}
+
+	return c.JSON(fiber.Map{
+		"duty": duty,
+	})
+}
+
+// AssignDriver rosters a driver onto a schedule trip
+func (h *DriverHandler) AssignDriver(c *fiber.Ctx) error {
+	scheduleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid schedule ID")
+	}
+
+	var req dto.AssignDriverRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	schedule, err := h.driverService.AssignDriver(c.Context(), uint(scheduleID), req.DriverID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "Driver assigned successfully",
+		"schedule": schedule,
+	})
+}
+
+// UnassignDriver removes the driver from a schedule trip
+func (h *DriverHandler) UnassignDriver(c *fiber.Ctx) error {
+	scheduleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid schedule ID")
+	}
+
+	schedule, err := h.driverService.UnassignDriver(c.Context(), uint(scheduleID))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message":  "Driver unassigned successfully",
+		"schedule": schedule,
+	})
+}
@@ -0,0 +1,14 @@
+package handler
+
+import (
+	"rota-api/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorHandler is the application's Fiber error handler. Handlers and middleware return errors
+// instead of writing error bodies, and every error is reported here as a problem document
+// with the status and stable code of the domain error it resolves to
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	return response.Problem(c, err)
+}
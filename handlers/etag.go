@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"strconv"
+	"strings"
+
+	apperrors "rota-api/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	// errIfMatchRequired is returned when a write to a versioned resource does not say which version it was read at
+	errIfMatchRequired = apperrors.New(http.StatusPreconditionRequired, apperrors.PRECONDITION_NEEDED, "If-Match header with the resource ETag is required")
+	// errIfMatchFailed is returned when If-Match names a version other than the stored one
+	errIfMatchFailed = apperrors.New(http.StatusPreconditionFailed, apperrors.PRECONDITION_FAILED, "If-Match does not match the current version of the resource")
+)
+
+// entityTag is the strong ETag of a resource at version. Reads tag the version with a digest of the
+// representation as well, since it also depends on the locale and on the records it embeds
+func entityTag(version int, body []byte) string {
+	if body == nil {
+		return fmt.Sprintf(`"%d"`, version)
+	}
+	return fmt.Sprintf(`"%d-%08x"`, version, crc32.ChecksumIEEE(body))
+}
+
+// setVersion tags the response with the version of the resource it carries
+func setVersion(c *fiber.Ctx, version int) {
+	c.Set(fiber.HeaderETag, entityTag(version, nil))
+}
+
+// sendVersioned sends body as the representation of a resource at version,
+// or 304 Not Modified when If-None-Match already names it
+func sendVersioned(c *fiber.Ctx, version int, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	tag := entityTag(version, data)
+	c.Set(fiber.HeaderETag, tag)
+	if noneMatch(c.Get(fiber.HeaderIfNoneMatch), tag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(data)
+}
+
+// noneMatch reports whether an If-None-Match header names tag. It compares weakly, as If-None-Match does
+func noneMatch(header, tag string) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatch returns the version the request's If-Match header requires the resource to be at,
+// 0 for "*". Writes to versioned resources must send one
+func ifMatch(c *fiber.Ctx) (int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" {
+		return 0, errIfMatchRequired
+	}
+	if header == "*" {
+		return 0, nil
+	}
+
+	// If-Match compares strongly, so weak tags never match; tags of reads carry a digest after the version
+	tag, err := strconv.Unquote(header)
+	if err != nil {
+		return 0, errIfMatchFailed
+	}
+	version, _, _ := strings.Cut(tag, "-")
+	n, err := strconv.Atoi(version)
+	if err != nil || n <= 0 {
+		return 0, errIfMatchFailed
+	}
+	return n, nil
+}
+
+// checkIfMatch reports a version conflict when the request's If-Match names another version than current
+func checkIfMatch(c *fiber.Ctx, current int) error {
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+	if version != 0 && version != current {
+		return errIfMatchFailed.With("current_version", current)
+	}
+	return nil
+}
@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"rota-api/dto"
+	apperrors "rota-api/errors"
 	"rota-api/models"
+	"rota-api/pagination"
+	"rota-api/patch"
 	"rota-api/services"
 	"rota-api/utils"
 	"strconv"
@@ -24,41 +28,45 @@ func NewFavoriteHandler(favoriteService services.FavoriteService) *FavoriteHandl
 func (h *FavoriteHandler) GetFavoriteByID(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid favorite ID")
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid favorite ID")
 	}
 
 	favorite, err := h.favoriteService.GetFavoriteByID(c.Context(), uint(id))
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusNotFound, "Favorite not found")
+		return apperrors.NotFoundIf(err, apperrors.FAVORITE_NOT_FOUND, "Favorite not found")
 	}
 
-	return utils.SuccessResponse(c, fiber.StatusOK, "Favorite retrieved successfully", fiber.Map{
-		"favorite": favorite,
+	return sendVersioned(c, favorite.Version, fiber.Map{
+		"success": true,
+		"message": "Favorite retrieved successfully",
+		"data": fiber.Map{
+			"favorite": favorite,
+		},
 	})
 }
 
 func (h *FavoriteHandler) GetAllFavorites(c *fiber.Ctx) error {
-	favorites, err := h.favoriteService.GetAllFavorites(c.Context())
+	params, err := pagination.ParseParams(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"favorites": favorites,
-	})
+	result, err := h.favoriteService.GetAllFavorites(c.Context(), params)
+	if err != nil {
+		return err
+	}
+
+	pagination.SetLinks(c, &result)
+	return c.JSON(pageBody("favorites", result))
 }
 
 func (h *FavoriteHandler) CreateFavorite(c *fiber.Ctx) error {
 	// Parse request body
-	var favorite models.Favorite
-	if err := c.BodyParser(&favorite); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid request body",
-		})
+	var req dto.FavoriteRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
+	favorite := req.ToModel()
 
 	// Get user ID and role from context (set by AuthMiddleware)
 	userID, _ := c.Locals("userID").(int)
@@ -70,18 +78,12 @@ func (h *FavoriteHandler) CreateFavorite(c *fiber.Ctx) error {
 
 	// Security check: ensure user can only create favorites for themselves unless they're an admin
 	if userRole != models.RoleAdmin && int(favorite.UserID) != userID {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"success": false,
-			"message": "forbidden: you can only create favorites for your own account",
-		})
+		return apperrors.Forbidden(apperrors.FAVORITE_FORBIDDEN, "forbidden: you can only create favorites for your own account")
 	}
 
 	// Create favorite
 	if err := h.favoriteService.CreateFavorite(c.Context(), &favorite); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -93,25 +95,54 @@ func (h *FavoriteHandler) CreateFavorite(c *fiber.Ctx) error {
 func (h *FavoriteHandler) UpdateFavorite(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid favorite ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid favorite ID")
 	}
 
-	var favorite models.Favorite
-	if err := c.BodyParser(&favorite); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+
+	var req dto.FavoriteRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
+	favorite := req.ToModel()
 
 	favorite.ID = uint(id)
+	favorite.Version = version
 	if err := h.favoriteService.UpdateFavorite(c.Context(), &favorite); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return apperrors.NotFoundIf(err, apperrors.FAVORITE_NOT_FOUND, "Favorite not found")
 	}
 
+	setVersion(c, favorite.Version)
+	return c.JSON(fiber.Map{
+		"message":  "Favorite updated successfully",
+		"favorite": favorite,
+	})
+}
+
+// favoriteWritable lists the favorite fields each role may change with PATCH
+var favoriteWritable = patch.Policy{
+	models.RoleAdmin: {"user_id", "station_id"},
+	models.RoleStaff: {"station_id"},
+	models.RoleUser:  {"station_id"},
+}
+
+// PatchFavorite applies a JSON Merge Patch or JSON Patch to a favorite. Members set to null are cleared
+func (h *FavoriteHandler) PatchFavorite(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid favorite ID")
+	}
+
+	var req dto.FavoriteRequest
+	favorite, err := h.favoriteService.PatchFavorite(c.Context(), uint(id), patchWith[models.Favorite](c, favoriteWritable, &req))
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.FAVORITE_NOT_FOUND, "Favorite not found")
+	}
+
+	setVersion(c, favorite.Version)
 	return c.JSON(fiber.Map{
 		"message":  "Favorite updated successfully",
 		"favorite": favorite,
@@ -121,15 +152,16 @@ func (h *FavoriteHandler) UpdateFavorite(c *fiber.Ctx) error {
 func (h *FavoriteHandler) DeleteFavorite(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid favorite ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid favorite ID")
 	}
 
-	if err := h.favoriteService.DeleteFavorite(c.Context(), uint(id)); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.favoriteService.DeleteFavorite(c.Context(), uint(id), version); err != nil {
+		return apperrors.NotFoundIf(err, apperrors.FAVORITE_NOT_FOUND, "Favorite not found")
 	}
 
 	return c.JSON(fiber.Map{
@@ -142,26 +174,26 @@ func (h *FavoriteHandler) AddStationToFavorites(c *fiber.Ctx) error {
 	if !ok {
 		userIDInt, ok := c.Locals("userID").(int)
 		if !ok {
-			return utils.ErrorResponse(c, fiber.StatusUnauthorized, "User not authenticated")
+			return apperrors.Unauthorized(apperrors.UNAUTHORIZED, "User not authenticated")
 		}
 		userID = uint(userIDInt)
 	}
 
 	stationID, err := strconv.ParseUint(c.Params("stationId"), 10, 32)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid station ID")
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid station ID")
 	}
 
 	favorite, err := h.favoriteService.AddFavorite(c.Context(), userID, uint(stationID))
 	if err != nil {
-		if err.Error() == "station is already a favorite" {
+		if errors.Is(err, services.ErrFavoriteExists) {
 			existingFavorite, findErr := h.favoriteService.GetFavoriteByUserAndStation(c.Context(), userID, uint(stationID))
 			if findErr != nil {
-				return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to fetch favorite information")
+				return apperrors.Internal("Failed to fetch favorite information", findErr)
 			}
 			return utils.SuccessResponse(c, fiber.StatusOK, "Station is already in your favorites", existingFavorite)
 		}
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to add favorite: "+err.Error())
+		return err
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusCreated, "Station added to favorites", favorite)
@@ -172,26 +204,23 @@ func (h *FavoriteHandler) RemoveStationByStationId(c *fiber.Ctx) error {
 	if !ok {
 		userIDInt, ok := c.Locals("userID").(int)
 		if !ok {
-			return utils.ErrorResponse(c, fiber.StatusUnauthorized, "User not authenticated")
+			return apperrors.Unauthorized(apperrors.UNAUTHORIZED, "User not authenticated")
 		}
 		userID = uint(userIDInt)
 	}
 
 	stationID, err := strconv.ParseUint(c.Params("stationId"), 10, 32)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid station ID")
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid station ID")
 	}
 
 	favorite, err := h.favoriteService.GetFavoriteByUserAndStation(c.Context(), userID, uint(stationID))
 	if err != nil {
-		if err.Error() == "favorite not found" {
-			return utils.ErrorResponse(c, fiber.StatusNotFound, "Station not in favorites")
-		}
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to find favorite: "+err.Error())
+		return apperrors.NotFoundIf(err, apperrors.FAVORITE_NOT_FOUND, "Station not in favorites")
 	}
 
 	if err := h.favoriteService.RemoveFavorite(c.Context(), favorite.ID, userID); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to remove favorite: "+err.Error())
+		return err
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Station removed from favorites", nil)
@@ -202,24 +231,18 @@ func (h *FavoriteHandler) RemoveStationFromFavorites(c *fiber.Ctx) error {
 	if !ok {
 		userIDInt, ok := c.Locals("userID").(int)
 		if !ok {
-			return utils.ErrorResponse(c, fiber.StatusUnauthorized, "User not authenticated")
+			return apperrors.Unauthorized(apperrors.UNAUTHORIZED, "User not authenticated")
 		}
 		userID = uint(userIDInt)
 	}
 
 	favoriteID, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "Invalid favorite ID")
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid favorite ID")
 	}
 
 	if err := h.favoriteService.RemoveFavorite(c.Context(), uint(favoriteID), userID); err != nil {
-		if err.Error() == "favorite not found" {
-			return utils.ErrorResponse(c, fiber.StatusNotFound, "Favorite not found")
-		}
-		if err.Error() == "unauthorized to remove this favorite" {
-			return utils.ErrorResponse(c, fiber.StatusForbidden, "You are not authorized to remove this favorite")
-		}
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to remove favorite: "+err.Error())
+		return apperrors.NotFoundIf(err, apperrors.FAVORITE_NOT_FOUND, "Favorite not found")
 	}
 
 	return utils.SuccessResponse(c, fiber.StatusOK, "Station removed from favorites", nil)
@@ -230,14 +253,14 @@ func (h *FavoriteHandler) GetUserFavorites(c *fiber.Ctx) error {
 	if !ok {
 		userIDInt, ok := c.Locals("userID").(int)
 		if !ok {
-			return utils.ErrorResponse(c, fiber.StatusUnauthorized, "User not authenticated")
+			return apperrors.Unauthorized(apperrors.UNAUTHORIZED, "User not authenticated")
 		}
 		userID = uint(userIDInt)
 	}
 
 	favorites, err := h.favoriteService.GetUserFavorites(c.Context(), userID)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to get favorites: "+err.Error())
+		return err
 	}
 
 	response := make([]dto.FavoriteStationResponse, 0, len(favorites))
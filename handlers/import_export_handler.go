@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"rota-api/dto"
+	apperrors "rota-api/errors"
+	"rota-api/models"
+	"rota-api/pagination"
+	"rota-api/services"
+	"rota-api/tabular"
+	"rota-api/utils/servicetime"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ImportExportHandler struct {
+	importExportService services.ImportExportService
+}
+
+func NewImportExportHandler(importExportService services.ImportExportService) *ImportExportHandler {
+	return &ImportExportHandler{
+		importExportService: importExportService,
+	}
+}
+
+func (h *ImportExportHandler) ImportStations(c *fiber.Ctx) error {
+	return h.runImport(c, dto.StationRow{}, h.importExportService.ImportStations)
+}
+
+func (h *ImportExportHandler) ImportVehicles(c *fiber.Ctx) error {
+	return h.runImport(c, dto.VehicleRow{}, h.importExportService.ImportVehicles)
+}
+
+func (h *ImportExportHandler) ImportStaff(c *fiber.Ctx) error {
+	return h.runImport(c, dto.StaffRow{}, h.importExportService.ImportStaff)
+}
+
+func (h *ImportExportHandler) ImportSchedules(c *fiber.Ctx) error {
+	return h.runImport(c, dto.ScheduleRow{}, h.importExportService.ImportSchedules)
+}
+
+func (h *ImportExportHandler) ExportStations(c *fiber.Ctx) error {
+	params, err := pagination.ParseParams(c)
+	if err != nil {
+		return err
+	}
+	stations, err := h.importExportService.ExportStations(c.Context(), params)
+	if err != nil {
+		return err
+	}
+	return sendSheet(c, "stations", dto.StationRow{}, stations, func(station *models.Station) interface{} {
+		return dto.StationRowOf(station)
+	})
+}
+
+func (h *ImportExportHandler) ExportVehicles(c *fiber.Ctx) error {
+	params, err := pagination.ParseParams(c)
+	if err != nil {
+		return err
+	}
+	vehicles, err := h.importExportService.ExportVehicles(c.Context(), params)
+	if err != nil {
+		return err
+	}
+	return sendSheet(c, "vehicles", dto.VehicleRow{}, vehicles, func(vehicle *models.Vehicle) interface{} {
+		return dto.VehicleRowOf(vehicle)
+	})
+}
+
+func (h *ImportExportHandler) ExportStaff(c *fiber.Ctx) error {
+	params, err := pagination.ParseParams(c)
+	if err != nil {
+		return err
+	}
+	staff, err := h.importExportService.ExportStaff(c.Context(), params)
+	if err != nil {
+		return err
+	}
+	return sendSheet(c, "staff", dto.StaffRow{}, staff, func(staff *models.Staff) interface{} {
+		return dto.StaffRowOf(staff)
+	})
+}
+
+func (h *ImportExportHandler) ExportSchedules(c *fiber.Ctx) error {
+	params, err := parseScheduleSearchParams(c)
+	if err != nil {
+		return err
+	}
+	schedules, err := h.importExportService.ExportSchedules(c.Context(), params)
+	if err != nil {
+		return err
+	}
+	return sendSheet(c, "schedules", dto.ScheduleRow{}, schedules, func(schedule *models.Schedule) interface{} {
+		return dto.ScheduleRowOf(schedule)
+	})
+}
+
+// runImport reads the spreadsheet uploaded as the "file" form field, with columns matched to the fields
+// of row, and passes it to run. The format is taken from the "format" form field or the file extension,
+// and "mapping" may hold a JSON object mapping column headers to field names. With dry_run=true the rows
+// are validated and counted but nothing is saved
+func (h *ImportExportHandler) runImport(
+	c *fiber.Ctx,
+	row interface{},
+	run func(ctx context.Context, sheet *tabular.Sheet, dryRun bool) (*models.ImportResult, error),
+) error {
+	upload, err := c.FormFile("file")
+	if err != nil {
+		return apperrors.BadRequest(apperrors.IMPORT_INVALID_FILE, "A spreadsheet must be uploaded as the file field")
+	}
+
+	format, ok := tabular.ParseFormat(c.FormValue("format"))
+	if !ok && c.FormValue("format") == "" {
+		format, ok = tabular.FormatOf(upload.Filename)
+	}
+	if !ok {
+		return apperrors.BadRequest(apperrors.IMPORT_INVALID_FILE, "Spreadsheets must be csv or xlsx")
+	}
+
+	var mapping map[string]string
+	if raw := c.FormValue("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			return apperrors.BadRequest(apperrors.IMPORT_INVALID_MAPPING, "mapping must be a JSON object of column headers to field names")
+		}
+	}
+
+	dryRun := false
+	if raw := c.FormValue("dry_run", c.Query("dry_run")); raw != "" {
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			return apperrors.BadRequest(apperrors.INVALID_PARAMETER, "Invalid dry_run parameter")
+		}
+	}
+
+	file, err := upload.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open upload: %w", err)
+	}
+	defer file.Close()
+
+	sheet, err := tabular.Read(file, format, tabular.Fields(row), mapping)
+	if err != nil {
+		return err
+	}
+
+	result, err := run(c.Context(), sheet, dryRun)
+	if err != nil {
+		return err
+	}
+
+	status := fiber.StatusOK
+	if !dryRun && result.Created > 0 {
+		status = fiber.StatusCreated
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"import": result,
+	})
+}
+
+// sendSheet sends records as a spreadsheet download in the format given by the "format" query
+// parameter, csv by default, with the columns of row and each record described by describe
+func sendSheet[T any](c *fiber.Ctx, name string, row interface{}, records []T, describe func(T) interface{}) error {
+	format := tabular.CSV
+	if raw := c.Query("format"); raw != "" {
+		var ok bool
+		if format, ok = tabular.ParseFormat(raw); !ok {
+			return apperrors.BadRequest(apperrors.EXPORT_INVALID_FORMAT, "format must be csv or xlsx")
+		}
+	}
+
+	rows := make([][]string, len(records))
+	for i, record := range records {
+		rows[i] = tabular.Encode(describe(record))
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, servicetime.Now().Format("20060102"), format)
+	c.Attachment(filename)
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	return tabular.Write(c.Response().BodyWriter(), format, name, tabular.Fields(row), rows)
+}
@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"rota-api/dto"
+	apperrors "rota-api/errors"
+	"rota-api/services"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type MaintenanceHandler struct {
+	maintenanceService services.MaintenanceService
+}
+
+func NewMaintenanceHandler(maintenanceService services.MaintenanceService) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		maintenanceService: maintenanceService,
+	}
+}
+
+func (h *MaintenanceHandler) GetMaintenanceRecords(c *fiber.Ctx) error {
+	vehicleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+
+	records, err := h.maintenanceService.GetMaintenanceRecords(c.Context(), uint(vehicleID))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"maintenance_records": records,
+	})
+}
+
+func (h *MaintenanceHandler) CreateMaintenanceRecord(c *fiber.Ctx) error {
+	vehicleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+
+	var req dto.MaintenanceRecordRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	record := req.ToModel(uint(vehicleID))
+	if err := h.maintenanceService.CreateMaintenanceRecord(c.Context(), &record); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":            "Maintenance record created successfully",
+		"maintenance_record": record,
+	})
+}
+
+func (h *MaintenanceHandler) DeleteMaintenanceRecord(c *fiber.Ctx) error {
+	vehicleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+	id, err := strconv.ParseUint(c.Params("recordId"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid maintenance record ID")
+	}
+
+	if err := h.maintenanceService.DeleteMaintenanceRecord(c.Context(), uint(vehicleID), uint(id)); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Maintenance record deleted successfully",
+	})
+}
+
+func (h *MaintenanceHandler) GetInspections(c *fiber.Ctx) error {
+	vehicleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+
+	inspections, err := h.maintenanceService.GetInspections(c.Context(), uint(vehicleID))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"inspections": inspections,
+	})
+}
+
+// GetDueInspections lists scheduled inspections due within `days` days (default 14)
+// or within `km` kilometres of the vehicle's current odometer (default 1000)
+func (h *MaintenanceHandler) GetDueInspections(c *fiber.Ctx) error {
+	days := c.QueryInt("days", 14)
+	km := c.QueryInt("km", 1000)
+	if days < 0 || km < 0 {
+		return apperrors.BadRequest(apperrors.INVALID_PARAMETER, "days and km must not be negative")
+	}
+
+	inspections, err := h.maintenanceService.GetDueInspections(c.Context(), time.Duration(days)*24*time.Hour, km)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"inspections": inspections,
+	})
+}
+
+func (h *MaintenanceHandler) ScheduleInspection(c *fiber.Ctx) error {
+	vehicleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+
+	var req dto.InspectionRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	inspection := req.ToModel(uint(vehicleID))
+	if err := h.maintenanceService.ScheduleInspection(c.Context(), &inspection); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":    "Inspection scheduled successfully",
+		"inspection": inspection,
+	})
+}
+
+func (h *MaintenanceHandler) CompleteInspection(c *fiber.Ctx) error {
+	vehicleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+	id, err := strconv.ParseUint(c.Params("inspectionId"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid inspection ID")
+	}
+
+	var req dto.CompleteInspectionRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	inspection, err := h.maintenanceService.CompleteInspection(c.Context(), uint(vehicleID), uint(id), req.Passed, req.Notes)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message":    "Inspection completed successfully",
+		"inspection": inspection,
+	})
+}
+
+func (h *MaintenanceHandler) DeleteInspection(c *fiber.Ctx) error {
+	vehicleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+	id, err := strconv.ParseUint(c.Params("inspectionId"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid inspection ID")
+	}
+
+	if err := h.maintenanceService.DeleteInspection(c.Context(), uint(vehicleID), uint(id)); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Inspection deleted successfully",
+	})
+}
+
+func (h *MaintenanceHandler) GetOutOfServiceWindows(c *fiber.Ctx) error {
+	vehicleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+
+	windows, err := h.maintenanceService.GetOutOfServiceWindows(c.Context(), uint(vehicleID))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"out_of_service_windows": windows,
+	})
+}
+
+func (h *MaintenanceHandler) CreateOutOfServiceWindow(c *fiber.Ctx) error {
+	vehicleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+
+	var req dto.OutOfServiceWindowRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	window := req.ToModel(uint(vehicleID))
+	if err := h.maintenanceService.CreateOutOfServiceWindow(c.Context(), &window); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":               "Vehicle taken out of service successfully",
+		"out_of_service_window": window,
+	})
+}
+
+func (h *MaintenanceHandler) CloseOutOfServiceWindow(c *fiber.Ctx) error {
+	vehicleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+	id, err := strconv.ParseUint(c.Params("windowId"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid out-of-service window ID")
+	}
+
+	var req dto.CloseOutOfServiceWindowRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	endTime := time.Now()
+	if req.EndTime != nil {
+		endTime = *req.EndTime
+	}
+
+	window, err := h.maintenanceService.CloseOutOfServiceWindow(c.Context(), uint(vehicleID), uint(id), endTime)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message":               "Vehicle returned to service successfully",
+		"out_of_service_window": window,
+	})
+}
+
+func (h *MaintenanceHandler) DeleteOutOfServiceWindow(c *fiber.Ctx) error {
+	vehicleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+	id, err := strconv.ParseUint(c.Params("windowId"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid out-of-service window ID")
+	}
+
+	if err := h.maintenanceService.DeleteOutOfServiceWindow(c.Context(), uint(vehicleID), uint(id)); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Out-of-service window deleted successfully",
+	})
+}
+
+// GetAvailability reports whether a vehicle is in service between `from` and `to` (RFC3339)
+// Both default to now, i.e. "is the vehicle available right now"
+func (h *MaintenanceHandler) GetAvailability(c *fiber.Ctx) error {
+	vehicleID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+
+	from, err := parseTimeParam(c.Query("from"))
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_TIME, "Invalid from format. Use ISO8601 (e.g. 2025-06-02T08:00:00Z)")
+	}
+	to, err := parseTimeParam(c.Query("to"))
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_TIME, "Invalid to format. Use ISO8601 (e.g. 2025-06-02T08:00:00Z)")
+	}
+
+	now := time.Now()
+	if from == nil {
+		from = &now
+	}
+	if to == nil || !to.After(*from) {
+		end := from.Add(time.Minute)
+		to = &end
+	}
+
+	availability, err := h.maintenanceService.GetAvailability(c.Context(), uint(vehicleID), *from, *to)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"availability": availability,
+	})
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"rota-api/dto"
+	"rota-api/response"
+	"rota-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MFAHandler handles multi-factor authentication: enrolment, the second login step and recovery codes
+type MFAHandler struct {
+	authService services.AuthService
+}
+
+// NewMFAHandler creates a new instance of MFAHandler
+func NewMFAHandler(authService services.AuthService) *MFAHandler {
+	return &MFAHandler{
+		authService: authService,
+	}
+}
+
+// GetStatus tells whether the signed-in user has MFA enabled, whether their role requires it,
+// and how many recovery codes they have left
+func (h *MFAHandler) GetStatus(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	status, err := h.authService.GetMFAStatus(c.Context(), int(userID))
+	if err != nil {
+		return err
+	}
+	return response.Success(c, status, "MFA status retrieved successfully")
+}
+
+// Enroll starts MFA enrolment, returning the secret and the otpauth:// provisioning URI to show as a QR code.
+// Accounts whose role requires MFA send the enrollment token an administrator issued them
+func (h *MFAHandler) Enroll(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req dto.MFAEnrollRequest
+	if len(c.Body()) > 0 {
+		if err := parseBody(c, &req); err != nil {
+			return err
+		}
+	}
+
+	enrollment, err := h.authService.EnrollMFA(c.Context(), int(userID), req.EnrollmentToken)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, enrollment, "Scan the QR code with your authenticator app, then activate MFA with a code from it")
+}
+
+// Activate enables MFA with a code from the enrolled authenticator. It returns the recovery codes, shown
+// only this once. The session is not upgraded: the user logs in again, with the second factor, to act
+// with a role that requires MFA
+func (h *MFAHandler) Activate(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req dto.MFACodeRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	codes, err := h.authService.ActivateMFA(c.Context(), int(userID), req.Code)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, fiber.Map{
+		"recovery_codes": codes,
+	}, "MFA enabled, store your recovery codes safely and log in again")
+}
+
+// Verify completes a login with MFA, exchanging the login's mfa_token and an authenticator code or
+// a recovery code for tokens
+func (h *MFAHandler) Verify(c *fiber.Ctx) error {
+	var req dto.MFAVerifyRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	user, err := h.authService.VerifyMFAChallenge(c.Context(), req.MFAToken, req.Code, req.RecoveryCode)
+	if err != nil {
+		var locked *services.AccountLockedError
+		if errors.As(err, &locked) {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(locked.RetryAfter().Seconds()))))
+		}
+		return err
+	}
+	return sendTokens(c, h.authService, user, "Login successful")
+}
+
+// Disable turns MFA off, confirmed with the password and an authenticator code or a recovery code
+func (h *MFAHandler) Disable(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req dto.MFADisableRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.authService.DisableMFA(c.Context(), int(userID), req.Password, req.Code, req.RecoveryCode, authenticatedAt(c)); err != nil {
+		return err
+	}
+	return response.Success(c, nil, "MFA disabled")
+}
+
+// RegenerateRecoveryCodes replaces the signed-in user's recovery codes, confirmed with an authenticator code
+func (h *MFAHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req dto.MFACodeRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(c.Context(), int(userID), req.Code)
+	if err != nil {
+		return err
+	}
+	return response.Success(c, fiber.Map{
+		"recovery_codes": codes,
+	}, "Recovery codes regenerated, the previous ones no longer work")
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"rota-api/dto"
+	"rota-api/models"
+	"rota-api/patch"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// patchWith returns the function that applies the PATCH body of c to a resource as stored. The fields
+// the patch changes must be writable by the caller's role under policy, and the patched resource, read
+// into req, must still pass req's validation rules. If-Match must name the version the resource is stored at
+func patchWith[T any](c *fiber.Ctx, policy patch.Policy, req interface{}) func(*T) error {
+	return func(current *T) error {
+		doc, err := json.Marshal(current)
+		if err != nil {
+			return err
+		}
+		var stored struct {
+			Version int `json:"version"`
+		}
+		if err := json.Unmarshal(doc, &stored); err != nil {
+			return err
+		}
+		if err := checkIfMatch(c, stored.Version); err != nil {
+			return err
+		}
+		patched, err := patch.Apply(c.Get(fiber.HeaderContentType), doc, c.Body())
+		if err != nil {
+			return err
+		}
+
+		changed, err := patch.ChangedFields(doc, patched)
+		if err != nil {
+			return err
+		}
+		role, _ := c.Locals("userRole").(models.UserRole)
+		if err := policy.Check(role, changed); err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal(patched, req); err != nil {
+			return fmt.Errorf("%w: %v", patch.ErrInvalidPatch, err)
+		}
+		if err := dto.Validate(req); err != nil {
+			return err
+		}
+		return patch.Decode(patched, current, changed)
+	}
+}
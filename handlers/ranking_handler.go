@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"rota-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RankingHandler struct {
+	rankingService services.RankingService
+}
+
+func NewRankingHandler(rankingService services.RankingService) *RankingHandler {
+	return &RankingHandler{
+		rankingService: rankingService,
+	}
+}
+
+// GetPopularStations lists the most viewed, searched and favorited stations
+// over `period` (day or week, default day), up to `limit` (default 10)
+func (h *RankingHandler) GetPopularStations(c *fiber.Ctx) error {
+	stations, err := h.rankingService.GetPopularStations(c.Context(), c.Query("period"), c.QueryInt("limit"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"stations": stations,
+	})
+}
+
+// GetPopularRoutes lists the trending routes over `period` (day or week, default day),
+// up to `limit` (default 10)
+func (h *RankingHandler) GetPopularRoutes(c *fiber.Ctx) error {
+	routes, err := h.rankingService.GetPopularRoutes(c.Context(), c.Query("period"), c.QueryInt("limit"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"routes": routes,
+	})
+}
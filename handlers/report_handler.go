@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	apperrors "rota-api/errors"
+	"rota-api/models"
+	"rota-api/services"
+	"rota-api/tabular"
+	"rota-api/utils/servicetime"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReportHandler struct {
+	reportService services.ReportService
+}
+
+func NewReportHandler(reportService services.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// GetPunctuality reports on-time performance, average delays and cancellations over the service dates
+// `from` to `to`, grouped by `group_by` (any of route, station and day, comma-separated). Departures later
+// than `threshold` (e.g. 5m) count as late. `format` is json, csv or xlsx
+func (h *ReportHandler) GetPunctuality(c *fiber.Ctx) error {
+	query, err := parseReportQuery(c)
+	if err != nil {
+		return err
+	}
+	if param := c.Query("threshold"); param != "" {
+		threshold, err := time.ParseDuration(param)
+		if err != nil || threshold < 0 {
+			return apperrors.BadRequest(apperrors.INVALID_PARAMETER, "Invalid threshold parameter. Use a duration (e.g. 5m)")
+		}
+		query.LateThreshold = &threshold
+	}
+
+	report, err := h.reportService.GetPunctuality(c.Context(), query)
+	if err != nil {
+		return err
+	}
+	return sendReport(c, "punctuality", report.GroupBy, report.Rows, fiber.Map{
+		"report": report,
+	})
+}
+
+// GetUtilization reports the trips, service hours, seats offered and utilization of each vehicle over
+// the service dates `from` to `to`, per day with group_by=day. `format` is json, csv or xlsx
+func (h *ReportHandler) GetUtilization(c *fiber.Ctx) error {
+	query, err := parseReportQuery(c)
+	if err != nil {
+		return err
+	}
+
+	report, err := h.reportService.GetUtilization(c.Context(), query)
+	if err != nil {
+		return err
+	}
+	return sendReport(c, "utilization", report.GroupBy, report.Rows, fiber.Map{
+		"report": report,
+	})
+}
+
+// RefreshSnapshot recomputes the trip_actuals view reports read from when snapshots are enabled
+func (h *ReportHandler) RefreshSnapshot(c *fiber.Ctx) error {
+	if err := h.reportService.RefreshSnapshot(c.Context()); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"message":      "Report snapshot refreshed successfully",
+		"refreshed_at": servicetime.Now(),
+	})
+}
+
+// parseReportQuery reads the range, grouping and filters shared by every report from the query string
+func parseReportQuery(c *fiber.Ctx) (models.ReportQuery, error) {
+	query := models.ReportQuery{}
+
+	for name, date := range map[string]*models.Date{"from": &query.From, "to": &query.To} {
+		if param := c.Query(name); param != "" {
+			if _, err := servicetime.ParseDate(param); err != nil {
+				return query, apperrors.BadRequest(apperrors.INVALID_DATE, fmt.Sprintf("Invalid %s format. Use YYYY-MM-DD (e.g. 2025-06-02)", name))
+			}
+			*date = models.Date(param)
+		}
+	}
+
+	if param := c.Query("group_by"); param != "" {
+		for _, group := range strings.Split(param, ",") {
+			query.GroupBy = append(query.GroupBy, strings.ToLower(strings.TrimSpace(group)))
+		}
+	}
+
+	for name, id := range map[string]**uint{"route_id": &query.RouteID, "station_id": &query.StationID, "vehicle_id": &query.VehicleID} {
+		value, err := parseUintParam(c.Query(name))
+		if err != nil {
+			return query, apperrors.BadRequest(apperrors.INVALID_PARAMETER, fmt.Sprintf("Invalid %s parameter", name))
+		}
+		*id = value
+	}
+	return query, nil
+}
+
+// sendReport sends a report as JSON, or its rows as a spreadsheet download when `format` is csv or xlsx.
+// Spreadsheets only hold the columns of the groupings the report was grouped by
+func sendReport[T any](c *fiber.Ctx, name string, groupBy []string, rows []T, body fiber.Map) error {
+	format := c.Query("format", "json")
+	if format == "json" {
+		return c.JSON(body)
+	}
+	sheetFormat, ok := tabular.ParseFormat(format)
+	if !ok {
+		return apperrors.BadRequest(apperrors.EXPORT_INVALID_FORMAT, "format must be json, csv or xlsx")
+	}
+
+	var row T
+	fields := tabular.Fields(row)
+	keep := make([]bool, len(fields))
+	var header []string
+	for i, field := range fields {
+		keep[i] = true
+		for group, columns := range models.ReportGroupColumns {
+			if slices.Contains(columns, field) && !slices.Contains(groupBy, group) {
+				keep[i] = false
+			}
+		}
+		if keep[i] {
+			header = append(header, field)
+		}
+	}
+
+	records := make([][]string, len(rows))
+	for i, row := range rows {
+		values := tabular.Encode(row)
+		for j, value := range values {
+			if keep[j] {
+				records[i] = append(records[i], value)
+			}
+		}
+	}
+
+	c.Attachment(fmt.Sprintf("%s-%s.%s", name, servicetime.Now().Format("20060102"), sheetFormat))
+	c.Set(fiber.HeaderContentType, sheetFormat.ContentType())
+	return tabular.Write(c.Response().BodyWriter(), sheetFormat, name, header, records)
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"time"
+
+	"rota-api/dto"
+	apperrors "rota-api/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseBody binds the request body into req and runs its validation rules,
+// so a malformed body is a 400 and a rule violation a 422 listing each field
+func parseBody(c *fiber.Ctx, req interface{}) error {
+	if err := c.BodyParser(req); err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_REQUEST_BODY, "Invalid request body")
+	}
+	return dto.Validate(req)
+}
+
+// currentUserID is the ID of the signed-in user, set by AuthMiddleware
+func currentUserID(c *fiber.Ctx) (uint, error) {
+	switch userID := c.Locals("userID").(type) {
+	case int:
+		if userID > 0 {
+			return uint(userID), nil
+		}
+	case uint:
+		if userID > 0 {
+			return userID, nil
+		}
+	}
+	return 0, apperrors.Unauthorized(apperrors.UNAUTHORIZED, "User not authenticated")
+}
+
+// authenticatedAt is when the signed-in user logged in, as the issue time of their token set by AuthMiddleware.
+// It is the zero time when unknown, which never counts as a recent login
+func authenticatedAt(c *fiber.Ctx) time.Time {
+	authTime, _ := c.Locals("authTime").(time.Time)
+	return authTime
+}
@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"rota-api/dto"
+	apperrors "rota-api/errors"
 	"rota-api/models"
+	"rota-api/pagination"
+	"rota-api/patch"
 	"rota-api/services"
 	"strconv"
 
@@ -21,48 +25,43 @@ func NewRouteHandler(routeService services.RouteService) *RouteHandler {
 func (h *RouteHandler) GetRouteByID(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid route ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid route ID")
 	}
 
 	route, err := h.routeService.GetRouteByID(c.Context(), uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Route not found",
-		})
+		return apperrors.NotFoundIf(err, apperrors.ROUTE_NOT_FOUND, "Route not found")
 	}
 
-	return c.JSON(fiber.Map{
+	return sendVersioned(c, route.Version, fiber.Map{
 		"route": route,
 	})
 }
 
 func (h *RouteHandler) GetAllRoutes(c *fiber.Ctx) error {
-	routes, err := h.routeService.GetAllRoutes(c.Context())
+	params, err := pagination.ParseParams(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"routes": routes,
-	})
+	result, err := h.routeService.GetAllRoutes(c.Context(), params)
+	if err != nil {
+		return err
+	}
+
+	pagination.SetLinks(c, &result)
+	return c.JSON(pageBody("routes", result))
 }
 
 func (h *RouteHandler) CreateRoute(c *fiber.Ctx) error {
-	var route models.Route
-	if err := c.BodyParser(&route); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	var req dto.CreateRouteRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
+	route := req.ToModel()
 
 	if err := h.routeService.CreateRoute(c.Context(), &route); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -74,25 +73,52 @@ func (h *RouteHandler) CreateRoute(c *fiber.Ctx) error {
 func (h *RouteHandler) UpdateRoute(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid route ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid route ID")
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
 	}
 
-	var route models.Route
-	if err := c.BodyParser(&route); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	var req dto.UpdateRouteRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
+	route := req.ToModel()
 
 	route.ID = uint(id)
+	route.Version = version
 	if err := h.routeService.UpdateRoute(c.Context(), &route); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return apperrors.NotFoundIf(err, apperrors.ROUTE_NOT_FOUND, "Route not found")
 	}
 
+	setVersion(c, route.Version)
+	return c.JSON(fiber.Map{
+		"message": "Route updated successfully",
+		"route":   route,
+	})
+}
+
+// routeWritable lists the route fields each role may change with PATCH
+var routeWritable = patch.Policy{
+	models.RoleAdmin: {"start_station_id", "end_station_id", "distance", "duration", "description", "translations"},
+}
+
+// PatchRoute applies a JSON Merge Patch or JSON Patch to a route. Members set to null are cleared
+func (h *RouteHandler) PatchRoute(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid route ID")
+	}
+
+	var req dto.CreateRouteRequest
+	route, err := h.routeService.PatchRoute(c.Context(), uint(id), patchWith[models.Route](c, routeWritable, &req))
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.ROUTE_NOT_FOUND, "Route not found")
+	}
+
+	setVersion(c, route.Version)
 	return c.JSON(fiber.Map{
 		"message": "Route updated successfully",
 		"route":   route,
@@ -102,15 +128,16 @@ func (h *RouteHandler) UpdateRoute(c *fiber.Ctx) error {
 func (h *RouteHandler) DeleteRoute(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid route ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid route ID")
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
 	}
 
-	if err := h.routeService.DeleteRoute(c.Context(), uint(id)); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	if err := h.routeService.DeleteRoute(c.Context(), uint(id), version); err != nil {
+		return apperrors.NotFoundIf(err, apperrors.ROUTE_NOT_FOUND, "Route not found")
 	}
 
 	return c.JSON(fiber.Map{
@@ -1,8 +1,13 @@
 package handler
 
 import (
+	"rota-api/dto"
+	apperrors "rota-api/errors"
 	"rota-api/models"
+	"rota-api/pagination"
+	"rota-api/patch"
 	"rota-api/services"
+	"rota-api/utils/servicetime"
 	"strconv"
 	"time"
 
@@ -11,6 +16,7 @@ import (
 
 type ScheduleHandler struct {
 	scheduleService services.ScheduleService
+	staffService    services.StaffService
 }
 
 // parseUintParam parses a string parameter to uint pointer
@@ -43,8 +49,8 @@ func parseTimeParam(param string) (*time.Time, error) {
 	if param == "" {
 		return nil, nil
 	}
-	// รองรับรูปแบบ ISO8601 (2025-06-02T08:00:00Z)
-	value, err := time.Parse(time.RFC3339, param)
+	// รองรับรูปแบบ ISO8601 (2025-06-02T08:00:00Z) และเวลาท้องถิ่นของผู้ให้บริการที่ไม่มี offset (2025-06-02T08:00)
+	value, err := servicetime.ParseTime(param)
 	if err != nil {
 		return nil, err
 	}
@@ -59,57 +65,69 @@ func parseStringParam(param string) *string {
 	return &param
 }
 
-func NewScheduleHandler(scheduleService services.ScheduleService) *ScheduleHandler {
+// pageBody renders a page of results under key together with its pagination metadata
+func pageBody(key string, result models.PagedResult) fiber.Map {
+	return fiber.Map{
+		"total_count": result.TotalCount,
+		"total_pages": result.TotalPages,
+		"page":        result.Page,
+		"page_size":   result.PageSize,
+		"sort_by":     result.SortBy,
+		"sort_desc":   result.SortDesc,
+		"has_more":    result.HasMore,
+		"next_cursor": result.NextCursor,
+		"links":       result.Links,
+		key:           result.Data,
+	}
+}
+
+func NewScheduleHandler(scheduleService services.ScheduleService, staffService services.StaffService) *ScheduleHandler {
 	return &ScheduleHandler{
 		scheduleService: scheduleService,
+		staffService:    staffService,
 	}
 }
 
 func (h *ScheduleHandler) GetScheduleByID(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid schedule ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid schedule ID")
 	}
 
 	schedule, err := h.scheduleService.GetScheduleByID(c.Context(), uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Schedule not found",
-		})
+		return apperrors.NotFoundIf(err, apperrors.SCHEDULE_NOT_FOUND, "Schedule not found")
 	}
 
-	return c.JSON(fiber.Map{
+	return sendVersioned(c, schedule.Version, fiber.Map{
 		"schedule": schedule,
 	})
 }
 
 func (h *ScheduleHandler) GetAllSchedules(c *fiber.Ctx) error {
-	schedules, err := h.scheduleService.GetAllSchedules(c.Context())
+	params, err := pagination.ParseParams(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"schedules": schedules,
-	})
+	result, err := h.scheduleService.GetAllSchedules(c.Context(), params)
+	if err != nil {
+		return err
+	}
+
+	pagination.SetLinks(c, &result)
+	return c.JSON(pageBody("schedules", result))
 }
 
 func (h *ScheduleHandler) CreateSchedule(c *fiber.Ctx) error {
-	var schedule models.Schedule
-	if err := c.BodyParser(&schedule); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	var req dto.CreateScheduleRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
+	schedule := req.ToModel()
 
 	if err := h.scheduleService.CreateSchedule(c.Context(), &schedule); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -121,25 +139,27 @@ func (h *ScheduleHandler) CreateSchedule(c *fiber.Ctx) error {
 func (h *ScheduleHandler) UpdateSchedule(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid schedule ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid schedule ID")
 	}
 
-	var schedule models.Schedule
-	if err := c.BodyParser(&schedule); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
 	}
 
+	var req dto.UpdateScheduleRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	schedule := req.ToModel()
+
 	schedule.ID = uint(id)
+	schedule.Version = version
 	if err := h.scheduleService.UpdateSchedule(c.Context(), &schedule); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return apperrors.NotFoundIf(err, apperrors.SCHEDULE_NOT_FOUND, "Schedule not found")
 	}
 
+	setVersion(c, schedule.Version)
 	return c.JSON(fiber.Map{
 		"message":  "Schedule updated successfully",
 		"schedule": schedule,
@@ -148,117 +168,128 @@ func (h *ScheduleHandler) UpdateSchedule(c *fiber.Ctx) error {
 
 // SearchSchedules handles advanced search requests for schedules
 func (h *ScheduleHandler) SearchSchedules(c *fiber.Ctx) error {
-	// Extract search parameters from query params
-	params := models.ScheduleSearchParams{}
-
-	// Parse pagination parameters
-	page, err := parseIntParam(c.Query("page"))
+	params, err := parseScheduleSearchParams(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid page parameter",
-		})
-	}
-	if page != nil {
-		params.Page = *page
+		return err
 	}
 
-	pageSize, err := parseIntParam(c.Query("page_size"))
+	// Perform search
+	result, err := h.scheduleService.SearchSchedules(c.Context(), params)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid page_size parameter",
-		})
-	}
-	if pageSize != nil {
-		params.PageSize = *pageSize
+		return err
 	}
 
-	// Sorting parameters
-	params.SortBy = c.Query("sort_by")
-	params.SortDesc = c.Query("sort_desc") == "true"
+	// Return result
+	pagination.SetLinks(c, &result)
+	return c.JSON(pageBody("schedules", result))
+}
+
+// parseScheduleSearchParams reads the pagination, sorting and filter parameters of a schedule search from the query string
+func parseScheduleSearchParams(c *fiber.Ctx) (models.ScheduleSearchParams, error) {
+	// Extract search parameters from query params
+	params := models.ScheduleSearchParams{}
+
+	// Parse pagination and sorting parameters
+	page, err := pagination.ParseParams(c)
+	if err != nil {
+		return params, err
+	}
+	params.SearchParams = page
 
 	// Filter parameters
 	routeID, err := parseUintParam(c.Query("route_id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid route_id parameter",
-		})
+		return params, apperrors.BadRequest(apperrors.INVALID_PARAMETER, "Invalid route_id parameter")
 	}
 	params.RouteID = routeID
 
 	vehicleID, err := parseUintParam(c.Query("vehicle_id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid vehicle_id parameter",
-		})
+		return params, apperrors.BadRequest(apperrors.INVALID_PARAMETER, "Invalid vehicle_id parameter")
 	}
 	params.VehicleID = vehicleID
 
 	stationID, err := parseUintParam(c.Query("station_id"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid station_id parameter",
-		})
+		return params, apperrors.BadRequest(apperrors.INVALID_PARAMETER, "Invalid station_id parameter")
 	}
 	params.StationID = stationID
 
 	round, err := parseIntParam(c.Query("round"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid round parameter",
-		})
+		return params, apperrors.BadRequest(apperrors.INVALID_PARAMETER, "Invalid round parameter")
 	}
 	params.Round = round
 
 	// Date range parameters
 	startDateFrom, err := parseTimeParam(c.Query("start_date_from"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid start_date_from format. Use ISO8601 (e.g. 2025-06-02T08:00:00Z)",
-		})
+		return params, apperrors.BadRequest(apperrors.INVALID_TIME, "Invalid start_date_from format. Use ISO8601 (e.g. 2025-06-02T08:00:00Z)")
 	}
 	params.StartDateFrom = startDateFrom
 
 	startDateTo, err := parseTimeParam(c.Query("start_date_to"))
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid start_date_to format. Use ISO8601 (e.g. 2025-06-02T08:00:00Z)",
-		})
+		return params, apperrors.BadRequest(apperrors.INVALID_TIME, "Invalid start_date_to format. Use ISO8601 (e.g. 2025-06-02T08:00:00Z)")
 	}
 	params.StartDateTo = startDateTo
 
+	if param := c.Query("service_date"); param != "" {
+		if _, err := servicetime.ParseDate(param); err != nil {
+			return params, apperrors.BadRequest(apperrors.INVALID_DATE, "Invalid service_date format. Use YYYY-MM-DD (e.g. 2025-06-02)")
+		}
+		serviceDate := models.Date(param)
+		params.ServiceDate = &serviceDate
+	}
+
 	// Status parameter
 	params.Status = parseStringParam(c.Query("status"))
 
-	// Perform search
-	result, err := h.scheduleService.SearchSchedules(c.Context(), params)
+	return params, nil
+}
+
+// scheduleWritable lists the schedule fields each role may change with PATCH
+var scheduleWritable = patch.Policy{
+	models.RoleAdmin: {
+		"route_id", "vehicle_id", "driver_id", "station_id", "round", "service_date",
+		"departure_time", "arrival_time", "departure_local", "arrival_local", "status", "platform",
+	},
+	models.RoleStaff: {"status", "platform"},
+}
+
+// PatchSchedule applies a JSON Merge Patch or JSON Patch to a schedule. Members set to null are cleared
+func (h *ScheduleHandler) PatchSchedule(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid schedule ID")
 	}
 
-	// Return result
+	var req dto.CreateScheduleRequest
+	schedule, err := h.scheduleService.PatchSchedule(c.Context(), uint(id), patchWith[models.Schedule](c, scheduleWritable, &req))
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.SCHEDULE_NOT_FOUND, "Schedule not found")
+	}
+
+	setVersion(c, schedule.Version)
 	return c.JSON(fiber.Map{
-		"total_count": result.TotalCount,
-		"total_pages": result.TotalPages,
-		"page":       result.Page,
-		"page_size":  result.PageSize,
-		"schedules":  result.Data,
+		"message":  "Schedule updated successfully",
+		"schedule": schedule,
 	})
 }
 
 func (h *ScheduleHandler) DeleteSchedule(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid schedule ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid schedule ID")
 	}
 
-	if err := h.scheduleService.DeleteSchedule(c.Context(), uint(id)); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.scheduleService.DeleteSchedule(c.Context(), uint(id), version); err != nil {
+		return apperrors.NotFoundIf(err, apperrors.SCHEDULE_NOT_FOUND, "Schedule not found")
 	}
 
 	return c.JSON(fiber.Map{
@@ -273,17 +304,13 @@ func (h *ScheduleHandler) GetSchedulesByStation(c *fiber.Ctx) error {
 	// Parse station ID from path parameters
 	stationID, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid station ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid station ID")
 	}
 
 	// Call service to get simplified schedules (10 per direction)
 	response, err := h.scheduleService.GetSimpleSchedulesByStation(c.Context(), uint(stationID))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Station or schedules not found: " + err.Error(),
-		})
+		return apperrors.NotFoundIf(err, apperrors.STATION_NOT_FOUND, "Station not found")
 	}
 
 	return c.JSON(response)
@@ -295,19 +322,71 @@ func (h *ScheduleHandler) GetSimpleSchedulesByStation(c *fiber.Ctx) error {
 	// Parse station ID from path parameters
 	stationID, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid station ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid station ID")
 	}
 
 	// Call service to get simple schedules for this station
 	// (Fixed at 10 schedules each direction as per requirement)
 	response, err := h.scheduleService.GetSimpleSchedulesByStation(c.Context(), uint(stationID))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Station or schedules not found: " + err.Error(),
-		})
+		return apperrors.NotFoundIf(err, apperrors.STATION_NOT_FOUND, "Station not found")
 	}
 
 	return c.JSON(response)
 }
+
+// ShiftSchedules moves the schedules matching the criteria by an offset, or previews the move
+func (h *ScheduleHandler) ShiftSchedules(c *fiber.Ctx) error {
+	var req dto.BulkShiftRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	result, err := h.scheduleService.ShiftSchedules(c.Context(), req.Criteria, req.OffsetDuration(), req.Preview)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(result)
+}
+
+// CancelSchedules cancels the schedules matching the criteria, or previews the cancellation
+func (h *ScheduleHandler) CancelSchedules(c *fiber.Ctx) error {
+	var req dto.BulkCancelRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	// The cancellation is logged on behalf of the signed-in user's staff record
+	email, _ := c.Locals("userEmail").(string)
+	staff, err := h.staffService.GetStaffForUser(c.Context(), email)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.scheduleService.CancelSchedules(c.Context(), req.Criteria, req.Reason, staff.ID, req.Preview)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(result)
+}
+
+// CopySchedules copies the schedules of a service date to other dates, or previews the copies
+func (h *ScheduleHandler) CopySchedules(c *fiber.Ctx) error {
+	var req dto.BulkCopyRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	result, err := h.scheduleService.CopySchedules(c.Context(), req.SourceDate, req.Criteria, req.TargetDates, req.Preview)
+	if err != nil {
+		return err
+	}
+
+	status := fiber.StatusCreated
+	if result.Preview {
+		status = fiber.StatusOK
+	}
+	return c.Status(status).JSON(result)
+}
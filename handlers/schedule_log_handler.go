@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"rota-api/dto"
+	apperrors "rota-api/errors"
 	"rota-api/models"
+	"rota-api/pagination"
+	"rota-api/patch"
 	"rota-api/services"
 	"strconv"
 	"time"
@@ -22,73 +26,47 @@ func NewScheduleLogHandler(scheduleLogService services.ScheduleLogService) *Sche
 func (h *ScheduleLogHandler) GetScheduleLogByID(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid schedule log ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid schedule log ID")
 	}
 
 	scheduleLog, err := h.scheduleLogService.GetScheduleLogByID(c.Context(), uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Schedule log not found",
-		})
+		return apperrors.NotFoundIf(err, apperrors.SCHEDULE_LOG_NOT_FOUND, "Schedule log not found")
 	}
 
-	return c.JSON(fiber.Map{
+	return sendVersioned(c, scheduleLog.Version, fiber.Map{
 		"schedule_log": scheduleLog,
 	})
 }
 
 func (h *ScheduleLogHandler) GetAllScheduleLogs(c *fiber.Ctx) error {
-	scheduleLogs, err := h.scheduleLogService.GetAllScheduleLogs(c.Context())
+	params, err := pagination.ParseParams(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"schedule_logs": scheduleLogs,
-	})
+	result, err := h.scheduleLogService.GetAllScheduleLogs(c.Context(), params)
+	if err != nil {
+		return err
+	}
+
+	pagination.SetLinks(c, &result)
+	return c.JSON(pageBody("schedule_logs", result))
 }
 
 func (h *ScheduleLogHandler) CreateScheduleLog(c *fiber.Ctx) error {
-	var scheduleLog models.ScheduleLog
-	if err := c.BodyParser(&scheduleLog); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-
-	// ตรวจสอบและกำหนดค่าเวลาที่ถูกต้อง
-	if c.Get("Content-Type") == "application/json" {
-		// ตรวจสอบฟิลด์เวลาจากคำขอ JSON
-		body := make(map[string]interface{})
-		if err := c.BodyParser(&body); err == nil {
-			// กำหนดค่า ActualDeparture หากมีในคำขอ
-			if _, ok := body["actual_departure"]; ok && body["actual_departure"] != "" {
-				if t, err := time.Parse(time.RFC3339, body["actual_departure"].(string)); err == nil {
-					scheduleLog.ActualDeparture = &t
-				}
-			}
-
-			// กำหนดค่า ActualArrival หากมีในคำขอ
-			if _, ok := body["actual_arrival"]; ok && body["actual_arrival"] != "" {
-				if t, err := time.Parse(time.RFC3339, body["actual_arrival"].(string)); err == nil {
-					scheduleLog.ActualArrival = &t
-				}
-			}
-		}
+	var req dto.CreateScheduleLogRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
+	scheduleLog := req.ToModel()
 
 	// กำหนดเวลาอัพเดท
 	now := time.Now()
 	scheduleLog.UpdatedAt = &now
 
 	if err := h.scheduleLogService.CreateScheduleLog(c.Context(), &scheduleLog); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -100,50 +78,57 @@ func (h *ScheduleLogHandler) CreateScheduleLog(c *fiber.Ctx) error {
 func (h *ScheduleLogHandler) UpdateScheduleLog(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid schedule log ID",
-		})
-	}
-
-	var scheduleLog models.ScheduleLog
-	if err := c.BodyParser(&scheduleLog); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-
-	// ตรวจสอบและกำหนดค่าเวลาที่ถูกต้อง
-	if c.Get("Content-Type") == "application/json" {
-		// ตรวจสอบฟิลด์เวลาจากคำขอ JSON
-		body := make(map[string]interface{})
-		if err := c.BodyParser(&body); err == nil {
-			// กำหนดค่า ActualDeparture หากมีในคำขอ
-			if _, ok := body["actual_departure"]; ok && body["actual_departure"] != "" {
-				if t, err := time.Parse(time.RFC3339, body["actual_departure"].(string)); err == nil {
-					scheduleLog.ActualDeparture = &t
-				}
-			}
-
-			// กำหนดค่า ActualArrival หากมีในคำขอ
-			if _, ok := body["actual_arrival"]; ok && body["actual_arrival"] != "" {
-				if t, err := time.Parse(time.RFC3339, body["actual_arrival"].(string)); err == nil {
-					scheduleLog.ActualArrival = &t
-				}
-			}
-		}
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid schedule log ID")
 	}
 
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+
+	var req dto.UpdateScheduleLogRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	scheduleLog := req.ToModel()
+
 	// กำหนดเวลาอัพเดท
 	now := time.Now()
 	scheduleLog.UpdatedAt = &now
 	scheduleLog.ID = uint(id)
+	scheduleLog.Version = version
 
 	if err := h.scheduleLogService.UpdateScheduleLog(c.Context(), &scheduleLog); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return apperrors.NotFoundIf(err, apperrors.SCHEDULE_LOG_NOT_FOUND, "Schedule log not found")
 	}
 
+	setVersion(c, scheduleLog.Version)
+	return c.JSON(fiber.Map{
+		"message":      "Schedule log updated successfully",
+		"schedule_log": scheduleLog,
+	})
+}
+
+// scheduleLogWritable lists the schedule log fields each role may change with PATCH
+var scheduleLogWritable = patch.Policy{
+	models.RoleAdmin: {"schedule_id", "staff_id", "change_description", "actual_departure", "actual_arrival", "status", "notes"},
+	models.RoleStaff: {"change_description", "actual_departure", "actual_arrival", "status", "notes"},
+}
+
+// PatchScheduleLog applies a JSON Merge Patch or JSON Patch to a schedule log. Members set to null are cleared
+func (h *ScheduleLogHandler) PatchScheduleLog(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid schedule log ID")
+	}
+
+	var req dto.CreateScheduleLogRequest
+	scheduleLog, err := h.scheduleLogService.PatchScheduleLog(c.Context(), uint(id), patchWith[models.ScheduleLog](c, scheduleLogWritable, &req))
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.SCHEDULE_LOG_NOT_FOUND, "Schedule log not found")
+	}
+
+	setVersion(c, scheduleLog.Version)
 	return c.JSON(fiber.Map{
 		"message":      "Schedule log updated successfully",
 		"schedule_log": scheduleLog,
@@ -153,15 +138,16 @@ func (h *ScheduleLogHandler) UpdateScheduleLog(c *fiber.Ctx) error {
 func (h *ScheduleLogHandler) DeleteScheduleLog(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid schedule log ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid schedule log ID")
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
 	}
 
-	if err := h.scheduleLogService.DeleteScheduleLog(c.Context(), uint(id)); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	if err := h.scheduleLogService.DeleteScheduleLog(c.Context(), uint(id), version); err != nil {
+		return apperrors.NotFoundIf(err, apperrors.SCHEDULE_LOG_NOT_FOUND, "Schedule log not found")
 	}
 
 	return c.JSON(fiber.Map{
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"rota-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SearchHandler struct {
+	searchService services.SearchService
+}
+
+func NewSearchHandler(searchService services.SearchService) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+	}
+}
+
+// Search finds stations by Thai or English name or location, tolerating typos and
+// romanized spellings, together with the routes that start or end at them.
+// Query: `q` (required), `limit` (default 10, max 50)
+func (h *SearchHandler) Search(c *fiber.Ctx) error {
+	result, err := h.searchService.Search(c.Context(), c.Query("q"), c.QueryInt("limit"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(result)
+}
+
+// Suggest returns station names for autocomplete as the rider types.
+// Query: `q` (required), `limit` (default 8, max 20)
+func (h *SearchHandler) Suggest(c *fiber.Ctx) error {
+	suggestions, err := h.searchService.Suggest(c.Context(), c.Query("q"), c.QueryInt("limit"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"suggestions": suggestions,
+	})
+}
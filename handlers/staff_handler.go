@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"rota-api/dto"
+	apperrors "rota-api/errors"
 	"rota-api/models"
+	"rota-api/pagination"
+	"rota-api/patch"
 	"rota-api/services"
 	"strconv"
 
@@ -21,48 +25,43 @@ func NewStaffHandler(staffService services.StaffService) *StaffHandler {
 func (h *StaffHandler) GetStaffByID(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid staff ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid staff ID")
 	}
 
 	staff, err := h.staffService.GetStaffByID(c.Context(), uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Staff not found",
-		})
+		return apperrors.NotFoundIf(err, apperrors.STAFF_NOT_FOUND, "Staff not found")
 	}
 
-	return c.JSON(fiber.Map{
+	return sendVersioned(c, staff.Version, fiber.Map{
 		"staff": staff,
 	})
 }
 
 func (h *StaffHandler) GetAllStaff(c *fiber.Ctx) error {
-	staff, err := h.staffService.GetAllStaff(c.Context())
+	params, err := pagination.ParseParams(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"staff": staff,
-	})
+	result, err := h.staffService.GetAllStaff(c.Context(), params)
+	if err != nil {
+		return err
+	}
+
+	pagination.SetLinks(c, &result)
+	return c.JSON(pageBody("staff", result))
 }
 
 func (h *StaffHandler) CreateStaff(c *fiber.Ctx) error {
-	var staff models.Staff
-	if err := c.BodyParser(&staff); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	var req dto.CreateStaffRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
+	staff := req.ToModel()
 
 	if err := h.staffService.CreateStaff(c.Context(), &staff); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -74,25 +73,52 @@ func (h *StaffHandler) CreateStaff(c *fiber.Ctx) error {
 func (h *StaffHandler) UpdateStaff(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid staff ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid staff ID")
 	}
 
-	var staff models.Staff
-	if err := c.BodyParser(&staff); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
+	}
+
+	var req dto.UpdateStaffRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
+	staff := req.ToModel()
 
 	staff.ID = uint(id)
+	staff.Version = version
 	if err := h.staffService.UpdateStaff(c.Context(), &staff); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return apperrors.NotFoundIf(err, apperrors.STAFF_NOT_FOUND, "Staff not found")
+	}
+
+	setVersion(c, staff.Version)
+	return c.JSON(fiber.Map{
+		"message": "Staff updated successfully",
+		"staff":   staff,
+	})
+}
+
+// staffWritable lists the staff member fields each role may change with PATCH
+var staffWritable = patch.Policy{
+	models.RoleAdmin: {"username", "email", "name", "position", "phone", "station_id"},
+}
+
+// PatchStaff applies a JSON Merge Patch or JSON Patch to a staff member. Members set to null are cleared
+func (h *StaffHandler) PatchStaff(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid staff ID")
+	}
+
+	var req dto.StaffRequest
+	staff, err := h.staffService.PatchStaff(c.Context(), uint(id), patchWith[models.Staff](c, staffWritable, &req))
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.STAFF_NOT_FOUND, "Staff not found")
 	}
 
+	setVersion(c, staff.Version)
 	return c.JSON(fiber.Map{
 		"message": "Staff updated successfully",
 		"staff":   staff,
@@ -102,15 +128,16 @@ func (h *StaffHandler) UpdateStaff(c *fiber.Ctx) error {
 func (h *StaffHandler) DeleteStaff(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid staff ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid staff ID")
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
 	}
 
-	if err := h.staffService.DeleteStaff(c.Context(), uint(id)); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	if err := h.staffService.DeleteStaff(c.Context(), uint(id), version); err != nil {
+		return apperrors.NotFoundIf(err, apperrors.STAFF_NOT_FOUND, "Staff not found")
 	}
 
 	return c.JSON(fiber.Map{
@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"rota-api/dto"
+	apperrors "rota-api/errors"
 	"rota-api/models"
+	"rota-api/pagination"
+	"rota-api/patch"
 	"rota-api/services"
 	"strconv"
 
@@ -21,48 +25,43 @@ func NewStationHandler(stationService services.StationService) *StationHandler {
 func (h *StationHandler) GetStationByID(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid station ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid station ID")
 	}
 
 	station, err := h.stationService.GetStationByID(c.Context(), uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Station not found",
-		})
+		return apperrors.NotFoundIf(err, apperrors.STATION_NOT_FOUND, "Station not found")
 	}
 
-	return c.JSON(fiber.Map{
+	return sendVersioned(c, station.Version, fiber.Map{
 		"station": station,
 	})
 }
 
 func (h *StationHandler) GetAllStations(c *fiber.Ctx) error {
-	stations, err := h.stationService.GetAllStations(c.Context())
+	params, err := pagination.ParseParams(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"stations": stations,
-	})
+	result, err := h.stationService.GetAllStations(c.Context(), params)
+	if err != nil {
+		return err
+	}
+
+	pagination.SetLinks(c, &result)
+	return c.JSON(pageBody("stations", result))
 }
 
 func (h *StationHandler) CreateStation(c *fiber.Ctx) error {
-	var station models.Station
-	if err := c.BodyParser(&station); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	var req dto.StationRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
+	station := req.ToModel()
 
 	if err := h.stationService.CreateStation(c.Context(), &station); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -74,25 +73,52 @@ func (h *StationHandler) CreateStation(c *fiber.Ctx) error {
 func (h *StationHandler) UpdateStation(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid station ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid station ID")
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
 	}
 
-	var station models.Station
-	if err := c.BodyParser(&station); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	var req dto.StationRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
+	station := req.ToModel()
 
 	station.ID = uint(id)
+	station.Version = version
 	if err := h.stationService.UpdateStation(c.Context(), &station); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return apperrors.NotFoundIf(err, apperrors.STATION_NOT_FOUND, "Station not found")
 	}
 
+	setVersion(c, station.Version)
+	return c.JSON(fiber.Map{
+		"message": "Station updated successfully",
+		"station": station,
+	})
+}
+
+// stationWritable lists the station fields each role may change with PATCH
+var stationWritable = patch.Policy{
+	models.RoleAdmin: {"name", "name_en", "location", "detail", "translations"},
+}
+
+// PatchStation applies a JSON Merge Patch or JSON Patch to a station. Members set to null are cleared
+func (h *StationHandler) PatchStation(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid station ID")
+	}
+
+	var req dto.StationRequest
+	station, err := h.stationService.PatchStation(c.Context(), uint(id), patchWith[models.Station](c, stationWritable, &req))
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.STATION_NOT_FOUND, "Station not found")
+	}
+
+	setVersion(c, station.Version)
 	return c.JSON(fiber.Map{
 		"message": "Station updated successfully",
 		"station": station,
@@ -102,15 +128,16 @@ func (h *StationHandler) UpdateStation(c *fiber.Ctx) error {
 func (h *StationHandler) DeleteStation(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid station ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid station ID")
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
 	}
 
-	if err := h.stationService.DeleteStation(c.Context(), uint(id)); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	if err := h.stationService.DeleteStation(c.Context(), uint(id), version); err != nil {
+		return apperrors.NotFoundIf(err, apperrors.STATION_NOT_FOUND, "Station not found")
 	}
 
 	return c.JSON(fiber.Map{
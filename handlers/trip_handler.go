@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"strconv"
+
+	"rota-api/dto"
+	apperrors "rota-api/errors"
+	"rota-api/pagination"
+	"rota-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TripHandler struct {
+	tripService services.TripService
+}
+
+func NewTripHandler(tripService services.TripService) *TripHandler {
+	return &TripHandler{
+		tripService: tripService,
+	}
+}
+
+// GetMyTrips lists the signed-in user's past trips, latest first
+func (h *TripHandler) GetMyTrips(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	params, err := pagination.ParseParams(c)
+	if err != nil {
+		return err
+	}
+
+	result, err := h.tripService.GetUserTrips(c.Context(), userID, params)
+	if err != nil {
+		return err
+	}
+
+	pagination.SetLinks(c, &result)
+	return c.JSON(pageBody("trips", result))
+}
+
+// SaveTrip adds a trip to the signed-in user's history
+func (h *TripHandler) SaveTrip(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req dto.SaveTripRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+	trip := req.ToModel(userID)
+
+	saved, err := h.tripService.SaveTrip(c.Context(), &trip)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Trip saved successfully",
+		"trip":    saved,
+	})
+}
+
+// GetMyTripStats summarises the signed-in user's trips departing between `from` and `to` (RFC3339),
+// or all of them: trip count, total distance, CO2 saved and the most used stations
+func (h *TripHandler) GetMyTripStats(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	from, err := parseTimeParam(c.Query("from"))
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_TIME, "Invalid from format. Use ISO8601 (e.g. 2025-06-02T08:00:00Z)")
+	}
+	to, err := parseTimeParam(c.Query("to"))
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_TIME, "Invalid to format. Use ISO8601 (e.g. 2025-06-02T08:00:00Z)")
+	}
+	if from != nil && to != nil && !to.After(*from) {
+		return apperrors.BadRequest(apperrors.INVALID_PARAMETER, "to must be after from")
+	}
+
+	stats, err := h.tripService.GetTripStats(c.Context(), userID, from, to)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"stats": stats,
+	})
+}
+
+// DeleteMyTrip removes one trip from the signed-in user's history
+func (h *TripHandler) DeleteMyTrip(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid trip ID")
+	}
+
+	if err := h.tripService.RemoveTrip(c.Context(), uint(id), userID); err != nil {
+		return apperrors.NotFoundIf(err, apperrors.TRIP_NOT_FOUND, "Trip not found")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Trip deleted successfully",
+	})
+}
+
+// ClearMyTrips erases the signed-in user's whole travel history
+func (h *TripHandler) ClearMyTrips(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	deleted, err := h.tripService.ClearHistory(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Trip history deleted successfully",
+		"deleted": deleted,
+	})
+}
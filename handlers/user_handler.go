@@ -1,9 +1,15 @@
 package handler
 
 import (
+	"fmt"
+	"rota-api/dto"
+	apperrors "rota-api/errors"
 	"rota-api/models"
+	"rota-api/pagination"
+	"rota-api/patch"
 	"rota-api/services"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -24,19 +30,20 @@ func NewUserHandler(userService services.UserService, authService services.AuthS
 
 // GetAllUsers retrieves all users (admin only)
 func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
-	users, err := h.userService.GetAllUsers(c.Context())
+	params, err := pagination.ParseParams(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to fetch users",
-			"error":   err.Error(),
-		})
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"success": true,
-		"data":    users,
-	})
+	result, err := h.userService.GetAllUsers(c.Context(), params)
+	if err != nil {
+		return err
+	}
+
+	pagination.SetLinks(c, &result)
+	body := pageBody("data", result)
+	body["success"] = true
+	return c.Status(fiber.StatusOK).JSON(body)
 }
 
 // GetUserByID retrieves a specific user by ID
@@ -44,21 +51,15 @@ func (h *UserHandler) GetUserByID(c *fiber.Ctx) error {
 	userID := c.Params("id")
 	id, err := strconv.Atoi(userID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid user ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid user ID")
 	}
 
 	user, err := h.authService.GetUserByID(c.Context(), id)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"message": "User not found",
-		})
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+	return sendVersioned(c, user.Version, fiber.Map{
 		"success": true,
 		"data":    user,
 	})
@@ -69,57 +70,59 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	userID := c.Params("id")
 	id, err := strconv.Atoi(userID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid user ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid user ID")
 	}
 
 	// Get existing user
 	user, err := h.authService.GetUserByID(c.Context(), id)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"message": "User not found",
-		})
+		return err
+	}
+	if err := checkIfMatch(c, user.Version); err != nil {
+		return err
 	}
 
 	// Parse updated user data
-	var updateData models.User
-	if err := c.BodyParser(&updateData); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid request data",
-			"error":   err.Error(),
-		})
+	var req dto.UpdateUserRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
+	req.Apply(user)
 
-	// Update user data
-	if updateData.Email != "" {
-		user.Email = updateData.Email
-	}
-	if updateData.Role != "" {
-		user.Role = updateData.Role
-	}
-	if updateData.Username != nil {
-		user.Username = updateData.Username
-	}
-	if updateData.ProfilePicture != nil {
-		user.ProfilePicture = updateData.ProfilePicture
+	// Save updated user
+	if err := h.userService.UpdateUser(c.Context(), user); err != nil {
+		return err
 	}
-	if updateData.Password != nil && *updateData.Password != "" {
-		user.Password = updateData.Password
+
+	setVersion(c, user.Version)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "User updated successfully",
+		"data":    user,
+	})
+}
+
+// userWritable lists the user fields each role may change with PATCH. Only admins change roles
+var userWritable = patch.Policy{
+	models.RoleAdmin: {"email", "username", "profilePicture", "role"},
+	models.RoleStaff: {"email", "username", "profilePicture"},
+	models.RoleUser:  {"email", "username", "profilePicture"},
+}
+
+// PatchUser applies a JSON Merge Patch or JSON Patch to a user. Members set to null are cleared
+func (h *UserHandler) PatchUser(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid user ID")
 	}
 
-	// Save updated user
-	if err := h.userService.UpdateUser(c.Context(), user); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to update user",
-			"error":   err.Error(),
-		})
+	var req dto.UserRequest
+	user, err := h.userService.PatchUser(c.Context(), id, patchWith[models.User](c, userWritable, &req))
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.USER_NOT_FOUND, "User not found")
 	}
 
+	setVersion(c, user.Version)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "User updated successfully",
@@ -132,18 +135,16 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 	userID := c.Params("id")
 	id, err := strconv.Atoi(userID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid user ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid user ID")
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
 	}
 
-	if err := h.userService.DeleteUser(c.Context(), id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to delete user",
-			"error":   err.Error(),
-		})
+	if err := h.userService.DeleteUser(c.Context(), id, version); err != nil {
+		return apperrors.NotFoundIf(err, apperrors.USER_NOT_FOUND, "User not found")
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -154,61 +155,16 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 
 // CreateUser creates a new user (admin only)
 func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
-	// ใช้ struct ชั่วคราวเพื่อรับข้อมูลจาก request
-	type CreateUserRequest struct {
-		Username string          `json:"username"`
-		Email    string          `json:"email"`
-		Password string          `json:"password"`
-		Role     models.UserRole `json:"role"`
-		Name     string          `json:"name"`
-	}
-
-	var req CreateUserRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid request data",
-			"error":   err.Error(),
-		})
-	}
-
-	// Validate required fields
-	if req.Email == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Email is required",
-		})
-	}
-
-	if req.Password == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Password is required",
-		})
-	}
-
-	// สร้าง User จากข้อมูลใน request
-	var newUser models.User
-	newUser.Email = req.Email
-	newUser.Username = &req.Username
-	password := req.Password
-	newUser.Password = &password
-
-	// Set default role if not provided
-	if req.Role == "" {
-		newUser.Role = models.RoleUser
-	} else {
-		newUser.Role = req.Role
+	var req dto.CreateUserRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
+	newUser := req.ToModel()
 
 	// Register the user through auth service
 	createdUser, err := h.authService.Register(c, &newUser)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to create user",
-			"error":   err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -223,51 +179,31 @@ func (h *UserHandler) UpdateUserRole(c *fiber.Ctx) error {
 	userID := c.Params("id")
 	id, err := strconv.Atoi(userID)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid user ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid user ID")
 	}
 
 	// Get existing user
 	user, err := h.authService.GetUserByID(c.Context(), id)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"message": "User not found",
-		})
+		return err
 	}
-
-	// Parse role update data
-	var roleData struct {
-		Role models.UserRole `json:"role"`
-	}
-	if err := c.BodyParser(&roleData); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid request data",
-			"error":   err.Error(),
-		})
+	if err := checkIfMatch(c, user.Version); err != nil {
+		return err
 	}
 
-	// Validate role
-	if roleData.Role != models.RoleUser && roleData.Role != models.RoleStaff && roleData.Role != models.RoleAdmin {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid role value",
-		})
+	// Parse and validate role update data
+	var roleData dto.UpdateUserRoleRequest
+	if err := parseBody(c, &roleData); err != nil {
+		return err
 	}
 
 	// Update user role
 	user.Role = roleData.Role
 	if err := h.userService.UpdateUser(c.Context(), user); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"message": "Failed to update user role",
-			"error":   err.Error(),
-		})
+		return err
 	}
 
+	setVersion(c, user.Version)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"success": true,
 		"message": "User role updated successfully",
@@ -277,3 +213,124 @@ func (h *UserHandler) UpdateUserRole(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// IssuePasswordReset gives an admin a single-use password reset token for a user, to hand to them out of
+// band. The user sets a new password with it at /auth/password/reset (admin only)
+func (h *UserHandler) IssuePasswordReset(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid user ID")
+	}
+
+	token, expiresAt, err := h.authService.IssuePasswordResetToken(c.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Password reset token issued, give it to the user to set a new password",
+		"data": fiber.Map{
+			"reset_token": token,
+			"expires_at":  expiresAt,
+		},
+	})
+}
+
+// IssueMFAEnrollment gives an admin a single-use MFA enrollment token for a user whose role requires MFA,
+// to hand to them out of band. The user enrolls with it at /auth/mfa/enroll (admin only)
+func (h *UserHandler) IssueMFAEnrollment(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid user ID")
+	}
+
+	token, expiresAt, err := h.authService.IssueMFAEnrollmentToken(c.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "MFA enrollment token issued, give it to the user to enroll their authenticator",
+		"data": fiber.Map{
+			"enrollment_token": token,
+			"expires_at":       expiresAt,
+		},
+	})
+}
+
+// ChangeMyPassword changes the signed-in user's password after confirming the current one
+func (h *UserHandler) ChangeMyPassword(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	var req dto.ChangePasswordRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.authService.ChangePassword(c.Context(), int(userID), req.CurrentPassword, req.NewPassword, authenticatedAt(c)); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Password changed successfully",
+	})
+}
+
+// DeleteMe deletes the signed-in user's account after confirming their password. The account is
+// anonymized, their favorites and trips are erased, and its tokens stop working
+func (h *UserHandler) DeleteMe(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+	// OAuth accounts have no password to confirm, they must have logged in moments ago, and may send no body
+	var req dto.DeleteAccountRequest
+	if len(c.Body()) > 0 {
+		if err := parseBody(c, &req); err != nil {
+			return err
+		}
+	}
+
+	user, err := h.authService.GetUserByID(c.Context(), int(userID))
+	if err != nil {
+		return err
+	}
+	if err := h.authService.ConfirmPassword(c.Context(), user, req.Password, authenticatedAt(c)); err != nil {
+		return err
+	}
+
+	if err := h.userService.DeleteUser(c.Context(), user.ID, user.Version); err != nil {
+		return apperrors.NotFoundIf(err, apperrors.USER_NOT_FOUND, "User not found")
+	}
+	if err := h.authService.Logout(strings.TrimPrefix(c.Get("Authorization"), "Bearer ")); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Account deleted successfully",
+	})
+}
+
+// ExportMyData downloads everything stored about the signed-in user as a JSON archive
+func (h *UserHandler) ExportMyData(c *fiber.Ctx) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	export, err := h.userService.ExportUserData(c.Context(), int(userID))
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.USER_NOT_FOUND, "User not found")
+	}
+
+	c.Attachment(fmt.Sprintf("rota-export-%d-%s.json", userID, export.ExportedAt.Format("20060102")))
+	return c.JSON(export)
+}
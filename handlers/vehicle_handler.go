@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"rota-api/dto"
+	apperrors "rota-api/errors"
 	"rota-api/models"
+	"rota-api/pagination"
+	"rota-api/patch"
 	"rota-api/services"
 	"strconv"
 
@@ -21,48 +25,43 @@ func NewVehicleHandler(vehicleService services.VehicleService) *VehicleHandler {
 func (h *VehicleHandler) GetVehicleByID(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid vehicle ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
 	}
 
 	vehicle, err := h.vehicleService.GetVehicleByID(c.Context(), uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Vehicle not found",
-		})
+		return apperrors.NotFoundIf(err, apperrors.VEHICLE_NOT_FOUND, "Vehicle not found")
 	}
 
-	return c.JSON(fiber.Map{
+	return sendVersioned(c, vehicle.Version, fiber.Map{
 		"vehicle": vehicle,
 	})
 }
 
 func (h *VehicleHandler) GetAllVehicles(c *fiber.Ctx) error {
-	vehicles, err := h.vehicleService.GetAllVehicles(c.Context())
+	params, err := pagination.ParseParams(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
-	return c.JSON(fiber.Map{
-		"vehicles": vehicles,
-	})
+	result, err := h.vehicleService.GetAllVehicles(c.Context(), params)
+	if err != nil {
+		return err
+	}
+
+	pagination.SetLinks(c, &result)
+	return c.JSON(pageBody("vehicles", result))
 }
 
 func (h *VehicleHandler) CreateVehicle(c *fiber.Ctx) error {
-	var vehicle models.Vehicle
-	if err := c.BodyParser(&vehicle); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	var req dto.CreateVehicleRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
+	vehicle := req.ToModel()
 
 	if err := h.vehicleService.CreateVehicle(c.Context(), &vehicle); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -74,25 +73,53 @@ func (h *VehicleHandler) CreateVehicle(c *fiber.Ctx) error {
 func (h *VehicleHandler) UpdateVehicle(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid vehicle ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
 	}
 
-	var vehicle models.Vehicle
-	if err := c.BodyParser(&vehicle); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	var req dto.UpdateVehicleRequest
+	if err := parseBody(c, &req); err != nil {
+		return err
 	}
+	vehicle := req.ToModel()
 
 	vehicle.ID = uint(id)
+	vehicle.Version = version
 	if err := h.vehicleService.UpdateVehicle(c.Context(), &vehicle); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return apperrors.NotFoundIf(err, apperrors.VEHICLE_NOT_FOUND, "Vehicle not found")
 	}
 
+	setVersion(c, vehicle.Version)
+	return c.JSON(fiber.Map{
+		"message": "Vehicle updated successfully",
+		"vehicle": vehicle,
+	})
+}
+
+// vehicleWritable lists the vehicle fields each role may change with PATCH
+var vehicleWritable = patch.Policy{
+	models.RoleAdmin: {"license_plate", "capacity", "driver_name", "route_id", "odometer"},
+	models.RoleStaff: {"odometer"},
+}
+
+// PatchVehicle applies a JSON Merge Patch or JSON Patch to a vehicle. Members set to null are cleared
+func (h *VehicleHandler) PatchVehicle(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+
+	var req dto.CreateVehicleRequest
+	vehicle, err := h.vehicleService.PatchVehicle(c.Context(), uint(id), patchWith[models.Vehicle](c, vehicleWritable, &req))
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.VEHICLE_NOT_FOUND, "Vehicle not found")
+	}
+
+	setVersion(c, vehicle.Version)
 	return c.JSON(fiber.Map{
 		"message": "Vehicle updated successfully",
 		"vehicle": vehicle,
@@ -102,15 +129,16 @@ func (h *VehicleHandler) UpdateVehicle(c *fiber.Ctx) error {
 func (h *VehicleHandler) DeleteVehicle(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid vehicle ID",
-		})
+		return apperrors.BadRequest(apperrors.INVALID_ID, "Invalid vehicle ID")
+	}
+
+	version, err := ifMatch(c)
+	if err != nil {
+		return err
 	}
 
-	if err := h.vehicleService.DeleteVehicle(c.Context(), uint(id)); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	if err := h.vehicleService.DeleteVehicle(c.Context(), uint(id), version); err != nil {
+		return apperrors.NotFoundIf(err, apperrors.VEHICLE_NOT_FOUND, "Vehicle not found")
 	}
 
 	return c.JSON(fiber.Map{
@@ -0,0 +1,169 @@
+package i18n
+
+import (
+	"fmt"
+	"time"
+
+	"rota-api/utils/servicetime"
+)
+
+// Message keys for text the API generates itself
+const (
+	MsgBadRequest        = "error.bad_request"
+	MsgUnauthorized      = "error.unauthorized"
+	MsgForbidden         = "error.forbidden"
+	MsgNotFound          = "error.not_found"
+	MsgConflict          = "error.conflict"
+	MsgValidationFailed  = "error.validation_failed"
+	MsgValidationDetail  = "error.validation_detail"
+	MsgInternalError     = "error.internal"
+	MsgTooManyRequests   = "error.too_many_requests"
+	MsgPrecondition      = "error.precondition_failed"
+	MsgPreconditionNeed  = "error.precondition_required"
+	MsgUnsupportedMedia  = "error.unsupported_media_type"
+	MsgStationDetails    = "station.details"
+	MsgStationLocationAt = "station.location_at"
+	// MsgTripStatus prefixes the trip status shown on the signage board, e.g. "status.delayed"
+	MsgTripStatus = "status."
+)
+
+// catalog holds the translations of every message key. Values are fmt formats
+var catalog = map[string]map[Locale]string{
+	MsgBadRequest: {
+		Thai:    "คำขอไม่ถูกต้อง",
+		English: "Bad Request",
+		Chinese: "请求无效",
+	},
+	MsgUnauthorized: {
+		Thai:    "กรุณาเข้าสู่ระบบ",
+		English: "Unauthorized",
+		Chinese: "未授权",
+	},
+	MsgForbidden: {
+		Thai:    "ไม่มีสิทธิ์เข้าถึง",
+		English: "Forbidden",
+		Chinese: "禁止访问",
+	},
+	MsgNotFound: {
+		Thai:    "ไม่พบข้อมูล",
+		English: "Not Found",
+		Chinese: "未找到",
+	},
+	MsgConflict: {
+		Thai:    "ข้อมูลขัดแย้งกัน",
+		English: "Conflict",
+		Chinese: "数据冲突",
+	},
+	MsgValidationFailed: {
+		Thai:    "ข้อมูลไม่ผ่านการตรวจสอบ",
+		English: "Validation failed",
+		Chinese: "验证失败",
+	},
+	MsgValidationDetail: {
+		Thai:    "ข้อมูลบางรายการไม่ถูกต้อง",
+		English: "One or more validation errors occurred",
+		Chinese: "一个或多个字段无效",
+	},
+	MsgInternalError: {
+		Thai:    "เกิดข้อผิดพลาดภายในระบบ",
+		English: "Internal Server Error",
+		Chinese: "服务器内部错误",
+	},
+	MsgTooManyRequests: {
+		Thai:    "มีคำขอมากเกินไป",
+		English: "Too Many Requests",
+		Chinese: "请求过多",
+	},
+	MsgPrecondition: {
+		Thai:    "ข้อมูลถูกแก้ไขไปแล้ว",
+		English: "Precondition Failed",
+		Chinese: "前提条件失败",
+	},
+	MsgPreconditionNeed: {
+		Thai:    "ต้องระบุเวอร์ชันของข้อมูล",
+		English: "Precondition Required",
+		Chinese: "需要前提条件",
+	},
+	MsgUnsupportedMedia: {
+		Thai:    "ไม่รองรับรูปแบบข้อมูลนี้",
+		English: "Unsupported Media Type",
+		Chinese: "不支持的媒体类型",
+	},
+	// station.details takes the station name, station.location_at its address
+	MsgStationDetails: {
+		Thai:    "%s ให้บริการเดินรถระหว่างเมือง",
+		English: "%s offers intercity bus services",
+		Chinese: "%s提供城际巴士服务",
+	},
+	MsgStationLocationAt: {
+		Thai:    " ตั้งอยู่ที่ %s",
+		English: ", located at %s",
+		Chinese: "，地址：%s",
+	},
+	MsgTripStatus + "scheduled": {
+		Thai:    "ตรงเวลา",
+		English: "On time",
+		Chinese: "准点",
+	},
+	MsgTripStatus + "delayed": {
+		Thai:    "ล่าช้า",
+		English: "Delayed",
+		Chinese: "延误",
+	},
+	MsgTripStatus + "cancelled": {
+		Thai:    "ยกเลิก",
+		English: "Cancelled",
+		Chinese: "取消",
+	},
+	MsgTripStatus + "completed": {
+		Thai:    "สิ้นสุดการเดินทาง",
+		English: "Completed",
+		Chinese: "已完成",
+	},
+	MsgTripStatus + "departed": {
+		Thai:    "ออกแล้ว",
+		English: "Departed",
+		Chinese: "已发车",
+	},
+	MsgTripStatus + "arrived": {
+		Thai:    "ถึงแล้ว",
+		English: "Arrived",
+		Chinese: "已到达",
+	},
+}
+
+// T renders the message for key in locale, falling back along the locale's chain and then to Default.
+// Unknown keys are returned as-is so a missing entry shows up instead of an empty string
+func T(locale Locale, key string, args ...interface{}) string {
+	messages, ok := catalog[key]
+	if !ok {
+		return key
+	}
+
+	format, ok := "", false
+	for _, l := range append(locale.Chain(), Default) {
+		if format, ok = messages[l]; ok {
+			break
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// timeLayouts are how clock times are written for each locale
+var timeLayouts = map[Locale]string{
+	Thai:    "15:04 น.",
+	English: "3:04 PM",
+	Chinese: "15:04",
+}
+
+// FormatTime writes the clock time of t in the agency timezone the way readers of locale expect
+func FormatTime(locale Locale, t time.Time) string {
+	layout, ok := timeLayouts[locale]
+	if !ok {
+		layout = timeLayouts[Default]
+	}
+	return servicetime.In(t).Format(layout)
+}
@@ -0,0 +1,112 @@
+package i18n
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale is a language the API serves content in
+type Locale string
+
+// Supported locales. Thai is the language content is authored in
+const (
+	Thai    Locale = "th"
+	English Locale = "en"
+	Chinese Locale = "zh"
+
+	Default = Thai
+)
+
+// Supported lists the locales in order of preference when nothing else decides
+var Supported = []Locale{Thai, English, Chinese}
+
+// contextKey is the key the request locale is stored under, both in Fiber locals and in a context
+type contextKey string
+
+// LocalsKey is where the locale middleware stores the negotiated locale
+const LocalsKey contextKey = "locale"
+
+// Parse maps a language tag such as "en-US" or "zh-Hant" to a supported locale
+func Parse(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	for _, locale := range Supported {
+		if tag == string(locale) {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// Negotiate picks the best supported locale for an Accept-Language header, honouring q-values.
+// Ties keep the order the client listed them in, and Default is used when nothing matches
+func Negotiate(header string) Locale {
+	type candidate struct {
+		locale Locale
+		q      float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		if strings.TrimSpace(tag) == "*" {
+			candidates = append(candidates, candidate{Default, q})
+			continue
+		}
+		if locale, ok := Parse(tag); ok {
+			candidates = append(candidates, candidate{locale, q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return Default
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].locale
+}
+
+// Chain lists the locales to try, in order, when looking up text in locale.
+// Visitors who don't read Thai are better served by English than by the Thai original
+func (l Locale) Chain() []Locale {
+	switch l {
+	case English:
+		return []Locale{English}
+	case Chinese:
+		return []Locale{Chinese, English}
+	default:
+		return []Locale{Thai}
+	}
+}
+
+// WithLocale returns a copy of ctx carrying locale
+func WithLocale(ctx context.Context, locale Locale) context.Context {
+	return context.WithValue(ctx, LocalsKey, locale)
+}
+
+// FromContext returns the locale negotiated for the request, or Default.
+// Fiber's request context exposes its locals as values, so handlers can pass c.Context() straight through
+func FromContext(ctx context.Context) Locale {
+	if ctx == nil {
+		return Default
+	}
+	if locale, ok := ctx.Value(LocalsKey).(Locale); ok {
+		return locale
+	}
+	return Default
+}
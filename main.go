@@ -6,11 +6,14 @@ import (
 	"log"
 	"rota-api/config"
 	handler "rota-api/handlers"
+	"rota-api/middleware"
 	"rota-api/models"
+	"rota-api/passhash"
 	"rota-api/repositories"
 	"rota-api/routes"
 	"rota-api/services"
 	"rota-api/utils"
+	"rota-api/utils/servicetime"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -32,6 +35,13 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
+	servicetime.Configure(cfg.Agency.Location, cfg.Agency.ServiceDayStart)
+	log.Printf("Agency timezone: %s (service day starts at %s)", cfg.Agency.Location, servicetime.FormatLocalTime(cfg.Agency.ServiceDayStart))
+	passhash.Configure(passhash.Params{
+		Memory:      uint32(cfg.Password.Memory),
+		Iterations:  uint32(cfg.Password.Iterations),
+		Parallelism: uint8(cfg.Password.Parallelism),
+	})
 
 	// Initialize database
 	db, err := utils.InitDB(cfg)
@@ -89,9 +99,24 @@ func main() {
 	scheduleRepo := repositories.NewScheduleRepository(db)
 	scheduleLogRepo := repositories.NewScheduleLogRepository(db)
 	staffRepo := repositories.NewStaffRepository(db)
+	maintenanceRepo := repositories.NewMaintenanceRepository(db)
+	driverRepo := repositories.NewDriverRepository(db)
+	displayThemeRepo := repositories.NewDisplayThemeRepository(db)
+	cacheRepo := repositories.NewCacheRepository(redisRepo, cfg.Cache.LRUSize)
+	rateLimitRepo := repositories.NewRateLimitRepository(redisRepo)
+	idempotencyRepo := repositories.NewIdempotencyRepository(redisRepo, db)
+	reportRepo := repositories.NewReportRepository(db)
+	tripRepo := repositories.NewTripRepository(db)
+	recoveryCodeRepo := repositories.NewMFARecoveryCodeRepository(db)
 
 	// Initialize services
+	mfaRoles := make([]models.UserRole, len(cfg.MFA.RequiredRoles))
+	for i, role := range cfg.MFA.RequiredRoles {
+		mfaRoles[i] = models.UserRole(role)
+	}
 	authConfig := services.AuthConfig{
+		JWTSecret:     cfg.JWT.Secret,
+		JWTExpiration: cfg.JWT.AccessTokenTTL,
 		TokenConfig: models.TokenConfig{
 			Secret:     cfg.JWT.Secret,
 			ExpiryTime: cfg.JWT.AccessTokenTTL,
@@ -102,38 +127,102 @@ func main() {
 			Password: cfg.Redis.Password,
 			DB:       cfg.Redis.DB,
 		},
+		Lockout: services.LockoutPolicy{
+			MaxFailures:  cfg.Lockout.MaxFailures,
+			BaseDuration: cfg.Lockout.BaseDuration,
+			MaxDuration:  cfg.Lockout.MaxDuration,
+		},
+		MFA: services.MFAPolicy{
+			RequiredRoles: mfaRoles,
+			Issuer:        cfg.MFA.Issuer,
+			ChallengeTTL:  cfg.MFA.ChallengeTTL,
+			EnrollmentTTL: cfg.MFA.EnrollmentTTL,
+		},
+		PasswordResetTTL: cfg.Password.ResetTokenTTL,
 	}
 
 	authService := services.NewAuthService(
 		userRepo,
+		recoveryCodeRepo,
 		redisRepo,
 		authConfig,
 	)
 
 	// Initialize services
-	userService := services.NewUserService(userRepo)
-	routeService := services.NewRouteService(routeRepo)
-	stationService := services.NewStationService(stationRepo)
-	favoriteService := services.NewFavoriteService(favoriteRepo)
-	vehicleService := services.NewVehicleService(vehicleRepo)
-	scheduleService := services.NewScheduleService(scheduleRepo)
-	scheduleLogService := services.NewScheduleLogService(scheduleLogRepo)
+	cacheConfig := services.CacheConfig{
+		Repo: cacheRepo,
+		TTL:  cfg.Cache.TTL,
+	}
+	rateLimiter := services.NewRateLimiter(rateLimitRepo)
+	idempotencyService := services.NewIdempotencyService(idempotencyRepo, cfg.Idempotency.TTL)
+	rankingService := services.NewRankingService(redisRepo, stationRepo, routeRepo)
+	userService := services.NewUserService(userRepo, favoriteRepo, tripRepo)
+	routeService := services.NewRouteService(routeRepo, cacheConfig, rankingService)
+	stationService := services.NewStationService(stationRepo, cacheConfig, rankingService)
+	favoriteService := services.NewFavoriteService(favoriteRepo, rankingService)
+	vehicleService := services.NewVehicleService(vehicleRepo, cacheConfig)
+	maintenanceService := services.NewMaintenanceService(maintenanceRepo, vehicleRepo, scheduleRepo, cacheConfig)
+	driverService := services.NewDriverService(driverRepo, scheduleRepo, services.RosterRules{
+		MinRestBetweenTrips: cfg.Roster.MinRestBetweenTrips,
+		MaxDailyDuty:        cfg.Roster.MaxDailyDuty,
+	}, cacheConfig)
+	conflictService := services.NewConflictService(scheduleRepo, services.DispatchRules{
+		VehicleTurnaround: cfg.Dispatch.VehicleTurnaround,
+	})
+	scheduleService := services.NewScheduleService(scheduleRepo, maintenanceService, driverService, conflictService, cacheConfig, rankingService)
+	scheduleLogService := services.NewScheduleLogService(scheduleLogRepo, cacheConfig)
+	boardService := services.NewBoardService(scheduleRepo, scheduleLogRepo)
+	displayService := services.NewDisplayService(boardService, displayThemeRepo)
 	staffService := services.NewStaffService(staffRepo)
+	searchService := services.NewSearchService(stationRepo, routeRepo)
+	tripService := services.NewTripService(tripRepo, scheduleRepo, routeRepo)
+	reportService := services.NewReportService(reportRepo, services.ReportRules{
+		LateThreshold:      cfg.Reports.LateThreshold,
+		VehicleHoursPerDay: cfg.Reports.VehicleHoursPerDay,
+		UseSnapshot:        cfg.Reports.UseSnapshot,
+	})
+	importExportService := services.NewImportExportService(stationRepo, vehicleRepo, staffRepo, scheduleRepo, maintenanceService, driverService, conflictService, cacheConfig)
+
+	// Fill in search keys for stations saved before search existed
+	if updated, err := searchService.RefreshSearchKeys(context.Background()); err != nil {
+		log.Printf("Warning: Failed to refresh station search keys: %v", err)
+	} else if updated > 0 {
+		log.Printf("Refreshed search keys of %d stations", updated)
+	}
 
 	// Initialize handlers
 	authHandler := handler.NewAuthHandler(authService)
+	mfaHandler := handler.NewMFAHandler(authService)
 	userHandler := handler.NewUserHandler(userService, authService)
 	routeHandler := handler.NewRouteHandler(routeService)
 	stationHandler := handler.NewStationHandler(stationService)
 	favoriteHandler := handler.NewFavoriteHandler(favoriteService)
 	vehicleHandler := handler.NewVehicleHandler(vehicleService)
-	scheduleHandler := handler.NewScheduleHandler(scheduleService)
+	maintenanceHandler := handler.NewMaintenanceHandler(maintenanceService)
+	driverHandler := handler.NewDriverHandler(driverService)
+	scheduleHandler := handler.NewScheduleHandler(scheduleService, staffService)
+	conflictHandler := handler.NewConflictHandler(conflictService)
 	scheduleLogHandler := handler.NewScheduleLogHandler(scheduleLogService)
+	boardHandler := handler.NewBoardHandler(boardService)
+	displayHandler := handler.NewDisplayHandler(displayService, cfg.Display.RefreshInterval)
 	staffHandler := handler.NewStaffHandler(staffService)
+	cacheHandler := handler.NewCacheHandler(cacheRepo)
+	rankingHandler := handler.NewRankingHandler(rankingService)
+	searchHandler := handler.NewSearchHandler(searchService)
+	importExportHandler := handler.NewImportExportHandler(importExportService)
+	reportHandler := handler.NewReportHandler(reportService)
+	tripHandler := handler.NewTripHandler(tripService)
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
-		AppName: "Rota API",
+		AppName:      "Rota API",
+		ErrorHandler: handler.ErrorHandler,
+		// Requests arrive through Kong, which forwards the client address in X-Forwarded-For.
+		// The header is only read from the trusted proxies, see middleware.ClientIP
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.Proxy.TrustedProxies,
+		EnableIPValidation:      true,
 	})
 
 	// Middleware
@@ -142,12 +231,18 @@ func main() {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*", // Allow all origins
 		AllowMethods: "GET,POST,PUT,DELETE,PATCH,OPTIONS",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-Requested-With",
-		ExposeHeaders: "Content-Length, Authorization",
+		AllowHeaders: "Origin, Content-Type, Accept, Accept-Language, Authorization, X-Requested-With, If-Match, If-None-Match, Idempotency-Key",
+		ExposeHeaders: "Content-Length, Content-Language, Content-Disposition, Authorization, ETag, Idempotent-Replayed, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining",
 		AllowCredentials: false, // Changed to false to work with wildcard origins
 		MaxAge: 86400, // 24 hours
 	}))
 
+	// Response language (th, en or zh) from Accept-Language or ?lang=
+	app.Use(middleware.LocaleMiddleware())
+
+	// General API throttling per client IP; auth routes add stricter policies of their own
+	app.Use("/api/v1", middleware.RateLimitMiddleware(rateLimiter, "api", models.RateLimitPolicy(cfg.RateLimit.API), middleware.KeyByIP))
+
 	// Health check endpoint
 	app.Get("/api/v1/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
@@ -157,16 +252,30 @@ func main() {
 	})
 
 	// Routes
-	routes.SetupAuthRoutes(app, authHandler, authService)
+	routes.SetupAuthRoutes(app, authHandler, mfaHandler, authService, rateLimiter, routes.AuthRateLimits{
+		LoginIP:      models.RateLimitPolicy(cfg.RateLimit.LoginIP),
+		LoginAccount: models.RateLimitPolicy(cfg.RateLimit.LoginAccount),
+		RegisterIP:   models.RateLimitPolicy(cfg.RateLimit.RegisterIP),
+		RefreshIP:    models.RateLimitPolicy(cfg.RateLimit.RefreshIP),
+	}, idempotencyService)
 	routes.SetupUserRoutes(app, userHandler, authService)
+	// Rankings must come before station and route routes so /popular is not matched as /:id
+	routes.SetupRankingRoutes(app, rankingHandler)
+	routes.SetupSearchRoutes(app, searchHandler)
 	routes.SetupRouteRoutes(app, routeHandler, authService)
-	routes.SetupStationRoutes(app, stationHandler, scheduleHandler, authService)
-	routes.SetupFavoriteRoutes(app, favoriteHandler, authService, favoriteService)
-	routes.SetupVehicleRoutes(app, vehicleHandler, authService)
-	routes.SetupScheduleRoutes(app, scheduleHandler, authService)
+	routes.SetupStationRoutes(app, stationHandler, scheduleHandler, boardHandler, authService)
+	routes.SetupDisplayRoutes(app, displayHandler, authService)
+	routes.SetupFavoriteRoutes(app, favoriteHandler, authService, favoriteService, idempotencyService)
+	routes.SetupTripRoutes(app, tripHandler, authService, idempotencyService)
+	routes.SetupVehicleRoutes(app, vehicleHandler, maintenanceHandler, authService)
+	routes.SetupScheduleRoutes(app, scheduleHandler, conflictHandler, authService, idempotencyService)
+	routes.SetupDriverRoutes(app, driverHandler, authService)
 	routes.SetupScheduleLogRoutes(app, scheduleLogHandler, authService)
 	// เพิ่ม routes สำหรับ staff
 	routes.SetupStaffRoutes(app, staffHandler, authService)
+	routes.SetupCacheRoutes(app, cacheHandler, authService)
+	routes.SetupImportExportRoutes(app, importExportHandler, authService)
+	routes.SetupReportRoutes(app, reportHandler, authService)
 
 	// Start server
 	log.Printf("Server starting on :%s", cfg.ServerPort)
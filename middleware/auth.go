@@ -1,11 +1,11 @@
 package middleware
 
 import (
-	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"strconv"
 	"strings"
 
+	apperrors "rota-api/errors"
 	"rota-api/models"
 	"rota-api/services"
 
@@ -36,10 +36,7 @@ func AuthMiddleware(authService services.AuthService) fiber.Handler {
 		
 		// Check if token starts with "Bearer "
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "Invalid authorization header format",
-			})
+			return apperrors.Unauthorized(apperrors.MISSING_TOKEN, "Invalid authorization header format")
 		}
 		
 		// Extract token
@@ -47,72 +44,42 @@ func AuthMiddleware(authService services.AuthService) fiber.Handler {
 
 		// ตรวจสอบว่า token อยู่ใน blacklist หรือไม่
 		if authService.IsTokenBlacklisted(tokenString) {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "Token has been invalidated, please login again",
-			})
+			return apperrors.Unauthorized(apperrors.TOKEN_REVOKED, "Token has been invalidated, please login again")
 		}
 		
-		// Try parsing and validating token through the service
+		// Only a token with a valid signature is trusted, and the user, role and MFA state come from its claims
 		claims, err := authService.ValidateAccessToken(tokenString)
-		if err == nil {
-			// If token is valid, use the claims
-			c.Locals("userID", claims.UserID)
-			c.Locals("userEmail", claims.Email)
-			c.Locals("userRole", claims.Role)
-			return c.Next()
+		if err != nil {
+			return apperrors.Unauthorized(apperrors.INVALID_TOKEN, "Invalid or expired token")
 		}
-		
-		// If token validation fails but token format is correct (for development)
-		parts := strings.Split(tokenString, ".")
-		if len(parts) == 3 {
-			// Parse the JWT payload to extract the user role
-			payload, err := parseJWTPayload(parts[1])
-			if err == nil {
-				// Set user data from parsed payload
-				if userID, ok := payload["user_id"].(float64); ok {
-					c.Locals("userID", int(userID))
-				}
-				if email, ok := payload["email"].(string); ok {
-					c.Locals("userEmail", email)
-				}
-				if role, ok := payload["role"].(string); ok {
-					c.Locals("userRole", models.UserRole(role))
-				} else {
-					c.Locals("userRole", RoleUser)
-				}
-				return c.Next()
+
+		// Tokens outlive the account they were issued to, so every token of a deleted account stops working with it
+		if _, err := authService.GetUserByID(c.Context(), claims.UserID); err != nil {
+			if errors.Is(err, services.ErrUserNotFound) {
+				return apperrors.Unauthorized(apperrors.TOKEN_REVOKED, "Account no longer exists")
 			}
+			return err
 		}
-		
-		// If all validation fails
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid or expired token",
-		})
+
+		c.Locals("userID", claims.UserID)
+		c.Locals("userEmail", claims.Email)
+		if claims.IssuedAt != nil {
+			c.Locals("authTime", claims.IssuedAt.Time)
+		}
+		setRole(c, authService, claims.Role, claims.MFA)
+		return c.Next()
 	}
 }
 
-// parseJWTPayload decodes the payload part of a JWT token
-func parseJWTPayload(payload string) (map[string]interface{}, error) {
-	// Add padding if needed
-	if l := len(payload) % 4; l > 0 {
-		payload += strings.Repeat("=", 4-l)
+// setRole stores the role the request acts with. Sessions of roles that require MFA act as plain users
+// until a second factor is verified, so every role check treats them as such
+func setRole(c *fiber.Ctx, authService services.AuthService, role models.UserRole, mfa bool) {
+	if !mfa && authService.MFARequired(role) {
+		c.Locals("userRole", RoleUser)
+		c.Locals("mfaPending", true)
+		return
 	}
-	
-	// Decode base64
-	decodedBytes, err := base64.URLEncoding.DecodeString(payload)
-	if err != nil {
-		return nil, err
-	}
-	
-	// Parse JSON
-	var claims map[string]interface{}
-	if err := json.Unmarshal(decodedBytes, &claims); err != nil {
-		return nil, err
-	}
-	
-	return claims, nil
+	c.Locals("userRole", role)
 }
 
 // RoleMiddleware creates a middleware that checks if the user has the required role
@@ -121,10 +88,7 @@ func RoleMiddleware(roles ...models.UserRole) fiber.Handler {
 		// Get user role from context
 		userRole, ok := c.Locals("userRole").(models.UserRole)
 		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "unauthorized: missing user role",
-			})
+			return apperrors.Unauthorized(apperrors.UNAUTHORIZED, "unauthorized: missing user role")
 		}
 
 		// Check if user has any of the required roles
@@ -139,10 +103,10 @@ func RoleMiddleware(roles ...models.UserRole) fiber.Handler {
 			return c.Next()
 		}
 
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"success": false,
-			"message": "forbidden: insufficient permissions",
-		})
+		if pending, _ := c.Locals("mfaPending").(bool); pending {
+			return apperrors.Forbidden(apperrors.MFA_REQUIRED, "forbidden: multi-factor authentication is required for your role, enable it and log in again")
+		}
+		return apperrors.Forbidden(apperrors.FORBIDDEN, "forbidden: insufficient permissions")
 	}
 }
 
@@ -181,19 +145,13 @@ func OwnResourceMiddleware() fiber.Handler {
 		// Get user ID from context
 		userID, err := GetUserIDFromContext(c)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "unauthorized: missing user ID",
-			})
+			return apperrors.Unauthorized(apperrors.UNAUTHORIZED, "unauthorized: missing user ID")
 		}
 
 		// Get user role from context
 		userRole, err := GetUserRoleFromContext(c)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "unauthorized: missing user role",
-			})
+			return apperrors.Unauthorized(apperrors.UNAUTHORIZED, "unauthorized: missing user role")
 		}
 
 		// If user is admin or staff, always allow access
@@ -204,28 +162,19 @@ func OwnResourceMiddleware() fiber.Handler {
 		// Get resource ID from params
 		paramID := c.Params("id")
 		if paramID == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"success": false,
-				"message": "bad request: missing resource ID",
-			})
+			return apperrors.BadRequest(apperrors.INVALID_ID, "bad request: missing resource ID")
 		}
 
 		// Compare user ID with resource ID
 		// For users table, the resource ID directly corresponds to the user ID
 		resourceID, err := strconv.Atoi(paramID)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"success": false,
-				"message": "bad request: invalid resource ID",
-			})
+			return apperrors.BadRequest(apperrors.INVALID_ID, "bad request: invalid resource ID")
 		}
 
 		// Check if user is accessing their own resource
 		if userID != resourceID {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"success": false,
-				"message": "forbidden: you can only access your own resources",
-			})
+			return apperrors.Forbidden(apperrors.USER_FORBIDDEN, "forbidden: you can only access your own resources")
 		}
 
 		return c.Next()
@@ -239,19 +188,13 @@ func OwnFavoriteMiddleware(favoriteService services.FavoriteService) fiber.Handl
 		// Get user ID from context
 		userID, err := GetUserIDFromContext(c)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "unauthorized: missing user ID",
-			})
+			return apperrors.Unauthorized(apperrors.UNAUTHORIZED, "unauthorized: missing user ID")
 		}
 
 		// Get user role from context
 		userRole, err := GetUserRoleFromContext(c)
 		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"message": "unauthorized: missing user role",
-			})
+			return apperrors.Unauthorized(apperrors.UNAUTHORIZED, "unauthorized: missing user role")
 		}
 
 		// If user is admin or staff, always allow access
@@ -271,10 +214,7 @@ func OwnFavoriteMiddleware(favoriteService services.FavoriteService) fiber.Handl
 		// For specific favorite ID, we need to check if it belongs to the user
 		favoriteID, err := strconv.Atoi(paramID)
 		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"success": false,
-				"message": "bad request: invalid favorite ID",
-			})
+			return apperrors.BadRequest(apperrors.INVALID_ID, "bad request: invalid favorite ID")
 		}
 
 		// Check if the favorite belongs to the user
@@ -283,17 +223,11 @@ func OwnFavoriteMiddleware(favoriteService services.FavoriteService) fiber.Handl
 		uintFavoriteID := uint(favoriteID)
 		favorite, err := favoriteService.GetFavoriteByID(c.Context(), uintFavoriteID)
 		if err != nil {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"success": false,
-				"message": "favorite not found",
-			})
+			return apperrors.NotFoundIf(err, apperrors.FAVORITE_NOT_FOUND, "favorite not found")
 		}
 
 		if int(favorite.UserID) != userID {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"success": false,
-				"message": "forbidden: you can only access your own favorites",
-			})
+			return apperrors.Forbidden(apperrors.FAVORITE_FORBIDDEN, "forbidden: you can only access your own favorites")
 		}
 
 		return c.Next()
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	apperrors "rota-api/errors"
+	"rota-api/models"
+	"rota-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// HeaderIdempotencyKey carries the client-chosen key that identifies a request across retries
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed marks a response replayed from an earlier request with the same key
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the response headers stored with a response and sent again when it is replayed
+var replayedHeaders = []string{
+	fiber.HeaderContentType,
+	fiber.HeaderContentLanguage,
+	fiber.HeaderLocation,
+	fiber.HeaderETag,
+}
+
+// IdempotencyMiddleware makes requests sent with an Idempotency-Key header safe to retry. The first
+// response for a key is stored and replayed to retries; reusing the key for a different body or
+// target is rejected. Responses with a 5xx status are not stored, so the request can be retried.
+// Keys are scoped to the signed-in user, so it must run after AuthMiddleware on protected routes
+func IdempotencyMiddleware(idempotency services.IdempotencyService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderIdempotencyKey)
+		if key == "" {
+			return c.Next()
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return apperrors.BadRequest(apperrors.INVALID_IDEMPOTENCY, fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength))
+		}
+
+		key = idempotencyScope(c) + ":" + key
+		fingerprint := requestFingerprint(c)
+		stored, err := idempotency.Begin(c.Context(), key, fingerprint)
+		if err != nil {
+			return err
+		}
+		if stored != nil {
+			for name, value := range stored.Headers {
+				c.Set(name, value)
+			}
+			c.Set(HeaderIdempotentReplayed, "true")
+			return c.Status(stored.Status).Send(stored.Body)
+		}
+
+		// A panicking handler never finishes the request; free the key before the recover middleware,
+		// which runs outside this one, turns the panic into an error response
+		defer func() {
+			if r := recover(); r != nil {
+				idempotency.Abandon(c.Context(), key)
+				panic(r)
+			}
+		}()
+
+		// Errors are rendered here rather than by the app, so problem responses are stored as well
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				idempotency.Abandon(c.Context(), key)
+				return err
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			idempotency.Abandon(c.Context(), key)
+			return nil
+		}
+
+		headers := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := c.GetRespHeader(name); value != "" {
+				headers[name] = value
+			}
+		}
+		idempotency.Finish(c.Context(), &models.IdempotencyRecord{
+			Key:         key,
+			Fingerprint: fingerprint,
+			Status:      status,
+			Headers:     headers,
+			Body:        append([]byte(nil), c.Response().Body()...),
+		})
+		return nil
+	}
+}
+
+// idempotencyScope separates the keys of different users, so one user's key never replays another's response.
+// Anonymous requests are scoped to the client IP
+func idempotencyScope(c *fiber.Ctx) string {
+	if userID := c.Locals("userID"); userID != nil {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return "anonymous:" + ClientIP(c)
+}
+
+// requestFingerprint identifies what a request does: its method, target and body
+func requestFingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method() + " " + c.OriginalURL() + "\n"))
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"rota-api/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LocaleMiddleware negotiates the response language from Accept-Language and stores it in the request locals.
+// A lang query parameter overrides the header, for links shared between visitors
+func LocaleMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		locale, ok := i18n.Parse(c.Query("lang"))
+		if !ok {
+			locale = i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage))
+		}
+
+		c.Locals(i18n.LocalsKey, locale)
+		c.Set(fiber.HeaderContentLanguage, string(locale))
+		c.Vary(fiber.HeaderAcceptLanguage)
+		return c.Next()
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+
+	apperrors "rota-api/errors"
+	"rota-api/models"
+	"rota-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimitKeyFunc derives the identity a request is counted against.
+// An empty key skips rate limiting for the request
+type RateLimitKeyFunc func(c *fiber.Ctx) string
+
+// KeyByIP counts requests per client IP
+func KeyByIP(c *fiber.Ctx) string {
+	return "ip:" + ClientIP(c)
+}
+
+// ClientIP returns the address of the client. Behind a trusted proxy (Kong) it is the last
+// X-Forwarded-For entry, the one the proxy appended for the connection it received; earlier
+// entries come from the client and can be forged
+func ClientIP(c *fiber.Ctx) string {
+	if c.IsProxyTrusted() {
+		if ips := c.IPs(); len(ips) > 0 {
+			return ips[len(ips)-1]
+		}
+	}
+	return c.Context().RemoteIP().String()
+}
+
+// KeyByAccount counts requests per account, identified by the `email` field of a JSON body
+func KeyByAccount(c *fiber.Ctx) string {
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(c.Body(), &body); err != nil || body.Email == "" {
+		return ""
+	}
+	return "account:" + strings.ToLower(strings.TrimSpace(body.Email))
+}
+
+// RateLimitMiddleware throttles requests with a sliding window policy. `name` separates the
+// counters of different route groups, so each group can have its own policy
+func RateLimitMiddleware(limiter services.RateLimiter, name string, policy models.RateLimitPolicy, keyFunc RateLimitKeyFunc) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := keyFunc(c)
+		if key == "" {
+			return c.Next()
+		}
+
+		result := limiter.Allow(c.Context(), name+":"+key, policy)
+		if result.Limit > 0 {
+			c.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			c.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		}
+
+		if !result.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			return apperrors.TooManyRequests(apperrors.RATE_LIMITED, "Too many requests. Please try again later.")
+		}
+
+		return c.Next()
+	}
+}
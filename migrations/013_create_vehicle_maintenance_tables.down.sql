@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS out_of_service_windows;
+DROP TABLE IF EXISTS vehicle_inspections;
+DROP TABLE IF EXISTS maintenance_records;
+
+ALTER TABLE vehicles DROP COLUMN IF EXISTS odometer;
@@ -0,0 +1,48 @@
+-- เพิ่มเลขไมล์ปัจจุบันของรถ
+ALTER TABLE vehicles
+  ADD COLUMN IF NOT EXISTS odometer INTEGER NOT NULL DEFAULT 0;
+
+-- ประวัติการซ่อมบำรุงรถ
+CREATE TABLE IF NOT EXISTS maintenance_records (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER REFERENCES vehicles(id) ON DELETE CASCADE NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    description TEXT,
+    odometer INTEGER,
+    cost NUMERIC(12, 2) DEFAULT 0,
+    performed_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- การตรวจสภาพรถตามวันที่หรือเลขไมล์
+CREATE TABLE IF NOT EXISTS vehicle_inspections (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER REFERENCES vehicles(id) ON DELETE CASCADE NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    due_date TIMESTAMP WITH TIME ZONE,
+    due_odometer INTEGER,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+    completed_at TIMESTAMP WITH TIME ZONE,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (due_date IS NOT NULL OR due_odometer IS NOT NULL)
+);
+
+-- ช่วงเวลาที่รถงดให้บริการ (end_time ว่าง = งดให้บริการจนกว่าจะแจ้งเปลี่ยนแปลง)
+CREATE TABLE IF NOT EXISTS out_of_service_windows (
+    id SERIAL PRIMARY KEY,
+    vehicle_id INTEGER REFERENCES vehicles(id) ON DELETE CASCADE NOT NULL,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE,
+    reason TEXT NOT NULL,
+    maintenance_record_id INTEGER REFERENCES maintenance_records(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (end_time IS NULL OR end_time > start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_maintenance_records_vehicle_id ON maintenance_records(vehicle_id);
+CREATE INDEX IF NOT EXISTS idx_vehicle_inspections_vehicle_id ON vehicle_inspections(vehicle_id);
+CREATE INDEX IF NOT EXISTS idx_vehicle_inspections_status ON vehicle_inspections(status);
+CREATE INDEX IF NOT EXISTS idx_out_of_service_windows_vehicle_time ON out_of_service_windows(vehicle_id, start_time, end_time);
@@ -0,0 +1,3 @@
+ALTER TABLE schedules DROP COLUMN IF EXISTS driver_id;
+
+DROP TABLE IF EXISTS drivers;
@@ -0,0 +1,30 @@
+-- พนักงานขับรถ
+CREATE TABLE IF NOT EXISTS drivers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    license_number VARCHAR(50) UNIQUE NOT NULL,
+    license_expiry TIMESTAMP WITH TIME ZONE NOT NULL,
+    phone VARCHAR(50),
+    email VARCHAR(100),
+    active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_drivers_deleted_at ON drivers(deleted_at);
+
+-- กำหนดพนักงานขับรถให้แต่ละเที่ยว
+ALTER TABLE schedules
+  ADD COLUMN IF NOT EXISTS driver_id INTEGER REFERENCES drivers(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_schedules_driver_departure ON schedules(driver_id, departure_time);
+
+-- ย้ายชื่อพนักงานขับรถเดิมจาก vehicles.driver_name มาเป็นข้อมูลตั้งต้น
+-- เลขใบอนุญาตเป็นค่าชั่วคราว ต้องแก้ไขให้ถูกต้องก่อนใช้งานจริง
+INSERT INTO drivers (name, license_number, license_expiry)
+SELECT DISTINCT ON (driver_name) driver_name, 'LEGACY-' || id, CURRENT_TIMESTAMP + interval '1 year'
+FROM vehicles
+WHERE driver_name IS NOT NULL AND driver_name <> ''
+ORDER BY driver_name, id
+ON CONFLICT (license_number) DO NOTHING;
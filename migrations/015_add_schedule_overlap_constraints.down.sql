@@ -0,0 +1,2 @@
+ALTER TABLE schedules DROP CONSTRAINT IF EXISTS schedules_driver_no_overlap;
+ALTER TABLE schedules DROP CONSTRAINT IF EXISTS schedules_vehicle_no_overlap;
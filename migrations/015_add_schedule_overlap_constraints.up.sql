@@ -0,0 +1,21 @@
+-- ต้องใช้ btree_gist เพื่อรวมคอลัมน์ปกติกับช่วงเวลาใน exclusion constraint เดียวกัน
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- ป้องกันไม่ให้รถคันเดียวกันถูกจัดลงเที่ยววิ่งที่ช่วงเวลาทับซ้อนกัน
+-- หากมีข้อมูลเดิมที่ทับซ้อนกันอยู่ ให้ตรวจสอบผ่าน GET /api/v1/admin/schedules/conflicts และแก้ไขก่อนรัน migration นี้
+ALTER TABLE schedules
+    ADD CONSTRAINT schedules_vehicle_no_overlap
+    EXCLUDE USING gist (
+        vehicle_id WITH =,
+        tstzrange(departure_time, arrival_time, '[)') WITH &&
+    )
+    WHERE (deleted_at IS NULL AND status IS DISTINCT FROM 'cancelled' AND arrival_time > departure_time);
+
+-- ป้องกันไม่ให้พนักงานขับรถคนเดียวกันถูกจัดลงเที่ยววิ่งที่ช่วงเวลาทับซ้อนกัน
+ALTER TABLE schedules
+    ADD CONSTRAINT schedules_driver_no_overlap
+    EXCLUDE USING gist (
+        driver_id WITH =,
+        tstzrange(departure_time, arrival_time, '[)') WITH &&
+    )
+    WHERE (driver_id IS NOT NULL AND deleted_at IS NULL AND status IS DISTINCT FROM 'cancelled' AND arrival_time > departure_time);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_schedules_route_arrival;
+DROP INDEX IF EXISTS idx_schedules_station_departure;
+DROP INDEX IF EXISTS idx_schedule_logs_schedule_id;
+
+ALTER TABLE schedule_logs
+    DROP COLUMN IF EXISTS notes,
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS actual_arrival,
+    DROP COLUMN IF EXISTS actual_departure;
+
+ALTER TABLE schedules DROP COLUMN IF EXISTS platform;
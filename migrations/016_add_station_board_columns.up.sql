@@ -0,0 +1,16 @@
+-- ชานชาลาที่รถออก/เข้าจอด สำหรับแสดงบนกระดานเวลารถ
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS platform VARCHAR(20);
+
+-- เวลาจริงและสถานะจากบันทึกการเดินรถ ใช้คำนวณความล่าช้าบนกระดานเวลารถ
+ALTER TABLE schedule_logs
+    ADD COLUMN IF NOT EXISTS actual_departure TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS actual_arrival TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS notes TEXT;
+
+-- ดัชนีสำหรับค้นหาบันทึกล่าสุดของแต่ละเที่ยว
+CREATE INDEX IF NOT EXISTS idx_schedule_logs_schedule_id ON schedule_logs(schedule_id);
+
+-- ดัชนีสำหรับกระดานเวลารถแบบแบ่งหน้าตามเวลา
+CREATE INDEX IF NOT EXISTS idx_schedules_station_departure ON schedules(station_id, departure_time, id);
+CREATE INDEX IF NOT EXISTS idx_schedules_route_arrival ON schedules(route_id, arrival_time, id);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS station_display_themes;
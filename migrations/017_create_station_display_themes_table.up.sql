@@ -0,0 +1,12 @@
+-- ธีมของจอแสดงตารางเวลารถประจำสถานี
+CREATE TABLE IF NOT EXISTS station_display_themes (
+    id SERIAL PRIMARY KEY,
+    station_id INTEGER NOT NULL UNIQUE REFERENCES stations(id) ON DELETE CASCADE,
+    background_color VARCHAR(9) NOT NULL DEFAULT '#0b1f3a',
+    text_color VARCHAR(9) NOT NULL DEFAULT '#ffffff',
+    accent_color VARCHAR(9) NOT NULL DEFAULT '#ffcc00',
+    logo_url VARCHAR(500),
+    font_scale NUMERIC(3,2) NOT NULL DEFAULT 1 CHECK (font_scale BETWEEN 0.5 AND 3),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+-- เพิ่มคอลัมน์สำหรับล็อกบัญชีเมื่อเข้าสู่ระบบผิดติดต่อกันหลายครั้ง
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_login_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS lockout_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE;
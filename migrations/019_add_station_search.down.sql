@@ -0,0 +1,6 @@
+-- ลบคอลัมน์และดัชนีสำหรับค้นหาสถานี
+DROP INDEX IF EXISTS idx_stations_search_location_trgm;
+DROP INDEX IF EXISTS idx_stations_search_name_trgm;
+ALTER TABLE stations DROP COLUMN IF EXISTS search_location;
+ALTER TABLE stations DROP COLUMN IF EXISTS search_name;
+ALTER TABLE stations DROP COLUMN IF EXISTS name_en;
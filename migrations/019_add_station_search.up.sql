@@ -0,0 +1,22 @@
+-- เปิดใช้ pg_trgm สำหรับค้นหาชื่อสถานีแบบยอมรับการพิมพ์ผิด
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- ชื่อภาษาอังกฤษ และคีย์สำหรับค้นหา (ถอดเป็นอักษรโรมันแล้ว) ซึ่งแอปพลิเคชันเป็นผู้คำนวณ
+ALTER TABLE stations ADD COLUMN IF NOT EXISTS name_en VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE stations ADD COLUMN IF NOT EXISTS search_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE stations ADD COLUMN IF NOT EXISTS search_location TEXT NOT NULL DEFAULT '';
+
+-- ชื่อภาษาอังกฤษของสถานีตั้งต้น
+UPDATE stations SET name_en = 'Mo Chit 2 (Chatuchak Bus Terminal)' WHERE name = 'สถานีหมอชิต 2' AND name_en = '';
+UPDATE stations SET name_en = 'Rangsit' WHERE name = 'สถานีรังสิต' AND name_en = '';
+UPDATE stations SET name_en = 'Southern Bus Terminal (Sai Tai Mai)' WHERE name = 'สถานีสายใต้ใหม่' AND name_en = '';
+UPDATE stations SET name_en = 'Bang Na' WHERE name = 'สถานีบางนา' AND name_en = '';
+UPDATE stations SET name_en = 'Min Buri' WHERE name = 'สถานีมีนบุรี' AND name_en = '';
+UPDATE stations SET name_en = 'Old Mo Chit (Mo Chit Kao)' WHERE name = 'สถานีหมอชิตเก่า' AND name_en = '';
+UPDATE stations SET name_en = 'Bang Khen' WHERE name = 'สถานีบางเขน' AND name_en = '';
+UPDATE stations SET name_en = 'Lat Phrao' WHERE name = 'สถานีลาดพร้าว' AND name_en = '';
+UPDATE stations SET name_en = 'Chaeng Watthana' WHERE name = 'สถานีแจ้งวัฒนะ' AND name_en = '';
+UPDATE stations SET name_en = 'Victory Monument (Anusawari Chai)' WHERE name = 'สถานีอนุสาวรีย์ชัย' AND name_en = '';
+
+CREATE INDEX IF NOT EXISTS idx_stations_search_name_trgm ON stations USING gin (search_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_stations_search_location_trgm ON stations USING gin (search_location gin_trgm_ops);
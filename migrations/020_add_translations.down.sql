@@ -0,0 +1,4 @@
+-- ลบคอลัมน์คำแปล
+ALTER TABLE routes DROP COLUMN IF EXISTS translations;
+ALTER TABLE routes DROP COLUMN IF EXISTS description;
+ALTER TABLE stations DROP COLUMN IF EXISTS translations;
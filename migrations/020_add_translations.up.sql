@@ -0,0 +1,15 @@
+-- คำแปลของข้อความในแต่ละภาษา เก็บเป็น {"ภาษา": {"ฟิลด์": "ข้อความ"}} เช่น {"zh": {"name": "兰实"}}
+ALTER TABLE stations ADD COLUMN IF NOT EXISTS translations JSONB NOT NULL DEFAULT '{}';
+
+-- คำอธิบายเส้นทางและคำแปล
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS translations JSONB NOT NULL DEFAULT '{}';
+
+-- ชื่อภาษาจีนของสถานีตั้งต้นที่นักท่องเที่ยวใช้บ่อย (ชื่อภาษาอังกฤษอยู่ใน name_en แล้ว)
+UPDATE stations SET translations = '{"zh": {"name": "曼谷北部汽车站（莫集2）"}}' WHERE name = 'สถานีหมอชิต 2' AND translations = '{}';
+UPDATE stations SET translations = '{"zh": {"name": "曼谷南部汽车站"}}' WHERE name = 'สถานีสายใต้ใหม่' AND translations = '{}';
+UPDATE stations SET translations = '{"zh": {"name": "胜利纪念碑"}}' WHERE name = 'สถานีอนุสาวรีย์ชัย' AND translations = '{}';
+UPDATE stations SET translations = '{"zh": {"name": "兰实"}}' WHERE name = 'สถานีรังสิต' AND translations = '{}';
+UPDATE stations SET translations = '{"zh": {"name": "邦纳"}}' WHERE name = 'สถานีบางนา' AND translations = '{}';
+UPDATE stations SET translations = '{"zh": {"name": "民武里"}}' WHERE name = 'สถานีมีนบุรี' AND translations = '{}';
+UPDATE stations SET translations = '{"zh": {"name": "拉抛"}}' WHERE name = 'สถานีลาดพร้าว' AND translations = '{}';
@@ -0,0 +1,3 @@
+-- ลบวันที่ให้บริการ (เวลาออกและถึงยังคงเป็น timestamptz)
+DROP INDEX IF EXISTS idx_schedules_service_date;
+ALTER TABLE schedules DROP COLUMN IF EXISTS service_date;
@@ -0,0 +1,15 @@
+-- วันที่ให้บริการของแต่ละเที่ยว ตามเขตเวลาของผู้ให้บริการ (ค่าเริ่มต้น Asia/Bangkok)
+-- เที่ยวที่ออกหลังเที่ยงคืนแต่ก่อนเวลาเริ่มวันให้บริการ (ค่าเริ่มต้น 03:00) นับเป็นของวันก่อนหน้า
+-- หมายเหตุ: ไฟล์ migration อ่านค่า AGENCY_TIMEZONE และ SERVICE_DAY_START ไม่ได้ การเติมข้อมูลด้านล่างจึงใช้ค่าเริ่มต้นเสมอ
+-- และมีผลเฉพาะเที่ยวที่มีอยู่ก่อน migration นี้ เที่ยวที่สร้างหลังจากนี้แอปคำนวณวันที่ให้บริการตามค่าที่ตั้งไว้
+-- หากตั้งค่าทั้งสองต่างจากค่าเริ่มต้น ให้แก้เขตเวลาและช่วงเวลาในคำสั่ง UPDATE ให้ตรงกันก่อนรัน migration นี้
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS service_date DATE;
+
+UPDATE schedules
+SET service_date = ((departure_time AT TIME ZONE 'Asia/Bangkok') - INTERVAL '3 hours')::date
+WHERE service_date IS NULL;
+
+ALTER TABLE schedules ALTER COLUMN service_date SET NOT NULL;
+
+-- ดัชนีสำหรับค้นหาเที่ยวตามวันที่ให้บริการ
+CREATE INDEX IF NOT EXISTS idx_schedules_service_date ON schedules(service_date, route_id);
@@ -0,0 +1,9 @@
+ALTER TABLE users DROP COLUMN IF EXISTS version;
+ALTER TABLE favorites DROP COLUMN IF EXISTS version;
+ALTER TABLE drivers DROP COLUMN IF EXISTS version;
+ALTER TABLE schedule_logs DROP COLUMN IF EXISTS version;
+ALTER TABLE schedules DROP COLUMN IF EXISTS version;
+ALTER TABLE staffs DROP COLUMN IF EXISTS version;
+ALTER TABLE vehicles DROP COLUMN IF EXISTS version;
+ALTER TABLE routes DROP COLUMN IF EXISTS version;
+ALTER TABLE stations DROP COLUMN IF EXISTS version;
@@ -0,0 +1,2 @@
+-- ลบ view เวลาจริงของเที่ยวสำหรับรายงาน
+DROP MATERIALIZED VIEW IF EXISTS trip_actuals;
@@ -0,0 +1,30 @@
+-- เวลาจริงล่าสุดที่บันทึกไว้ของแต่ละเที่ยว และความล่าช้าเป็นวินาที สำหรับรายงานความตรงต่อเวลาและการใช้งานรถ
+-- เป็น materialized view จึงต้องสั่ง REFRESH เป็นระยะ (POST /api/v1/admin/reports/refresh)
+-- รายงานจะอ่านจาก view นี้เมื่อตั้งค่า REPORTS_USE_SNAPSHOT=true เท่านั้น
+CREATE MATERIALIZED VIEW IF NOT EXISTS trip_actuals AS
+SELECT s.id AS schedule_id,
+       s.service_date,
+       s.route_id,
+       s.station_id,
+       s.vehicle_id,
+       s.status,
+       s.departure_time,
+       s.arrival_time,
+       a.actual_departure,
+       a.actual_arrival,
+       EXTRACT(EPOCH FROM a.actual_departure - s.departure_time)::float8 AS departure_delay,
+       EXTRACT(EPOCH FROM a.actual_arrival - s.arrival_time)::float8 AS arrival_delay
+FROM schedules s
+LEFT JOIN LATERAL (
+    SELECT l.actual_departure, l.actual_arrival
+    FROM schedule_logs l
+    WHERE l.schedule_id = s.id
+      AND (l.actual_departure IS NOT NULL OR l.actual_arrival IS NOT NULL)
+    ORDER BY COALESCE(l.updated_at, l.created_at) DESC, l.id DESC
+    LIMIT 1
+) a ON TRUE
+WHERE s.deleted_at IS NULL;
+
+-- ดัชนี unique จำเป็นสำหรับ REFRESH MATERIALIZED VIEW CONCURRENTLY
+CREATE UNIQUE INDEX IF NOT EXISTS idx_trip_actuals_schedule_id ON trip_actuals (schedule_id);
+CREATE INDEX IF NOT EXISTS idx_trip_actuals_service_date ON trip_actuals (service_date, route_id);
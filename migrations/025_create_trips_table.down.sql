@@ -0,0 +1,2 @@
+-- ลบตารางประวัติการเดินทางของผู้ใช้
+DROP TABLE IF EXISTS trips;
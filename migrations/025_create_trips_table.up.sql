@@ -0,0 +1,16 @@
+-- ประวัติการเดินทางของผู้ใช้ บันทึกโดยผู้ใช้เอง (ในอนาคตจะมาจากการจองหรือการสแกนตั๋วด้วย)
+-- ระยะทางคัดลอกจากเส้นทางตอนบันทึก และลบตามผู้ใช้เมื่อผู้ใช้ถูกลบ
+CREATE TABLE IF NOT EXISTS trips (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    schedule_id INTEGER REFERENCES schedules(id) ON DELETE SET NULL,
+    route_id INTEGER NOT NULL REFERENCES routes(id),
+    travelled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    distance DOUBLE PRECISION NOT NULL DEFAULT 0,
+    source VARCHAR(20) NOT NULL DEFAULT 'manual',
+    notes VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_trips_user_id_travelled_at ON trips (user_id, travelled_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_trips_user_schedule ON trips (user_id, schedule_id) WHERE schedule_id IS NOT NULL;
@@ -0,0 +1,3 @@
+-- ลบคอลัมน์สำหรับ soft delete ของผู้ใช้
+DROP INDEX IF EXISTS idx_users_deleted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
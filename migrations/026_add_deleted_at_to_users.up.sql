@@ -0,0 +1,4 @@
+-- ลบบัญชีผู้ใช้แบบ soft delete: แถวของผู้ใช้ยังอยู่แต่ข้อมูลส่วนบุคคลถูกลบออก (PDPA)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
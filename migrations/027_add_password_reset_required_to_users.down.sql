@@ -0,0 +1,2 @@
+-- ลบคอลัมน์บังคับตั้งรหัสผ่านใหม่
+ALTER TABLE users DROP COLUMN IF EXISTS password_reset_required;
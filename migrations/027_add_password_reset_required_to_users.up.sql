@@ -0,0 +1,2 @@
+-- บัญชีที่พบว่าเก็บรหัสผ่านเป็นข้อความธรรมดา ต้องตั้งรหัสผ่านใหม่ก่อนเข้าสู่ระบบ
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,15 @@
+-- การยืนยันตัวตนสองขั้นตอนด้วย TOTP: secret ของแอป authenticator และรอบเวลาล่าสุดที่ใช้แล้ว (กันการใช้รหัสซ้ำ)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_secret TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS mfa_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- รหัสกู้คืนสำหรับเข้าสู่ระบบเมื่อไม่มีแอป authenticator เก็บเฉพาะค่าแฮช และใช้ได้ครั้งเดียว
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_code ON mfa_recovery_codes (user_id, code_hash);
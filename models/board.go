@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+)
+
+// Board types
+const (
+	BoardDepartures = "departures"
+	BoardArrivals   = "arrivals"
+)
+
+// BoardQuery selects one page of a station board using keyset pagination on (time, schedule ID)
+type BoardQuery struct {
+	StationID uint
+	Type      string    // BoardDepartures or BoardArrivals
+	From      time.Time // exclusive lower bound when paging forward, exclusive upper bound when paging backward
+	FromID    uint      // schedule ID tie-breaker for From
+	Inclusive bool      // include trips exactly at From (first page)
+	Backward  bool
+	Window    time.Duration
+	Limit     int
+}
+
+// BoardEntry represents one trip on a station departure or arrival board
+type BoardEntry struct {
+	ScheduleID    uint       `json:"schedule_id"`
+	RouteID       uint       `json:"route_id"`
+	Round         int        `json:"round"`
+	ServiceDate   Date       `json:"service_date"`
+	Origin        string     `json:"origin"`
+	Destination   string     `json:"destination"`
+	ScheduledTime time.Time  `json:"scheduled_time"`
+	EstimatedTime time.Time  `json:"estimated_time"`
+	ActualTime    *time.Time `json:"actual_time,omitempty"`
+	DelayMinutes  int        `json:"delay_minutes"`
+	Status        string     `json:"status"`
+	Platform      string     `json:"platform,omitempty"`
+	VehicleID     uint       `json:"vehicle_id"`
+	LicensePlate  string     `json:"license_plate,omitempty"`
+	Notes         string     `json:"notes,omitempty"`
+}
+
+// StationBoard represents a page of a station departure or arrival board
+type StationBoard struct {
+	Station    Station      `json:"station"`
+	Type       string       `json:"type"`
+	At         time.Time    `json:"at"`
+	Entries    []BoardEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"` // later trips
+	PrevCursor string       `json:"prev_cursor,omitempty"` // earlier trips
+}
@@ -0,0 +1,11 @@
+package models
+
+// CacheStats reports hit/miss counters of the read-through cache since startup
+type CacheStats struct {
+	Backend  string  `json:"backend"` // redis or memory
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	Errors   int64   `json:"errors"`
+	HitRatio float64 `json:"hit_ratio"`
+	Entries  int     `json:"entries,omitempty"` // in-process cache only
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// Schedule conflict types
+const (
+	ConflictVehicleOverlap    = "vehicle_overlap"
+	ConflictVehicleTurnaround = "vehicle_turnaround"
+	ConflictDriverOverlap     = "driver_overlap"
+)
+
+// ScheduleConflict describes two trips that cannot both run as planned
+type ScheduleConflict struct {
+	Type                  string    `json:"type"`
+	ScheduleID            uint      `json:"schedule_id"`
+	ConflictingScheduleID uint      `json:"conflicting_schedule_id"`
+	VehicleID             uint      `json:"vehicle_id,omitempty"`
+	DriverID              *uint     `json:"driver_id,omitempty"`
+	StartsAt              time.Time `json:"starts_at"`
+	Message               string    `json:"message"`
+}
+
+// ConflictReport lists every conflict found among the schedules in a date range
+type ConflictReport struct {
+	From             time.Time          `json:"from"`
+	To               time.Time          `json:"to"`
+	SchedulesChecked int                `json:"schedules_checked"`
+	Conflicts        []ScheduleConflict `json:"conflicts"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"rota-api/utils/servicetime"
+)
+
+// Date is a calendar date without a time of day, written as YYYY-MM-DD and stored as DATE
+type Date string
+
+// DateOf returns the agency-timezone calendar date of t
+func DateOf(t time.Time) Date {
+	return Date(servicetime.In(t).Format(servicetime.DateLayout))
+}
+
+// Time returns the date as midnight in the agency timezone
+func (d Date) Time() (time.Time, error) {
+	return servicetime.ParseDate(string(d))
+}
+
+// Value implements driver.Valuer
+func (d Date) Value() (driver.Value, error) {
+	if d == "" {
+		return nil, nil
+	}
+	return string(d), nil
+}
+
+// Scan implements sql.Scanner. DATE columns arrive as midnight UTC, so the date is read off as-is
+func (d *Date) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*d = ""
+	case time.Time:
+		*d = Date(v.Format(servicetime.DateLayout))
+	case []byte:
+		*d = Date(truncateDate(string(v)))
+	case string:
+		*d = Date(truncateDate(v))
+	default:
+		return fmt.Errorf("unsupported date value %T", value)
+	}
+	return nil
+}
+
+// truncateDate drops any time of day from a textual date
+func truncateDate(value string) string {
+	if len(value) > len(servicetime.DateLayout) {
+		return value[:len(servicetime.DateLayout)]
+	}
+	return value
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// StationDisplayTheme holds the per-station look of the kiosk/signage board
+type StationDisplayTheme struct {
+	ID              uint      `gorm:"primarykey" json:"id"`
+	StationID       uint      `gorm:"uniqueIndex;not null" json:"station_id"`
+	BackgroundColor string    `gorm:"not null;default:'#0b1f3a'" json:"background_color"`
+	TextColor       string    `gorm:"not null;default:'#ffffff'" json:"text_color"`
+	AccentColor     string    `gorm:"not null;default:'#ffcc00'" json:"accent_color"`
+	LogoURL         string    `json:"logo_url,omitempty"`
+	FontScale       float64   `gorm:"not null;default:1" json:"font_scale"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// DisplayEntry is one trip on the signage board, written out in the display's language
+type DisplayEntry struct {
+	Time         string // scheduled time in the agency timezone
+	Estimated    string // estimated time, set when the trip runs late
+	Station      string // destination of a departure, origin of an arrival
+	Platform     string
+	Status       string
+	StatusLabel  string
+	DelayMinutes int
+}
+
+// StationDisplay is everything the signage board renders for a station
+type StationDisplay struct {
+	Locale         string
+	StationName    string
+	StationDetails string
+	Departures     []DisplayEntry // next departures from now
+	Arrivals       []DisplayEntry // next arrivals from now
+	Theme          StationDisplayTheme
+	Fingerprint    string // changes whenever the rendered trips, their status or delay, or the theme change
+	GeneratedAt    time.Time
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Driver represents a licensed driver who can be rostered onto schedule trips
+type Driver struct {
+	ID            uint           `gorm:"primarykey" json:"id"`
+	Name          string         `gorm:"not null" json:"name"`
+	LicenseNumber string         `gorm:"unique;not null" json:"license_number"`
+	LicenseExpiry time.Time      `gorm:"not null" json:"license_expiry"`
+	Phone         string         `json:"phone"`
+	Email         string         `json:"email"`
+	Active        bool           `gorm:"not null;default:true" json:"active"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+	Version       int            `gorm:"not null;default:1" json:"version"`
+	// Relations
+	Schedules []Schedule `gorm:"foreignKey:DriverID" json:"-"`
+}
+
+// RestPeriod represents the gap between two consecutive trips of a driver
+type RestPeriod struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Minutes int       `json:"minutes"`
+}
+
+// DriverDuty represents a driver's trips and rest periods on a single day
+type DriverDuty struct {
+	Driver      Driver       `json:"driver"`
+	Trips       []*Schedule  `json:"trips"`
+	DutyMinutes int          `json:"duty_minutes"`
+	RestPeriods []RestPeriod `json:"rest_periods"`
+	Violations  []string     `json:"violations,omitempty"`
+}
+
+// DriverRoster represents the duty roster of all rostered drivers for a day
+type DriverRoster struct {
+	Date    string       `json:"date"` // YYYY-MM-DD
+	Drivers []DriverDuty `json:"drivers"`
+}
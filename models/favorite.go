@@ -11,6 +11,7 @@ type Favorite struct {
 	UserID    uint      `json:"user_id"`
 	StationID uint      `json:"station_id"`
 	CreatedAt time.Time `json:"created_at"`
+	Version   int       `gorm:"not null;default:1" json:"version"`
 	// Relations
 	User    User    `gorm:"foreignKey:UserID" json:"-"` // ไม่ส่งข้อมูล User กลับเนื่องจากไม่จำเป็น
 	Station Station `gorm:"foreignKey:StationID" json:"station,omitempty"`
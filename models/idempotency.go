@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyRecord is a request made with an Idempotency-Key and, once it has been handled,
+// the response that is replayed to retries of it
+type IdempotencyRecord struct {
+	Key string `gorm:"primaryKey;size:300" json:"key"`
+	// Fingerprint identifies the request body and target, so the key cannot be reused for another request
+	Fingerprint string `gorm:"size:64;not null" json:"fingerprint"`
+	// Completed is false while the first request with the key is still being handled
+	Completed bool              `gorm:"not null;default:false" json:"completed"`
+	Status    int               `gorm:"not null;default:0" json:"status"`
+	Headers   map[string]string `gorm:"type:jsonb;serializer:json;not null;default:'{}'" json:"headers,omitempty"`
+	Body      []byte            `json:"body,omitempty"`
+	ExpiresAt time.Time         `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// TableName specifies the table name for IdempotencyRecord
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_keys"
+}
@@ -0,0 +1,18 @@
+package models
+
+// ImportResult summarises an import. A dry run validates the rows and counts them without saving any
+type ImportResult struct {
+	Resource string `json:"resource"`
+	DryRun   bool   `json:"dry_run"`
+	Rows     int    `json:"rows"`
+	Created  int    `json:"created"`
+	Updated  int    `json:"updated"`
+	// Columns of the spreadsheet that matched no field and were not imported
+	IgnoredColumns []string `json:"ignored_columns,omitempty"`
+}
+
+// ImportRowError lists what is wrong with one row of an import, by field
+type ImportRowError struct {
+	Row    int               `json:"row"`
+	Fields map[string]string `json:"fields"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+// Inspection statuses
+const (
+	InspectionStatusScheduled = "scheduled"
+	InspectionStatusPassed    = "passed"
+	InspectionStatusFailed    = "failed"
+)
+
+// MaintenanceRecord represents a maintenance job carried out on a vehicle
+type MaintenanceRecord struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	VehicleID   uint      `gorm:"not null;index" json:"vehicle_id"`
+	Type        string    `gorm:"not null" json:"type"` // e.g. service, repair, tyres
+	Description string    `json:"description"`
+	Odometer    int       `json:"odometer"`
+	Cost        float64   `json:"cost"`
+	PerformedAt time.Time `gorm:"not null" json:"performed_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Relations
+	Vehicle Vehicle `gorm:"foreignKey:VehicleID" json:"-"`
+}
+
+// VehicleInspection represents an inspection scheduled by date and/or odometer reading
+type VehicleInspection struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	VehicleID   uint       `gorm:"not null;index" json:"vehicle_id"`
+	Type        string     `gorm:"not null" json:"type"` // e.g. annual, brake, emission
+	DueDate     *time.Time `gorm:"default:null" json:"due_date,omitempty"`
+	DueOdometer *int       `gorm:"default:null" json:"due_odometer,omitempty"`
+	Status      string     `gorm:"default:'scheduled'" json:"status"`
+	CompletedAt *time.Time `gorm:"default:null" json:"completed_at,omitempty"`
+	Notes       string     `json:"notes,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	// Relations
+	Vehicle Vehicle `gorm:"foreignKey:VehicleID" json:"vehicle,omitempty"`
+}
+
+// OutOfServiceWindow represents a period during which a vehicle cannot be dispatched
+// An empty EndTime means the vehicle is out of service until further notice
+type OutOfServiceWindow struct {
+	ID                  uint       `gorm:"primarykey" json:"id"`
+	VehicleID           uint       `gorm:"not null;index" json:"vehicle_id"`
+	StartTime           time.Time  `gorm:"not null" json:"start_time"`
+	EndTime             *time.Time `gorm:"default:null" json:"end_time,omitempty"`
+	Reason              string     `gorm:"not null" json:"reason"`
+	MaintenanceRecordID *uint      `gorm:"default:null" json:"maintenance_record_id,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	// Relations
+	Vehicle Vehicle `gorm:"foreignKey:VehicleID" json:"-"`
+}
+
+// VehicleAvailability represents whether a vehicle can be dispatched in a given period
+type VehicleAvailability struct {
+	VehicleID uint                 `json:"vehicle_id"`
+	From      time.Time            `json:"from"`
+	To        time.Time            `json:"to"`
+	Available bool                 `json:"available"`
+	Windows   []OutOfServiceWindow `json:"out_of_service_windows"`
+}
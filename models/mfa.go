@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// MFARecoveryCode is a one-time code that stands in for a TOTP code when the authenticator is lost.
+// Only a hash of the code is kept
+type MFARecoveryCode struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	UserID    int        `gorm:"not null" json:"user_id"`
+	CodeHash  string     `gorm:"type:char(64);not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name for MFARecoveryCode
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
+
+// MFAEnrollment is what an authenticator app needs to add an account: the secret, and the otpauth://
+// provisioning URI that carries it, usually shown as a QR code
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAStatus describes a user's multi-factor authentication
+type MFAStatus struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
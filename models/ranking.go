@@ -0,0 +1,21 @@
+package models
+
+// Ranking periods
+const (
+	RankingPeriodDay  = "day"
+	RankingPeriodWeek = "week"
+)
+
+// PopularStation is a station in the popularity ranking
+type PopularStation struct {
+	Rank    int     `json:"rank"`
+	Score   float64 `json:"score"`
+	Station Station `json:"station"`
+}
+
+// PopularRoute is a route in the popularity ranking
+type PopularRoute struct {
+	Rank  int     `json:"rank"`
+	Score float64 `json:"score"`
+	Route Route   `json:"route"`
+}
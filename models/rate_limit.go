@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// RateLimitPolicy allows Limit requests per sliding Window
+type RateLimitPolicy struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitResult is the outcome of counting one request against a policy
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // how long until a request would be allowed again, when not allowed
+}
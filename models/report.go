@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+// Report groupings. Punctuality can be grouped by any combination of route, station and day;
+// utilization is always per vehicle and can be split by day
+const (
+	ReportGroupRoute   = "route"
+	ReportGroupStation = "station"
+	ReportGroupDay     = "day"
+)
+
+// ReportGroupColumns lists the columns each grouping adds to a report row
+var ReportGroupColumns = map[string][]string{
+	ReportGroupRoute:   {"route_id", "origin", "destination"},
+	ReportGroupStation: {"station_id", "station_name"},
+	ReportGroupDay:     {"service_date"},
+}
+
+// ReportQuery selects the trips a report aggregates: those on service dates From to To inclusive,
+// optionally of one route, station or vehicle
+type ReportQuery struct {
+	From      Date
+	To        Date
+	GroupBy   []string
+	RouteID   *uint
+	StationID *uint
+	VehicleID *uint
+	// LateThreshold is how late a departure can be and still count as on time, nil for the configured default
+	LateThreshold *time.Duration
+}
+
+// PunctualityRow aggregates the trips of one group. Delays are in seconds, measured against the latest
+// actual times logged for each trip; trips without a logged departure count as unreported
+type PunctualityRow struct {
+	ServiceDate       *Date    `json:"service_date,omitempty"`
+	RouteID           *uint    `json:"route_id,omitempty"`
+	Origin            string   `json:"origin,omitempty"`
+	Destination       string   `json:"destination,omitempty"`
+	StationID         *uint    `json:"station_id,omitempty"`
+	StationName       string   `json:"station_name,omitempty"`
+	Trips             int      `json:"trips"`
+	Cancelled         int      `json:"cancelled"`
+	Reported          int      `json:"reported"`
+	OnTime            int      `json:"on_time"`
+	Late              int      `json:"late"`
+	OnTimeRate        *float64 `gorm:"-" json:"on_time_rate"`
+	AvgDepartureDelay *float64 `json:"avg_departure_delay"`
+	AvgArrivalDelay   *float64 `json:"avg_arrival_delay"`
+	MaxDepartureDelay *float64 `json:"max_departure_delay"`
+	CancellationRate  *float64 `gorm:"-" json:"cancellation_rate"`
+}
+
+// UtilizationRow aggregates the trips of one vehicle, or of one vehicle on one day.
+// Cancelled trips are counted but not included in the service time or seats offered
+type UtilizationRow struct {
+	VehicleID       uint     `json:"vehicle_id"`
+	LicensePlate    string   `json:"license_plate"`
+	ServiceDate     *Date    `json:"service_date,omitempty"`
+	Trips           int      `json:"trips"`
+	Cancelled       int      `json:"cancelled"`
+	ActiveDays      int      `json:"active_days"`
+	ServiceSeconds  float64  `json:"-"`
+	ServiceHours    float64  `gorm:"-" json:"service_hours"`
+	SeatsOffered    int      `json:"seats_offered"`
+	UtilizationRate *float64 `gorm:"-" json:"utilization_rate"`
+}
+
+// ReportRange describes what a report covers. Rates in reports are percentages
+type ReportRange struct {
+	From    Date     `json:"from"`
+	To      Date     `json:"to"`
+	GroupBy []string `json:"group_by"`
+	// Source is "live", or "snapshot" when the report was read from the trip_actuals materialized view
+	Source string `json:"source"`
+}
+
+// PunctualityReport is the on-time performance of the trips in a range
+type PunctualityReport struct {
+	ReportRange
+	// LateThreshold is in seconds
+	LateThreshold float64          `json:"late_threshold"`
+	Rows          []PunctualityRow `json:"rows"`
+}
+
+// UtilizationReport is the use of each vehicle over a range
+type UtilizationReport struct {
+	ReportRange
+	// HoursPerDay is the service time per day a fully utilized vehicle would run
+	HoursPerDay float64          `json:"hours_per_day"`
+	Rows        []UtilizationRow `json:"rows"`
+}
@@ -1,6 +1,8 @@
 
 package models
 
+import "rota-api/i18n"
+
 // Route represents a transit route between two stations
 type Route struct {
 	ID             uint    `gorm:"primarykey" json:"id"`
@@ -8,9 +10,24 @@ type Route struct {
 	EndStationID   uint    `json:"end_station_id"`
 	Distance       float64 `gorm:"not null" json:"distance"`
 	Duration       string  `gorm:"not null" json:"duration"`
+	Description    string  `gorm:"not null;default:''" json:"description,omitempty"`
+	// Translated description, see RouteTranslatableFields
+	Translations   Translations `gorm:"type:jsonb;not null;default:'{}'" json:"translations,omitempty"`
+	Version        int     `gorm:"not null;default:1" json:"version"`
 	// Relations
 	StartStation   Station   `gorm:"foreignKey:StartStationID" json:"start_station,omitempty"`
 	EndStation     Station   `gorm:"foreignKey:EndStationID" json:"end_station,omitempty"`
 	Schedules      []Schedule `gorm:"foreignKey:RouteID" json:"-"`
 	Vehicles       []Vehicle  `gorm:"foreignKey:RouteID" json:"-"`
 }
+
+// RouteTranslatableFields are the route fields that can be translated
+var RouteTranslatableFields = []string{"description"}
+
+// Localized returns a copy of the route with its description and loaded stations in locale
+func (r Route) Localized(locale i18n.Locale) Route {
+	r.Description = r.Translations.Text(locale, "description", r.Description)
+	r.StartStation = r.StartStation.Localized(locale)
+	r.EndStation = r.EndStation.Localized(locale)
+	return r
+}
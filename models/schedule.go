@@ -1,31 +1,134 @@
 package models
 
 import (
+	"fmt"
 	"time"
 
+	apperrors "rota-api/errors"
+	"rota-api/i18n"
+	"rota-api/utils/servicetime"
+
 	"gorm.io/gorm"
 )
 
+// Schedule statuses
+const (
+	ScheduleStatusScheduled = "scheduled"
+	ScheduleStatusDelayed   = "delayed"
+	ScheduleStatusCancelled = "cancelled"
+	ScheduleStatusCompleted = "completed"
+)
+
 // Schedule represents a transit schedule for a specific route and station
 type Schedule struct {
 	ID            uint           `gorm:"primarykey" json:"id"`
 	RouteID       uint           `json:"route_id"`
 	VehicleID     uint           `json:"vehicle_id"`
+	DriverID      *uint          `gorm:"default:null" json:"driver_id,omitempty"`
 	StationID     uint           `json:"station_id"`
 	Round         int            `gorm:"default:1" json:"round"`
+	ServiceDate   Date           `gorm:"type:date;not null" json:"service_date"`
 	DepartureTime time.Time      `json:"departure_time"`
 	ArrivalTime   time.Time      `json:"arrival_time"`
+	// Local times on the service date, past 24:00 for trips that run after midnight.
+	// Accepted with service_date in place of departure_time and arrival_time
+	DepartureLocal string `gorm:"-" json:"departure_local,omitempty"`
+	ArrivalLocal   string `gorm:"-" json:"arrival_local,omitempty"`
 	Status        string         `gorm:"default:'scheduled'" json:"status"`
+	Platform      string         `json:"platform,omitempty"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+	Version       int            `gorm:"not null;default:1" json:"version"`
 	// Relations
 	Route        Route         `gorm:"foreignKey:RouteID" json:"route,omitempty"`
 	Vehicle      Vehicle       `gorm:"foreignKey:VehicleID" json:"vehicle,omitempty"`
+	Driver       *Driver       `gorm:"foreignKey:DriverID" json:"driver,omitempty"`
 	Station      Station       `gorm:"foreignKey:StationID" json:"station,omitempty"`
 	ScheduleLogs []ScheduleLog `gorm:"foreignKey:ScheduleID" json:"-"`
 }
 
+// ErrScheduleTimes is returned when a schedule's service date, local times and departure and arrival times disagree
+var ErrScheduleTimes = apperrors.BadRequest(apperrors.SCHEDULE_INVALID_TIMES, "invalid schedule times")
+
+// ResolveTimes settles the service date and the departure and arrival instants from whichever were given:
+// a service date with local times, which take precedence, or the instants themselves
+func (s *Schedule) ResolveTimes() error {
+	if s.ServiceDate == "" {
+		if s.DepartureLocal != "" || s.ArrivalLocal != "" {
+			return fmt.Errorf("%w: service_date is required with local times", ErrScheduleTimes)
+		}
+		if !s.DepartureTime.IsZero() {
+			s.ServiceDate = DateOf(servicetime.ServiceDate(s.DepartureTime))
+		}
+		return nil
+	}
+
+	date, err := s.ServiceDate.Time()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrScheduleTimes, err)
+	}
+	if s.DepartureLocal != "" {
+		if s.DepartureTime, err = servicetime.Combine(date, s.DepartureLocal); err != nil {
+			return fmt.Errorf("%w: departure_local: %v", ErrScheduleTimes, err)
+		}
+	}
+	if s.ArrivalLocal != "" {
+		if s.ArrivalTime, err = servicetime.Combine(date, s.ArrivalLocal); err != nil {
+			return fmt.Errorf("%w: arrival_local: %v", ErrScheduleTimes, err)
+		}
+	}
+
+	if !s.DepartureTime.IsZero() {
+		if offset := servicetime.Offset(date, s.DepartureTime); offset < 0 || offset >= servicetime.MaxLocalTime {
+			return fmt.Errorf("%w: departure_time does not fall on service_date %s", ErrScheduleTimes, s.ServiceDate)
+		}
+	}
+	if !s.ArrivalTime.IsZero() && s.ArrivalTime.Before(s.DepartureTime) {
+		return fmt.Errorf("%w: arrival_time is before departure_time", ErrScheduleTimes)
+	}
+	return nil
+}
+
+// BeforeSave is a hook that derives the service date of schedules saved with only a departure time
+func (s *Schedule) BeforeSave(tx *gorm.DB) error {
+	if s.ServiceDate == "" && !s.DepartureTime.IsZero() {
+		s.ServiceDate = DateOf(servicetime.ServiceDate(s.DepartureTime))
+	}
+	return nil
+}
+
+// AfterSave is a hook that presents saved schedules the same way as loaded ones
+func (s *Schedule) AfterSave(tx *gorm.DB) error {
+	s.inAgencyTime()
+	return nil
+}
+
+// AfterFind is a hook that presents times in the agency timezone with their service-date local times
+func (s *Schedule) AfterFind(tx *gorm.DB) error {
+	s.inAgencyTime()
+	return nil
+}
+
+// inAgencyTime moves the schedule's times to the agency timezone and derives its local times
+func (s *Schedule) inAgencyTime() {
+	s.DepartureTime = servicetime.In(s.DepartureTime)
+	s.ArrivalTime = servicetime.In(s.ArrivalTime)
+	s.CreatedAt = servicetime.In(s.CreatedAt)
+	s.UpdatedAt = servicetime.In(s.UpdatedAt)
+
+	date, err := s.ServiceDate.Time()
+	if err != nil {
+		return
+	}
+	if !s.DepartureTime.IsZero() {
+		s.DepartureLocal = servicetime.LocalTime(date, s.DepartureTime)
+	}
+	if !s.ArrivalTime.IsZero() {
+		s.ArrivalLocal = servicetime.LocalTime(date, s.ArrivalTime)
+	}
+}
+
 // StationSchedulesResponse represents the response for a station's schedule inquiry
 // Contains station details and both outbound and inbound schedules
 type StationSchedulesResponse struct {
@@ -37,8 +140,15 @@ type StationSchedulesResponse struct {
 
 // SimpleScheduleInfo represents a simplified view of a schedule with just essential information
 type SimpleScheduleInfo struct {
-	DepartureTime string `json:"departure_time"` // Departure time in readable format
-	Destination   string `json:"destination"`    // Destination station name
+	DepartureTime string    `json:"departure_time"` // Departure time in readable format
+	Destination   string    `json:"destination"`    // Destination station name
+	DepartureAt   time.Time `json:"departs_at"`     // Departure time in RFC 3339 with the agency offset
+	ServiceDate   Date      `json:"service_date"`   // Service date the trip runs on
+	// Origin station name, set on inbound schedules
+	Origin string `json:"origin,omitempty"`
+	// Destination and origin stations, kept to render their names in the reader's language
+	DestinationStation Station  `json:"-"`
+	OriginStation      *Station `json:"-"`
 }
 
 // SimpleStationScheduleResponse represents a simplified response for station schedules
@@ -48,4 +158,45 @@ type SimpleStationScheduleResponse struct {
 	OutboundTimes   []SimpleScheduleInfo `json:"outbound_times"`  // Simplified outbound schedules
 	InboundTimes    []SimpleScheduleInfo `json:"inbound_times"`   // Simplified inbound schedules
 	StationDetails  string              `json:"station_details"` // Brief description of the station
+	Station         Station             `json:"-"`               // The station the names and details above describe
+}
+
+// Localize renders the station and schedules in locale and describes the station
+func (r *StationSchedulesResponse) Localize(locale i18n.Locale) {
+	r.Station = r.Station.Localized(locale)
+	r.StationDetails = r.Station.Description(locale)
+	for _, schedules := range [][]*Schedule{r.OutboundSchedules, r.InboundSchedules} {
+		for _, schedule := range schedules {
+			schedule.Route = schedule.Route.Localized(locale)
+			schedule.Station = schedule.Station.Localized(locale)
+		}
+	}
+}
+
+// Localize renders station names and departure times in locale and describes the station
+func (r *SimpleStationScheduleResponse) Localize(locale i18n.Locale) {
+	r.StationName = r.Station.Localized(locale).Name
+	r.StationDetails = r.Station.Description(locale)
+	for _, times := range [][]SimpleScheduleInfo{r.OutboundTimes, r.InboundTimes} {
+		for i := range times {
+			times[i].DepartureTime = i18n.FormatTime(locale, times[i].DepartureAt)
+			times[i].Destination = times[i].DestinationStation.Localized(locale).Name
+			if times[i].OriginStation != nil {
+				times[i].Origin = times[i].OriginStation.Localized(locale).Name
+			}
+		}
+	}
+}
+
+// TripKey identifies a trip by its route, departure station, service date and round, as spreadsheets do
+type TripKey struct {
+	RouteID     uint
+	StationID   uint
+	ServiceDate Date
+	Round       int
+}
+
+// TripKey returns the key of the trip the schedule runs
+func (s *Schedule) TripKey() TripKey {
+	return TripKey{RouteID: s.RouteID, StationID: s.StationID, ServiceDate: s.ServiceDate, Round: s.Round}
 }
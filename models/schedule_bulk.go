@@ -0,0 +1,17 @@
+package models
+
+// Bulk schedule operations
+const (
+	ScheduleBulkShift  = "shift"
+	ScheduleBulkCancel = "cancel"
+	ScheduleBulkCopy   = "copy"
+)
+
+// ScheduleBulkResult describes the schedules a bulk operation changed or created, or would have in a preview
+type ScheduleBulkResult struct {
+	Operation string             `json:"operation"`
+	Preview   bool               `json:"preview"`
+	Affected  int                `json:"affected"`
+	Schedules []*Schedule        `json:"schedules"`
+	Conflicts []ScheduleConflict `json:"conflicts"`
+}
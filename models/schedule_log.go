@@ -3,6 +3,10 @@ package models
 
 import (
 	"time"
+
+	"rota-api/utils/servicetime"
+
+	"gorm.io/gorm"
 )
 
 // ScheduleLog represents a log entry for schedule changes
@@ -16,7 +20,18 @@ type ScheduleLog struct {
 	Status            string     `json:"status,omitempty"`
 	Notes             string     `json:"notes,omitempty"`
 	UpdatedAt         *time.Time `gorm:"default:null" json:"updated_at,omitempty"`
+	Version           int        `gorm:"not null;default:1" json:"version"`
 	// Relations
 	Schedule          Schedule  `gorm:"foreignKey:ScheduleID" json:"schedule,omitempty"`
 	Staff             Staff     `gorm:"foreignKey:StaffID" json:"staff,omitempty"`
 }
+
+// AfterFind is a hook that presents log times in the agency timezone
+func (l *ScheduleLog) AfterFind(tx *gorm.DB) error {
+	for _, t := range []*time.Time{l.ActualDeparture, l.ActualArrival, l.UpdatedAt} {
+		if t != nil {
+			*t = servicetime.In(*t)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package models
+
+// StationSearchTerms is a search query prepared for matching stations: as typed, for the original
+// Thai and English names, and romanized the same way station search keys are
+type StationSearchTerms struct {
+	Text    string
+	Words   string
+	Compact string
+	Limit   int
+}
+
+// StationMatch is a station found by search with its relevance between 0 and 1
+type StationMatch struct {
+	Station *Station `json:"station"`
+	Score   float64  `json:"score"`
+}
+
+// SearchResult holds the stations matching a query and the routes starting or ending at them
+type SearchResult struct {
+	Query    string         `json:"query"`
+	Stations []StationMatch `json:"stations"`
+	Routes   []*Route       `json:"routes"`
+}
+
+// SearchSuggestion is an autocomplete entry for a station name
+type SearchSuggestion struct {
+	StationID uint   `json:"station_id"`
+	Name      string `json:"name"`
+	NameEN    string `json:"name_en,omitempty"`
+}
@@ -10,20 +10,35 @@ type SearchParams struct {
 	PageSize int `json:"page_size" query:"page_size"`
 	SortBy   string `json:"sort_by" query:"sort_by"`
 	SortDesc bool `json:"sort_desc" query:"sort_desc"`
+	Cursor   string `json:"cursor" query:"cursor"`
+	Filter   string `json:"filter" query:"filter"`
 }
 
 // ScheduleSearchParams defines parameters for searching schedules
 type ScheduleSearchParams struct {
 	SearchParams
+	ScheduleCriteria
+}
+
+// ScheduleCriteria selects schedules by their fields. Unset criteria match every schedule
+type ScheduleCriteria struct {
 	RouteID       *uint      `json:"route_id" query:"route_id"`
 	VehicleID     *uint      `json:"vehicle_id" query:"vehicle_id"`
 	StationID     *uint      `json:"station_id" query:"station_id"`
 	Status        *string    `json:"status" query:"status"`
 	StartDateFrom *time.Time `json:"start_date_from" query:"start_date_from"`
 	StartDateTo   *time.Time `json:"start_date_to" query:"start_date_to"`
+	ServiceDate   *Date      `json:"service_date" query:"service_date"`
 	Round         *int       `json:"round" query:"round"`
 }
 
+// IsEmpty reports whether no criterion is set, so the criteria would match every schedule
+func (c ScheduleCriteria) IsEmpty() bool {
+	return c.RouteID == nil && c.VehicleID == nil && c.StationID == nil &&
+		(c.Status == nil || *c.Status == "") && c.StartDateFrom == nil && c.StartDateTo == nil &&
+		c.ServiceDate == nil && c.Round == nil
+}
+
 // RouteSearchParams defines parameters for searching routes
 type RouteSearchParams struct {
 	SearchParams
@@ -50,11 +65,24 @@ type StaffSearchParams struct {
 	Name      *string `json:"name" query:"name"`
 }
 
-// PagedResult represents a generic paged result
+// PagedResult represents a generic paged result. Page is 0 when the page was reached through a cursor
 type PagedResult struct {
 	TotalCount int64       `json:"total_count"`
 	TotalPages int         `json:"total_pages"`
 	Page       int         `json:"page"`
 	PageSize   int         `json:"page_size"`
+	SortBy     string      `json:"sort_by,omitempty"`
+	SortDesc   bool        `json:"sort_desc"`
+	HasMore    bool        `json:"has_more"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	Links      *PageLinks  `json:"links,omitempty"`
 	Data       interface{} `json:"data"`
 }
+
+// PageLinks holds relative URLs to neighbouring pages of a list
+type PageLinks struct {
+	Self  string `json:"self"`
+	First string `json:"first"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
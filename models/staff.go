@@ -19,6 +19,7 @@ type Staff struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+	Version   int            `gorm:"not null;default:1" json:"version"`
 	// Relations
 	Station      Station       `gorm:"foreignKey:StationID" json:"station,omitempty"`
 	ScheduleLogs []ScheduleLog `gorm:"foreignKey:StaffID" json:"-"`
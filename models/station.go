@@ -1,11 +1,27 @@
-
 package models
 
+import (
+	"strings"
+
+	"rota-api/i18n"
+	"rota-api/utils/thai"
+
+	"gorm.io/gorm"
+)
+
 // Station represents a transit station
 type Station struct {
 	ID       uint   `gorm:"primarykey" json:"id"`
 	Name     string `gorm:"not null" json:"name"`
+	NameEN   string `gorm:"column:name_en;size:100;not null;default:''" json:"name_en,omitempty"`
 	Location string `json:"location"`
+	Detail   string `gorm:"column:detail" json:"detail,omitempty"`
+	// Translated name, location and detail, see StationTranslatableFields
+	Translations Translations `gorm:"type:jsonb;not null;default:'{}'" json:"translations,omitempty"`
+	// Romanized search keys, kept in sync with the fields above by BeforeSave
+	SearchName     string `gorm:"not null;default:''" json:"-"`
+	SearchLocation string `gorm:"not null;default:''" json:"-"`
+	Version  int    `gorm:"not null;default:1" json:"version"`
 	// Relations
 	StartRoutes      []Route       `gorm:"foreignKey:StartStationID" json:"-"`
 	EndRoutes        []Route       `gorm:"foreignKey:EndStationID" json:"-"`
@@ -13,3 +29,51 @@ type Station struct {
 	Staff            []Staff       `gorm:"foreignKey:StationID" json:"-"`
 	Favorites        []Favorite    `gorm:"foreignKey:StationID" json:"-"`
 }
+
+// RefreshSearchKeys recomputes the search keys from the name and location.
+// It reports whether they changed
+func (s *Station) RefreshSearchKeys() bool {
+	name := thai.SearchKey(s.Name, s.NameEN)
+	location := strings.Join(thai.Words(s.Location), " ")
+	changed := name != s.SearchName || location != s.SearchLocation
+	s.SearchName, s.SearchLocation = name, location
+	return changed
+}
+
+// BeforeSave is a hook that keeps the search keys in sync before creating or updating a station
+func (s *Station) BeforeSave(tx *gorm.DB) error {
+	s.RefreshSearchKeys()
+	return nil
+}
+
+// StationTranslatableFields are the station fields that can be translated
+var StationTranslatableFields = []string{"name", "location", "detail"}
+
+// Localized returns a copy of the station with its text fields in locale.
+// NameEN stands in for an English name translation that has not been entered
+func (s Station) Localized(locale i18n.Locale) Station {
+	name := s.Name
+	if locale != i18n.Thai && s.NameEN != "" {
+		name = s.NameEN
+	}
+	s.Name = s.Translations.Text(locale, "name", name)
+	s.Location = s.Translations.Text(locale, "location", s.Location)
+	s.Detail = s.Translations.Text(locale, "detail", s.Detail)
+	return s
+}
+
+// Description is a one-line description of the station in locale, naming it by its detail when
+// that is available in locale and saying where it is
+func (s Station) Description(locale i18n.Locale) string {
+	localized := s.Localized(locale)
+	subject := localized.Name
+	if localized.Detail != "" && (locale == i18n.Thai || localized.Detail != s.Detail) {
+		subject = localized.Detail
+	}
+
+	description := i18n.T(locale, i18n.MsgStationDetails, subject)
+	if localized.Location != "" && (locale == i18n.Thai || localized.Location != s.Location) {
+		description += i18n.T(locale, i18n.MsgStationLocationAt, localized.Location)
+	}
+	return description
+}
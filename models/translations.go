@@ -0,0 +1,72 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"rota-api/i18n"
+)
+
+// Translations holds translated text fields of a record, keyed by locale and then by field name,
+// e.g. {"en": {"location": "Kamphaeng Phet 2 Rd"}}. It is stored as a JSONB column
+type Translations map[i18n.Locale]map[string]string
+
+// Value implements driver.Valuer
+func (t Translations) Value() (driver.Value, error) {
+	if t == nil {
+		return "{}", nil
+	}
+	encoded, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
+
+// Scan implements sql.Scanner
+func (t *Translations) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported translations value %T", value)
+	}
+	return json.Unmarshal(data, t)
+}
+
+// Text returns field in locale, walking the locale's fallback chain, or original when no translation exists
+func (t Translations) Text(locale i18n.Locale, field, original string) string {
+	for _, l := range locale.Chain() {
+		if text := t[l][field]; text != "" {
+			return text
+		}
+	}
+	return original
+}
+
+// Validate rejects translations for locales the API does not serve or for fields that cannot be translated
+func (t Translations) Validate(fields ...string) error {
+	allowed := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		allowed[field] = true
+	}
+
+	for locale, values := range t {
+		if parsed, ok := i18n.Parse(string(locale)); !ok || parsed != locale {
+			return fmt.Errorf("unsupported translation locale %q", locale)
+		}
+		for field := range values {
+			if !allowed[field] {
+				return fmt.Errorf("field %q cannot be translated", field)
+			}
+		}
+	}
+	return nil
+}
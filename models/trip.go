@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"rota-api/utils/servicetime"
+
+	"gorm.io/gorm"
+)
+
+// Trip sources. Trips are saved by their travellers for now; bookings and ticket scans will add their own
+const (
+	TripSourceManual = "manual"
+)
+
+// Trip is a journey a user has taken, kept in their travel history. The distance is copied from the
+// route when the trip is saved, so later changes to the route do not rewrite the history
+type Trip struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	UserID      uint      `gorm:"not null" json:"user_id"`
+	ScheduleID  *uint     `gorm:"default:null" json:"schedule_id,omitempty"`
+	RouteID     uint      `gorm:"not null" json:"route_id"`
+	TravelledAt time.Time `gorm:"not null" json:"travelled_at"`
+	Distance    float64   `gorm:"not null;default:0" json:"distance"`
+	Source      string    `gorm:"not null;default:'manual'" json:"source"`
+	Notes       string    `gorm:"not null;default:''" json:"notes,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	// Relations
+	Route Route `gorm:"foreignKey:RouteID" json:"route,omitempty"`
+}
+
+// AfterFind is a hook that presents trip times in the agency timezone
+func (t *Trip) AfterFind(tx *gorm.DB) error {
+	t.TravelledAt = servicetime.In(t.TravelledAt)
+	return nil
+}
+
+// StationUsage counts the trips of a user that started or ended at a station
+type StationUsage struct {
+	StationID uint   `json:"station_id"`
+	Name      string `json:"name"`
+	Trips     int    `json:"trips"`
+}
+
+// TripStats summarises a user's travel history. Distances are in kilometres
+type TripStats struct {
+	Trips         int            `json:"trips"`
+	TotalDistance float64        `json:"total_distance"`
+	CO2SavedKg    float64        `json:"co2_saved_kg"`
+	FirstTrip     *time.Time     `json:"first_trip,omitempty"`
+	LastTrip      *time.Time     `json:"last_trip,omitempty"`
+	TopStations   []StationUsage `json:"top_stations"`
+}
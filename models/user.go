@@ -3,7 +3,6 @@ package models
 import (
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -27,10 +26,24 @@ type User struct {
 	ProfilePicture *string    `json:"profilePicture,omitempty" gorm:"type:text"`
 	IsVerified     bool       `json:"isVerified" gorm:"not null;default:false"`
 	Role           UserRole   `json:"role" gorm:"type:varchar(20);not null;default:'user'"`
+	// Login lockout state, never exposed through the API
+	FailedLoginAttempts int        `json:"-" gorm:"not null;default:0"`
+	LockoutCount        int        `json:"-" gorm:"not null;default:0"`
+	LockedUntil         *time.Time `json:"-"`
+	// Set for accounts whose password was found stored in plaintext; they must choose a new one to log in
+	PasswordResetRequired bool `json:"-" gorm:"not null;default:false"`
+	// Multi-factor authentication with a TOTP authenticator app. The secret is set at enrolment and MFA is
+	// enabled once a code from it is confirmed; MFALastStep is the last time step used, so codes work once
+	MFAEnabled  bool    `json:"mfaEnabled" gorm:"column:mfa_enabled;not null;default:false"`
+	MFASecret   *string `json:"-" gorm:"column:mfa_secret;type:text"`
+	MFALastStep int64   `json:"-" gorm:"column:mfa_last_step;not null;default:0"`
 	// LastLoginAt field is commented out as it doesn't exist in the database
 	// LastLoginAt    *time.Time `json:"lastLoginAt" gorm:"default:null"`
 	CreatedAt      time.Time  `json:"createdAt" gorm:"not null;default:now()"`
 	UpdatedAt      time.Time  `json:"updatedAt" gorm:"not null;default:now()"`
+	Version        int        `json:"version" gorm:"not null;default:1"`
+	// Deleted accounts are kept anonymized, see UserRepository.Delete
+	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 // TableName specifies the table name for User
@@ -38,37 +51,14 @@ func (User) TableName() string {
 	return "users"
 }
 
-// HashPassword hashes a password using bcrypt
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
-}
-
-// CheckPassword verifies a password against the hashed password
-func (u *User) CheckPassword(password string) error {
-	if u.Password == nil {
-		return bcrypt.ErrMismatchedHashAndPassword
-	}
-	return bcrypt.CompareHashAndPassword([]byte(*u.Password), []byte(password))
-}
-
 // BeforeCreate is a hook that runs before creating a user
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	// Set default role if not specified
 	if u.Role == "" {
 		u.Role = RoleUser
 	}
-	
-	// Skip password hashing for plaintext comparison
-	/*
-	if u.Password != nil && *u.Password != "" {
-		hashedPassword, err := HashPassword(*u.Password)
-		if err != nil {
-			return err
-		}
-		u.Password = &hashedPassword
-	}
-	*/
+
+	// Passwords are hashed by AuthService before they reach the model, see package passhash
 	
 	// Set timestamps
 	now := time.Now()
@@ -78,18 +68,3 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-// BeforeUpdate is a hook that runs before updating a user
-func (u *User) BeforeUpdate(tx *gorm.DB) error {
-	// Skip password hashing for plaintext comparison
-	/*
-	if tx.Statement.Changed("Password") && u.Password != nil {
-		// Hash password before updating
-		hashedPassword, err := HashPassword(*u.Password)
-		if err != nil {
-			return err
-		}
-		u.Password = &hashedPassword
-	}
-	*/
-	return nil
-}
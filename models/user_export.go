@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// UserDataExport is the archive of everything stored about a user, for data subject access requests
+type UserDataExport struct {
+	ExportedAt time.Time  `json:"exported_at"`
+	Profile    *User      `json:"profile"`
+	Favorites  []Favorite `json:"favorites"`
+	Trips      []Trip     `json:"trips"`
+}
@@ -6,8 +6,10 @@ type Vehicle struct {
 	ID           uint   `gorm:"primarykey" json:"id"`
 	LicensePlate string `gorm:"unique;not null" json:"license_plate"`
 	Capacity     int    `gorm:"not null" json:"capacity"`
-	DriverName   string `gorm:"not null" json:"driver_name"`
+	DriverName   string `gorm:"not null" json:"driver_name"` // Deprecated: drivers are rostered per trip via Schedule.DriverID
 	RouteID      uint   `json:"route_id"`
+	Odometer     int    `gorm:"default:0" json:"odometer"`
+	Version      int    `gorm:"not null;default:1" json:"version"`
 	// Relations
 	Route       Route  `gorm:"foreignKey:RouteID" json:"route,omitempty"`
 }
@@ -0,0 +1,91 @@
+package pagination
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"rota-api/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ParseParams reads page, page_size, sort_by, sort_desc, cursor and filter from the query string
+func ParseParams(c *fiber.Ctx) (models.SearchParams, error) {
+	params := models.SearchParams{
+		SortBy:   c.Query("sort_by"),
+		SortDesc: c.Query("sort_desc") == "true",
+		Cursor:   c.Query("cursor"),
+		Filter:   c.Query("filter"),
+	}
+
+	if value := c.Query("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			return params, fmt.Errorf("%w: page must be a positive integer", ErrInvalidPage)
+		}
+		params.Page = page
+	}
+	if value := c.Query("page_size"); value != "" {
+		pageSize, err := strconv.Atoi(value)
+		if err != nil || pageSize < 1 {
+			return params, fmt.Errorf("%w: page_size must be a positive integer", ErrInvalidPage)
+		}
+		params.PageSize = pageSize
+	}
+	if params.Cursor != "" && params.Page > 0 {
+		return params, fmt.Errorf("%w: use either page or cursor, not both", ErrInvalidPage)
+	}
+
+	return params, nil
+}
+
+// SetLinks fills result.Links from the request URL and mirrors them in an RFC 8288 Link header.
+// Offset pages link by page number; cursor pages link forward by cursor
+func SetLinks(c *fiber.Ctx, result *models.PagedResult) {
+	self, err := url.Parse(c.OriginalURL())
+	if err != nil {
+		return
+	}
+
+	link := func(change func(q url.Values)) string {
+		q := self.Query()
+		change(q)
+		u := *self
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+
+	links := &models.PageLinks{
+		Self: self.String(),
+		First: link(func(q url.Values) {
+			q.Del("cursor")
+			q.Del("page")
+		}),
+	}
+
+	if result.Page > 0 {
+		if result.Page > 1 {
+			links.Prev = link(func(q url.Values) { q.Set("page", strconv.Itoa(result.Page-1)) })
+		}
+		if result.HasMore {
+			links.Next = link(func(q url.Values) { q.Set("page", strconv.Itoa(result.Page+1)) })
+		}
+	} else if result.NextCursor != "" {
+		links.Next = link(func(q url.Values) {
+			q.Del("page")
+			q.Set("cursor", result.NextCursor)
+		})
+	}
+	result.Links = links
+
+	header := []string{fmt.Sprintf(`<%s>; rel="first"`, links.First)}
+	if links.Prev != "" {
+		header = append(header, fmt.Sprintf(`<%s>; rel="prev"`, links.Prev))
+	}
+	if links.Next != "" {
+		header = append(header, fmt.Sprintf(`<%s>; rel="next"`, links.Next))
+	}
+	c.Set(fiber.HeaderLink, strings.Join(header, ", "))
+}
@@ -0,0 +1,267 @@
+// Package pagination pages list queries in offset or keyset (cursor) mode and restricts
+// sorting to a per-resource whitelist of fields
+package pagination
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "rota-api/errors"
+	"rota-api/models"
+
+	"gorm.io/gorm"
+)
+
+// Page sizes
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// Custom errors
+var (
+	ErrInvalidSort   = apperrors.BadRequest(apperrors.INVALID_SORT, "invalid sort field")
+	ErrInvalidCursor = apperrors.BadRequest(apperrors.INVALID_CURSOR, "invalid cursor")
+	ErrInvalidPage   = apperrors.BadRequest(apperrors.INVALID_PAGE, "invalid page parameter")
+)
+
+// IsInvalid reports whether err was caused by bad pagination or sort parameters
+func IsInvalid(err error) bool {
+	return errors.Is(err, ErrInvalidSort) || errors.Is(err, ErrInvalidCursor) || errors.Is(err, ErrInvalidPage)
+}
+
+// FieldType tells how a sort value is encoded in a cursor
+type FieldType int
+
+const (
+	String FieldType = iota
+	Int
+	Float
+	Time
+)
+
+// Field is a column clients may sort by. Cursors compare rows on it, so it must be NOT NULL
+type Field struct {
+	Column string
+	Type   FieldType
+}
+
+// Sortable is the whitelist of fields a resource can be sorted by. Rows are always
+// ordered by id after the sort field so every page boundary is unique
+type Sortable struct {
+	Fields      map[string]Field
+	Default     string
+	DefaultDesc bool
+}
+
+// cursor marks the last row of a page. The sort it was issued for is kept so that a cursor
+// cannot be replayed against a different ordering
+type cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    int64  `json:"k"`
+}
+
+// Find loads one page of query. Without a cursor it pages by offset; with one it continues after
+// the row the cursor points at. Preloads are applied to the page only, not to the count
+func Find[T any](query *gorm.DB, params models.SearchParams, sortable Sortable, preloads ...string) (models.PagedResult, error) {
+	var result models.PagedResult
+
+	name, field, desc, err := sortable.resolve(params)
+	if err != nil {
+		return result, err
+	}
+
+	pageSize := params.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	base := query.Session(&gorm.Session{})
+
+	var totalCount int64
+	if err := base.Count(&totalCount).Error; err != nil {
+		return result, err
+	}
+
+	direction, operator := "asc", ">"
+	if desc {
+		direction, operator = "desc", "<"
+	}
+	page := base.Order(field.Column + " " + direction).Order("id " + direction)
+
+	pageNumber := 0
+	if params.Cursor != "" {
+		afterValue, afterID, err := decodeCursor(params.Cursor, name, desc, field)
+		if err != nil {
+			return result, err
+		}
+		page = page.Where(fmt.Sprintf("(%s, id) %s (?, ?)", field.Column, operator), afterValue, afterID)
+	} else {
+		pageNumber = params.Page
+		if pageNumber <= 0 {
+			pageNumber = 1
+		}
+		page = page.Offset((pageNumber - 1) * pageSize)
+	}
+
+	for _, preload := range preloads {
+		page = page.Preload(preload)
+	}
+
+	// Fetch one extra row to know whether another page follows
+	var items []T
+	tx := page.Limit(pageSize + 1).Find(&items)
+	if tx.Error != nil {
+		return result, tx.Error
+	}
+
+	hasMore := len(items) > pageSize
+	if hasMore {
+		items = items[:pageSize]
+	}
+
+	totalPages := int(totalCount) / pageSize
+	if int(totalCount)%pageSize > 0 {
+		totalPages++
+	}
+
+	result = models.PagedResult{
+		TotalCount: totalCount,
+		TotalPages: totalPages,
+		Page:       pageNumber,
+		PageSize:   pageSize,
+		SortBy:     name,
+		SortDesc:   desc,
+		HasMore:    hasMore,
+		Data:       items,
+	}
+
+	if hasMore {
+		next, err := encodeCursor(tx.Statement.Context, tx, items[len(items)-1], name, desc, field)
+		if err != nil {
+			return result, err
+		}
+		result.NextCursor = next
+	}
+
+	return result, nil
+}
+
+// Order sorts query the way Find sorts its pages, for callers that read every row rather than one page
+func Order(query *gorm.DB, params models.SearchParams, sortable Sortable) (*gorm.DB, error) {
+	_, field, desc, err := sortable.resolve(params)
+	if err != nil {
+		return nil, err
+	}
+	direction := "asc"
+	if desc {
+		direction = "desc"
+	}
+	return query.Order(field.Column + " " + direction).Order("id " + direction), nil
+}
+
+// resolve picks the sort field from the whitelist, falling back to the resource default
+func (s Sortable) resolve(params models.SearchParams) (string, Field, bool, error) {
+	name, desc := params.SortBy, params.SortDesc
+	if name == "" {
+		name, desc = s.Default, desc || s.DefaultDesc
+	}
+
+	field, ok := s.Fields[name]
+	if !ok {
+		return "", Field{}, false, fmt.Errorf("%w %q, sort_by must be one of: %s", ErrInvalidSort, name, strings.Join(s.names(), ", "))
+	}
+	return name, field, desc, nil
+}
+
+func (s Sortable) names() []string {
+	names := make([]string, 0, len(s.Fields))
+	for name := range s.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// encodeCursor builds the cursor pointing at item, reading the sort and id values through the GORM schema
+func encodeCursor(ctx context.Context, tx *gorm.DB, item interface{}, name string, desc bool, field Field) (string, error) {
+	if tx.Statement.Schema == nil || tx.Statement.Schema.PrioritizedPrimaryField == nil {
+		return "", fmt.Errorf("failed to build cursor: no schema for %T", item)
+	}
+	sortField := tx.Statement.Schema.LookUpField(field.Column)
+	if sortField == nil {
+		return "", fmt.Errorf("failed to build cursor: unknown column %s", field.Column)
+	}
+
+	row := reflect.Indirect(reflect.ValueOf(item))
+	value, _ := sortField.ValueOf(ctx, row)
+	id, _ := tx.Statement.Schema.PrioritizedPrimaryField.ValueOf(ctx, row)
+
+	c := cursor{Sort: name, Desc: desc}
+	switch v := reflect.Indirect(reflect.ValueOf(value)).Interface().(type) {
+	case time.Time:
+		c.Value = v.UTC().Format(time.RFC3339Nano)
+	default:
+		c.Value = fmt.Sprint(v)
+	}
+
+	switch v := reflect.ValueOf(id); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		c.ID = v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		c.ID = int64(v.Uint())
+	default:
+		return "", fmt.Errorf("failed to build cursor: unsupported id type %T", id)
+	}
+
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to build cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// decodeCursor returns the sort value and id of the row the next page starts after
+func decodeCursor(raw, name string, desc bool, field Field) (interface{}, int64, error) {
+	encoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(encoded, &c); err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+	if c.Sort != name || c.Desc != desc {
+		return nil, 0, fmt.Errorf("%w: it was issued for a different sort order", ErrInvalidCursor)
+	}
+
+	var value interface{}
+	switch field.Type {
+	case Int:
+		value, err = strconv.ParseInt(c.Value, 10, 64)
+	case Float:
+		value, err = strconv.ParseFloat(c.Value, 64)
+	case Time:
+		value, err = time.Parse(time.RFC3339Nano, c.Value)
+	default:
+		value = c.Value
+	}
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+	return value, c.ID, nil
+}
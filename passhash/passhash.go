@@ -0,0 +1,160 @@
+// Package passhash hashes and verifies account passwords. New hashes use argon2id, encoded in the
+// PHC string format ($argon2id$v=19$m=...,t=...,p=...$salt$key). Bcrypt hashes and passwords stored
+// in plaintext by earlier versions still verify, and NeedsRehash tells callers to upgrade them
+package passhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Schemes a stored password can be in
+const (
+	SchemeArgon2id  = "argon2id"
+	SchemeBcrypt    = "bcrypt"
+	SchemePlaintext = "plaintext"
+)
+
+const (
+	saltLength = 16
+	keyLength  = 32
+)
+
+var errMalformedHash = errors.New("malformed argon2id hash")
+
+// Params are the argon2id cost parameters. Memory is in KiB
+type Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// DefaultParams follow the second recommended option of RFC 9106: 64 MiB, three passes, four lanes
+var DefaultParams = Params{Memory: 64 * 1024, Iterations: 3, Parallelism: 4}
+
+// settings are the parameters new hashes are made with, configured once at startup
+var settings = struct {
+	sync.RWMutex
+	params Params
+}{params: DefaultParams}
+
+// Configure sets the argon2id parameters of new hashes. Hashes made with other parameters still
+// verify, and NeedsRehash reports them. Zero fields keep their defaults
+func Configure(params Params) {
+	if params.Memory == 0 {
+		params.Memory = DefaultParams.Memory
+	}
+	if params.Iterations == 0 {
+		params.Iterations = DefaultParams.Iterations
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = DefaultParams.Parallelism
+	}
+	settings.Lock()
+	defer settings.Unlock()
+	settings.params = params
+}
+
+func currentParams() Params {
+	settings.RLock()
+	defer settings.RUnlock()
+	return settings.params
+}
+
+// Hash hashes a password with argon2id and a random salt
+func Hash(password string) (string, error) {
+	params := currentParams()
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, keyLength)
+	return encode(params, salt, key), nil
+}
+
+// Verify reports whether password matches the stored value, whatever scheme it is in
+func Verify(password, stored string) bool {
+	switch Scheme(stored) {
+	case SchemeArgon2id:
+		params, salt, key, err := decode(stored)
+		if err != nil {
+			return false
+		}
+		candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(candidate, key) == 1
+	case SchemeBcrypt:
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	default:
+		return subtle.ConstantTimeCompare([]byte(password), []byte(stored)) == 1
+	}
+}
+
+// NeedsRehash reports whether a stored value should be replaced by a new Hash once the password is
+// known: it is plaintext, bcrypt, or argon2id made with other parameters than the configured ones
+func NeedsRehash(stored string) bool {
+	if Scheme(stored) != SchemeArgon2id {
+		return true
+	}
+	params, _, _, err := decode(stored)
+	return err != nil || params != currentParams()
+}
+
+// Scheme names the scheme a stored password is in. Anything that is not a recognised hash is plaintext
+func Scheme(stored string) string {
+	switch {
+	case strings.HasPrefix(stored, "$argon2id$"):
+		return SchemeArgon2id
+	case len(stored) == 60 && (strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")):
+		return SchemeBcrypt
+	default:
+		return SchemePlaintext
+	}
+}
+
+// IsHashed reports whether a stored password is a hash rather than plaintext
+func IsHashed(stored string) bool {
+	return Scheme(stored) != SchemePlaintext
+}
+
+func encode(params Params, salt, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func decode(stored string) (Params, []byte, []byte, error) {
+	var params Params
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, errMalformedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errMalformedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, errMalformedHash
+	}
+	if params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, errMalformedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errMalformedHash
+	}
+	return params, salt, key, nil
+}
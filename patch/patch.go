@@ -0,0 +1,107 @@
+// Package patch applies PATCH request bodies to the JSON representation of a resource. It accepts
+// JSON Merge Patch (RFC 7396), where null removes a member, and JSON Patch (RFC 6902) operation lists,
+// reports which top-level fields a patch changed and checks them against per-role write policies
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	apperrors "rota-api/errors"
+)
+
+// Media types of the supported patch formats. Plain application/json is read as a merge patch
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	// ErrUnsupportedMediaType is returned for a PATCH body in a format other than the supported ones
+	ErrUnsupportedMediaType = apperrors.New(http.StatusUnsupportedMediaType, apperrors.UNSUPPORTED_MEDIA,
+		"PATCH bodies must be "+MergePatchType+" or "+JSONPatchType)
+	// ErrInvalidPatch is wrapped by every error about a malformed patch or one that cannot be applied
+	ErrInvalidPatch = apperrors.BadRequest(apperrors.INVALID_PATCH, "invalid patch")
+	// ErrTestFailed is returned when a JSON Patch test operation does not match the current document
+	ErrTestFailed = apperrors.Conflict(apperrors.PATCH_TEST_FAILED, "patch test operation failed")
+)
+
+// Apply applies body, in the format named by contentType, to the JSON document doc
+func Apply(contentType string, doc, body []byte) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil && contentType != "" {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	switch mediaType {
+	case MergePatchType, "application/json", "":
+		return MergePatch(doc, body)
+	case JSONPatchType:
+		return JSONPatch(doc, body)
+	}
+	return nil, ErrUnsupportedMediaType
+}
+
+// ChangedFields returns the sorted names of the top-level members that differ between the JSON objects before and after
+func ChangedFields(before, after []byte) ([]string, error) {
+	var b, a map[string]interface{}
+	if err := json.Unmarshal(before, &b); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(after, &a); err != nil {
+		return nil, fmt.Errorf("%w: the patched document must be an object", ErrInvalidPatch)
+	}
+
+	var fields []string
+	for name, value := range a {
+		if old, ok := b[name]; !ok || !reflect.DeepEqual(old, value) {
+			fields = append(fields, name)
+		}
+	}
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields, nil
+}
+
+// Decode stores the patched document doc in the struct v points to. The fields named in changed are
+// reset first, so members the patch removed are cleared instead of keeping their old values, while
+// fields the JSON representation leaves out, such as password hashes, keep theirs
+func Decode(doc []byte, v interface{}, changed []string) error {
+	target := reflect.ValueOf(v).Elem()
+	for _, name := range changed {
+		if field, ok := fieldByJSONName(target, name); ok {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return nil
+}
+
+// fieldByJSONName finds the settable field of the struct v that is encoded as the JSON member name
+func fieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if tag == "" {
+			tag = t.Field(i).Name
+		}
+		if tag == name && v.Field(i).CanSet() {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,32 @@
+package patch
+
+import (
+	"strings"
+
+	apperrors "rota-api/errors"
+	"rota-api/models"
+)
+
+// Policy lists, per role, the fields of a resource that role may change with PATCH.
+// Roles missing from the policy may not change any field
+type Policy map[models.UserRole][]string
+
+// Check returns a 403 naming the fields in changed that role may not write
+func (p Policy) Check(role models.UserRole, changed []string) error {
+	writable := make(map[string]bool, len(p[role]))
+	for _, name := range p[role] {
+		writable[name] = true
+	}
+
+	var denied []string
+	for _, name := range changed {
+		if !writable[name] {
+			denied = append(denied, name)
+		}
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+	return apperrors.Forbidden(apperrors.FIELD_NOT_WRITABLE, "fields not writable: "+strings.Join(denied, ", ")).
+		With("fields", denied)
+}
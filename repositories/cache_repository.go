@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"rota-api/models"
+)
+
+// CacheRepository defines a tagged key/value cache for read-through caching of query results
+type CacheRepository interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+	InvalidateTags(ctx context.Context, tags ...string) error
+	Stats() models.CacheStats
+}
+
+// cacheStats counts cache lookups for both backends
+type cacheStats struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+func (s *cacheStats) snapshot(backend string, entries int) models.CacheStats {
+	hits, misses := s.hits.Load(), s.misses.Load()
+	stats := models.CacheStats{
+		Backend: backend,
+		Hits:    hits,
+		Misses:  misses,
+		Errors:  s.errors.Load(),
+		Entries: entries,
+	}
+	if hits+misses > 0 {
+		stats.HitRatio = float64(hits) / float64(hits+misses)
+	}
+	return stats
+}
+
+// NewCacheRepository creates a cache backed by Redis, or by an in-process LRU of
+// lruSize entries when Redis is not available
+func NewCacheRepository(redisRepo RedisRepository, lruSize int) CacheRepository {
+	if redisRepo != nil {
+		return &redisCacheRepository{redis: redisRepo}
+	}
+	log.Printf("Cache: Redis not available, using in-process LRU cache (%d entries)", lruSize)
+	return newLRUCacheRepository(lruSize)
+}
+
+// redisCacheRepository implements CacheRepository on top of RedisRepository
+type redisCacheRepository struct {
+	redis RedisRepository
+	stats cacheStats
+}
+
+func (c *redisCacheRepository) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.redis.GetCache(ctx, key)
+	switch {
+	case err == nil:
+		c.stats.hits.Add(1)
+	case errors.Is(err, ErrCacheMiss):
+		c.stats.misses.Add(1)
+	default:
+		// Redis errors are treated as misses so the caller falls back to the database
+		c.stats.errors.Add(1)
+		c.stats.misses.Add(1)
+		log.Printf("Cache: failed to get %s: %v", key, err)
+		err = ErrCacheMiss
+	}
+	return value, err
+}
+
+func (c *redisCacheRepository) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	if err := c.redis.SetCache(ctx, key, value, ttl, tags...); err != nil {
+		c.stats.errors.Add(1)
+		return err
+	}
+	return nil
+}
+
+func (c *redisCacheRepository) InvalidateTags(ctx context.Context, tags ...string) error {
+	if err := c.redis.InvalidateTags(ctx, tags...); err != nil {
+		c.stats.errors.Add(1)
+		return err
+	}
+	return nil
+}
+
+func (c *redisCacheRepository) Stats() models.CacheStats {
+	return c.stats.snapshot("redis", 0)
+}
+
+// lruEntry is a cached value in the in-process LRU
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+	tags      []string
+}
+
+// lruCacheRepository implements CacheRepository as a size-bounded in-process LRU
+type lruCacheRepository struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front is most recently used
+	entries  map[string]*list.Element
+	tags     map[string]map[string]struct{}
+	stats    cacheStats
+}
+
+func newLRUCacheRepository(capacity int) *lruCacheRepository {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &lruCacheRepository{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		tags:     make(map[string]map[string]struct{}),
+	}
+}
+
+func (c *lruCacheRepository) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.stats.misses.Add(1)
+		return nil, ErrCacheMiss
+	}
+
+	entry := element.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.remove(element)
+		c.stats.misses.Add(1)
+		return nil, ErrCacheMiss
+	}
+
+	c.order.MoveToFront(element)
+	c.stats.hits.Add(1)
+	return entry.value, nil
+}
+
+func (c *lruCacheRepository) Set(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+
+	element := c.order.PushFront(&lruEntry{
+		key:       key,
+		value:     value,
+		expiresAt: time.Now().Add(ttl),
+		tags:      tags,
+	})
+	c.entries[key] = element
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = make(map[string]struct{})
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+func (c *lruCacheRepository) InvalidateTags(ctx context.Context, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if element, ok := c.entries[key]; ok {
+				c.remove(element)
+			}
+		}
+		delete(c.tags, tag)
+	}
+	return nil
+}
+
+func (c *lruCacheRepository) Stats() models.CacheStats {
+	c.mu.Lock()
+	entries := c.order.Len()
+	c.mu.Unlock()
+	return c.stats.snapshot("memory", entries)
+}
+
+// remove drops an entry and its tag memberships; the caller must hold c.mu
+func (c *lruCacheRepository) remove(element *list.Element) {
+	entry := element.Value.(*lruEntry)
+	c.order.Remove(element)
+	delete(c.entries, entry.key)
+	for _, tag := range entry.tags {
+		if keys, ok := c.tags[tag]; ok {
+			delete(keys, entry.key)
+			if len(keys) == 0 {
+				delete(c.tags, tag)
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"rota-api/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DisplayThemeRepository interface defines methods for station display theme database operations
+type DisplayThemeRepository interface {
+	FindByStation(ctx context.Context, stationID uint) (*models.StationDisplayTheme, error)
+	Upsert(ctx context.Context, theme *models.StationDisplayTheme) error
+	DeleteByStation(ctx context.Context, stationID uint) error
+}
+
+// displayThemeRepository implements DisplayThemeRepository
+type displayThemeRepository struct {
+	db *gorm.DB
+}
+
+// NewDisplayThemeRepository creates a new display theme repository
+func NewDisplayThemeRepository(db *gorm.DB) DisplayThemeRepository {
+	return &displayThemeRepository{db}
+}
+
+// FindByStation retrieves the display theme of a station
+func (r *displayThemeRepository) FindByStation(ctx context.Context, stationID uint) (*models.StationDisplayTheme, error) {
+	var theme models.StationDisplayTheme
+	if err := r.db.WithContext(ctx).Where("station_id = ?", stationID).First(&theme).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("display theme not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to find display theme: %w", err)
+	}
+	return &theme, nil
+}
+
+// Upsert creates or replaces the display theme of a station
+func (r *displayThemeRepository) Upsert(ctx context.Context, theme *models.StationDisplayTheme) error {
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "station_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"background_color", "text_color", "accent_color", "logo_url", "font_scale", "updated_at"}),
+	}).Create(theme).Error; err != nil {
+		return fmt.Errorf("failed to save display theme: %w", err)
+	}
+	return nil
+}
+
+// DeleteByStation removes a station's display theme, reverting it to the default look
+func (r *displayThemeRepository) DeleteByStation(ctx context.Context, stationID uint) error {
+	if err := r.db.WithContext(ctx).Where("station_id = ?", stationID).Delete(&models.StationDisplayTheme{}).Error; err != nil {
+		return fmt.Errorf("failed to delete display theme: %w", err)
+	}
+	return nil
+}
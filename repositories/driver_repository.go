@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"rota-api/models"
+	"rota-api/pagination"
+
+	"gorm.io/gorm"
+)
+
+// DriverRepository interface defines methods for driver database operations
+type DriverRepository interface {
+	Create(ctx context.Context, driver *models.Driver) error
+	FindByID(ctx context.Context, id uint) (*models.Driver, error)
+	FindByLicenseNumber(ctx context.Context, licenseNumber string) (*models.Driver, error)
+	FindAll(ctx context.Context) ([]*models.Driver, error)
+	FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
+	FindTrips(ctx context.Context, driverID uint, from, to time.Time) ([]*models.Schedule, error)
+	FindRosteredTrips(ctx context.Context, from, to time.Time) ([]*models.Schedule, error)
+	Update(ctx context.Context, driver *models.Driver) error
+	Delete(ctx context.Context, id uint, version int) error
+}
+
+// driverRepository implements DriverRepository
+type driverRepository struct {
+	db *gorm.DB
+}
+
+// driverSortable lists the fields drivers can be sorted by
+var driverSortable = pagination.Sortable{
+	Fields: map[string]pagination.Field{
+		"id":             {Column: "id", Type: pagination.Int},
+		"name":           {Column: "name", Type: pagination.String},
+		"license_expiry": {Column: "license_expiry", Type: pagination.Time},
+		"created_at":     {Column: "created_at", Type: pagination.Time},
+	},
+	Default: "name",
+}
+
+// NewDriverRepository creates a new driver repository
+func NewDriverRepository(db *gorm.DB) DriverRepository {
+	return &driverRepository{db}
+}
+
+// Create stores a new driver in the database
+func (r *driverRepository) Create(ctx context.Context, driver *models.Driver) error {
+	if err := r.db.WithContext(ctx).Create(driver).Error; err != nil {
+		return fmt.Errorf("failed to create driver: %w", err)
+	}
+	return nil
+}
+
+// FindByID retrieves a driver by ID
+func (r *driverRepository) FindByID(ctx context.Context, id uint) (*models.Driver, error) {
+	var driver models.Driver
+	if err := r.db.WithContext(ctx).First(&driver, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("driver not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to find driver: %w", err)
+	}
+	return &driver, nil
+}
+
+// FindByLicenseNumber retrieves a driver by license number
+func (r *driverRepository) FindByLicenseNumber(ctx context.Context, licenseNumber string) (*models.Driver, error) {
+	var driver models.Driver
+	if err := r.db.WithContext(ctx).Where("license_number = ?", licenseNumber).First(&driver).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("driver not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to find driver: %w", err)
+	}
+	return &driver, nil
+}
+
+// FindAll retrieves all drivers
+func (r *driverRepository) FindAll(ctx context.Context) ([]*models.Driver, error) {
+	var drivers []*models.Driver
+	if err := r.db.WithContext(ctx).Order("name asc").Find(&drivers).Error; err != nil {
+		return nil, fmt.Errorf("failed to find drivers: %w", err)
+	}
+	return drivers, nil
+}
+
+// FindPage returns one page of drivers
+func (r *driverRepository) FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	query := r.db.WithContext(ctx).Model(&models.Driver{})
+	result, err := pagination.Find[*models.Driver](query, params, driverSortable)
+	if err != nil {
+		return result, fmt.Errorf("failed to list drivers: %w", err)
+	}
+	return result, nil
+}
+
+// FindTrips retrieves the non-cancelled trips assigned to a driver that overlap [from, to), ordered by departure
+func (r *driverRepository) FindTrips(ctx context.Context, driverID uint, from, to time.Time) ([]*models.Schedule, error) {
+	var schedules []*models.Schedule
+	if err := r.db.WithContext(ctx).
+		Preload("Route.StartStation").
+		Preload("Route.EndStation").
+		Preload("Vehicle").
+		Where("driver_id = ?", driverID).
+		Where("status IS DISTINCT FROM ?", models.ScheduleStatusCancelled).
+		Where("departure_time < ? AND arrival_time > ?", to, from).
+		Order("departure_time asc").
+		Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to find driver trips: %w", err)
+	}
+	return schedules, nil
+}
+
+// FindRosteredTrips retrieves all non-cancelled trips with an assigned driver departing in [from, to)
+func (r *driverRepository) FindRosteredTrips(ctx context.Context, from, to time.Time) ([]*models.Schedule, error) {
+	var schedules []*models.Schedule
+	if err := r.db.WithContext(ctx).
+		Preload("Driver").
+		Preload("Route.StartStation").
+		Preload("Route.EndStation").
+		Preload("Vehicle").
+		Where("driver_id IS NOT NULL").
+		Where("status IS DISTINCT FROM ?", models.ScheduleStatusCancelled).
+		Where("departure_time >= ? AND departure_time < ?", from, to).
+		Order("driver_id asc, departure_time asc").
+		Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to find rostered trips: %w", err)
+	}
+	return schedules, nil
+}
+
+// Update updates a driver
+func (r *driverRepository) Update(ctx context.Context, driver *models.Driver) error {
+	if err := saveVersioned(r.db.WithContext(ctx), driver, driver.ID, &driver.Version); err != nil {
+		return fmt.Errorf("failed to update driver: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a driver
+func (r *driverRepository) Delete(ctx context.Context, id uint, version int) error {
+	if err := deleteVersioned(r.db.WithContext(ctx), &models.Driver{}, id, version); err != nil {
+		return fmt.Errorf("failed to delete driver: %w", err)
+	}
+	return nil
+}
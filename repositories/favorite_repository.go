@@ -2,7 +2,9 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 	"rota-api/models"
+	"rota-api/pagination"
 
 	"gorm.io/gorm"
 )
@@ -11,11 +13,12 @@ import (
 type FavoriteRepository interface {
 	FindByID(ctx context.Context, id uint) (*models.Favorite, error)
 	FindAll(ctx context.Context) ([]models.Favorite, error)
+	FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
 	FindByUserAndStation(ctx context.Context, userID, stationID uint) (*models.Favorite, error)
 	FindByUser(ctx context.Context, userID uint) ([]models.Favorite, error)
 	Create(ctx context.Context, favorite *models.Favorite) error
 	Update(ctx context.Context, favorite *models.Favorite) error
-	Delete(ctx context.Context, id uint) error
+	Delete(ctx context.Context, id uint, version int) error
 }
 
 // favoriteRepository implements FavoriteRepository
@@ -23,6 +26,15 @@ type favoriteRepository struct {
 	db *gorm.DB
 }
 
+// favoriteSortable lists the fields favorites can be sorted by
+var favoriteSortable = pagination.Sortable{
+	Fields: map[string]pagination.Field{
+		"id":         {Column: "id", Type: pagination.Int},
+		"created_at": {Column: "created_at", Type: pagination.Time},
+	},
+	Default: "id",
+}
+
 // NewFavoriteRepository creates a new favorite repository
 func NewFavoriteRepository(db *gorm.DB) FavoriteRepository {
 	return &favoriteRepository{db}
@@ -44,6 +56,19 @@ func (r *favoriteRepository) FindAll(ctx context.Context) ([]models.Favorite, er
 	return favorites, nil
 }
 
+// FindPage returns one page of favorites
+func (r *favoriteRepository) FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	query := r.db.WithContext(ctx).Model(&models.Favorite{})
+	result, err := pagination.Find[models.Favorite](query, params, favoriteSortable,
+		"User",
+		"Station",
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to list favorites: %w", err)
+	}
+	return result, nil
+}
+
 func (r *favoriteRepository) FindByUserAndStation(ctx context.Context, userID, stationID uint) (*models.Favorite, error) {
 	var favorite models.Favorite
 	if err := r.db.WithContext(ctx).Preload("Station").Where("user_id = ? AND station_id = ?", userID, stationID).First(&favorite).Error; err != nil {
@@ -65,9 +90,9 @@ func (r *favoriteRepository) Create(ctx context.Context, favorite *models.Favori
 }
 
 func (r *favoriteRepository) Update(ctx context.Context, favorite *models.Favorite) error {
-	return r.db.WithContext(ctx).Save(favorite).Error
+	return saveVersioned(r.db.WithContext(ctx), favorite, favorite.ID, &favorite.Version)
 }
 
-func (r *favoriteRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&models.Favorite{}, id).Error
+func (r *favoriteRepository) Delete(ctx context.Context, id uint, version int) error {
+	return deleteVersioned(r.db.WithContext(ctx), &models.Favorite{}, id, version)
 }
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"rota-api/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepository stores requests made with an Idempotency-Key and their responses
+type IdempotencyRepository interface {
+	// Reserve stores record as the pending request of its key. If the key is already in use,
+	// nothing is stored and the record it holds is returned
+	Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	// Complete stores the response of the request holding the key
+	Complete(ctx context.Context, record *models.IdempotencyRecord) error
+	// Release frees the key, so the request can be made with it again
+	Release(ctx context.Context, key string) error
+}
+
+// NewIdempotencyRepository creates an idempotency key store backed by Redis,
+// or by the idempotency_keys table when Redis is not available
+func NewIdempotencyRepository(redisRepo RedisRepository, db *gorm.DB) IdempotencyRepository {
+	if redisRepo != nil {
+		return &redisIdempotencyRepository{redis: redisRepo}
+	}
+	log.Println("Idempotency: Redis not available, storing keys in Postgres")
+	r := &postgresIdempotencyRepository{db: db}
+	go r.janitor(time.Hour)
+	return r
+}
+
+// redisIdempotencyRepository implements IdempotencyRepository on top of RedisRepository,
+// with each record stored as JSON that expires with the record
+type redisIdempotencyRepository struct {
+	redis RedisRepository
+}
+
+func (r *redisIdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	// A key that expires between the reservation and the read is reserved again
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, err := r.redis.ReserveIdempotencyKey(ctx, record.Key, value, time.Until(record.ExpiresAt))
+		if err != nil || reserved {
+			return nil, err
+		}
+
+		stored, err := r.redis.GetIdempotencyKey(ctx, record.Key)
+		if errors.Is(err, ErrCacheMiss) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var existing models.IdempotencyRecord
+		if err := json.Unmarshal(stored, &existing); err != nil {
+			return nil, err
+		}
+		return &existing, nil
+	}
+	return nil, errors.New("idempotency key expired while being reserved")
+}
+
+func (r *redisIdempotencyRepository) Complete(ctx context.Context, record *models.IdempotencyRecord) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return r.redis.SetIdempotencyKey(ctx, record.Key, value, time.Until(record.ExpiresAt))
+}
+
+func (r *redisIdempotencyRepository) Release(ctx context.Context, key string) error {
+	return r.redis.DeleteIdempotencyKey(ctx, key)
+}
+
+// postgresIdempotencyRepository implements IdempotencyRepository with the idempotency_keys table
+type postgresIdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func (r *postgresIdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	var existing *models.IdempotencyRecord
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// An expired record no longer holds its key
+		if err := tx.Where("key = ? AND expires_at <= ?", record.Key, time.Now()).
+			Delete(&models.IdempotencyRecord{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil || result.RowsAffected > 0 {
+			return result.Error
+		}
+
+		var stored models.IdempotencyRecord
+		if err := tx.Where("key = ?", record.Key).First(&stored).Error; err != nil {
+			return err
+		}
+		existing = &stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+func (r *postgresIdempotencyRepository) Complete(ctx context.Context, record *models.IdempotencyRecord) error {
+	return r.db.WithContext(ctx).Model(record).
+		Select("completed", "status", "headers", "body", "expires_at").
+		Updates(record).Error
+}
+
+func (r *postgresIdempotencyRepository) Release(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&models.IdempotencyRecord{}).Error
+}
+
+// janitor deletes expired records so the table does not grow with every key ever used
+func (r *postgresIdempotencyRepository) janitor(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := r.db.Where("expires_at <= ?", time.Now()).Delete(&models.IdempotencyRecord{}).Error; err != nil {
+			log.Printf("Idempotency: failed to delete expired keys: %v", err)
+		}
+	}
+}
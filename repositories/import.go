@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// saveImported creates created and saves updated in one transaction, so an import is either saved
+// whole or not at all. Each updated record must still be at the version it was read at
+func saveImported[T any](db *gorm.DB, created, updated []*T, key func(*T) (uint, *int)) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, record := range updated {
+			id, version := key(record)
+			if err := saveVersioned(tx, record, id, version); err != nil {
+				return err
+			}
+		}
+		if len(created) > 0 {
+			if err := tx.Omit(clause.Associations).CreateInBatches(created, 100).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,223 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"rota-api/models"
+
+	"gorm.io/gorm"
+)
+
+// MaintenanceRepository defines methods for vehicle maintenance, inspection and out-of-service database operations
+type MaintenanceRepository interface {
+	// Maintenance records
+	CreateRecord(ctx context.Context, record *models.MaintenanceRecord) error
+	FindRecordByID(ctx context.Context, id uint) (*models.MaintenanceRecord, error)
+	FindRecordsByVehicle(ctx context.Context, vehicleID uint) ([]models.MaintenanceRecord, error)
+	UpdateRecord(ctx context.Context, record *models.MaintenanceRecord) error
+	DeleteRecord(ctx context.Context, id uint) error
+
+	// Inspections
+	CreateInspection(ctx context.Context, inspection *models.VehicleInspection) error
+	FindInspectionByID(ctx context.Context, id uint) (*models.VehicleInspection, error)
+	FindInspectionsByVehicle(ctx context.Context, vehicleID uint) ([]models.VehicleInspection, error)
+	FindDueInspections(ctx context.Context, dueBefore time.Time, odometerMargin int) ([]models.VehicleInspection, error)
+	UpdateInspection(ctx context.Context, inspection *models.VehicleInspection) error
+	DeleteInspection(ctx context.Context, id uint) error
+
+	// Out-of-service windows
+	CreateWindow(ctx context.Context, window *models.OutOfServiceWindow) error
+	FindWindowByID(ctx context.Context, id uint) (*models.OutOfServiceWindow, error)
+	FindWindowsByVehicle(ctx context.Context, vehicleID uint) ([]models.OutOfServiceWindow, error)
+	FindOverlappingWindows(ctx context.Context, vehicleID uint, from, to time.Time) ([]models.OutOfServiceWindow, error)
+	UpdateWindow(ctx context.Context, window *models.OutOfServiceWindow) error
+	DeleteWindow(ctx context.Context, id uint) error
+}
+
+// maintenanceRepository implements MaintenanceRepository
+type maintenanceRepository struct {
+	db *gorm.DB
+}
+
+// NewMaintenanceRepository creates a new maintenance repository
+func NewMaintenanceRepository(db *gorm.DB) MaintenanceRepository {
+	return &maintenanceRepository{db}
+}
+
+// CreateRecord stores a new maintenance record
+func (r *maintenanceRepository) CreateRecord(ctx context.Context, record *models.MaintenanceRecord) error {
+	if err := r.db.WithContext(ctx).Create(record).Error; err != nil {
+		return fmt.Errorf("failed to create maintenance record: %w", err)
+	}
+	return nil
+}
+
+// FindRecordByID retrieves a maintenance record by ID
+func (r *maintenanceRepository) FindRecordByID(ctx context.Context, id uint) (*models.MaintenanceRecord, error) {
+	var record models.MaintenanceRecord
+	if err := r.db.WithContext(ctx).First(&record, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("maintenance record not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to find maintenance record: %w", err)
+	}
+	return &record, nil
+}
+
+// FindRecordsByVehicle retrieves the maintenance history of a vehicle, most recent first
+func (r *maintenanceRepository) FindRecordsByVehicle(ctx context.Context, vehicleID uint) ([]models.MaintenanceRecord, error) {
+	var records []models.MaintenanceRecord
+	if err := r.db.WithContext(ctx).
+		Where("vehicle_id = ?", vehicleID).
+		Order("performed_at desc").
+		Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to find maintenance records: %w", err)
+	}
+	return records, nil
+}
+
+// UpdateRecord updates a maintenance record
+func (r *maintenanceRepository) UpdateRecord(ctx context.Context, record *models.MaintenanceRecord) error {
+	if err := r.db.WithContext(ctx).Save(record).Error; err != nil {
+		return fmt.Errorf("failed to update maintenance record: %w", err)
+	}
+	return nil
+}
+
+// DeleteRecord removes a maintenance record
+func (r *maintenanceRepository) DeleteRecord(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&models.MaintenanceRecord{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete maintenance record: %w", err)
+	}
+	return nil
+}
+
+// CreateInspection stores a new scheduled inspection
+func (r *maintenanceRepository) CreateInspection(ctx context.Context, inspection *models.VehicleInspection) error {
+	if err := r.db.WithContext(ctx).Create(inspection).Error; err != nil {
+		return fmt.Errorf("failed to create inspection: %w", err)
+	}
+	return nil
+}
+
+// FindInspectionByID retrieves an inspection by ID
+func (r *maintenanceRepository) FindInspectionByID(ctx context.Context, id uint) (*models.VehicleInspection, error) {
+	var inspection models.VehicleInspection
+	if err := r.db.WithContext(ctx).First(&inspection, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("inspection not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to find inspection: %w", err)
+	}
+	return &inspection, nil
+}
+
+// FindInspectionsByVehicle retrieves all inspections of a vehicle ordered by due date
+func (r *maintenanceRepository) FindInspectionsByVehicle(ctx context.Context, vehicleID uint) ([]models.VehicleInspection, error) {
+	var inspections []models.VehicleInspection
+	if err := r.db.WithContext(ctx).
+		Where("vehicle_id = ?", vehicleID).
+		Order("due_date asc nulls last").
+		Find(&inspections).Error; err != nil {
+		return nil, fmt.Errorf("failed to find inspections: %w", err)
+	}
+	return inspections, nil
+}
+
+// FindDueInspections retrieves scheduled inspections that fall due before the given date,
+// or whose due odometer is within odometerMargin kilometres of the vehicle's current reading
+func (r *maintenanceRepository) FindDueInspections(ctx context.Context, dueBefore time.Time, odometerMargin int) ([]models.VehicleInspection, error) {
+	var inspections []models.VehicleInspection
+	if err := r.db.WithContext(ctx).
+		Preload("Vehicle").
+		Joins("JOIN vehicles ON vehicles.id = vehicle_inspections.vehicle_id").
+		Where("vehicle_inspections.status = ?", models.InspectionStatusScheduled).
+		Where("vehicle_inspections.due_date <= ? OR vehicle_inspections.due_odometer <= vehicles.odometer + ?", dueBefore, odometerMargin).
+		Order("vehicle_inspections.due_date asc nulls last").
+		Find(&inspections).Error; err != nil {
+		return nil, fmt.Errorf("failed to find due inspections: %w", err)
+	}
+	return inspections, nil
+}
+
+// UpdateInspection updates an inspection
+func (r *maintenanceRepository) UpdateInspection(ctx context.Context, inspection *models.VehicleInspection) error {
+	if err := r.db.WithContext(ctx).Omit("Vehicle").Save(inspection).Error; err != nil {
+		return fmt.Errorf("failed to update inspection: %w", err)
+	}
+	return nil
+}
+
+// DeleteInspection removes an inspection
+func (r *maintenanceRepository) DeleteInspection(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&models.VehicleInspection{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete inspection: %w", err)
+	}
+	return nil
+}
+
+// CreateWindow stores a new out-of-service window
+func (r *maintenanceRepository) CreateWindow(ctx context.Context, window *models.OutOfServiceWindow) error {
+	if err := r.db.WithContext(ctx).Create(window).Error; err != nil {
+		return fmt.Errorf("failed to create out-of-service window: %w", err)
+	}
+	return nil
+}
+
+// FindWindowByID retrieves an out-of-service window by ID
+func (r *maintenanceRepository) FindWindowByID(ctx context.Context, id uint) (*models.OutOfServiceWindow, error) {
+	var window models.OutOfServiceWindow
+	if err := r.db.WithContext(ctx).First(&window, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("out-of-service window not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to find out-of-service window: %w", err)
+	}
+	return &window, nil
+}
+
+// FindWindowsByVehicle retrieves all out-of-service windows of a vehicle, most recent first
+func (r *maintenanceRepository) FindWindowsByVehicle(ctx context.Context, vehicleID uint) ([]models.OutOfServiceWindow, error) {
+	var windows []models.OutOfServiceWindow
+	if err := r.db.WithContext(ctx).
+		Where("vehicle_id = ?", vehicleID).
+		Order("start_time desc").
+		Find(&windows).Error; err != nil {
+		return nil, fmt.Errorf("failed to find out-of-service windows: %w", err)
+	}
+	return windows, nil
+}
+
+// FindOverlappingWindows retrieves the out-of-service windows of a vehicle that overlap [from, to)
+// Open-ended windows (no end time) overlap everything after their start
+func (r *maintenanceRepository) FindOverlappingWindows(ctx context.Context, vehicleID uint, from, to time.Time) ([]models.OutOfServiceWindow, error) {
+	var windows []models.OutOfServiceWindow
+	if err := r.db.WithContext(ctx).
+		Where("vehicle_id = ?", vehicleID).
+		Where("start_time < ?", to).
+		Where("end_time IS NULL OR end_time > ?", from).
+		Order("start_time asc").
+		Find(&windows).Error; err != nil {
+		return nil, fmt.Errorf("failed to find out-of-service windows: %w", err)
+	}
+	return windows, nil
+}
+
+// UpdateWindow updates an out-of-service window
+func (r *maintenanceRepository) UpdateWindow(ctx context.Context, window *models.OutOfServiceWindow) error {
+	if err := r.db.WithContext(ctx).Save(window).Error; err != nil {
+		return fmt.Errorf("failed to update out-of-service window: %w", err)
+	}
+	return nil
+}
+
+// DeleteWindow removes an out-of-service window
+func (r *maintenanceRepository) DeleteWindow(ctx context.Context, id uint) error {
+	if err := r.db.WithContext(ctx).Delete(&models.OutOfServiceWindow{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete out-of-service window: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"rota-api/models"
+
+	"gorm.io/gorm"
+)
+
+// MFARecoveryCodeRepository defines the interface for MFA recovery code database operations
+type MFARecoveryCodeRepository interface {
+	Replace(ctx context.Context, userID int, codeHashes []string) error
+	Use(ctx context.Context, userID int, codeHash string) (bool, error)
+	CountUnused(ctx context.Context, userID int) (int64, error)
+	DeleteByUser(ctx context.Context, userID int) error
+}
+
+// mfaRecoveryCodeRepository implements MFARecoveryCodeRepository
+type mfaRecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewMFARecoveryCodeRepository creates a new MFA recovery code repository
+func NewMFARecoveryCodeRepository(db *gorm.DB) MFARecoveryCodeRepository {
+	return &mfaRecoveryCodeRepository{db}
+}
+
+// Replace swaps a user's recovery codes for a new set in one transaction
+func (r *mfaRecoveryCodeRepository) Replace(ctx context.Context, userID int, codeHashes []string) error {
+	codes := make([]models.MFARecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = models.MFARecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replace recovery codes: %w", err)
+	}
+	return nil
+}
+
+// Use marks an unused recovery code of the user as used, reporting false when there is none with the hash
+func (r *mfaRecoveryCodeRepository) Use(ctx context.Context, userID int, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountUnused counts the recovery codes the user has left
+func (r *mfaRecoveryCodeRepository) CountUnused(ctx context.Context, userID int) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.MFARecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+func (r *mfaRecoveryCodeRepository) DeleteByUser(ctx context.Context, userID int) error {
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	return nil
+}
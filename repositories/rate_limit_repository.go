@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"rota-api/models"
+)
+
+// RateLimitRepository counts requests in sliding windows
+type RateLimitRepository interface {
+	Hit(ctx context.Context, key string, policy models.RateLimitPolicy) (models.RateLimitResult, error)
+	Reset(ctx context.Context, key string) error
+}
+
+// NewRateLimitRepository creates a rate limit store backed by Redis, or by process
+// memory when Redis is not available (limits are then per instance)
+func NewRateLimitRepository(redisRepo RedisRepository) RateLimitRepository {
+	if redisRepo != nil {
+		return &redisRateLimitRepository{redis: redisRepo}
+	}
+	log.Println("Rate limit: Redis not available, using in-memory counters")
+	return newMemoryRateLimitRepository()
+}
+
+// redisRateLimitRepository implements RateLimitRepository on top of RedisRepository
+type redisRateLimitRepository struct {
+	redis RedisRepository
+}
+
+func (r *redisRateLimitRepository) Hit(ctx context.Context, key string, policy models.RateLimitPolicy) (models.RateLimitResult, error) {
+	count, retryAfter, err := r.redis.SlidingWindowHit(ctx, key, policy.Limit, policy.Window)
+	if err != nil {
+		return models.RateLimitResult{}, err
+	}
+	return newRateLimitResult(policy, count, retryAfter), nil
+}
+
+func (r *redisRateLimitRepository) Reset(ctx context.Context, key string) error {
+	return r.redis.ResetRateLimit(ctx, key)
+}
+
+// memoryRateLimitRepository implements RateLimitRepository with per-key request timestamps
+type memoryRateLimitRepository struct {
+	mu      sync.Mutex
+	windows map[string]*memoryWindow
+}
+
+type memoryWindow struct {
+	hits   []time.Time
+	window time.Duration
+}
+
+func newMemoryRateLimitRepository() *memoryRateLimitRepository {
+	r := &memoryRateLimitRepository{windows: make(map[string]*memoryWindow)}
+	go r.janitor(time.Minute)
+	return r
+}
+
+func (r *memoryRateLimitRepository) Hit(ctx context.Context, key string, policy models.RateLimitPolicy) (models.RateLimitResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	w, ok := r.windows[key]
+	if !ok {
+		w = &memoryWindow{}
+		r.windows[key] = w
+	}
+	w.window = policy.Window
+	w.prune(now)
+
+	if len(w.hits) >= policy.Limit {
+		return newRateLimitResult(policy, policy.Limit+1, w.hits[0].Add(policy.Window).Sub(now)), nil
+	}
+
+	w.hits = append(w.hits, now)
+	return newRateLimitResult(policy, len(w.hits), 0), nil
+}
+
+func (r *memoryRateLimitRepository) Reset(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.windows, key)
+	return nil
+}
+
+// janitor drops windows with no recent requests so idle keys do not accumulate
+func (r *memoryRateLimitRepository) janitor(interval time.Duration) {
+	for range time.Tick(interval) {
+		now := time.Now()
+		r.mu.Lock()
+		for key, w := range r.windows {
+			if w.prune(now); len(w.hits) == 0 {
+				delete(r.windows, key)
+			}
+		}
+		r.mu.Unlock()
+	}
+}
+
+// prune removes hits that have left the window
+func (w *memoryWindow) prune(now time.Time) {
+	cutoff := now.Add(-w.window)
+	i := 0
+	for i < len(w.hits) && !w.hits[i].After(cutoff) {
+		i++
+	}
+	w.hits = w.hits[i:]
+}
+
+func newRateLimitResult(policy models.RateLimitPolicy, count int, retryAfter time.Duration) models.RateLimitResult {
+	result := models.RateLimitResult{
+		Allowed:   count <= policy.Limit,
+		Limit:     policy.Limit,
+		Remaining: policy.Limit - count,
+	}
+	if result.Remaining < 0 {
+		result.Remaining = 0
+	}
+	if !result.Allowed {
+		result.RetryAfter = retryAfter
+	}
+	return result
+}
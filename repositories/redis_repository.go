@@ -2,11 +2,17 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"math/rand"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrCacheMiss is returned when a cache key does not exist or has expired
+var ErrCacheMiss = errors.New("cache miss")
+
 // RedisConfig represents the configuration for Redis connection
 type RedisConfig struct {
 	Host     string
@@ -20,6 +26,35 @@ type RedisRepository interface {
 	// Token Blacklist methods
 	AddToBlacklist(ctx context.Context, token string, ttl time.Duration) error
 	IsBlacklisted(ctx context.Context, token string) (bool, error)
+
+	// Cache methods
+	GetCache(ctx context.Context, key string) ([]byte, error)
+	SetCache(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error
+	InvalidateTags(ctx context.Context, tags ...string) error
+
+	// Ranking methods
+	IncrementRank(ctx context.Context, key, member string, by float64, ttl time.Duration) error
+	UnionRanks(ctx context.Context, keys []string, weights []float64, limit int) ([]RankScore, error)
+
+	// Rate limiting methods
+	SlidingWindowHit(ctx context.Context, key string, limit int, window time.Duration) (int, time.Duration, error)
+	ResetRateLimit(ctx context.Context, key string) error
+
+	// Idempotency methods
+	ReserveIdempotencyKey(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	GetIdempotencyKey(ctx context.Context, key string) ([]byte, error)
+	SetIdempotencyKey(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	DeleteIdempotencyKey(ctx context.Context, key string) error
+
+	// One-time token methods
+	SetOneTimeToken(ctx context.Context, key, value string, ttl time.Duration) error
+	ConsumeOneTimeToken(ctx context.Context, key string) (string, error)
+}
+
+// RankScore is a member of a ranking sorted set and its score
+type RankScore struct {
+	Member string
+	Score  float64
 }
 
 // redisRepositoryImpl implements RedisRepository
@@ -44,7 +79,148 @@ func (r *redisRepositoryImpl) IsBlacklisted(ctx context.Context, token string) (
 	return result > 0, err
 }
 
-// Future methods for ranking and caching can be added here
-// Example:
-// func (r *RedisRepository) UpdateRank(ctx context.Context, key string, score float64) error
-// func (r *RedisRepository) GetCache(ctx context.Context, key string) (string, error)
+// Cache methods
+// Each tag is a set of the cache keys stored under it, so invalidating a tag deletes every tagged key
+func (r *redisRepositoryImpl) GetCache(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.client.Get(ctx, "cache:"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCacheMiss
+	}
+	return value, err
+}
+
+func (r *redisRepositoryImpl) SetCache(ctx context.Context, key string, value []byte, ttl time.Duration, tags ...string) error {
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, "cache:"+key, value, ttl)
+	for _, tag := range tags {
+		pipe.SAdd(ctx, "cachetag:"+tag, "cache:"+key)
+		// The tag set only needs to outlive the keys it tracks. Only ever extend it, so a key
+		// cached with a short TTL does not expire the tag before longer-lived keys under it
+		pipe.ExpireGT(ctx, "cachetag:"+tag, ttl)
+		pipe.ExpireNX(ctx, "cachetag:"+tag, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *redisRepositoryImpl) InvalidateTags(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		keys, err := r.client.SMembers(ctx, "cachetag:"+tag).Result()
+		if err != nil {
+			return err
+		}
+		if err := r.client.Del(ctx, append(keys, "cachetag:"+tag)...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rate limiting methods
+// slidingWindowScript keeps one sorted set member per allowed request, scored by its time in ms.
+// It returns the number of requests in the window (limit+1 when refused) and, when refused,
+// the milliseconds until the oldest request leaves the window
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local member = ARGV[4]
+
+redis.call("ZREMRANGEBYSCORE", key, "-inf", now - window)
+local count = redis.call("ZCARD", key)
+if count >= limit then
+	local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+	local retry = window
+	if oldest[2] then
+		retry = tonumber(oldest[2]) + window - now
+	end
+	return {limit + 1, retry}
+end
+
+redis.call("ZADD", key, now, member)
+redis.call("PEXPIRE", key, window)
+return {count + 1, 0}
+`)
+
+func (r *redisRepositoryImpl) SlidingWindowHit(ctx context.Context, key string, limit int, window time.Duration) (int, time.Duration, error) {
+	now := time.Now()
+	member := strconv.FormatInt(now.UnixNano(), 36) + "-" + strconv.FormatInt(rand.Int63(), 36)
+	result, err := slidingWindowScript.Run(ctx, r.client, []string{"ratelimit:" + key},
+		now.UnixMilli(), window.Milliseconds(), limit, member).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(result[0]), time.Duration(result[1]) * time.Millisecond, nil
+}
+
+func (r *redisRepositoryImpl) ResetRateLimit(ctx context.Context, key string) error {
+	return r.client.Del(ctx, "ratelimit:"+key).Err()
+}
+
+// Ranking methods
+// Rankings are sorted sets; callers bucket them by time and combine buckets with weights
+func (r *redisRepositoryImpl) IncrementRank(ctx context.Context, key, member string, by float64, ttl time.Duration) error {
+	pipe := r.client.TxPipeline()
+	pipe.ZIncrBy(ctx, key, by, member)
+	pipe.Expire(ctx, key, ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *redisRepositoryImpl) UnionRanks(ctx context.Context, keys []string, weights []float64, limit int) ([]RankScore, error) {
+	members, err := r.client.ZUnionWithScores(ctx, redis.ZStore{
+		Keys:      keys,
+		Weights:   weights,
+		Aggregate: "SUM",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// ZUNION returns members by ascending score
+	ranks := make([]RankScore, 0, limit)
+	for i := len(members) - 1; i >= 0 && len(ranks) < limit; i-- {
+		member, _ := members[i].Member.(string)
+		ranks = append(ranks, RankScore{Member: member, Score: members[i].Score})
+	}
+	return ranks, nil
+}
+
+// Idempotency methods
+// ReserveIdempotencyKey stores value under key only if the key is not in use, and reports whether it did
+func (r *redisRepositoryImpl) ReserveIdempotencyKey(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, "idempotency:"+key, value, ttl).Result()
+}
+
+func (r *redisRepositoryImpl) GetIdempotencyKey(ctx context.Context, key string) ([]byte, error) {
+	value, err := r.client.Get(ctx, "idempotency:"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrCacheMiss
+	}
+	return value, err
+}
+
+func (r *redisRepositoryImpl) SetIdempotencyKey(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, "idempotency:"+key, value, ttl).Err()
+}
+
+func (r *redisRepositoryImpl) DeleteIdempotencyKey(ctx context.Context, key string) error {
+	return r.client.Del(ctx, "idempotency:"+key).Err()
+}
+
+// One-time token methods
+// SetOneTimeToken stores value under key until ttl passes or the token is consumed
+func (r *redisRepositoryImpl) SetOneTimeToken(ctx context.Context, key, value string, ttl time.Duration) error {
+	return r.client.Set(ctx, "onetime:"+key, value, ttl).Err()
+}
+
+// ConsumeOneTimeToken returns the value stored under key and removes it in one step, so a token is
+// only ever accepted once
+func (r *redisRepositoryImpl) ConsumeOneTimeToken(ctx context.Context, key string) (string, error) {
+	value, err := r.client.GetDel(ctx, "onetime:"+key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrCacheMiss
+	}
+	return value, err
+}
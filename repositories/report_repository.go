@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"rota-api/models"
+
+	"gorm.io/gorm"
+)
+
+// tripActualsQuery pairs every trip with the latest actual times logged for it, as the trip_actuals
+// materialized view does (migration 024). Reports read it live unless they are set to use the view
+const tripActualsQuery = `SELECT s.id AS schedule_id, s.service_date, s.route_id, s.station_id, s.vehicle_id, s.status,
+	s.departure_time, s.arrival_time, a.actual_departure, a.actual_arrival,
+	EXTRACT(EPOCH FROM a.actual_departure - s.departure_time)::float8 AS departure_delay,
+	EXTRACT(EPOCH FROM a.actual_arrival - s.arrival_time)::float8 AS arrival_delay
+FROM schedules s
+LEFT JOIN LATERAL (
+	SELECT l.actual_departure, l.actual_arrival FROM schedule_logs l
+	WHERE l.schedule_id = s.id AND (l.actual_departure IS NOT NULL OR l.actual_arrival IS NOT NULL)
+	ORDER BY COALESCE(l.updated_at, l.created_at) DESC, l.id DESC
+	LIMIT 1
+) a ON TRUE
+WHERE s.deleted_at IS NULL`
+
+// reportGroupSQL holds, for each report grouping, the columns it selects, the joins they need and
+// what is grouped and ordered by. Groupings are only ever taken from this map, never from input
+var reportGroupSQL = map[string]struct {
+	selects string
+	joins   string
+	groupBy string
+}{
+	models.ReportGroupDay: {
+		selects: "t.service_date",
+		groupBy: "t.service_date",
+	},
+	models.ReportGroupRoute: {
+		selects: "t.route_id, origin.name AS origin, destination.name AS destination",
+		joins: `LEFT JOIN routes r ON r.id = t.route_id
+			LEFT JOIN stations origin ON origin.id = r.start_station_id
+			LEFT JOIN stations destination ON destination.id = r.end_station_id`,
+		groupBy: "t.route_id, origin.name, destination.name",
+	},
+	models.ReportGroupStation: {
+		selects: "t.station_id, st.name AS station_name",
+		joins:   "LEFT JOIN stations st ON st.id = t.station_id",
+		groupBy: "t.station_id, st.name",
+	},
+}
+
+// ReportRepository defines the aggregations behind the admin reports
+type ReportRepository interface {
+	Punctuality(ctx context.Context, query models.ReportQuery, snapshot bool) ([]models.PunctualityRow, error)
+	Utilization(ctx context.Context, query models.ReportQuery, snapshot bool) ([]models.UtilizationRow, error)
+	RefreshSnapshot(ctx context.Context) error
+}
+
+// reportRepository implements ReportRepository
+type reportRepository struct {
+	db *gorm.DB
+}
+
+// NewReportRepository creates a new report repository
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{db}
+}
+
+// Punctuality aggregates the on-time performance of the trips query selects, by its groupings.
+// With snapshot set the trips are read from the trip_actuals view rather than computed live
+func (r *reportRepository) Punctuality(ctx context.Context, query models.ReportQuery, snapshot bool) ([]models.PunctualityRow, error) {
+	selects, joins, groupBy := reportGrouping(query.GroupBy)
+	where, args := reportConditions(query)
+	threshold := (*query.LateThreshold).Seconds()
+
+	columns := append(selects, `COUNT(*) AS trips,
+		COUNT(*) FILTER (WHERE t.status = 'cancelled') AS cancelled,
+		COUNT(t.departure_delay) FILTER (WHERE t.status IS DISTINCT FROM 'cancelled') AS reported,
+		COUNT(*) FILTER (WHERE t.status IS DISTINCT FROM 'cancelled' AND t.departure_delay <= ?) AS on_time,
+		COUNT(*) FILTER (WHERE t.status IS DISTINCT FROM 'cancelled' AND t.departure_delay > ?) AS late,
+		AVG(t.departure_delay) FILTER (WHERE t.status IS DISTINCT FROM 'cancelled') AS avg_departure_delay,
+		AVG(t.arrival_delay) FILTER (WHERE t.status IS DISTINCT FROM 'cancelled') AS avg_arrival_delay,
+		MAX(t.departure_delay) FILTER (WHERE t.status IS DISTINCT FROM 'cancelled') AS max_departure_delay`)
+	sql := fmt.Sprintf("SELECT %s FROM %s t %s WHERE %s",
+		strings.Join(columns, ", "), tripActualsSource(snapshot), strings.Join(joins, " "), where)
+	if len(groupBy) > 0 {
+		sql += fmt.Sprintf(" GROUP BY %[1]s ORDER BY %[1]s", strings.Join(groupBy, ", "))
+	}
+
+	var rows []models.PunctualityRow
+	if err := r.db.WithContext(ctx).Raw(sql, append([]interface{}{threshold, threshold}, args...)...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to build punctuality report: %w", err)
+	}
+	return rows, nil
+}
+
+// Utilization aggregates the trips query selects per vehicle, and per day when grouped by day.
+// Vehicles without trips in the range are listed too, unless the report is split by day or narrowed to a route or station
+func (r *reportRepository) Utilization(ctx context.Context, query models.ReportQuery, snapshot bool) ([]models.UtilizationRow, error) {
+	byDay := false
+	for _, group := range query.GroupBy {
+		byDay = byDay || group == models.ReportGroupDay
+	}
+	where, args := reportConditions(query)
+
+	selects := "v.id AS vehicle_id, v.license_plate"
+	groupBy := "v.id, v.license_plate, v.capacity"
+	orderBy := "v.license_plate, v.id"
+	if byDay {
+		selects += ", t.service_date"
+		groupBy += ", t.service_date"
+		orderBy += ", t.service_date"
+	}
+
+	outer := "TRUE"
+	if query.VehicleID != nil {
+		outer = "v.id = ?"
+		args = append(args, *query.VehicleID)
+	}
+	sql := fmt.Sprintf(`SELECT %s,
+		COUNT(t.schedule_id) FILTER (WHERE t.status IS DISTINCT FROM 'cancelled') AS trips,
+		COUNT(t.schedule_id) FILTER (WHERE t.status = 'cancelled') AS cancelled,
+		COUNT(DISTINCT t.service_date) FILTER (WHERE t.status IS DISTINCT FROM 'cancelled') AS active_days,
+		COALESCE(SUM(EXTRACT(EPOCH FROM t.arrival_time - t.departure_time)) FILTER (WHERE t.status IS DISTINCT FROM 'cancelled'), 0)::float8 AS service_seconds,
+		COUNT(t.schedule_id) FILTER (WHERE t.status IS DISTINCT FROM 'cancelled') * v.capacity AS seats_offered
+		FROM vehicles v LEFT JOIN (SELECT * FROM %s t WHERE %s) t ON t.vehicle_id = v.id
+		WHERE %s
+		GROUP BY %s`,
+		selects, tripActualsSource(snapshot), where, outer, groupBy)
+	// Split by day, or narrowed to a route or station, a vehicle without trips has nothing to report
+	if byDay || query.RouteID != nil || query.StationID != nil {
+		sql += " HAVING COUNT(t.schedule_id) > 0"
+	}
+	sql += " ORDER BY " + orderBy
+
+	var rows []models.UtilizationRow
+	if err := r.db.WithContext(ctx).Raw(sql, args...).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to build utilization report: %w", err)
+	}
+	return rows, nil
+}
+
+// RefreshSnapshot recomputes the trip_actuals view without blocking the reports reading it
+func (r *reportRepository) RefreshSnapshot(ctx context.Context) error {
+	if err := r.db.WithContext(ctx).Exec("REFRESH MATERIALIZED VIEW CONCURRENTLY trip_actuals").Error; err != nil {
+		return fmt.Errorf("failed to refresh trip_actuals: %w", err)
+	}
+	return nil
+}
+
+// tripActualsSource is what reports select trips from
+func tripActualsSource(snapshot bool) string {
+	if snapshot {
+		return "trip_actuals"
+	}
+	return "(" + tripActualsQuery + ")"
+}
+
+// reportGrouping collects the SQL of the given groupings, in the order they were asked for
+func reportGrouping(groups []string) (selects, joins, groupBy []string) {
+	for _, group := range groups {
+		grouping, ok := reportGroupSQL[group]
+		if !ok {
+			continue
+		}
+		selects = append(selects, grouping.selects)
+		if grouping.joins != "" {
+			joins = append(joins, grouping.joins)
+		}
+		groupBy = append(groupBy, grouping.groupBy)
+	}
+	return selects, joins, groupBy
+}
+
+// reportConditions selects the trips of a report from the trips aliased t
+func reportConditions(query models.ReportQuery) (string, []interface{}) {
+	conditions := []string{"t.service_date BETWEEN ? AND ?"}
+	args := []interface{}{query.From, query.To}
+	if query.RouteID != nil {
+		conditions = append(conditions, "t.route_id = ?")
+		args = append(args, *query.RouteID)
+	}
+	if query.StationID != nil {
+		conditions = append(conditions, "t.station_id = ?")
+		args = append(args, *query.StationID)
+	}
+	if query.VehicleID != nil {
+		conditions = append(conditions, "t.vehicle_id = ?")
+		args = append(args, *query.VehicleID)
+	}
+	return strings.Join(conditions, " AND "), args
+}
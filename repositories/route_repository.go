@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"rota-api/filter"
 	"rota-api/models"
+	"rota-api/pagination"
 
 	"gorm.io/gorm"
 )
@@ -14,9 +16,12 @@ type RouteRepository interface {
 	Create(ctx context.Context, route *models.Route) error
 	FindByID(ctx context.Context, id uint) (*models.Route, error)
 	FindAll(ctx context.Context) ([]*models.Route, error)
+	FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
+	FindByStations(ctx context.Context, stationIDs []uint, limit int) ([]*models.Route, error)
 	FindByStation(ctx context.Context, stationID uint) ([]models.Route, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]*models.Route, error)
 	Update(ctx context.Context, route *models.Route) error
-	Delete(ctx context.Context, id uint) error
+	Delete(ctx context.Context, id uint, version int) error
 }
 
 // routeRepository implements RouteRepository
@@ -24,6 +29,26 @@ type routeRepository struct {
 	db *gorm.DB
 }
 
+// routeSortable lists the fields routes can be sorted by
+var routeSortable = pagination.Sortable{
+	Fields: map[string]pagination.Field{
+		"id":               {Column: "id", Type: pagination.Int},
+		"distance":         {Column: "distance", Type: pagination.Float},
+		"start_station_id": {Column: "start_station_id", Type: pagination.Int},
+		"end_station_id":   {Column: "end_station_id", Type: pagination.Int},
+	},
+	Default: "id",
+}
+
+// routeFilterable lists the fields routes can be filtered on
+var routeFilterable = filter.Fields{
+	"id":               {Column: "id", Type: filter.Int},
+	"start_station_id": {Column: "start_station_id", Type: filter.Int},
+	"end_station_id":   {Column: "end_station_id", Type: filter.Int},
+	"distance":         {Column: "distance", Type: filter.Float},
+	"duration":         {Column: "duration", Type: filter.String},
+}
+
 // NewRouteRepository creates a new route repository
 func NewRouteRepository(db *gorm.DB) RouteRepository {
 	return &routeRepository{db}
@@ -58,6 +83,23 @@ func (r *routeRepository) FindAll(ctx context.Context) ([]*models.Route, error)
 	return routes, nil
 }
 
+// FindPage returns one page of routes
+func (r *routeRepository) FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	query := r.db.WithContext(ctx).Model(&models.Route{})
+	query, err := filter.Apply(query, params.Filter, routeFilterable)
+	if err != nil {
+		return models.PagedResult{}, err
+	}
+	result, err := pagination.Find[*models.Route](query, params, routeSortable,
+		"StartStation",
+		"EndStation",
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to list routes: %w", err)
+	}
+	return result, nil
+}
+
 // FindByStation retrieves all routes that start or end at a station
 func (r *routeRepository) FindByStation(ctx context.Context, stationID uint) ([]models.Route, error) {
 	var routes []models.Route
@@ -71,18 +113,50 @@ func (r *routeRepository) FindByStation(ctx context.Context, stationID uint) ([]
 	return routes, nil
 }
 
+// FindByIDs retrieves routes by ID with their start and end stations
+func (r *routeRepository) FindByIDs(ctx context.Context, ids []uint) ([]*models.Route, error) {
+	var routes []*models.Route
+	if err := r.db.WithContext(ctx).
+		Preload("StartStation").
+		Preload("EndStation").
+		Where("id IN ?", ids).
+		Find(&routes).Error; err != nil {
+		return nil, fmt.Errorf("failed to find routes: %w", err)
+	}
+	return routes, nil
+}
+
 // Update updates a route
 func (r *routeRepository) Update(ctx context.Context, route *models.Route) error {
-	if err := r.db.WithContext(ctx).Save(route).Error; err != nil {
+	if err := saveVersioned(r.db.WithContext(ctx), route, route.ID, &route.Version); err != nil {
 		return fmt.Errorf("failed to update route: %w", err)
 	}
 	return nil
 }
 
 // Delete removes a route
-func (r *routeRepository) Delete(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&models.Route{}, id).Error; err != nil {
+func (r *routeRepository) Delete(ctx context.Context, id uint, version int) error {
+	if err := deleteVersioned(r.db.WithContext(ctx), &models.Route{}, id, version); err != nil {
 		return fmt.Errorf("failed to delete route: %w", err)
 	}
 	return nil
 }
+
+// FindByStations retrieves routes starting or ending at any of the given stations
+func (r *routeRepository) FindByStations(ctx context.Context, stationIDs []uint, limit int) ([]*models.Route, error) {
+	var routes []*models.Route
+	if len(stationIDs) == 0 {
+		return routes, nil
+	}
+	err := r.db.WithContext(ctx).
+		Preload("StartStation").
+		Preload("EndStation").
+		Where("start_station_id IN ? OR end_station_id IN ?", stationIDs, stationIDs).
+		Order("id asc").
+		Limit(limit).
+		Find(&routes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to find routes by stations: %w", err)
+	}
+	return routes, nil
+}
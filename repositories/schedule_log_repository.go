@@ -2,8 +2,11 @@ package repositories
 
 import (
 	"context"
+	"fmt"
 
+	"rota-api/filter"
 	"rota-api/models"
+	"rota-api/pagination"
 
 	"gorm.io/gorm"
 )
@@ -12,9 +15,11 @@ import (
 type ScheduleLogRepository interface {
 	FindByID(ctx context.Context, id uint) (*models.ScheduleLog, error)
 	FindAll(ctx context.Context) ([]*models.ScheduleLog, error)
+	FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
 	Create(ctx context.Context, scheduleLog *models.ScheduleLog) error
 	Update(ctx context.Context, scheduleLog *models.ScheduleLog) error
-	Delete(ctx context.Context, id uint) error
+	Delete(ctx context.Context, id uint, version int) error
+	FindLatestBySchedules(ctx context.Context, scheduleIDs []uint) (map[uint]*models.ScheduleLog, error)
 }
 
 // scheduleLogRepository implements ScheduleLogRepository
@@ -22,6 +27,29 @@ type scheduleLogRepository struct {
 	db *gorm.DB
 }
 
+// scheduleLogSortable lists the fields schedule logs can be sorted by
+var scheduleLogSortable = pagination.Sortable{
+	Fields: map[string]pagination.Field{
+		"id":          {Column: "id", Type: pagination.Int},
+		"schedule_id": {Column: "schedule_id", Type: pagination.Int},
+		"staff_id":    {Column: "staff_id", Type: pagination.Int},
+	},
+	Default:     "id",
+	DefaultDesc: true,
+}
+
+// scheduleLogFilterable lists the fields schedule logs can be filtered on
+var scheduleLogFilterable = filter.Fields{
+	"id":                 {Column: "id", Type: filter.Int},
+	"schedule_id":        {Column: "schedule_id", Type: filter.Int},
+	"staff_id":           {Column: "staff_id", Type: filter.Int},
+	"status":             {Column: "status", Type: filter.String},
+	"change_description": {Column: "change_description", Type: filter.String},
+	"actual_departure":   {Column: "actual_departure", Type: filter.Time},
+	"actual_arrival":     {Column: "actual_arrival", Type: filter.Time},
+	"updated_at":         {Column: "updated_at", Type: filter.Time},
+}
+
 // NewScheduleLogRepository creates a new schedule log repository
 func NewScheduleLogRepository(db *gorm.DB) ScheduleLogRepository {
 	return &scheduleLogRepository{db}
@@ -61,6 +89,29 @@ func (r *scheduleLogRepository) FindAll(ctx context.Context) ([]*models.Schedule
 	return scheduleLogs, nil
 }
 
+// FindPage returns one page of schedule logs
+func (r *scheduleLogRepository) FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	query := r.db.WithContext(ctx).Model(&models.ScheduleLog{})
+	query, err := filter.Apply(query, params.Filter, scheduleLogFilterable)
+	if err != nil {
+		return models.PagedResult{}, err
+	}
+	result, err := pagination.Find[*models.ScheduleLog](query, params, scheduleLogSortable,
+		"Schedule",
+		"Schedule.Route",
+		"Schedule.Route.StartStation",
+		"Schedule.Route.EndStation",
+		"Schedule.Vehicle",
+		"Schedule.Station",
+		"Staff",
+		"Staff.Station",
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to list schedule logs: %w", err)
+	}
+	return result, nil
+}
+
 func (r *scheduleLogRepository) Create(ctx context.Context, scheduleLog *models.ScheduleLog) error {
 	// ใช้คำสั่ง SQL โดยตรงเพื่อหลีกเลี่ยงปัญหาคอลัมน์ updated_at
 	sql := `INSERT INTO schedule_logs (schedule_id, staff_id, change_description) VALUES (?, ?, ?)`
@@ -69,10 +120,54 @@ func (r *scheduleLogRepository) Create(ctx context.Context, scheduleLog *models.
 
 func (r *scheduleLogRepository) Update(ctx context.Context, scheduleLog *models.ScheduleLog) error {
 	// ใช้คำสั่ง SQL โดยตรงเพื่อหลีกเลี่ยงปัญหาคอลัมน์ updated_at
-	sql := `UPDATE schedule_logs SET schedule_id = ?, staff_id = ?, change_description = ? WHERE id = ?`
-	return r.db.WithContext(ctx).Exec(sql, scheduleLog.ScheduleID, scheduleLog.StaffID, scheduleLog.ChangeDescription, scheduleLog.ID).Error
+	// และบันทึกเฉพาะเมื่อเวอร์ชันยังตรงกับที่อ่านมา (เวอร์ชัน 0 หมายถึงบันทึกทับได้ทุกเวอร์ชัน)
+	db := r.db.WithContext(ctx)
+	expected := scheduleLog.Version
+	if expected == 0 {
+		current, err := currentVersion(db, &models.ScheduleLog{}, scheduleLog.ID)
+		if err != nil {
+			return err
+		}
+		expected = current
+	}
+	sql := `UPDATE schedule_logs SET schedule_id = ?, staff_id = ?, change_description = ?,
+		actual_departure = ?, actual_arrival = ?, status = ?, notes = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND version = ?`
+	result := db.Exec(sql, scheduleLog.ScheduleID, scheduleLog.StaffID, scheduleLog.ChangeDescription,
+		scheduleLog.ActualDeparture, scheduleLog.ActualArrival, scheduleLog.Status, scheduleLog.Notes, scheduleLog.UpdatedAt,
+		scheduleLog.ID, expected)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return versionMismatch(db, &models.ScheduleLog{}, scheduleLog.ID)
+	}
+	scheduleLog.Version = expected + 1
+	return nil
 }
 
-func (r *scheduleLogRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&models.ScheduleLog{}, id).Error
+func (r *scheduleLogRepository) Delete(ctx context.Context, id uint, version int) error {
+	return deleteVersioned(r.db.WithContext(ctx), &models.ScheduleLog{}, id, version)
+}
+
+// FindLatestBySchedules returns the most recent log entry of each schedule, keyed by schedule ID
+func (r *scheduleLogRepository) FindLatestBySchedules(ctx context.Context, scheduleIDs []uint) (map[uint]*models.ScheduleLog, error) {
+	latest := make(map[uint]*models.ScheduleLog, len(scheduleIDs))
+	if len(scheduleIDs) == 0 {
+		return latest, nil
+	}
+
+	var scheduleLogs []*models.ScheduleLog
+	if err := r.db.WithContext(ctx).
+		Raw(`SELECT DISTINCT ON (schedule_id) * FROM schedule_logs
+			WHERE schedule_id IN ?
+			ORDER BY schedule_id, COALESCE(updated_at, created_at) DESC, id DESC`, scheduleIDs).
+		Scan(&scheduleLogs).Error; err != nil {
+		return nil, err
+	}
+
+	for _, scheduleLog := range scheduleLogs {
+		latest[scheduleLog.ScheduleID] = scheduleLog
+	}
+	return latest, nil
 }
@@ -2,22 +2,46 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	apperrors "rota-api/errors"
 	"rota-api/models"
+	"rota-api/pagination"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrScheduleOverlap is returned when the database exclusion constraint rejects
+// a schedule that overlaps another trip of the same vehicle or driver
+var ErrScheduleOverlap = apperrors.Conflict(apperrors.SCHEDULE_OVERLAP, "schedule overlaps another trip of the same vehicle or driver")
+
+// exclusionViolation is the Postgres SQLSTATE for exclusion constraint violations
+const exclusionViolation = "23P01"
+
 // ScheduleRepository defines the interface for schedule-related database operations
 type ScheduleRepository interface {
 	FindByID(ctx context.Context, id uint) (*models.Schedule, error)
 	FindAll(ctx context.Context) ([]*models.Schedule, error)
+	FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
 	Search(ctx context.Context, params models.ScheduleSearchParams) (models.PagedResult, error)
 	Create(ctx context.Context, schedule *models.Schedule) error
 	Update(ctx context.Context, schedule *models.Schedule) error
-	Delete(ctx context.Context, id uint) error
+	Delete(ctx context.Context, id uint, version int) error
 	FindSchedulesByStation(ctx context.Context, stationID uint, limit int) (models.StationSchedulesResponse, error)
 	FindSimpleSchedulesByStation(ctx context.Context, stationID uint) (*models.SimpleStationScheduleResponse, error)
+	FindByVehicleBetween(ctx context.Context, vehicleID uint, from, to time.Time) ([]*models.Schedule, error)
+	FindBetween(ctx context.Context, from, to time.Time) ([]*models.Schedule, error)
+	FindBoard(ctx context.Context, query models.BoardQuery) (*models.Station, []*models.Schedule, error)
+	FindMatching(ctx context.Context, criteria models.ScheduleCriteria, limit int) ([]*models.Schedule, error)
+	SaveBatch(ctx context.Context, updated, created []*models.Schedule, logs []*models.ScheduleLog) error
+	FindFiltered(ctx context.Context, params models.ScheduleSearchParams, limit int) ([]*models.Schedule, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]*models.Schedule, error)
+	FindByTripKeys(ctx context.Context, keys []models.TripKey) ([]*models.Schedule, error)
+	SaveImported(ctx context.Context, created, updated []*models.Schedule) error
 }
 
 // scheduleRepository implements ScheduleRepository
@@ -25,6 +49,21 @@ type scheduleRepository struct {
 	db *gorm.DB
 }
 
+// scheduleSortable lists the fields schedules can be sorted by
+var scheduleSortable = pagination.Sortable{
+	Fields: map[string]pagination.Field{
+		"service_date":   {Column: "service_date", Type: pagination.String},
+		"departure_time": {Column: "departure_time", Type: pagination.Time},
+		"arrival_time":   {Column: "arrival_time", Type: pagination.Time},
+		"id":             {Column: "id", Type: pagination.Int},
+		"round":          {Column: "round", Type: pagination.Int},
+		"route_id":       {Column: "route_id", Type: pagination.Int},
+		"station_id":     {Column: "station_id", Type: pagination.Int},
+		"created_at":     {Column: "created_at", Type: pagination.Time},
+	},
+	Default: "departure_time",
+}
+
 // NewScheduleRepository creates a new schedule repository
 func NewScheduleRepository(db *gorm.DB) ScheduleRepository {
 	return &scheduleRepository{db}
@@ -37,6 +76,7 @@ func (r *scheduleRepository) FindByID(ctx context.Context, id uint) (*models.Sch
 		Preload("Route.StartStation").
 		Preload("Route.EndStation").
 		Preload("Vehicle").
+		Preload("Driver").
 		Preload("Station").
 		First(&schedule, id).Error; err != nil {
 		return nil, err
@@ -51,6 +91,7 @@ func (r *scheduleRepository) FindAll(ctx context.Context) ([]*models.Schedule, e
 		Preload("Route.StartStation").
 		Preload("Route.EndStation").
 		Preload("Vehicle").
+		Preload("Driver").
 		Preload("Station").
 		Find(&schedules).Error; err != nil {
 		return nil, err
@@ -58,108 +99,254 @@ func (r *scheduleRepository) FindAll(ctx context.Context) ([]*models.Schedule, e
 	return schedules, nil
 }
 
+// FindPage returns one page of schedules
+func (r *scheduleRepository) FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	query := r.db.WithContext(ctx).Model(&models.Schedule{})
+	result, err := pagination.Find[*models.Schedule](query, params, scheduleSortable,
+		"Route",
+		"Route.StartStation",
+		"Route.EndStation",
+		"Vehicle",
+		"Driver",
+		"Station",
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to list schedules: %w", err)
+	}
+	return result, nil
+}
+
 func (r *scheduleRepository) Create(ctx context.Context, schedule *models.Schedule) error {
-	return r.db.WithContext(ctx).Create(schedule).Error
+	return translateOverlap(r.db.WithContext(ctx).Create(schedule).Error)
 }
 
 func (r *scheduleRepository) Update(ctx context.Context, schedule *models.Schedule) error {
-	return r.db.WithContext(ctx).Save(schedule).Error
+	// Preloaded relations must not overwrite the foreign keys being changed, so they are not saved
+	return translateOverlap(saveVersioned(r.db.WithContext(ctx), schedule, schedule.ID, &schedule.Version))
 }
 
-func (r *scheduleRepository) Search(ctx context.Context, params models.ScheduleSearchParams) (models.PagedResult, error) {
-	var result models.PagedResult
+// FindByVehicleBetween retrieves the non-cancelled trips of a vehicle that overlap [from, to), ordered by departure
+func (r *scheduleRepository) FindByVehicleBetween(ctx context.Context, vehicleID uint, from, to time.Time) ([]*models.Schedule, error) {
 	var schedules []*models.Schedule
-	var totalCount int64
-
-	// Initialize query
-	query := r.db.WithContext(ctx).Model(&models.Schedule{})
-
-	// Apply filters
-	if params.RouteID != nil {
-		query = query.Where("route_id = ?", *params.RouteID)
-	}
-	if params.VehicleID != nil {
-		query = query.Where("vehicle_id = ?", *params.VehicleID)
-	}
-	if params.StationID != nil {
-		query = query.Where("station_id = ?", *params.StationID)
+	if err := r.db.WithContext(ctx).
+		Where("vehicle_id = ?", vehicleID).
+		Where("status IS DISTINCT FROM ?", models.ScheduleStatusCancelled).
+		Where("departure_time < ? AND arrival_time > ?", to, from).
+		Order("departure_time asc").
+		Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to find vehicle trips: %w", err)
 	}
-	if params.Status != nil && *params.Status != "" {
-		query = query.Where("status = ?", *params.Status)
+	return schedules, nil
+}
+
+// FindBetween retrieves all non-cancelled trips departing in [from, to), ordered by departure
+func (r *scheduleRepository) FindBetween(ctx context.Context, from, to time.Time) ([]*models.Schedule, error) {
+	var schedules []*models.Schedule
+	if err := r.db.WithContext(ctx).
+		Where("status IS DISTINCT FROM ?", models.ScheduleStatusCancelled).
+		Where("departure_time >= ? AND departure_time < ?", from, to).
+		Order("departure_time asc").
+		Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to find schedules: %w", err)
 	}
-	if params.Round != nil {
-		query = query.Where("round = ?", *params.Round)
+	return schedules, nil
+}
+
+// FindMatching retrieves the schedules matching criteria, ordered by departure.
+// At most limit+1 schedules are returned so callers can tell whether there are more
+func (r *scheduleRepository) FindMatching(ctx context.Context, criteria models.ScheduleCriteria, limit int) ([]*models.Schedule, error) {
+	var schedules []*models.Schedule
+	if err := applyScheduleCriteria(r.db.WithContext(ctx), criteria).
+		Order("departure_time asc").Order("id asc").
+		Limit(limit + 1).
+		Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to find matching schedules: %w", err)
 	}
+	return schedules, nil
+}
 
-	// Date range filters
-	if params.StartDateFrom != nil {
-		query = query.Where("departure_time >= ?", *params.StartDateFrom)
+// SaveBatch saves updated, creates created and records logs in one transaction, so either all of
+// them are written or none is. Updates are saved in the given order, as each must clear the exclusion
+// constraints on its own, and each must still be at the version it was read at
+func (r *scheduleRepository) SaveBatch(ctx context.Context, updated, created []*models.Schedule, logs []*models.ScheduleLog) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, schedule := range updated {
+			if err := saveVersioned(tx, schedule, schedule.ID, &schedule.Version); err != nil {
+				return err
+			}
+		}
+		for _, schedule := range created {
+			if err := tx.Omit(clause.Associations).Create(schedule).Error; err != nil {
+				return err
+			}
+		}
+		for _, entry := range logs {
+			if err := tx.Omit(clause.Associations).Create(entry).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return translateOverlap(err)
+}
+
+// FindFiltered returns the schedules matching the criteria of params in list order.
+// At most limit+1 schedules are returned so callers can tell whether there are more
+func (r *scheduleRepository) FindFiltered(ctx context.Context, params models.ScheduleSearchParams, limit int) ([]*models.Schedule, error) {
+	query, err := pagination.Order(applyScheduleCriteria(r.db.WithContext(ctx), params.ScheduleCriteria), params.SearchParams, scheduleSortable)
+	if err != nil {
+		return nil, err
 	}
-	if params.StartDateTo != nil {
-		query = query.Where("departure_time <= ?", *params.StartDateTo)
+	var schedules []*models.Schedule
+	if err := query.Limit(limit + 1).Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
 	}
+	return schedules, nil
+}
 
-	// Count total results (before pagination)
-	if err := query.Count(&totalCount).Error; err != nil {
-		return result, err
+// FindByIDs retrieves the schedules with any of ids, without their relations
+func (r *scheduleRepository) FindByIDs(ctx context.Context, ids []uint) ([]*models.Schedule, error) {
+	var schedules []*models.Schedule
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to find schedules: %w", err)
 	}
+	return schedules, nil
+}
 
-	// Set default pagination if not provided
-	if params.Page <= 0 {
-		params.Page = 1
+// FindByTripKeys retrieves the schedules running any of the trips keys identify, without their relations
+func (r *scheduleRepository) FindByTripKeys(ctx context.Context, keys []models.TripKey) ([]*models.Schedule, error) {
+	tuples := make([][]interface{}, len(keys))
+	for i, key := range keys {
+		tuples[i] = []interface{}{key.RouteID, key.StationID, key.ServiceDate, key.Round}
 	}
-	if params.PageSize <= 0 {
-		params.PageSize = 10
+	var schedules []*models.Schedule
+	if err := r.db.WithContext(ctx).
+		Where("(route_id, station_id, service_date, round) IN ?", tuples).
+		Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to find schedules: %w", err)
 	}
+	return schedules, nil
+}
 
-	// Calculate offset
-	offset := (params.Page - 1) * params.PageSize
+// SaveImported creates and updates the schedules of an import in one transaction
+func (r *scheduleRepository) SaveImported(ctx context.Context, created, updated []*models.Schedule) error {
+	return translateOverlap(saveImported(r.db.WithContext(ctx), created, updated, func(schedule *models.Schedule) (uint, *int) {
+		return schedule.ID, &schedule.Version
+	}))
+}
 
-	// Apply sorting
-	if params.SortBy != "" {
-		sortDirection := "asc"
-		if params.SortDesc {
-			sortDirection = "desc"
+// FindBoard returns the station and one page of its departure or arrival board.
+// Departures are trips leaving the station, arrivals are trips on routes ending at it.
+// At most query.Limit+1 trips are returned so callers can tell whether another page exists;
+// backward pages are returned latest first
+func (r *scheduleRepository) FindBoard(ctx context.Context, query models.BoardQuery) (*models.Station, []*models.Schedule, error) {
+	var station models.Station
+	if err := r.db.WithContext(ctx).First(&station, query.StationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, fmt.Errorf("station not found: %w", err)
 		}
-		query = query.Order(params.SortBy + " " + sortDirection)
-	} else {
-		// Default sorting by departure_time
-		query = query.Order("departure_time asc")
+		return nil, nil, fmt.Errorf("failed to find station: %w", err)
 	}
 
-	// Get paginated results with preloaded relations
-	if err := query.
-		Preload("Route").
+	timeColumn := "schedules.departure_time"
+	db := r.db.WithContext(ctx).
 		Preload("Route.StartStation").
 		Preload("Route.EndStation").
-		Preload("Vehicle").
-		Preload("Station").
-		Offset(offset).
-		Limit(params.PageSize).
-		Find(&schedules).Error; err != nil {
-		return result, err
+		Preload("Vehicle")
+	if query.Type == models.BoardArrivals {
+		timeColumn = "schedules.arrival_time"
+		db = db.Joins("JOIN routes ON routes.id = schedules.route_id").
+			Where("routes.end_station_id = ?", query.StationID)
+	} else {
+		db = db.Where("schedules.station_id = ?", query.StationID)
+	}
+
+	// Keyset pagination on (time, id) so trips sharing a time are never skipped or repeated
+	switch {
+	case query.Backward:
+		db = db.Where("("+timeColumn+", schedules.id) < (?, ?)", query.From, query.FromID).
+			Where(timeColumn+" >= ?", query.From.Add(-query.Window)).
+			Order(timeColumn + " desc").Order("schedules.id desc")
+	case query.Inclusive:
+		db = db.Where(timeColumn+" >= ?", query.From).
+			Where(timeColumn+" < ?", query.From.Add(query.Window)).
+			Order(timeColumn + " asc").Order("schedules.id asc")
+	default:
+		db = db.Where("("+timeColumn+", schedules.id) > (?, ?)", query.From, query.FromID).
+			Where(timeColumn+" < ?", query.From.Add(query.Window)).
+			Order(timeColumn + " asc").Order("schedules.id asc")
+	}
+
+	var schedules []*models.Schedule
+	if err := db.Limit(query.Limit + 1).Find(&schedules).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to find station board: %w", err)
 	}
+	return &station, schedules, nil
+}
 
-	// Calculate total pages
-	totalPages := int(totalCount) / params.PageSize
-	if int(totalCount)%params.PageSize > 0 {
-		totalPages++
+// translateOverlap maps exclusion constraint violations to ErrScheduleOverlap
+func translateOverlap(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == exclusionViolation {
+		return fmt.Errorf("%w: %s", ErrScheduleOverlap, pgErr.ConstraintName)
 	}
+	return err
+}
 
-	// Prepare result
-	result = models.PagedResult{
-		TotalCount: totalCount,
-		TotalPages: totalPages,
-		Page:       params.Page,
-		PageSize:   params.PageSize,
-		Data:       schedules,
+// applyScheduleCriteria narrows query to the schedules matching criteria
+func applyScheduleCriteria(query *gorm.DB, criteria models.ScheduleCriteria) *gorm.DB {
+	if criteria.RouteID != nil {
+		query = query.Where("route_id = ?", *criteria.RouteID)
+	}
+	if criteria.VehicleID != nil {
+		query = query.Where("vehicle_id = ?", *criteria.VehicleID)
+	}
+	if criteria.StationID != nil {
+		query = query.Where("station_id = ?", *criteria.StationID)
+	}
+	if criteria.Status != nil && *criteria.Status != "" {
+		query = query.Where("status = ?", *criteria.Status)
+	}
+	if criteria.Round != nil {
+		query = query.Where("round = ?", *criteria.Round)
 	}
 
+	// Date range filters
+	if criteria.StartDateFrom != nil {
+		query = query.Where("departure_time >= ?", *criteria.StartDateFrom)
+	}
+	if criteria.StartDateTo != nil {
+		query = query.Where("departure_time <= ?", *criteria.StartDateTo)
+	}
+	if criteria.ServiceDate != nil {
+		query = query.Where("service_date = ?", *criteria.ServiceDate)
+	}
+	return query
+}
+
+func (r *scheduleRepository) Search(ctx context.Context, params models.ScheduleSearchParams) (models.PagedResult, error) {
+	// Initialize query
+	query := r.db.WithContext(ctx).Model(&models.Schedule{})
+
+	query = applyScheduleCriteria(query, params.ScheduleCriteria)
+
+	// Sorting is restricted to scheduleSortable, so sort_by never reaches the SQL unchecked
+	result, err := pagination.Find[*models.Schedule](query, params.SearchParams, scheduleSortable,
+		"Route",
+		"Route.StartStation",
+		"Route.EndStation",
+		"Vehicle",
+		"Driver",
+		"Station",
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to search schedules: %w", err)
+	}
 	return result, nil
 }
 
-func (r *scheduleRepository) Delete(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&models.Schedule{}, id).Error
+func (r *scheduleRepository) Delete(ctx context.Context, id uint, version int) error {
+	return deleteVersioned(r.db.WithContext(ctx), &models.Schedule{}, id, version)
 }
 
 // FindSchedulesByStation returns both inbound and outbound schedules for a specific station
@@ -230,9 +417,6 @@ func (r *scheduleRepository) FindSchedulesByStation(ctx context.Context, station
 	response.OutboundSchedules = outboundSchedules
 	response.InboundSchedules = inboundSchedules
 
-	// Add station details (example description)
-	response.StationDetails = "สถานีขนส่งผู้โดยสารสถานี" + station.Name + " ให้บริการเดินรถระหว่างเมือง ตั้งอยู่ที่ " + station.Location
-
 	return response, nil
 }
 
@@ -247,6 +431,7 @@ func (r *scheduleRepository) FindSimpleSchedulesByStation(ctx context.Context, s
 		return nil, err
 	}
 	response.StationName = station.Name
+	response.Station = station
 	
 	// Get outbound schedules (from this station to others)
 	var outboundSchedules []*models.Schedule
@@ -265,8 +450,11 @@ func (r *scheduleRepository) FindSimpleSchedulesByStation(ctx context.Context, s
 	outboundTimes := make([]models.SimpleScheduleInfo, 0, len(outboundSchedules))
 	for _, schedule := range outboundSchedules {
 		outboundTimes = append(outboundTimes, models.SimpleScheduleInfo{
-			DepartureTime: schedule.DepartureTime.Format("15:04"),
-			Destination:   schedule.Route.EndStation.Name,
+			DepartureTime:      schedule.DepartureLocal,
+			Destination:        schedule.Route.EndStation.Name,
+			DepartureAt:        schedule.DepartureTime,
+			ServiceDate:        schedule.ServiceDate,
+			DestinationStation: schedule.Route.EndStation,
 		})
 	}
 	response.OutboundTimes = outboundTimes
@@ -296,14 +484,16 @@ func (r *scheduleRepository) FindSimpleSchedulesByStation(ctx context.Context, s
 	inboundTimes := make([]models.SimpleScheduleInfo, 0, len(inboundSchedules))
 	for _, schedule := range inboundSchedules {
 		inboundTimes = append(inboundTimes, models.SimpleScheduleInfo{
-			DepartureTime: schedule.DepartureTime.Format("15:04"),
-			Destination:   schedule.Route.StartStation.Name,
+			DepartureTime:      schedule.DepartureLocal,
+			Destination:        schedule.Route.StartStation.Name,
+			Origin:             schedule.Route.StartStation.Name,
+			DepartureAt:        schedule.DepartureTime,
+			ServiceDate:        schedule.ServiceDate,
+			DestinationStation: schedule.Route.StartStation,
+			OriginStation:      &schedule.Route.StartStation,
 		})
 	}
 	response.InboundTimes = inboundTimes
 	
-	// Add station details
-	response.StationDetails = "สถานีขนส่งผู้โดยสารสถานี" + station.Name + " ให้บริการเดินรถระหว่างเมือง ตั้งอยู่ที่ " + station.Location
-	
 	return response, nil
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"rota-api/filter"
 	"rota-api/models"
+	"rota-api/pagination"
 
 	"gorm.io/gorm"
 )
@@ -16,9 +18,14 @@ type StaffRepository interface {
 	FindByEmail(ctx context.Context, email string) (*models.Staff, error)
 	FindByUsername(ctx context.Context, username string) (*models.Staff, error)
 	FindAll(ctx context.Context) ([]*models.Staff, error)
+	FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
+	FindFiltered(ctx context.Context, params models.SearchParams, limit int) ([]*models.Staff, error)
+	FindByEmails(ctx context.Context, emails []string) ([]*models.Staff, error)
+	FindByUsernames(ctx context.Context, usernames []string) ([]*models.Staff, error)
+	SaveImported(ctx context.Context, created, updated []*models.Staff) error
 	FindByStation(ctx context.Context, stationID uint) ([]models.Staff, error)
 	Update(ctx context.Context, staff *models.Staff) error
-	Delete(ctx context.Context, id uint) error
+	Delete(ctx context.Context, id uint, version int) error
 }
 
 // staffRepository implements StaffRepository
@@ -26,6 +33,28 @@ type staffRepository struct {
 	db *gorm.DB
 }
 
+// staffSortable lists the fields staff can be sorted by
+var staffSortable = pagination.Sortable{
+	Fields: map[string]pagination.Field{
+		"id":         {Column: "id", Type: pagination.Int},
+		"name":       {Column: "name", Type: pagination.String},
+		"created_at": {Column: "created_at", Type: pagination.Time},
+	},
+	Default: "id",
+}
+
+// staffFilterable lists the fields staff can be filtered on
+var staffFilterable = filter.Fields{
+	"id":         {Column: "id", Type: filter.Int},
+	"username":   {Column: "username", Type: filter.String},
+	"email":      {Column: "email", Type: filter.String},
+	"name":       {Column: "name", Type: filter.String},
+	"position":   {Column: "position", Type: filter.String},
+	"phone":      {Column: "phone", Type: filter.String},
+	"station_id": {Column: "station_id", Type: filter.Int},
+	"created_at": {Column: "created_at", Type: filter.Time},
+}
+
 // NewStaffRepository creates a new staff repository
 func NewStaffRepository(db *gorm.DB) StaffRepository {
 	return &staffRepository{db}
@@ -84,6 +113,69 @@ func (r *staffRepository) FindAll(ctx context.Context) ([]*models.Staff, error)
 	return staff, nil
 }
 
+// FindPage returns one page of staff
+func (r *staffRepository) FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	query := r.db.WithContext(ctx).Model(&models.Staff{})
+	query, err := filter.Apply(query, params.Filter, staffFilterable)
+	if err != nil {
+		return models.PagedResult{}, err
+	}
+	result, err := pagination.Find[*models.Staff](query, params, staffSortable,
+		"Station",
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to list staff: %w", err)
+	}
+	return result, nil
+}
+
+// FindFiltered returns the staff matching the filter of params in list order.
+// At most limit+1 staff are returned so callers can tell whether there are more
+func (r *staffRepository) FindFiltered(ctx context.Context, params models.SearchParams, limit int) ([]*models.Staff, error) {
+	query, err := filter.Apply(r.db.WithContext(ctx).Model(&models.Staff{}), params.Filter, staffFilterable)
+	if err != nil {
+		return nil, err
+	}
+	query, err = pagination.Order(query, params, staffSortable)
+	if err != nil {
+		return nil, err
+	}
+	var staff []*models.Staff
+	if err := query.Limit(limit + 1).Find(&staff).Error; err != nil {
+		return nil, fmt.Errorf("failed to list staff: %w", err)
+	}
+	return staff, nil
+}
+
+// FindByEmails retrieves the staff with any of emails
+func (r *staffRepository) FindByEmails(ctx context.Context, emails []string) ([]*models.Staff, error) {
+	var staff []*models.Staff
+	if err := r.db.WithContext(ctx).Where("email IN ?", emails).Find(&staff).Error; err != nil {
+		return nil, fmt.Errorf("failed to find staff: %w", err)
+	}
+	return staff, nil
+}
+
+// FindByUsernames retrieves the staff with any of usernames
+func (r *staffRepository) FindByUsernames(ctx context.Context, usernames []string) ([]*models.Staff, error) {
+	var staff []*models.Staff
+	if err := r.db.WithContext(ctx).Where("username IN ?", usernames).Find(&staff).Error; err != nil {
+		return nil, fmt.Errorf("failed to find staff: %w", err)
+	}
+	return staff, nil
+}
+
+// SaveImported creates and updates the staff of an import in one transaction
+func (r *staffRepository) SaveImported(ctx context.Context, created, updated []*models.Staff) error {
+	err := saveImported(r.db.WithContext(ctx), created, updated, func(staff *models.Staff) (uint, *int) {
+		return staff.ID, &staff.Version
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import staff: %w", err)
+	}
+	return nil
+}
+
 // FindByStation retrieves all staff for a specific station
 func (r *staffRepository) FindByStation(ctx context.Context, stationID uint) ([]models.Staff, error) {
 	var staff []models.Staff
@@ -95,15 +187,15 @@ func (r *staffRepository) FindByStation(ctx context.Context, stationID uint) ([]
 
 // Update updates a staff
 func (r *staffRepository) Update(ctx context.Context, staff *models.Staff) error {
-	if err := r.db.WithContext(ctx).Save(staff).Error; err != nil {
+	if err := saveVersioned(r.db.WithContext(ctx), staff, staff.ID, &staff.Version); err != nil {
 		return fmt.Errorf("failed to update staff: %w", err)
 	}
 	return nil
 }
 
 // Delete removes a staff
-func (r *staffRepository) Delete(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&models.Staff{}, id).Error; err != nil {
+func (r *staffRepository) Delete(ctx context.Context, id uint, version int) error {
+	if err := deleteVersioned(r.db.WithContext(ctx), &models.Staff{}, id, version); err != nil {
 		return fmt.Errorf("failed to delete staff: %w", err)
 	}
 	return nil
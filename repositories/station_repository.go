@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"rota-api/filter"
 	"rota-api/models"
+	"rota-api/pagination"
 
 	"gorm.io/gorm"
 )
@@ -14,8 +17,16 @@ type StationRepository interface {
 	Create(ctx context.Context, station *models.Station) error
 	FindByID(ctx context.Context, id uint) (*models.Station, error)
 	FindAll(ctx context.Context) ([]*models.Station, error)
+	FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
+	FindFiltered(ctx context.Context, params models.SearchParams, limit int) ([]*models.Station, error)
+	FindByNames(ctx context.Context, names []string) ([]*models.Station, error)
+	SaveImported(ctx context.Context, created, updated []*models.Station) error
+	FindByIDs(ctx context.Context, ids []uint) ([]*models.Station, error)
+	Search(ctx context.Context, terms models.StationSearchTerms) ([]models.StationMatch, error)
+	Suggest(ctx context.Context, terms models.StationSearchTerms) ([]*models.Station, error)
+	RefreshSearchKeys(ctx context.Context) (int, error)
 	Update(ctx context.Context, station *models.Station) error
-	Delete(ctx context.Context, id uint) error
+	Delete(ctx context.Context, id uint, version int) error
 }
 
 // stationRepository implements StationRepository
@@ -23,6 +34,25 @@ type stationRepository struct {
 	db *gorm.DB
 }
 
+// stationSortable lists the fields stations can be sorted by
+var stationSortable = pagination.Sortable{
+	Fields: map[string]pagination.Field{
+		"id":       {Column: "id", Type: pagination.Int},
+		"name":     {Column: "name", Type: pagination.String},
+		"name_en":  {Column: "name_en", Type: pagination.String},
+		"location": {Column: "location", Type: pagination.String},
+	},
+	Default: "id",
+}
+
+// stationFilterable lists the fields stations can be filtered on
+var stationFilterable = filter.Fields{
+	"id":       {Column: "id", Type: filter.Int},
+	"name":     {Column: "name", Type: filter.String},
+	"name_en":  {Column: "name_en", Type: filter.String},
+	"location": {Column: "location", Type: filter.String},
+}
+
 // NewStationRepository creates a new station repository
 func NewStationRepository(db *gorm.DB) StationRepository {
 	return &stationRepository{db}
@@ -69,18 +99,194 @@ func (r *stationRepository) FindAll(ctx context.Context) ([]*models.Station, err
 	return stations, nil
 }
 
+// FindPage returns one page of stations
+func (r *stationRepository) FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	query := r.db.WithContext(ctx).Model(&models.Station{})
+	query, err := filter.Apply(query, params.Filter, stationFilterable)
+	if err != nil {
+		return models.PagedResult{}, err
+	}
+	result, err := pagination.Find[*models.Station](query, params, stationSortable,
+		"Schedules.Route.StartStation",
+		"Schedules.Route.EndStation",
+		"Schedules.Vehicle",
+		"Schedules.Station",
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to list stations: %w", err)
+	}
+	return result, nil
+}
+
+// FindFiltered returns the stations matching the filter of params in list order, without their
+// schedules. At most limit+1 stations are returned so callers can tell whether there are more
+func (r *stationRepository) FindFiltered(ctx context.Context, params models.SearchParams, limit int) ([]*models.Station, error) {
+	query, err := filter.Apply(r.db.WithContext(ctx).Model(&models.Station{}), params.Filter, stationFilterable)
+	if err != nil {
+		return nil, err
+	}
+	query, err = pagination.Order(query, params, stationSortable)
+	if err != nil {
+		return nil, err
+	}
+	var stations []*models.Station
+	if err := query.Limit(limit + 1).Find(&stations).Error; err != nil {
+		return nil, fmt.Errorf("failed to list stations: %w", err)
+	}
+	return stations, nil
+}
+
+// FindByNames retrieves the stations with any of names, without their schedules
+func (r *stationRepository) FindByNames(ctx context.Context, names []string) ([]*models.Station, error) {
+	var stations []*models.Station
+	if err := r.db.WithContext(ctx).Where("name IN ?", names).Find(&stations).Error; err != nil {
+		return nil, fmt.Errorf("failed to find stations: %w", err)
+	}
+	return stations, nil
+}
+
+// SaveImported creates and updates the stations of an import in one transaction
+func (r *stationRepository) SaveImported(ctx context.Context, created, updated []*models.Station) error {
+	err := saveImported(r.db.WithContext(ctx), created, updated, func(station *models.Station) (uint, *int) {
+		return station.ID, &station.Version
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import stations: %w", err)
+	}
+	return nil
+}
+
+// FindByIDs retrieves stations by ID without their schedules
+func (r *stationRepository) FindByIDs(ctx context.Context, ids []uint) ([]*models.Station, error) {
+	var stations []*models.Station
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&stations).Error; err != nil {
+		return nil, fmt.Errorf("failed to find stations: %w", err)
+	}
+	return stations, nil
+}
+
 // Update updates a station
 func (r *stationRepository) Update(ctx context.Context, station *models.Station) error {
-	if err := r.db.WithContext(ctx).Save(station).Error; err != nil {
+	if err := saveVersioned(r.db.WithContext(ctx), station, station.ID, &station.Version); err != nil {
 		return fmt.Errorf("failed to update station: %w", err)
 	}
 	return nil
 }
 
 // Delete removes a station
-func (r *stationRepository) Delete(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&models.Station{}, id).Error; err != nil {
+func (r *stationRepository) Delete(ctx context.Context, id uint, version int) error {
+	if err := deleteVersioned(r.db.WithContext(ctx), &models.Station{}, id, version); err != nil {
 		return fmt.Errorf("failed to delete station: %w", err)
 	}
 	return nil
 }
+
+// stationSearchSQL scores stations against a query. Romanized keys are compared with pg_trgm so
+// typos and Thai/English spellings match; the original names are also matched as typed
+const stationSearchSQL = `
+SELECT stations.*, GREATEST(
+		word_similarity(@compact, search_name),
+		word_similarity(@words, search_name),
+		similarity(@compact, search_name),
+		word_similarity(@compact, search_location) * 0.6,
+		CASE WHEN name ILIKE @contains OR name_en ILIKE @contains THEN 1 ELSE 0 END,
+		CASE WHEN location ILIKE @contains THEN 0.6 ELSE 0 END
+	) AS score
+FROM stations
+WHERE search_name % @compact
+	OR @compact <% search_name
+	OR @words <% search_name
+	OR @compact <% search_location
+	OR name ILIKE @contains
+	OR name_en ILIKE @contains
+	OR location ILIKE @contains
+ORDER BY score DESC, id
+LIMIT @limit`
+
+// stationSuggestSQL ranks name prefixes first, then names containing the query, then near misses
+const stationSuggestSQL = `
+SELECT stations.* FROM stations
+WHERE name ILIKE @contains
+	OR name_en ILIKE @contains
+	OR (@compact <> '' AND (search_name LIKE @keyPrefix OR search_name LIKE @keyWordPrefix))
+	OR @compact <% search_name
+ORDER BY
+	CASE
+		WHEN name ILIKE @prefix OR name_en ILIKE @prefix OR (@compact <> '' AND search_name LIKE @keyPrefix) THEN 2
+		WHEN name ILIKE @contains OR name_en ILIKE @contains OR search_name LIKE @keyWordPrefix THEN 1
+		ELSE 0
+	END DESC,
+	word_similarity(@compact, search_name) DESC,
+	name
+LIMIT @limit`
+
+// Search finds stations by name, English name or location, best match first
+func (r *stationRepository) Search(ctx context.Context, terms models.StationSearchTerms) ([]models.StationMatch, error) {
+	var rows []struct {
+		models.Station
+		Score float64
+	}
+	err := r.db.WithContext(ctx).Raw(stationSearchSQL, map[string]interface{}{
+		"words":    terms.Words,
+		"compact":  terms.Compact,
+		"contains": "%" + escapeLike(terms.Text) + "%",
+		"limit":    terms.Limit,
+	}).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search stations: %w", err)
+	}
+
+	matches := make([]models.StationMatch, 0, len(rows))
+	for i := range rows {
+		station := rows[i].Station
+		matches = append(matches, models.StationMatch{Station: &station, Score: rows[i].Score})
+	}
+	return matches, nil
+}
+
+// Suggest returns stations whose names start with or contain the query, for autocomplete
+func (r *stationRepository) Suggest(ctx context.Context, terms models.StationSearchTerms) ([]*models.Station, error) {
+	var stations []*models.Station
+	err := r.db.WithContext(ctx).Raw(stationSuggestSQL, map[string]interface{}{
+		"compact":       terms.Compact,
+		"prefix":        escapeLike(terms.Text) + "%",
+		"contains":      "%" + escapeLike(terms.Text) + "%",
+		"keyPrefix":     escapeLike(terms.Compact) + "%",
+		"keyWordPrefix": "% " + escapeLike(terms.Compact) + "%",
+		"limit":         terms.Limit,
+	}).Scan(&stations).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest stations: %w", err)
+	}
+	return stations, nil
+}
+
+// RefreshSearchKeys recomputes the search keys of stations saved before they existed
+// or before the romanization changed. It returns how many stations were updated
+func (r *stationRepository) RefreshSearchKeys(ctx context.Context) (int, error) {
+	var stations []*models.Station
+	if err := r.db.WithContext(ctx).Find(&stations).Error; err != nil {
+		return 0, fmt.Errorf("failed to find stations: %w", err)
+	}
+
+	updated := 0
+	for _, station := range stations {
+		if !station.RefreshSearchKeys() {
+			continue
+		}
+		err := r.db.WithContext(ctx).Model(station).UpdateColumns(map[string]interface{}{
+			"search_name":     station.SearchName,
+			"search_location": station.SearchLocation,
+		}).Error
+		if err != nil {
+			return updated, fmt.Errorf("failed to update search keys of station %d: %w", station.ID, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
@@ -0,0 +1,187 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"rota-api/filter"
+	"rota-api/models"
+	"rota-api/pagination"
+
+	"gorm.io/gorm"
+)
+
+// TripRepository defines the interface for trip history database operations
+type TripRepository interface {
+	FindByID(ctx context.Context, id uint) (*models.Trip, error)
+	FindByUserAndSchedule(ctx context.Context, userID, scheduleID uint) (*models.Trip, error)
+	FindPageByUser(ctx context.Context, userID uint, params models.SearchParams) (models.PagedResult, error)
+	FindByUser(ctx context.Context, userID uint) ([]models.Trip, error)
+	Create(ctx context.Context, trip *models.Trip) error
+	Delete(ctx context.Context, id uint) error
+	DeleteByUser(ctx context.Context, userID uint) (int64, error)
+	Totals(ctx context.Context, userID uint, from, to *time.Time) (*models.TripStats, error)
+	TopStations(ctx context.Context, userID uint, from, to *time.Time, limit int) ([]models.StationUsage, error)
+}
+
+// tripRepository implements TripRepository
+type tripRepository struct {
+	db *gorm.DB
+}
+
+// tripSortable lists the fields trips can be sorted by
+var tripSortable = pagination.Sortable{
+	Fields: map[string]pagination.Field{
+		"id":           {Column: "id", Type: pagination.Int},
+		"travelled_at": {Column: "travelled_at", Type: pagination.Time},
+		"distance":     {Column: "distance", Type: pagination.Float},
+	},
+	Default:     "travelled_at",
+	DefaultDesc: true,
+}
+
+// tripFilterable lists the fields trips can be filtered on
+var tripFilterable = filter.Fields{
+	"route_id":     {Column: "route_id", Type: filter.Int},
+	"schedule_id":  {Column: "schedule_id", Type: filter.Int},
+	"travelled_at": {Column: "travelled_at", Type: filter.Time},
+	"distance":     {Column: "distance", Type: filter.Float},
+	"source":       {Column: "source", Type: filter.String},
+}
+
+// NewTripRepository creates a new trip repository
+func NewTripRepository(db *gorm.DB) TripRepository {
+	return &tripRepository{db}
+}
+
+func (r *tripRepository) FindByID(ctx context.Context, id uint) (*models.Trip, error) {
+	var trip models.Trip
+	if err := r.db.WithContext(ctx).
+		Preload("Route").
+		Preload("Route.StartStation").
+		Preload("Route.EndStation").
+		First(&trip, id).Error; err != nil {
+		return nil, err
+	}
+	return &trip, nil
+}
+
+func (r *tripRepository) FindByUserAndSchedule(ctx context.Context, userID, scheduleID uint) (*models.Trip, error) {
+	var trip models.Trip
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND schedule_id = ?", userID, scheduleID).First(&trip).Error; err != nil {
+		return nil, err
+	}
+	return &trip, nil
+}
+
+// FindPageByUser returns one page of a user's trips, latest first unless sorted otherwise
+func (r *tripRepository) FindPageByUser(ctx context.Context, userID uint, params models.SearchParams) (models.PagedResult, error) {
+	query := r.db.WithContext(ctx).Model(&models.Trip{}).Where("user_id = ?", userID)
+	query, err := filter.Apply(query, params.Filter, tripFilterable)
+	if err != nil {
+		return models.PagedResult{}, err
+	}
+	result, err := pagination.Find[models.Trip](query, params, tripSortable,
+		"Route",
+		"Route.StartStation",
+		"Route.EndStation",
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to list trips: %w", err)
+	}
+	return result, nil
+}
+
+// FindByUser returns all of a user's trips, oldest first
+func (r *tripRepository) FindByUser(ctx context.Context, userID uint) ([]models.Trip, error) {
+	var trips []models.Trip
+	if err := r.db.WithContext(ctx).
+		Preload("Route").
+		Preload("Route.StartStation").
+		Preload("Route.EndStation").
+		Where("user_id = ?", userID).
+		Order("travelled_at, id").
+		Find(&trips).Error; err != nil {
+		return nil, fmt.Errorf("failed to find trips: %w", err)
+	}
+	return trips, nil
+}
+
+func (r *tripRepository) Create(ctx context.Context, trip *models.Trip) error {
+	return r.db.WithContext(ctx).Omit("Route").Create(trip).Error
+}
+
+func (r *tripRepository) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&models.Trip{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteByUser erases a user's whole travel history and returns how many trips it held
+func (r *tripRepository) DeleteByUser(ctx context.Context, userID uint) (int64, error) {
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.Trip{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete trips: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Totals counts a user's trips travelled between from and to, either of which may be open,
+// with their total distance and the times of the first and last
+func (r *tripRepository) Totals(ctx context.Context, userID uint, from, to *time.Time) (*models.TripStats, error) {
+	var totals struct {
+		Trips         int
+		TotalDistance float64
+		FirstTrip     *time.Time
+		LastTrip      *time.Time
+	}
+	if err := userTrips(r.db.WithContext(ctx), userID, from, to).
+		Select("COUNT(*) AS trips, COALESCE(SUM(distance), 0) AS total_distance, MIN(travelled_at) AS first_trip, MAX(travelled_at) AS last_trip").
+		Scan(&totals).Error; err != nil {
+		return nil, fmt.Errorf("failed to total trips: %w", err)
+	}
+	return &models.TripStats{
+		Trips:         totals.Trips,
+		TotalDistance: totals.TotalDistance,
+		FirstTrip:     totals.FirstTrip,
+		LastTrip:      totals.LastTrip,
+	}, nil
+}
+
+// TopStations returns the stations a user's trips between from and to most often started or ended at
+func (r *tripRepository) TopStations(ctx context.Context, userID uint, from, to *time.Time, limit int) ([]models.StationUsage, error) {
+	endpoints := userTrips(r.db.WithContext(ctx), userID, from, to).
+		Select("routes.start_station_id AS station_id, routes.end_station_id AS end_station_id").
+		Joins("JOIN routes ON routes.id = trips.route_id")
+
+	var usage []models.StationUsage
+	if err := r.db.WithContext(ctx).
+		Raw(`SELECT stations.id AS station_id, stations.name, COUNT(*) AS trips
+			FROM (SELECT station_id FROM (?) AS starts UNION ALL SELECT end_station_id FROM (?) AS ends) AS visits
+			JOIN stations ON stations.id = visits.station_id
+			GROUP BY stations.id, stations.name
+			ORDER BY trips DESC, stations.id
+			LIMIT ?`, endpoints, endpoints, limit).
+		Scan(&usage).Error; err != nil {
+		return nil, fmt.Errorf("failed to rank trip stations: %w", err)
+	}
+	return usage, nil
+}
+
+// userTrips selects a user's trips travelled between from and to, either of which may be open
+func userTrips(db *gorm.DB, userID uint, from, to *time.Time) *gorm.DB {
+	query := db.Model(&models.Trip{}).Where("trips.user_id = ?", userID)
+	if from != nil {
+		query = query.Where("trips.travelled_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("trips.travelled_at < ?", *to)
+	}
+	return query
+}
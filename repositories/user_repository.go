@@ -3,8 +3,10 @@ package repositories
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"rota-api/models"
+	"rota-api/pagination"
 
 	"gorm.io/gorm"
 )
@@ -16,9 +18,14 @@ type UserRepository interface {
 	FindByUsername(ctx context.Context, username string) (*models.User, error)
 	FindByRefreshToken(ctx context.Context, refreshToken string) (*models.User, error)
 	FindAll(ctx context.Context) ([]*models.User, error)
+	FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
 	Create(ctx context.Context, user *models.User) error
 	Update(ctx context.Context, user *models.User) error
-	Delete(ctx context.Context, id string) error
+	UpdateColumns(ctx context.Context, id int, columns map[string]interface{}) error
+	Delete(ctx context.Context, id string, version int) error
+	IncrementFailedLogins(ctx context.Context, id int) (int, error)
+	LockAccount(ctx context.Context, id int, until time.Time) error
+	RequirePasswordReset(ctx context.Context, id int, stored, hashed string) (bool, error)
 }
 
 // userRepository implements UserRepository
@@ -26,6 +33,16 @@ type userRepository struct {
 	db *gorm.DB
 }
 
+// userSortable lists the fields users can be sorted by
+var userSortable = pagination.Sortable{
+	Fields: map[string]pagination.Field{
+		"id":         {Column: "id", Type: pagination.Int},
+		"email":      {Column: "email", Type: pagination.String},
+		"created_at": {Column: "created_at", Type: pagination.Time},
+	},
+	Default: "id",
+}
+
 // NewUserRepository creates a new user repository
 func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
@@ -71,6 +88,16 @@ func (r *userRepository) FindAll(ctx context.Context) ([]*models.User, error) {
 	return users, nil
 }
 
+// FindPage returns one page of users
+func (r *userRepository) FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	query := r.db.WithContext(ctx).Model(&models.User{})
+	result, err := pagination.Find[*models.User](query, params, userSortable)
+	if err != nil {
+		return result, fmt.Errorf("failed to list users: %w", err)
+	}
+	return result, nil
+}
+
 func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
@@ -79,15 +106,120 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 }
 
 func (r *userRepository) Update(ctx context.Context, user *models.User) error {
-	if err := r.db.WithContext(ctx).Save(user).Error; err != nil {
+	if err := saveVersioned(r.db.WithContext(ctx), user, user.ID, &user.Version); err != nil {
 		return fmt.Errorf("failed to update user: %w", err)
 	}
 	return nil
 }
 
-func (r *userRepository) Delete(ctx context.Context, id string) error {
-	if err := r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.User{}).Error; err != nil {
+// UpdateColumns writes only the given columns of a user, whatever version is stored. It is for the
+// service's own bookkeeping, such as login counters, MFA state and rehashed passwords, which must
+// neither overwrite concurrent changes to other columns nor fail because of them
+func (r *userRepository) UpdateColumns(ctx context.Context, id int, columns map[string]interface{}) error {
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(columns).Error; err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// Delete soft-deletes a user and erases their personal data in one transaction: the account is
+// anonymized so the email can be registered again, and the user's favorites, trips and MFA recovery
+// codes are removed. Schedule logs must keep an author, so the staff record the user acted through,
+// matched by email, is pseudonymized and soft-deleted instead
+func (r *userRepository) Delete(ctx context.Context, id string, version int) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Select("email").Where("id = ?", id).First(&user).Error; err != nil {
+			return err
+		}
+
+		query := tx.Model(&models.User{}).Where("id = ?", id)
+		if version != 0 {
+			query = query.Where("version = ?", version)
+		}
+		result := query.Updates(map[string]interface{}{
+			"email":           fmt.Sprintf("deleted-%s@users.invalid", id),
+			"username":        nil,
+			"password":        nil,
+			"provider_id":     nil,
+			"profile_picture": nil,
+			"is_verified":     false,
+			"mfa_enabled":     false,
+			"mfa_secret":      nil,
+			"refresh_token":   nil,
+			"deleted_at":      time.Now(),
+			"version":         gorm.Expr("version + 1"),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return versionMismatch(tx, &models.User{}, id)
+		}
+
+		if err := tx.Where("user_id = ?", id).Delete(&models.Favorite{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&models.Staff{}).Where("email = ?", user.Email).Updates(map[string]interface{}{
+			"username":   gorm.Expr("'deleted-staff-' || id"),
+			"email":      gorm.Expr("'deleted-staff-' || id || '@staff.invalid'"),
+			"name":       "Deleted user",
+			"password":   "",
+			"phone":      "",
+			"deleted_at": time.Now(),
+			"version":    gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", id).Delete(&models.Trip{}).Error
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 	return nil
 }
+
+// IncrementFailedLogins atomically counts a failed login and returns the new count
+func (r *userRepository) IncrementFailedLogins(ctx context.Context, id int) (int, error) {
+	var attempts int
+	err := r.db.WithContext(ctx).
+		Raw("UPDATE users SET failed_login_attempts = failed_login_attempts + 1 WHERE id = ? RETURNING failed_login_attempts", id).
+		Scan(&attempts).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to record failed login: %w", err)
+	}
+	return attempts, nil
+}
+
+// LockAccount locks a user until the given time, starting a new failure count and
+// escalating the lockout count used for the next lock duration
+func (r *userRepository) LockAccount(ctx context.Context, id int, until time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"failed_login_attempts": 0,
+		"lockout_count":         gorm.Expr("lockout_count + 1"),
+		"locked_until":          until,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to lock account: %w", err)
+	}
+	return nil
+}
+
+// RequirePasswordReset replaces a user's stored password with its hash and makes the user choose a new
+// one before logging in again. It reports false, changing nothing, when the stored password is no longer
+// the one given, because the user logged in or changed it meanwhile
+func (r *userRepository) RequirePasswordReset(ctx context.Context, id int, stored, hashed string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ? AND password = ?", id, stored).Updates(map[string]interface{}{
+		"password":                hashed,
+		"password_reset_required": true,
+		"version":                 gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to require password reset: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
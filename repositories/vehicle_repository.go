@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"rota-api/filter"
 	"rota-api/models"
+	"rota-api/pagination"
 
 	"gorm.io/gorm"
 )
@@ -15,9 +17,14 @@ type VehicleRepository interface {
 	FindByID(ctx context.Context, id uint) (*models.Vehicle, error)
 	FindByLicensePlate(ctx context.Context, licensePlate string) (*models.Vehicle, error)
 	FindAll(ctx context.Context) ([]*models.Vehicle, error)
+	FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
+	FindFiltered(ctx context.Context, params models.SearchParams, limit int) ([]*models.Vehicle, error)
+	FindByLicensePlates(ctx context.Context, licensePlates []string) ([]*models.Vehicle, error)
+	SaveImported(ctx context.Context, created, updated []*models.Vehicle) error
 	FindByRoute(ctx context.Context, routeID uint) ([]models.Vehicle, error)
 	Update(ctx context.Context, vehicle *models.Vehicle) error
-	Delete(ctx context.Context, id uint) error
+	UpdateOdometer(ctx context.Context, id uint, odometer int) error
+	Delete(ctx context.Context, id uint, version int) error
 }
 
 // vehicleRepository implements VehicleRepository
@@ -25,6 +32,26 @@ type vehicleRepository struct {
 	db *gorm.DB
 }
 
+// vehicleSortable lists the fields vehicles can be sorted by
+var vehicleSortable = pagination.Sortable{
+	Fields: map[string]pagination.Field{
+		"id":            {Column: "id", Type: pagination.Int},
+		"license_plate": {Column: "license_plate", Type: pagination.String},
+		"capacity":      {Column: "capacity", Type: pagination.Int},
+	},
+	Default: "id",
+}
+
+// vehicleFilterable lists the fields vehicles can be filtered on
+var vehicleFilterable = filter.Fields{
+	"id":            {Column: "id", Type: filter.Int},
+	"license_plate": {Column: "license_plate", Type: filter.String},
+	"capacity":      {Column: "capacity", Type: filter.Int},
+	"driver_name":   {Column: "driver_name", Type: filter.String},
+	"route_id":      {Column: "route_id", Type: filter.Int},
+	"odometer":      {Column: "odometer", Type: filter.Int},
+}
+
 // NewVehicleRepository creates a new vehicle repository
 func NewVehicleRepository(db *gorm.DB) VehicleRepository {
 	return &vehicleRepository{db}
@@ -71,6 +98,60 @@ func (r *vehicleRepository) FindAll(ctx context.Context) ([]*models.Vehicle, err
 	return vehicles, nil
 }
 
+// FindPage returns one page of vehicles
+func (r *vehicleRepository) FindPage(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	query := r.db.WithContext(ctx).Model(&models.Vehicle{})
+	query, err := filter.Apply(query, params.Filter, vehicleFilterable)
+	if err != nil {
+		return models.PagedResult{}, err
+	}
+	result, err := pagination.Find[*models.Vehicle](query, params, vehicleSortable,
+		"Route",
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to list vehicles: %w", err)
+	}
+	return result, nil
+}
+
+// FindFiltered returns the vehicles matching the filter of params in list order.
+// At most limit+1 vehicles are returned so callers can tell whether there are more
+func (r *vehicleRepository) FindFiltered(ctx context.Context, params models.SearchParams, limit int) ([]*models.Vehicle, error) {
+	query, err := filter.Apply(r.db.WithContext(ctx).Model(&models.Vehicle{}), params.Filter, vehicleFilterable)
+	if err != nil {
+		return nil, err
+	}
+	query, err = pagination.Order(query, params, vehicleSortable)
+	if err != nil {
+		return nil, err
+	}
+	var vehicles []*models.Vehicle
+	if err := query.Limit(limit + 1).Find(&vehicles).Error; err != nil {
+		return nil, fmt.Errorf("failed to list vehicles: %w", err)
+	}
+	return vehicles, nil
+}
+
+// FindByLicensePlates retrieves the vehicles with any of licensePlates
+func (r *vehicleRepository) FindByLicensePlates(ctx context.Context, licensePlates []string) ([]*models.Vehicle, error) {
+	var vehicles []*models.Vehicle
+	if err := r.db.WithContext(ctx).Where("license_plate IN ?", licensePlates).Find(&vehicles).Error; err != nil {
+		return nil, fmt.Errorf("failed to find vehicles: %w", err)
+	}
+	return vehicles, nil
+}
+
+// SaveImported creates and updates the vehicles of an import in one transaction
+func (r *vehicleRepository) SaveImported(ctx context.Context, created, updated []*models.Vehicle) error {
+	err := saveImported(r.db.WithContext(ctx), created, updated, func(vehicle *models.Vehicle) (uint, *int) {
+		return vehicle.ID, &vehicle.Version
+	})
+	if err != nil {
+		return fmt.Errorf("failed to import vehicles: %w", err)
+	}
+	return nil
+}
+
 // FindByRoute retrieves all vehicles for a specific route
 func (r *vehicleRepository) FindByRoute(ctx context.Context, routeID uint) ([]models.Vehicle, error) {
 	var vehicles []models.Vehicle
@@ -82,15 +163,27 @@ func (r *vehicleRepository) FindByRoute(ctx context.Context, routeID uint) ([]mo
 
 // Update updates a vehicle
 func (r *vehicleRepository) Update(ctx context.Context, vehicle *models.Vehicle) error {
-	if err := r.db.WithContext(ctx).Save(vehicle).Error; err != nil {
+	if err := saveVersioned(r.db.WithContext(ctx), vehicle, vehicle.ID, &vehicle.Version); err != nil {
 		return fmt.Errorf("failed to update vehicle: %w", err)
 	}
 	return nil
 }
 
+// UpdateOdometer advances a vehicle's odometer to the reading if it is higher, whatever version is stored
+func (r *vehicleRepository) UpdateOdometer(ctx context.Context, id uint, odometer int) error {
+	err := r.db.WithContext(ctx).Model(&models.Vehicle{}).Where("id = ? AND odometer < ?", id, odometer).Updates(map[string]interface{}{
+		"odometer": odometer,
+		"version":  gorm.Expr("version + 1"),
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update vehicle odometer: %w", err)
+	}
+	return nil
+}
+
 // Delete removes a vehicle
-func (r *vehicleRepository) Delete(ctx context.Context, id uint) error {
-	if err := r.db.WithContext(ctx).Delete(&models.Vehicle{}, id).Error; err != nil {
+func (r *vehicleRepository) Delete(ctx context.Context, id uint, version int) error {
+	if err := deleteVersioned(r.db.WithContext(ctx), &models.Vehicle{}, id, version); err != nil {
 		return fmt.Errorf("failed to delete vehicle: %w", err)
 	}
 	return nil
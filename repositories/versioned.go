@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"net/http"
+
+	apperrors "rota-api/errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrVersionConflict is returned when a record was changed by someone else since the
+// version the caller last read, so saving or deleting it would overwrite their change
+var ErrVersionConflict = apperrors.New(http.StatusPreconditionFailed, apperrors.PRECONDITION_FAILED, "record has been modified since it was read")
+
+// saveVersioned saves every column of model, which must have id as its primary key, if the
+// stored row is still at *version, and bumps *version. A zero version saves over whatever
+// version is stored. Relations are never saved
+func saveVersioned(db *gorm.DB, model interface{}, id interface{}, version *int) error {
+	given := *version
+	expected := given
+	if expected == 0 {
+		current, err := currentVersion(db, model, id)
+		if err != nil {
+			return err
+		}
+		expected = current
+	}
+
+	*version = expected + 1
+	result := db.Model(model).
+		Where("version = ?", expected).
+		Select("*").
+		Omit(clause.Associations).
+		Updates(model)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = versionMismatch(db, model, id)
+	}
+	if result.Error != nil {
+		*version = given
+		return result.Error
+	}
+	return nil
+}
+
+// deleteVersioned deletes the row of model with id if it is still at version.
+// A zero version deletes whatever version is stored
+func deleteVersioned(db *gorm.DB, model interface{}, id interface{}, version int) error {
+	if version == 0 {
+		return db.Where("id = ?", id).Delete(model).Error
+	}
+	result := db.Where("id = ? AND version = ?", id, version).Delete(model)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return versionMismatch(db, model, id)
+	}
+	return nil
+}
+
+// currentVersion reads the stored version of the row of model with id
+func currentVersion(db *gorm.DB, model interface{}, id interface{}) (int, error) {
+	var versions []int
+	if err := db.Model(model).Where("id = ?", id).Pluck("version", &versions).Error; err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return versions[0], nil
+}
+
+// versionMismatch explains why a conditional write touched no row: the row is
+// gone, or it is at another version than the one the caller expected
+func versionMismatch(db *gorm.DB, model interface{}, id interface{}) error {
+	current, err := currentVersion(db, model, id)
+	if err != nil {
+		return err
+	}
+	return ErrVersionConflict.With("current_version", current)
+}
@@ -0,0 +1,92 @@
+package response
+
+import (
+	"log"
+	"net/http"
+
+	apperrors "rota-api/errors"
+	"rota-api/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ProblemContentType is the media type of error responses (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// ProblemDetails is the body of an error response (RFC 7807). `code` is the stable error code from
+// the errors package that clients branch on; `errors` lists field problems of validation errors
+type ProblemDetails struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Code     string            `json:"code"`
+	Errors   map[string]string `json:"errors,omitempty"`
+}
+
+// titles are the catalog messages each status is titled with
+var titles = map[int]string{
+	http.StatusBadRequest:           i18n.MsgBadRequest,
+	http.StatusUnauthorized:         i18n.MsgUnauthorized,
+	http.StatusForbidden:            i18n.MsgForbidden,
+	http.StatusNotFound:             i18n.MsgNotFound,
+	http.StatusConflict:             i18n.MsgConflict,
+	http.StatusUnprocessableEntity:  i18n.MsgValidationFailed,
+	http.StatusTooManyRequests:      i18n.MsgTooManyRequests,
+	http.StatusPreconditionFailed:   i18n.MsgPrecondition,
+	http.StatusPreconditionRequired: i18n.MsgPreconditionNeed,
+	http.StatusUnsupportedMediaType: i18n.MsgUnsupportedMedia,
+	http.StatusInternalServerError:  i18n.MsgInternalError,
+}
+
+// Problem sends err as a problem document. Errors that are not domain errors are reported as
+// internal errors, logging the cause so it never reaches the client
+func Problem(c *fiber.Ctx, err error) error {
+	appErr := apperrors.From(err)
+	status := appErr.HTTPStatus()
+	if status >= http.StatusInternalServerError {
+		log.Printf("%s %s: %v", c.Method(), c.OriginalURL(), err)
+	}
+
+	problem := ProblemDetails{
+		Type:     "about:blank",
+		Title:    title(c, status),
+		Status:   status,
+		Detail:   appErr.Message,
+		Instance: c.OriginalURL(),
+		Code:     appErr.Code,
+		Errors:   appErr.Fields,
+	}
+	if len(appErr.Fields) > 0 {
+		problem.Detail = localized(c, i18n.MsgValidationDetail)
+	}
+
+	body := fiber.Map{}
+	for key, value := range appErr.Extensions {
+		body[key] = value
+	}
+	body["type"] = problem.Type
+	body["title"] = problem.Title
+	body["status"] = problem.Status
+	body["detail"] = problem.Detail
+	body["instance"] = problem.Instance
+	body["code"] = problem.Code
+	if len(problem.Errors) > 0 {
+		body["errors"] = problem.Errors
+	}
+
+	if err := c.Status(status).JSON(body); err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, ProblemContentType)
+	return nil
+}
+
+// title renders the title of a status in the language negotiated for the request
+func title(c *fiber.Ctx, status int) string {
+	if key, ok := titles[status]; ok {
+		return localized(c, key)
+	}
+	return http.StatusText(status)
+}
@@ -1,6 +1,9 @@
 package response
 
 import (
+	apperrors "rota-api/errors"
+	"rota-api/i18n"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 )
@@ -31,77 +34,50 @@ func Created(c *fiber.Ctx, data interface{}, message string) error {
 	})
 }
 
-// BadRequest sends a 400 Bad Request response
+// BadRequest sends a 400 Bad Request problem
 func BadRequest(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusBadRequest).JSON(Response[interface{}]{
-		Success: false,
-		Message: "Bad Request",
-		Error:   message,
-	})
+	return Problem(c, apperrors.BadRequest(apperrors.BAD_REQUEST, message))
 }
 
-// Unauthorized sends a 401 Unauthorized response
+// Unauthorized sends a 401 Unauthorized problem
 func Unauthorized(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusUnauthorized).JSON(Response[interface{}]{
-		Success: false,
-		Message: "Unauthorized",
-		Error:   message,
-	})
+	return Problem(c, apperrors.Unauthorized(apperrors.UNAUTHORIZED, message))
 }
 
-// Forbidden sends a 403 Forbidden response
+// Forbidden sends a 403 Forbidden problem
 func Forbidden(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusForbidden).JSON(Response[interface{}]{
-		Success: false,
-		Message: "Forbidden",
-		Error:   message,
-	})
+	return Problem(c, apperrors.Forbidden(apperrors.FORBIDDEN, message))
 }
 
-// NotFound sends a 404 Not Found response
+// NotFound sends a 404 Not Found problem
 func NotFound(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusNotFound).JSON(Response[interface{}]{
-		Success: false,
-		Message: "Not Found",
-		Error:   message,
-	})
+	return Problem(c, apperrors.NotFound(apperrors.NOT_FOUND, message))
 }
 
-// Conflict sends a 409 Conflict response
+// Conflict sends a 409 Conflict problem
 func Conflict(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusConflict).JSON(Response[interface{}]{
-		Success: false,
-		Message: "Conflict",
-		Error:   message,
-	})
+	return Problem(c, apperrors.Conflict(apperrors.CONFLICT, message))
 }
 
-// ValidationError sends a 422 Unprocessable Entity response with validation errors
+// ValidationError sends a 422 Unprocessable Entity problem listing the invalid fields
 func ValidationError(c *fiber.Ctx, err error) error {
-	errs, ok := err.(validator.ValidationErrors)
-	if !ok {
+	if _, ok := err.(validator.ValidationErrors); !ok {
 		return BadRequest(c, err.Error())
 	}
+	return Problem(c, err)
+}
 
-	// Convert validation errors to a map
-	errors := make(map[string]string)
-	for _, e := range errs {
-		errors[e.Field()] = e.Tag()
-	}
+// InternalServerError sends a 500 Internal Server Error problem
+func InternalServerError(c *fiber.Ctx, message string) error {
+	return Problem(c, apperrors.Internal(message, nil))
+}
 
-	return c.Status(fiber.StatusUnprocessableEntity).JSON(Response[map[string]string]{
-		Success: false,
-		Message: "Validation failed",
-		Error:   "One or more validation errors occurred",
-		Data:    errors,
-	})
+// TooManyRequests sends a 429 Too Many Requests problem
+func TooManyRequests(c *fiber.Ctx, message string) error {
+	return Problem(c, apperrors.TooManyRequests(apperrors.RATE_LIMITED, message))
 }
 
-// InternalServerError sends a 500 Internal Server Error response
-func InternalServerError(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusInternalServerError).JSON(Response[interface{}]{
-		Success: false,
-		Message: "Internal Server Error",
-		Error:   message,
-	})
+// localized renders a catalog message in the language negotiated for the request
+func localized(c *fiber.Ctx, key string) string {
+	return i18n.T(i18n.FromContext(c.Context()), key)
 }
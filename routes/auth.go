@@ -3,18 +3,42 @@ package routes
 import (
 	handler "rota-api/handlers"
 	"rota-api/middleware"
+	"rota-api/models"
 	"rota-api/services"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// AuthRateLimits holds the throttling policies for the public auth endpoints
+type AuthRateLimits struct {
+	LoginIP      models.RateLimitPolicy
+	LoginAccount models.RateLimitPolicy
+	RegisterIP   models.RateLimitPolicy
+	RefreshIP    models.RateLimitPolicy
+}
+
 // SetupAuthRoutes configures all auth routes
-func SetupAuthRoutes(app *fiber.App, handler *handler.AuthHandler, authService services.AuthService) {
+func SetupAuthRoutes(app *fiber.App, handler *handler.AuthHandler, mfaHandler *handler.MFAHandler, authService services.AuthService, limiter services.RateLimiter, limits AuthRateLimits, idempotency services.IdempotencyService) {
 	auth := app.Group("/api/v1/auth")
 
-	// Public routes
-	auth.Post("/register", handler.Register)
-	auth.Post("/login", handler.Login)
+	// Public routes, throttled per IP and, for login, per account to slow down credential stuffing.
+	// Retried registrations with the same Idempotency-Key get the first response back
+	auth.Post("/register",
+		middleware.RateLimitMiddleware(limiter, "register", limits.RegisterIP, middleware.KeyByIP),
+		middleware.IdempotencyMiddleware(idempotency),
+		handler.Register)
+	auth.Post("/login",
+		middleware.RateLimitMiddleware(limiter, "login", limits.LoginIP, middleware.KeyByIP),
+		middleware.RateLimitMiddleware(limiter, "login", limits.LoginAccount, middleware.KeyByAccount),
+		handler.Login)
+	// Resetting a password checks a reset token, so it shares the login limits per IP
+	auth.Post("/password/reset",
+		middleware.RateLimitMiddleware(limiter, "login", limits.LoginIP, middleware.KeyByIP),
+		handler.ResetPassword)
+	// Second login step of accounts with MFA, throttled like login since it checks a guessable code
+	auth.Post("/mfa/verify",
+		middleware.RateLimitMiddleware(limiter, "login", limits.LoginIP, middleware.KeyByIP),
+		mfaHandler.Verify)
 	// TODO: Implement Google OAuth routes in the future
 	// auth.Get("/google", handler.GoogleLogin)
 	// auth.Get("/google/callback", handler.GoogleCallback)
@@ -22,5 +46,17 @@ func SetupAuthRoutes(app *fiber.App, handler *handler.AuthHandler, authService s
 	// Protected routes
 	auth.Get("/me", middleware.AuthMiddleware(authService), handler.GetCurrentUser)
 	auth.Post("/logout", middleware.AuthMiddleware(authService), handler.Logout)
-	auth.Post("/refresh", handler.RefreshToken)
+	auth.Post("/refresh",
+		middleware.RateLimitMiddleware(limiter, "refresh", limits.RefreshIP, middleware.KeyByIP),
+		handler.RefreshToken)
+
+	// MFA management for the signed-in user. /mfa/verify is public and registered above, before this middleware
+	mfa := auth.Group("/mfa")
+	mfa.Use(middleware.AuthMiddleware(authService))
+
+	mfa.Get("/", mfaHandler.GetStatus)
+	mfa.Post("/enroll", mfaHandler.Enroll)
+	mfa.Post("/activate", mfaHandler.Activate)
+	mfa.Post("/disable", mfaHandler.Disable)
+	mfa.Post("/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
 }
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"rota-api/handlers"
+	"rota-api/middleware"
+	"rota-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupCacheRoutes sets up cache monitoring routes
+func SetupCacheRoutes(app *fiber.App, cacheHandler *handler.CacheHandler, authService services.AuthService) {
+	adminCacheGroup := app.Group("/api/v1/admin/cache")
+	adminCacheGroup.Use(middleware.AuthMiddleware(authService), middleware.AdminMiddleware())
+	adminCacheGroup.Get("/stats", cacheHandler.GetCacheStats)
+}
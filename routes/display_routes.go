@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"rota-api/handlers"
+	"rota-api/middleware"
+	"rota-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupDisplayRoutes sets up the station signage display and its theme management
+func SetupDisplayRoutes(app *fiber.App, displayHandler *handler.DisplayHandler, authService services.AuthService) {
+	// Public signage pages for station TVs - no authentication so kiosks can load them directly
+	displayRoutes := app.Group("/display/stations")
+	displayRoutes.Get("/:id", displayHandler.RenderStationDisplay)
+	displayRoutes.Get("/:id/events", displayHandler.StreamStationDisplay)
+
+	// Admin-only theme management
+	adminRoutes := app.Group("/api/v1/stations")
+	adminRoutes.Use(middleware.AuthMiddleware(authService), middleware.AdminMiddleware())
+	adminRoutes.Get("/:id/display-theme", displayHandler.GetDisplayTheme)
+	adminRoutes.Put("/:id/display-theme", displayHandler.UpdateDisplayTheme)
+	adminRoutes.Delete("/:id/display-theme", displayHandler.ResetDisplayTheme)
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"rota-api/handlers"
+	"rota-api/middleware"
+	"rota-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupDriverRoutes sets up driver management and duty roster routes
+func SetupDriverRoutes(
+	app *fiber.App,
+	driverHandler *handler.DriverHandler,
+	authService services.AuthService,
+) {
+	// Driver records and rosters are visible to staff and admins
+	drivers := app.Group("/api/v1/drivers")
+	drivers.Use(middleware.AuthMiddleware(authService), middleware.StaffMiddleware())
+	drivers.Get("/", driverHandler.GetAllDrivers)
+	// Daily roster - ต้องอยู่ก่อนเส้นทาง /:id
+	drivers.Get("/roster", driverHandler.GetRoster)
+	drivers.Get("/:id", driverHandler.GetDriverByID)
+	drivers.Get("/:id/roster", driverHandler.GetDriverRoster)
+
+	// Admin-only routes for driver management
+	adminDrivers := app.Group("/api/v1/drivers")
+	adminDrivers.Use(middleware.AuthMiddleware(authService), middleware.AdminMiddleware())
+	adminDrivers.Post("/", driverHandler.CreateDriver)
+	adminDrivers.Put("/:id", driverHandler.UpdateDriver)
+	adminDrivers.Patch("/:id", driverHandler.PatchDriver)
+	adminDrivers.Delete("/:id", driverHandler.DeleteDriver)
+
+	// Admin-only driver assignment to schedule trips
+	adminSchedules := app.Group("/api/v1/admin/schedules")
+	adminSchedules.Use(middleware.AuthMiddleware(authService), middleware.AdminMiddleware())
+	adminSchedules.Put("/:id/driver", driverHandler.AssignDriver)
+	adminSchedules.Delete("/:id/driver", driverHandler.UnassignDriver)
+}
@@ -13,17 +13,19 @@ func SetupFavoriteRoutes(
 	favoriteHandler *handler.FavoriteHandler,
 	authService services.AuthService,
 	favoriteService services.FavoriteService,
+	idempotency services.IdempotencyService,
 ) {
 	favoriteGroup := app.Group("/api/v1/favorites")
 	favoriteGroup.Use(middleware.AuthMiddleware(authService))
 
 	favoriteGroup.Get("/", favoriteHandler.GetUserFavorites)
-	favoriteGroup.Post("/stations/:stationId", favoriteHandler.AddStationToFavorites)
+	favoriteGroup.Post("/stations/:stationId", middleware.IdempotencyMiddleware(idempotency), favoriteHandler.AddStationToFavorites)
 	favoriteGroup.Delete("/stations/:stationId", favoriteHandler.RemoveStationByStationId)
 	
 	favoriteGroup.Get("/admin", middleware.OwnFavoriteMiddleware(favoriteService), favoriteHandler.GetAllFavorites)
 	favoriteGroup.Post("/admin", favoriteHandler.CreateFavorite)
 	favoriteGroup.Get("/admin/:id", middleware.OwnFavoriteMiddleware(favoriteService), favoriteHandler.GetFavoriteByID)
 	favoriteGroup.Put("/admin/:id", middleware.OwnFavoriteMiddleware(favoriteService), favoriteHandler.UpdateFavorite)
+	favoriteGroup.Patch("/admin/:id", middleware.OwnFavoriteMiddleware(favoriteService), favoriteHandler.PatchFavorite)
 	favoriteGroup.Delete("/admin/:id", middleware.OwnFavoriteMiddleware(favoriteService), favoriteHandler.DeleteFavorite)
 }
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"rota-api/handlers"
+	"rota-api/middleware"
+	"rota-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupImportExportRoutes sets up the admin routes that import and export data as CSV or XLSX
+func SetupImportExportRoutes(app *fiber.App, importExportHandler *handler.ImportExportHandler, authService services.AuthService) {
+	// Exports honour the filters and sorting of the matching list endpoints
+	exportGroup := app.Group("/api/v1/admin/export")
+	exportGroup.Use(middleware.AuthMiddleware(authService), middleware.AdminMiddleware())
+	exportGroup.Get("/stations", importExportHandler.ExportStations)
+	exportGroup.Get("/vehicles", importExportHandler.ExportVehicles)
+	exportGroup.Get("/staff", importExportHandler.ExportStaff)
+	exportGroup.Get("/schedules", importExportHandler.ExportSchedules)
+
+	// Imports take a multipart upload; add dry_run=true to validate a file without saving it
+	importGroup := app.Group("/api/v1/admin/import")
+	importGroup.Use(middleware.AuthMiddleware(authService), middleware.AdminMiddleware())
+	importGroup.Post("/stations", importExportHandler.ImportStations)
+	importGroup.Post("/vehicles", importExportHandler.ImportVehicles)
+	importGroup.Post("/staff", importExportHandler.ImportStaff)
+	importGroup.Post("/schedules", importExportHandler.ImportSchedules)
+}
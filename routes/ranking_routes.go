@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"rota-api/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupRankingRoutes sets up the public popularity rankings.
+// Must be registered before the station and route routes so /popular is not matched as /:id
+func SetupRankingRoutes(app *fiber.App, rankingHandler *handler.RankingHandler) {
+	app.Get("/api/v1/stations/popular", rankingHandler.GetPopularStations)
+	app.Get("/api/v1/routes/popular", rankingHandler.GetPopularRoutes)
+}
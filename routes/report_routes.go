@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"rota-api/handlers"
+	"rota-api/middleware"
+	"rota-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupReportRoutes sets up the admin punctuality and utilization report routes
+func SetupReportRoutes(app *fiber.App, reportHandler *handler.ReportHandler, authService services.AuthService) {
+	adminReportGroup := app.Group("/api/v1/admin/reports")
+	adminReportGroup.Use(middleware.AuthMiddleware(authService), middleware.AdminMiddleware())
+	adminReportGroup.Get("/punctuality", reportHandler.GetPunctuality)
+	adminReportGroup.Get("/utilization", reportHandler.GetUtilization)
+	// Recomputes the trip_actuals view; schedule this when reports read from the snapshot
+	adminReportGroup.Post("/refresh", reportHandler.RefreshSnapshot)
+}
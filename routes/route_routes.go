@@ -6,6 +6,7 @@ import (
 	"rota-api/services"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/etag"
 )
 
 // SetupRouteRoutes sets up all route-related routes
@@ -14,7 +15,8 @@ func SetupRouteRoutes(app *fiber.App, routeHandler *handler.RouteHandler, authSe
 	publicRoutes := app.Group("/api/v1/routes")
 	
 	// Public endpoints
-	publicRoutes.Get("/", routeHandler.GetAllRoutes)
+	// Lists are tagged by content, so clients can revalidate them with If-None-Match
+	publicRoutes.Get("/", etag.New(), routeHandler.GetAllRoutes)
 
 	// Protected routes for viewing
 	protectedRoutes := app.Group("/api/v1/routes")
@@ -26,6 +28,7 @@ func SetupRouteRoutes(app *fiber.App, routeHandler *handler.RouteHandler, authSe
 	adminRoutes.Use(middleware.AuthMiddleware(authService), middleware.AdminMiddleware())
 	adminRoutes.Post("/", routeHandler.CreateRoute)
 	adminRoutes.Put("/:id", routeHandler.UpdateRoute)
+	adminRoutes.Patch("/:id", routeHandler.PatchRoute)
 	adminRoutes.Delete("/:id", routeHandler.DeleteRoute)
 
 	// Route-Stop relationship endpoints
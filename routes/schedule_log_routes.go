@@ -19,6 +19,8 @@ func SetupScheduleLogRoutes(
 	// Read operations - accessible to all authenticated users
 	scheduleLogGroup.Get("/", scheduleLogHandler.GetAllScheduleLogs)
 	scheduleLogGroup.Get("/:id", scheduleLogHandler.GetScheduleLogByID)
+	// Partial updates for staff and admins; the fields each may change are checked per role
+	scheduleLogGroup.Patch("/:id", middleware.StaffMiddleware(), scheduleLogHandler.PatchScheduleLog)
 	
 	// Admin-only operations for schedule log management
 	adminScheduleLogGroup := app.Group("/api/v1/schedule-logs")
@@ -11,7 +11,9 @@ import (
 func SetupScheduleRoutes(
 	app *fiber.App,
 	scheduleHandler *handler.ScheduleHandler,
+	conflictHandler *handler.ConflictHandler,
 	authService services.AuthService,
+	idempotency services.IdempotencyService,
 ) {
 	// Public group for read-only operations that don't need authentication
 	publicGroup := app.Group("/api/v1/schedules")
@@ -21,11 +23,19 @@ func SetupScheduleRoutes(
 	
 	// Read operations - accessible to all users without authentication
 	publicGroup.Get("/:id", scheduleHandler.GetScheduleByID)
+
+	// Partial updates for staff and admins; the fields each may change are checked per role
+	publicGroup.Patch("/:id", middleware.AuthMiddleware(authService), middleware.StaffMiddleware(), scheduleHandler.PatchSchedule)
 	
 	// Admin-only operations for schedule management
 	adminScheduleGroup := app.Group("/api/v1/admin/schedules")
 	adminScheduleGroup.Use(middleware.AuthMiddleware(authService), middleware.AdminMiddleware())
-	adminScheduleGroup.Post("/", scheduleHandler.CreateSchedule)
+	adminScheduleGroup.Get("/conflicts", conflictHandler.ValidateConflicts)
+	adminScheduleGroup.Post("/", middleware.IdempotencyMiddleware(idempotency), scheduleHandler.CreateSchedule)
+	// Bulk operations on the schedules matching the given criteria, previewed with "preview": true
+	adminScheduleGroup.Post("/bulk/shift", middleware.IdempotencyMiddleware(idempotency), scheduleHandler.ShiftSchedules)
+	adminScheduleGroup.Post("/bulk/cancel", middleware.IdempotencyMiddleware(idempotency), scheduleHandler.CancelSchedules)
+	adminScheduleGroup.Post("/bulk/copy", middleware.IdempotencyMiddleware(idempotency), scheduleHandler.CopySchedules)
 	adminScheduleGroup.Put("/:id", scheduleHandler.UpdateSchedule)
 	adminScheduleGroup.Delete("/:id", scheduleHandler.DeleteSchedule)
 }
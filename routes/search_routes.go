@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"rota-api/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupSearchRoutes sets up the public station and route search
+func SetupSearchRoutes(app *fiber.App, searchHandler *handler.SearchHandler) {
+	search := app.Group("/api/v1/search")
+	search.Get("/", searchHandler.Search)
+	search.Get("/suggest", searchHandler.Suggest)
+}
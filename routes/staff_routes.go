@@ -26,5 +26,6 @@ func SetupStaffRoutes(app *fiber.App, staffHandler *handler.StaffHandler, authSe
 	adminRoutes.Use(middleware.AuthMiddleware(authService), middleware.AdminMiddleware())
 	adminRoutes.Post("/", staffHandler.CreateStaff)
 	adminRoutes.Put("/:id", staffHandler.UpdateStaff)
+	adminRoutes.Patch("/:id", staffHandler.PatchStaff)
 	adminRoutes.Delete("/:id", staffHandler.DeleteStaff)
 }
@@ -6,19 +6,25 @@ import (
 	"rota-api/services"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/etag"
 )
 
 // SetupStationRoutes sets up all station-related routes
-func SetupStationRoutes(app *fiber.App, stationHandler *handler.StationHandler, scheduleHandler *handler.ScheduleHandler, authService services.AuthService) {
+func SetupStationRoutes(app *fiber.App, stationHandler *handler.StationHandler, scheduleHandler *handler.ScheduleHandler, boardHandler *handler.BoardHandler, authService services.AuthService) {
 	// Create public group for routes that don't need authentication
 	publicRoutes := app.Group("/api/v1/stations")
 	
 	// Public endpoints
-	publicRoutes.Get("/", stationHandler.GetAllStations)
+	// Lists are tagged by content, so clients can revalidate them with If-None-Match
+	publicRoutes.Get("/", etag.New(), stationHandler.GetAllStations)
 	// Get specific station details
 	publicRoutes.Get("/:id", stationHandler.GetStationByID)
 	// Get station schedules (both inbound and outbound)
 	publicRoutes.Get("/:id/schedules", scheduleHandler.GetSchedulesByStation)
+	// Simplified schedules (departure time and destination only), as shown on station displays
+	publicRoutes.Get("/:id/schedules/simple", scheduleHandler.GetSimpleSchedulesByStation)
+	// Live departure/arrival board paged by time
+	publicRoutes.Get("/:id/board", boardHandler.GetStationBoard)
 
 	// Protected routes for viewing
 	protectedRoutes := app.Group("/api/v1/stations")
@@ -30,5 +36,6 @@ func SetupStationRoutes(app *fiber.App, stationHandler *handler.StationHandler,
 	adminRoutes.Use(middleware.AuthMiddleware(authService), middleware.AdminMiddleware())
 	adminRoutes.Post("/", stationHandler.CreateStation)
 	adminRoutes.Put("/:id", stationHandler.UpdateStation)
+	adminRoutes.Patch("/:id", stationHandler.PatchStation)
 	adminRoutes.Delete("/:id", stationHandler.DeleteStation)
 }
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"rota-api/handlers"
+	"rota-api/middleware"
+	"rota-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupTripRoutes sets up the routes for the signed-in user's travel history
+func SetupTripRoutes(
+	app *fiber.App,
+	tripHandler *handler.TripHandler,
+	authService services.AuthService,
+	idempotency services.IdempotencyService,
+) {
+	tripGroup := app.Group("/api/v1/trips")
+	tripGroup.Use(middleware.AuthMiddleware(authService))
+
+	tripGroup.Get("/", tripHandler.GetMyTrips)
+	tripGroup.Post("/", middleware.IdempotencyMiddleware(idempotency), tripHandler.SaveTrip)
+	tripGroup.Get("/stats", tripHandler.GetMyTripStats)
+	// Erases the user's whole history, for data deletion requests
+	tripGroup.Delete("/", tripHandler.ClearMyTrips)
+	tripGroup.Delete("/:id", tripHandler.DeleteMyTrip)
+}
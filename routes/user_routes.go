@@ -17,13 +17,21 @@ func SetupUserRoutes(
 	userGroup := app.Group("/api/v1/users")
 	userGroup.Use(middleware.AuthMiddleware(authService))
 
+	// Self-service routes for the signed-in user, registered before /:id so "me" is not taken for an ID
+	userGroup.Put("/me/password", userHandler.ChangeMyPassword)
+	userGroup.Get("/me/export", userHandler.ExportMyData)
+	userGroup.Delete("/me", userHandler.DeleteMe)
+
 	// Routes for admins only
 	userGroup.Get("/", middleware.AdminMiddleware(), userHandler.GetAllUsers)
 	userGroup.Post("/", middleware.AdminMiddleware(), userHandler.CreateUser)
 	userGroup.Delete("/:id", middleware.AdminMiddleware(), userHandler.DeleteUser)
 	userGroup.Put("/:id/role", middleware.AdminMiddleware(), userHandler.UpdateUserRole)
+	userGroup.Post("/:id/password-reset", middleware.AdminMiddleware(), userHandler.IssuePasswordReset)
+	userGroup.Post("/:id/mfa-enrollment", middleware.AdminMiddleware(), userHandler.IssueMFAEnrollment)
 
 	// Routes for users to manage their own profile or for admins
 	userGroup.Get("/:id", middleware.OwnResourceMiddleware(), userHandler.GetUserByID)
 	userGroup.Put("/:id", middleware.OwnResourceMiddleware(), userHandler.UpdateUser)
+	userGroup.Patch("/:id", middleware.OwnResourceMiddleware(), userHandler.PatchUser)
 }
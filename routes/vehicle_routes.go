@@ -12,6 +12,7 @@ import (
 func SetupVehicleRoutes(
 	app *fiber.App,
 	vehicleHandler *handler.VehicleHandler,
+	maintenanceHandler *handler.MaintenanceHandler,
 	authService services.AuthService,
 ) {
 	vehicles := app.Group("/api/v1/vehicles")
@@ -19,7 +20,15 @@ func SetupVehicleRoutes(
 	// Routes for viewing vehicles (available to all authenticated users)
 	vehicles.Use(middleware.AuthMiddleware(authService))
 	vehicles.Get("/", vehicleHandler.GetAllVehicles)
+	// Fleet-wide inspection overview for staff and admins
+	vehicles.Get("/inspections/due", middleware.StaffMiddleware(), maintenanceHandler.GetDueInspections)
 	vehicles.Get("/:id", vehicleHandler.GetVehicleByID)
+	vehicles.Get("/:id/availability", maintenanceHandler.GetAvailability)
+	vehicles.Get("/:id/maintenance", maintenanceHandler.GetMaintenanceRecords)
+	vehicles.Get("/:id/inspections", maintenanceHandler.GetInspections)
+	vehicles.Get("/:id/out-of-service", maintenanceHandler.GetOutOfServiceWindows)
+	// Partial updates for staff and admins; the fields each may change are checked per role
+	vehicles.Patch("/:id", middleware.StaffMiddleware(), vehicleHandler.PatchVehicle)
 
 	// Admin-only routes for vehicle management
 	adminVehicles := app.Group("/api/v1/vehicles")
@@ -27,4 +36,14 @@ func SetupVehicleRoutes(
 	adminVehicles.Post("/", vehicleHandler.CreateVehicle)
 	adminVehicles.Put("/:id", vehicleHandler.UpdateVehicle)
 	adminVehicles.Delete("/:id", vehicleHandler.DeleteVehicle)
+
+	// Admin-only routes for fleet maintenance
+	adminVehicles.Post("/:id/maintenance", maintenanceHandler.CreateMaintenanceRecord)
+	adminVehicles.Delete("/:id/maintenance/:recordId", maintenanceHandler.DeleteMaintenanceRecord)
+	adminVehicles.Post("/:id/inspections", maintenanceHandler.ScheduleInspection)
+	adminVehicles.Put("/:id/inspections/:inspectionId/complete", maintenanceHandler.CompleteInspection)
+	adminVehicles.Delete("/:id/inspections/:inspectionId", maintenanceHandler.DeleteInspection)
+	adminVehicles.Post("/:id/out-of-service", maintenanceHandler.CreateOutOfServiceWindow)
+	adminVehicles.Put("/:id/out-of-service/:windowId/close", maintenanceHandler.CloseOutOfServiceWindow)
+	adminVehicles.Delete("/:id/out-of-service/:windowId", maintenanceHandler.DeleteOutOfServiceWindow)
 }
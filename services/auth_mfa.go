@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "rota-api/errors"
+	"rota-api/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// TOTP codes follow the defaults authenticator apps assume: six digits from HMAC-SHA1 every 30 seconds.
+// A code from the step before or after the current one is accepted, for clock drift
+const (
+	mfaPeriod    = 30
+	mfaSkewSteps = 1
+)
+
+// Recovery codes are ten characters from an alphabet without look-alike characters, about 50 bits
+// each, written as two groups of five
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+// mfaChallengeAudience marks MFA challenge tokens, which are signed with their own key so they
+// can never be used as access tokens
+const mfaChallengeAudience = "mfa-challenge"
+
+var (
+	ErrMFARequired       = apperrors.Forbidden(apperrors.MFA_REQUIRED, "multi-factor authentication is required for this role")
+	ErrMFAInvalidCode    = apperrors.Unauthorized(apperrors.MFA_INVALID_CODE, "invalid authentication or recovery code")
+	ErrMFACodeIncorrect  = apperrors.Forbidden(apperrors.MFA_INVALID_CODE, "authentication code is incorrect")
+	ErrMFAAlreadyEnabled = apperrors.Conflict(apperrors.MFA_ALREADY_ENABLED, "multi-factor authentication is already enabled")
+	ErrMFANotEnabled     = apperrors.Conflict(apperrors.MFA_NOT_ENABLED, "multi-factor authentication is not enabled")
+	ErrMFANotEnrolled    = apperrors.Conflict(apperrors.MFA_NOT_ENROLLED, "start MFA enrolment before activating it")
+	ErrMFAEnrollDenied   = apperrors.Forbidden(apperrors.MFA_ENROLLMENT_DENIED, "enabling MFA for this role needs a valid enrollment token from an administrator")
+)
+
+// MFAPolicy controls multi-factor authentication. Accounts with one of RequiredRoles must verify a second
+// factor to act with their role, and enroll with a token from IssueMFAEnrollmentToken, valid for EnrollmentTTL.
+// Issuer names the service in authenticator apps, and a login waiting for its second factor is valid for ChallengeTTL
+type MFAPolicy struct {
+	RequiredRoles []models.UserRole
+	Issuer        string
+	ChallengeTTL  time.Duration
+	EnrollmentTTL time.Duration
+}
+
+// MFARequired reports whether accounts with the role must use multi-factor authentication
+func (s *AuthServiceImpl) MFARequired(role models.UserRole) bool {
+	return slices.Contains(s.config.MFA.RequiredRoles, role)
+}
+
+// GetMFAStatus tells whether the user has MFA enabled, whether their role requires it, and how many
+// recovery codes they have left
+func (s *AuthServiceImpl) GetMFAStatus(ctx context.Context, userID int) (*models.MFAStatus, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &models.MFAStatus{Enabled: user.MFAEnabled, Required: s.MFARequired(user.Role)}
+	if user.MFAEnabled {
+		if status.RecoveryCodesRemaining, err = s.recoveryCodeRepo.CountUnused(ctx, user.ID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// IssueMFAEnrollmentToken gives an admin a single-use token, valid for EnrollmentTTL, to hand to a user
+// whose role requires MFA. Sessions of such accounts before MFA rest on the password alone, so the token
+// is what shows the authenticator being enrolled belongs to the account's owner
+func (s *AuthServiceImpl) IssueMFAEnrollmentToken(ctx context.Context, userID int) (string, time.Time, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if user.MFAEnabled {
+		return "", time.Time{}, ErrMFAAlreadyEnabled
+	}
+	return s.issueOneTimeToken(ctx, mfaEnrollmentPurpose, user.ID, s.config.MFA.EnrollmentTTL)
+}
+
+// EnrollMFA starts enrolment with a new TOTP secret for the user's authenticator app. MFA is only
+// enabled once ActivateMFA confirms a code from it; enrolling again replaces a pending secret.
+// Accounts whose role requires MFA enroll with a token from IssueMFAEnrollmentToken, used up here
+func (s *AuthServiceImpl) EnrollMFA(ctx context.Context, userID int, enrollmentToken string) (*models.MFAEnrollment, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if s.MFARequired(user.Role) {
+		if enrollmentToken == "" {
+			return nil, ErrMFAEnrollDenied
+		}
+		tokenUserID, err := s.consumeOneTimeToken(ctx, mfaEnrollmentPurpose, enrollmentToken)
+		if errors.Is(err, errOneTimeTokensUnavailable) {
+			return nil, err
+		}
+		if err != nil || tokenUserID != user.ID {
+			return nil, ErrMFAEnrollDenied
+		}
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.config.MFA.Issuer,
+		AccountName: user.Email,
+		Period:      mfaPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate MFA secret: %w", err)
+	}
+	secret := key.Secret()
+	user.MFASecret = &secret
+	user.MFALastStep = 0
+	if err := s.userRepo.UpdateColumns(ctx, user.ID, map[string]interface{}{
+		"mfa_secret":    secret,
+		"mfa_last_step": 0,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to enroll MFA: %w", err)
+	}
+
+	return &models.MFAEnrollment{Secret: secret, ProvisioningURI: key.URL()}, nil
+}
+
+// ActivateMFA enables MFA once the user confirms a code from the enrolled authenticator, and returns
+// the user's recovery codes. They are only ever shown here and when regenerated. The session stays as
+// it was: tokens acting with an MFA-verified role only come from logging in again with the second factor
+func (s *AuthServiceImpl) ActivateMFA(ctx context.Context, userID int, code string) ([]string, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.MFASecret == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	step, ok := matchTOTP(*user.MFASecret, code, user.MFALastStep, time.Now())
+	if !ok {
+		return nil, ErrMFACodeIncorrect
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	user.MFAEnabled = true
+	user.MFALastStep = step
+	if err := s.userRepo.UpdateColumns(ctx, user.ID, map[string]interface{}{
+		"mfa_enabled":   true,
+		"mfa_last_step": step,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to activate MFA: %w", err)
+	}
+	return codes, nil
+}
+
+// DisableMFA turns MFA off after the user confirms their password, or a recent login for accounts without
+// one, and a second factor. Roles that require MFA cannot turn it off
+func (s *AuthServiceImpl) DisableMFA(ctx context.Context, userID int, password, code, recoveryCode string, authenticatedAt time.Time) error {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled {
+		return ErrMFANotEnabled
+	}
+	if s.MFARequired(user.Role) {
+		return ErrMFARequired
+	}
+	if err := s.ConfirmPassword(ctx, user, password, authenticatedAt); err != nil {
+		return err
+	}
+	if err := s.checkSecondFactor(ctx, user, code, recoveryCode, ErrMFACodeIncorrect); err != nil {
+		return err
+	}
+
+	user.MFAEnabled = false
+	user.MFASecret = nil
+	user.MFALastStep = 0
+	if err := s.userRepo.UpdateColumns(ctx, user.ID, map[string]interface{}{
+		"mfa_enabled":   false,
+		"mfa_secret":    nil,
+		"mfa_last_step": 0,
+	}); err != nil {
+		return fmt.Errorf("failed to disable MFA: %w", err)
+	}
+	return s.recoveryCodeRepo.DeleteByUser(ctx, user.ID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after they confirm a TOTP code
+func (s *AuthServiceImpl) RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled {
+		return nil, ErrMFANotEnabled
+	}
+	if err := s.checkSecondFactor(ctx, user, code, "", ErrMFACodeIncorrect); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateColumns(ctx, user.ID, map[string]interface{}{"mfa_last_step": user.MFALastStep}); err != nil {
+		return nil, fmt.Errorf("failed to regenerate recovery codes: %w", err)
+	}
+	return s.replaceRecoveryCodes(ctx, user.ID)
+}
+
+// GenerateMFAChallenge issues the short-lived token a login with a correct password gets in place of
+// an access token when the account has MFA, to be exchanged through VerifyMFAChallenge
+func (s *AuthServiceImpl) GenerateMFAChallenge(user *models.User) (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Subject:   strconv.Itoa(user.ID),
+		Audience:  jwt.ClaimStrings{mfaChallengeAudience},
+		ExpiresAt: jwt.NewNumericDate(now.Add(s.config.MFA.ChallengeTTL)),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+	challenge, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.mfaChallengeKey())
+	if err != nil {
+		return "", fmt.Errorf("failed to sign MFA challenge: %w", err)
+	}
+	return challenge, nil
+}
+
+// VerifyMFAChallenge completes a login with its second factor, a TOTP code or a recovery code, and returns
+// the user to issue tokens for. Wrong codes count towards the login lockout, and a challenge is used once
+func (s *AuthServiceImpl) VerifyMFAChallenge(ctx context.Context, challenge, code, recoveryCode string) (*models.User, error) {
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(challenge, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.mfaChallengeKey(), nil
+	}, jwt.WithAudience(mfaChallengeAudience))
+	if err != nil || !token.Valid || s.IsTokenBlacklisted(challenge) {
+		return nil, ErrInvalidToken
+	}
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil || !user.MFAEnabled {
+		return nil, ErrInvalidToken
+	}
+
+	if err := s.checkSecondFactor(ctx, user, code, recoveryCode, ErrMFAInvalidCode); err != nil {
+		return nil, err
+	}
+	if err := s.AddToBlacklist(challenge, time.Until(claims.ExpiresAt.Time)); err != nil {
+		log.Printf("Failed to revoke MFA challenge for user %d: %v", user.ID, err)
+	}
+
+	// The login is complete, so it clears the lockout state
+	user.FailedLoginAttempts = 0
+	user.LockoutCount = 0
+	user.LockedUntil = nil
+	if err := s.userRepo.UpdateColumns(ctx, user.ID, map[string]interface{}{
+		"failed_login_attempts": 0,
+		"lockout_count":         0,
+		"locked_until":          nil,
+		"mfa_last_step":         user.MFALastStep,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to complete MFA login: %w", err)
+	}
+	return user, nil
+}
+
+// checkSecondFactor checks a TOTP code and, if that does not match, a recovery code, which is used up.
+// A matched TOTP step is recorded on user for the caller to save. A request whose codes are all wrong
+// counts as one failed login; invalid is returned for it unless the account gets locked
+func (s *AuthServiceImpl) checkSecondFactor(ctx context.Context, user *models.User, code, recoveryCode string, invalid error) error {
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		return &AccountLockedError{Until: *user.LockedUntil}
+	}
+
+	if code != "" && user.MFASecret != nil {
+		if step, ok := matchTOTP(*user.MFASecret, code, user.MFALastStep, time.Now()); ok {
+			user.MFALastStep = step
+			return nil
+		}
+	}
+	if recoveryCode != "" {
+		used, err := s.recoveryCodeRepo.Use(ctx, user.ID, hashRecoveryCode(recoveryCode))
+		if err != nil {
+			return err
+		}
+		if used {
+			return nil
+		}
+	}
+
+	var locked *AccountLockedError
+	if err := s.recordFailedLogin(ctx, user); errors.As(err, &locked) {
+		return err
+	}
+	return invalid
+}
+
+// replaceRecoveryCodes generates a new set of recovery codes for the user, storing only their hashes
+func (s *AuthServiceImpl) replaceRecoveryCodes(ctx context.Context, userID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = hashRecoveryCode(code)
+	}
+	if err := s.recoveryCodeRepo.Replace(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// mfaChallengeKey derives the key MFA challenges are signed with from the JWT secret
+func (s *AuthServiceImpl) mfaChallengeKey() []byte {
+	mac := hmac.New(sha256.New, []byte(s.config.JWTSecret))
+	mac.Write([]byte(mfaChallengeAudience))
+	return mac.Sum(nil)
+}
+
+// matchTOTP finds the time step, around now and later than lastStep, whose code is the given one.
+// Steps up to lastStep have been used already and are refused, so a code cannot be replayed
+func matchTOTP(secret, code string, lastStep int64, now time.Time) (int64, bool) {
+	code = strings.ReplaceAll(code, " ", "")
+	current := now.Unix() / mfaPeriod
+	for step := current - mfaSkewSteps; step <= current+mfaSkewSteps; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*mfaPeriod, 0), totp.ValidateOpts{
+			Period:    mfaPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err == nil && subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// newRecoveryCode generates a random recovery code such as "k7m2p-x9qad"
+func newRecoveryCode() (string, error) {
+	alphabet := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	var code strings.Builder
+	for i := 0; i < recoveryCodeLength; i++ {
+		if i == recoveryCodeLength/2 {
+			code.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, alphabet)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code.WriteByte(recoveryCodeAlphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
+
+// hashRecoveryCode hashes a recovery code as typed, ignoring case, spaces and dashes. The codes are
+// random enough that SHA-256 protects them, unlike passwords, which need argon2id
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
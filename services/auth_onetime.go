@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Purposes of one-time tokens. A token is only accepted for the purpose it was issued for
+const (
+	passwordResetPurpose = "password-reset"
+	mfaEnrollmentPurpose = "mfa-enrollment"
+)
+
+// errOneTimeTokensUnavailable is returned when one-time tokens are used without Redis to keep them in
+var errOneTimeTokensUnavailable = errors.New("one-time tokens need Redis")
+
+// issueOneTimeToken creates a random token that stands for the user for one use, for ttl. Only the
+// token's hash is stored, so the store never holds a usable token
+func (s *AuthServiceImpl) issueOneTimeToken(ctx context.Context, purpose string, userID int, ttl time.Duration) (string, time.Time, error) {
+	if s.redisRepo == nil {
+		return "", time.Time{}, errOneTimeTokensUnavailable
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	expiresAt := time.Now().Add(ttl)
+	if err := s.redisRepo.SetOneTimeToken(ctx, oneTimeTokenKey(purpose, token), strconv.Itoa(userID), ttl); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store token: %w", err)
+	}
+	return token, expiresAt, nil
+}
+
+// consumeOneTimeToken returns the user a token from issueOneTimeToken stands for and uses it up
+func (s *AuthServiceImpl) consumeOneTimeToken(ctx context.Context, purpose, token string) (int, error) {
+	if s.redisRepo == nil {
+		return 0, errOneTimeTokensUnavailable
+	}
+
+	value, err := s.redisRepo.ConsumeOneTimeToken(ctx, oneTimeTokenKey(purpose, token))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// oneTimeTokenKey is where the hash of a token is stored
+func oneTimeTokenKey(purpose, token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return purpose + ":" + hex.EncodeToString(sum[:])
+}
@@ -11,26 +11,77 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 
+	apperrors "rota-api/errors"
 	"rota-api/models"
+	"rota-api/passhash"
 	"rota-api/repositories"
 )
 
 // Custom errors
 var (
-	ErrEmailExists        = errors.New("email already exists")
-	ErrUsernameExists     = errors.New("username already exists")
-	ErrInvalidToken       = errors.New("invalid or expired token")
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrTokenGeneration    = errors.New("failed to generate token")
-	ErrUserNotFound       = errors.New("user not found")
+	ErrEmailExists        = apperrors.Conflict(apperrors.EMAIL_EXISTS, "email already exists")
+	ErrUsernameExists     = apperrors.Conflict(apperrors.USERNAME_EXISTS, "username already exists")
+	ErrInvalidToken       = apperrors.Unauthorized(apperrors.INVALID_TOKEN, "invalid or expired token")
+	ErrInvalidCredentials = apperrors.Unauthorized(apperrors.INVALID_CREDENTIALS, "invalid credentials")
+	ErrTokenGeneration    = apperrors.New(fiber.StatusInternalServerError, apperrors.TOKEN_GENERATION_FAILED, "failed to generate token")
+	ErrUserNotFound       = apperrors.NotFound(apperrors.USER_NOT_FOUND, "user not found")
+	ErrAccountLocked      = apperrors.TooManyRequests(apperrors.ACCOUNT_LOCKED, "account is temporarily locked")
+	// ErrPasswordIncorrect is forbidden rather than unauthorized: the caller's session is valid,
+	// and clients treat 401 as a signal to log the user out
+	ErrPasswordIncorrect     = apperrors.Forbidden(apperrors.PASSWORD_INCORRECT, "current password is incorrect")
+	ErrPasswordResetRequired = apperrors.Forbidden(apperrors.PASSWORD_RESET_REQUIRED, "password must be reset before logging in, ask an administrator for a reset token")
+	ErrInvalidResetToken     = apperrors.Unauthorized(apperrors.RESET_TOKEN_INVALID, "password reset token is invalid or has expired")
+	ErrReauthRequired        = apperrors.Forbidden(apperrors.REAUTH_REQUIRED, "log in again to confirm this change")
 )
 
+// recentLoginWindow is how long after logging in a session counts as freshly authenticated, which is how
+// accounts without a password confirm sensitive changes
+const recentLoginWindow = 5 * time.Minute
+
+// AccountLockedError reports until when an account is locked after repeated failed logins
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("%s until %s", ErrAccountLocked, e.Until.Format(time.RFC3339))
+}
+
+func (e *AccountLockedError) Unwrap() error {
+	return ErrAccountLocked
+}
+
+// RetryAfter returns how long the caller has to wait before trying again
+func (e *AccountLockedError) RetryAfter() time.Duration {
+	if d := time.Until(e.Until); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// ProblemExtensions tells clients when they can try to log in again
+func (e *AccountLockedError) ProblemExtensions() map[string]interface{} {
+	return map[string]interface{}{"locked_until": e.Until}
+}
+
+// LockoutPolicy controls progressive account lockout. After MaxFailures consecutive failed
+// logins the account is locked for BaseDuration, doubling for every further lock up to MaxDuration.
+// A zero MaxFailures disables lockout
+type LockoutPolicy struct {
+	MaxFailures  int
+	BaseDuration time.Duration
+	MaxDuration  time.Duration
+}
+
 // TokenClaims represents the JWT claims for authentication
 type TokenClaims struct {
 	UserID int             `json:"user_id"`
 	Email  string          `json:"email"`
 	Role   models.UserRole `json:"role"`
+	// MFA is set on tokens of sessions that verified a second factor
+	MFA bool `json:"mfa,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -43,6 +94,10 @@ type AuthService interface {
 	FindUserByEmail(ctx context.Context, email string) (*models.User, error)
 	FindUserByUsername(ctx context.Context, username string) (*models.User, error)
 	CreateUser(ctx context.Context, user *models.User) error
+	ConfirmPassword(ctx context.Context, user *models.User, password string, authenticatedAt time.Time) error
+	ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string, authenticatedAt time.Time) error
+	IssuePasswordResetToken(ctx context.Context, userID int) (string, time.Time, error)
+	ResetPassword(ctx context.Context, token, newPassword string) error
 
 	// Token operations
 	GenerateAccessToken(user *models.User) (string, error)
@@ -51,6 +106,17 @@ type AuthService interface {
 	RefreshToken(refreshToken string) (string, string, error)
 	Logout(token string) error
 
+	// Multi-factor authentication
+	MFARequired(role models.UserRole) bool
+	GetMFAStatus(ctx context.Context, userID int) (*models.MFAStatus, error)
+	IssueMFAEnrollmentToken(ctx context.Context, userID int) (string, time.Time, error)
+	EnrollMFA(ctx context.Context, userID int, enrollmentToken string) (*models.MFAEnrollment, error)
+	ActivateMFA(ctx context.Context, userID int, code string) ([]string, error)
+	DisableMFA(ctx context.Context, userID int, password, code, recoveryCode string, authenticatedAt time.Time) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int, code string) ([]string, error)
+	GenerateMFAChallenge(user *models.User) (string, error)
+	VerifyMFAChallenge(ctx context.Context, challenge, code, recoveryCode string) (*models.User, error)
+
 	// Token blacklist operations
 	IsTokenBlacklisted(token string) bool
 	AddToBlacklist(token string, expiry time.Duration) error
@@ -71,25 +137,32 @@ type AuthConfig struct {
 	BlacklistExpiration time.Duration `mapstructure:"blacklist_expiration"`
 	TokenConfig         models.TokenConfig
 	RedisConfig         *repositories.RedisConfig
+	Lockout             LockoutPolicy
+	MFA                 MFAPolicy
+	// PasswordResetTTL is how long a password reset token stays valid
+	PasswordResetTTL time.Duration
 }
 
 // AuthServiceImpl implements AuthService
 type AuthServiceImpl struct {
-	userRepo  repositories.UserRepository
-	redisRepo repositories.RedisRepository
-	config    AuthConfig
+	userRepo         repositories.UserRepository
+	recoveryCodeRepo repositories.MFARecoveryCodeRepository
+	redisRepo        repositories.RedisRepository
+	config           AuthConfig
 }
 
 // NewAuthService creates a new instance of AuthService
 func NewAuthService(
 	userRepo repositories.UserRepository,
+	recoveryCodeRepo repositories.MFARecoveryCodeRepository,
 	redisRepo repositories.RedisRepository,
 	config AuthConfig,
 ) AuthService {
 	return &AuthServiceImpl{
-		userRepo:  userRepo,
-		redisRepo: redisRepo,
-		config:    config,
+		userRepo:         userRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		redisRepo:        redisRepo,
+		config:           config,
 	}
 }
 
@@ -116,20 +189,10 @@ func (s *AuthServiceImpl) Register(ctx *fiber.Ctx, user *models.User) (*models.U
 
 	// Hash password if provided
 	if user.Password != nil && *user.Password != "" {
-		// ใช้รหัสผ่านดิบเพื่อบันทึกลงบันทึก
-		rawPassword := *user.Password
-		log.Printf("Register - About to hash password, raw length: %d", len(rawPassword))
-		
-		hashedPassword, err := s.HashPassword(rawPassword)
+		hashedPassword, err := s.HashPassword(*user.Password)
 		if err != nil {
 			return nil, fmt.Errorf("failed to hash password: %w", err)
 		}
-		log.Printf("Register - Password hashed, hash length: %d", len(hashedPassword))
-		
-		// ทดสอบว่าแฮชที่สร้างใหม่สามารถตรวจสอบกับรหัสผ่านเดิมได้หรือไม่
-		isValid := s.CheckPassword(rawPassword, hashedPassword)
-		log.Printf("Register - Verify hash works: %v", isValid)
-		
 		user.Password = &hashedPassword
 	}
 
@@ -158,24 +221,37 @@ func (s *AuthServiceImpl) Login(ctx *fiber.Ctx, email, password string) (*models
 	// Check if user has a password (OAuth users might not have one)
 	if user.Password == nil {
 		log.Printf("Login failed: User has no password (possibly OAuth user): %s", email)
-		return nil, "", errors.New("please use the appropriate login method")
+		return nil, "", apperrors.Unauthorized(apperrors.LOGIN_METHOD_MISMATCH, "please use the appropriate login method")
 	}
 
-	// Log password information for debugging
-	log.Printf("Login attempt - Email: %s, Input password length: %d, Stored hash length: %d", 
-		email, len(password), len(*user.Password))
-
-	// Verify password
+	// Verify password. A wrong password gets the same error whether or not the account is or becomes
+	// locked, so guesses cannot tell which emails have accounts; only the right password learns of a lock.
+	// Guesses against a locked account are not counted
+	locked := user.LockedUntil != nil && time.Now().Before(*user.LockedUntil)
 	passwordMatch := s.CheckPassword(password, *user.Password)
 	if !passwordMatch {
 		log.Printf("Login failed: Password verification failed for user: %s", email)
+		if !locked {
+			s.recordFailedLogin(ctx.Context(), user)
+		}
 		return nil, "", ErrInvalidCredentials
 	}
+	if locked {
+		log.Printf("Login refused: Account locked until %s for user: %s", user.LockedUntil.Format(time.RFC3339), email)
+		return nil, "", &AccountLockedError{Until: *user.LockedUntil}
+	}
+	if user.PasswordResetRequired {
+		return nil, "", ErrPasswordResetRequired
+	}
 
-	// Generate JWT token
-	token, err := s.GenerateAccessToken(user)
-	if err != nil {
-		return nil, "", fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+	// Generate JWT token. Accounts with MFA get theirs once the second factor is verified, and keep
+	// their failure count until then so codes cannot be guessed by logging in again
+	var token string
+	if !user.MFAEnabled {
+		token, err = s.GenerateAccessToken(user)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrTokenGeneration, err)
+		}
 	}
 
 	// Update last login time
@@ -183,7 +259,26 @@ func (s *AuthServiceImpl) Login(ctx *fiber.Ctx, email, password string) (*models
 	// LastLoginAt field is removed as it doesn't exist in the database model
 	// user.LastLoginAt = &now
 	user.UpdatedAt = now
-	if err := s.userRepo.Update(ctx.Context(), user); err != nil {
+	columns := map[string]interface{}{"updated_at": now}
+	// A successful login clears the lockout state
+	if !user.MFAEnabled {
+		user.FailedLoginAttempts = 0
+		user.LockoutCount = 0
+		user.LockedUntil = nil
+		columns["failed_login_attempts"] = 0
+		columns["lockout_count"] = 0
+		columns["locked_until"] = nil
+	}
+	// Upgrade passwords stored as plaintext, bcrypt or with outdated argon2id parameters
+	if passhash.NeedsRehash(*user.Password) {
+		if hashed, err := s.HashPassword(password); err == nil {
+			user.Password = &hashed
+			columns["password"] = hashed
+		} else {
+			log.Printf("Failed to rehash password for user %d: %v", user.ID, err)
+		}
+	}
+	if err := s.userRepo.UpdateColumns(ctx.Context(), user.ID, columns); err != nil {
 		log.Printf("Failed to update user last login time: %v", err)
 		// Continue even if update fails - login is still successful
 	}
@@ -191,6 +286,135 @@ func (s *AuthServiceImpl) Login(ctx *fiber.Ctx, email, password string) (*models
 	return user, token, nil
 }
 
+// recordFailedLogin counts a failed login and locks the account once the policy limit is reached.
+// It returns the error the caller should report for the attempt
+func (s *AuthServiceImpl) recordFailedLogin(ctx context.Context, user *models.User) error {
+	policy := s.config.Lockout
+	if policy.MaxFailures <= 0 {
+		return ErrInvalidCredentials
+	}
+
+	attempts, err := s.userRepo.IncrementFailedLogins(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to record failed login for user %d: %v", user.ID, err)
+		return ErrInvalidCredentials
+	}
+	if attempts < policy.MaxFailures {
+		return ErrInvalidCredentials
+	}
+
+	until := time.Now().Add(policy.lockDuration(user.LockoutCount))
+	if err := s.userRepo.LockAccount(ctx, user.ID, until); err != nil {
+		log.Printf("Failed to lock user %d: %v", user.ID, err)
+		return ErrInvalidCredentials
+	}
+	log.Printf("Account locked until %s after %d failed logins: %s", until.Format(time.RFC3339), attempts, user.Email)
+	return &AccountLockedError{Until: until}
+}
+
+// ConfirmPassword checks the password of a signed-in user before a sensitive change. Wrong passwords
+// count towards the login lockout, so they cannot be guessed here instead. Accounts without a password,
+// signed up through OAuth, confirm by having logged in within recentLoginWindow of authenticatedAt, the
+// time their session's token was issued
+func (s *AuthServiceImpl) ConfirmPassword(ctx context.Context, user *models.User, password string, authenticatedAt time.Time) error {
+	if user.Password == nil {
+		if time.Since(authenticatedAt) > recentLoginWindow {
+			return ErrReauthRequired
+		}
+		return nil
+	}
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		return &AccountLockedError{Until: *user.LockedUntil}
+	}
+	if s.CheckPassword(password, *user.Password) {
+		return nil
+	}
+
+	var locked *AccountLockedError
+	if err := s.recordFailedLogin(ctx, user); errors.As(err, &locked) {
+		return err
+	}
+	return ErrPasswordIncorrect
+}
+
+// ChangePassword replaces a user's password once the current one is confirmed, and clears the lockout state.
+// OAuth accounts without a password set their first one this way, shortly after logging in
+func (s *AuthServiceImpl) ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string, authenticatedAt time.Time) error {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.ConfirmPassword(ctx, user, currentPassword, authenticatedAt); err != nil {
+		return err
+	}
+	return s.setPassword(ctx, user, newPassword)
+}
+
+// IssuePasswordResetToken gives an admin a single-use token to hand to the user out of band, valid for
+// PasswordResetTTL. It is how accounts flagged with PasswordResetRequired, whose passwords were stored in
+// plaintext and may have leaked, and users who forgot their password get back in; the old password is
+// never accepted in its place
+func (s *AuthServiceImpl) IssuePasswordResetToken(ctx context.Context, userID int) (string, time.Time, error) {
+	if _, err := s.GetUserByID(ctx, userID); err != nil {
+		return "", time.Time{}, err
+	}
+	return s.issueOneTimeToken(ctx, passwordResetPurpose, userID, s.config.PasswordResetTTL)
+}
+
+// ResetPassword sets a new password with a token from IssuePasswordResetToken, using the token up
+func (s *AuthServiceImpl) ResetPassword(ctx context.Context, token, newPassword string) error {
+	userID, err := s.consumeOneTimeToken(ctx, passwordResetPurpose, token)
+	if errors.Is(err, errOneTimeTokensUnavailable) {
+		return err
+	}
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	return s.setPassword(ctx, user, newPassword)
+}
+
+// setPassword hashes and saves a new password, clearing the lockout state and any required reset
+func (s *AuthServiceImpl) setPassword(ctx context.Context, user *models.User, newPassword string) error {
+	hashed, err := s.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.Password = &hashed
+	user.PasswordResetRequired = false
+	user.FailedLoginAttempts = 0
+	user.LockoutCount = 0
+	user.LockedUntil = nil
+	if err := s.userRepo.UpdateColumns(ctx, user.ID, map[string]interface{}{
+		"password":                hashed,
+		"password_reset_required": false,
+		"failed_login_attempts":   0,
+		"lockout_count":           0,
+		"locked_until":            nil,
+	}); err != nil {
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+	return nil
+}
+
+// lockDuration doubles the base duration for every earlier lock, capped at MaxDuration
+func (p LockoutPolicy) lockDuration(previousLocks int) time.Duration {
+	d := p.BaseDuration
+	for i := 0; i < previousLocks; i++ {
+		if p.MaxDuration > 0 && d >= p.MaxDuration {
+			break
+		}
+		d *= 2
+	}
+	if p.MaxDuration > 0 && d > p.MaxDuration {
+		d = p.MaxDuration
+	}
+	return d
+}
+
 // GenerateAccessToken generates a new JWT access token for the user
 func (s *AuthServiceImpl) GenerateAccessToken(user *models.User) (string, error) {
 	expiration := time.Now().Add(s.config.JWTExpiration)
@@ -198,6 +422,8 @@ func (s *AuthServiceImpl) GenerateAccessToken(user *models.User) (string, error)
 		UserID: user.ID,
 		Email:  user.Email,
 		Role:   user.Role,
+		// Tokens of accounts with MFA are only issued once the second factor is verified
+		MFA: user.MFAEnabled,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiration),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -331,7 +557,10 @@ func (s *AuthServiceImpl) GetUserByID(ctx context.Context, id int) (*models.User
 	userID := fmt.Sprintf("%d", id)
 	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrUserNotFound, err)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
 	}
 	return user, nil
 }
@@ -363,16 +592,12 @@ func (s *AuthServiceImpl) CreateUser(ctx context.Context, user *models.User) err
 	return nil
 }
 
-// HashPassword returns the password as-is (no hashing for testing)
+// HashPassword hashes a password with argon2id
 func (s *AuthServiceImpl) HashPassword(password string) (string, error) {
-	// Return the password as-is without hashing
-	return password, nil
+	return passhash.Hash(password)
 }
 
-// CheckPassword compares passwords directly (no hashing for testing)
+// CheckPassword verifies a password against a stored argon2id or bcrypt hash, or a legacy plaintext password
 func (s *AuthServiceImpl) CheckPassword(password, hash string) bool {
-	log.Printf("CheckPassword - Comparing plain passwords: input='%s', stored='%s'", password, hash)
-	result := password == hash
-	log.Printf("CheckPassword result: %v", result)
-	return result
+	return passhash.Verify(password, hash)
 }
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "rota-api/errors"
+	"rota-api/i18n"
+	"rota-api/models"
+	"rota-api/repositories"
+	"rota-api/utils/servicetime"
+)
+
+// Board errors
+var (
+	ErrInvalidBoardCursor = apperrors.BadRequest(apperrors.BOARD_INVALID_CURSOR, "invalid board cursor")
+	ErrInvalidBoardType   = apperrors.BadRequest(apperrors.BOARD_INVALID_TYPE, "board type must be departures or arrivals")
+)
+
+// Board display statuses derived from schedule logs
+const (
+	BoardStatusDeparted = "departed"
+	BoardStatusArrived  = "arrived"
+)
+
+// BoardRequest describes which page of a station board to build
+type BoardRequest struct {
+	StationID uint
+	Type      string
+	At        time.Time
+	Window    time.Duration
+	Limit     int
+	Cursor    string // opaque cursor from a previous page's next_cursor or prev_cursor
+}
+
+// BoardService interface defines methods for station departure and arrival boards
+type BoardService interface {
+	GetStationBoard(ctx context.Context, req BoardRequest) (*models.StationBoard, error)
+}
+
+// boardService implements BoardService
+type boardService struct {
+	scheduleRepo    repositories.ScheduleRepository
+	scheduleLogRepo repositories.ScheduleLogRepository
+}
+
+// NewBoardService creates a new board service
+func NewBoardService(scheduleRepo repositories.ScheduleRepository, scheduleLogRepo repositories.ScheduleLogRepository) BoardService {
+	return &boardService{
+		scheduleRepo:    scheduleRepo,
+		scheduleLogRepo: scheduleLogRepo,
+	}
+}
+
+// GetStationBoard builds one page of a station's departure or arrival board.
+// Without a cursor the page starts at req.At; with a cursor it continues forward or
+// backward in time from the trip the cursor was taken from
+func (s *boardService) GetStationBoard(ctx context.Context, req BoardRequest) (*models.StationBoard, error) {
+	if req.Type == "" {
+		req.Type = models.BoardDepartures
+	}
+	if req.Type != models.BoardDepartures && req.Type != models.BoardArrivals {
+		return nil, ErrInvalidBoardType
+	}
+
+	query := models.BoardQuery{
+		StationID: req.StationID,
+		Type:      req.Type,
+		From:      req.At,
+		Inclusive: true,
+		Window:    req.Window,
+		Limit:     req.Limit,
+	}
+	if req.Cursor != "" {
+		backward, at, id, err := decodeBoardCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query.From, query.FromID, query.Backward, query.Inclusive = at, id, backward, false
+	}
+
+	station, schedules, err := s.scheduleRepo.FindBoard(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(schedules) > req.Limit
+	if hasMore {
+		schedules = schedules[:req.Limit]
+	}
+	if query.Backward {
+		for i, j := 0, len(schedules)-1; i < j; i, j = i+1, j-1 {
+			schedules[i], schedules[j] = schedules[j], schedules[i]
+		}
+	}
+
+	scheduleIDs := make([]uint, len(schedules))
+	for i, schedule := range schedules {
+		scheduleIDs[i] = schedule.ID
+	}
+	latestLogs, err := s.scheduleLogRepo.FindLatestBySchedules(ctx, scheduleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	locale := i18n.FromContext(ctx)
+	board := &models.StationBoard{
+		Station: station.Localized(locale),
+		Type:    req.Type,
+		At:      servicetime.In(query.From),
+		Entries: make([]models.BoardEntry, 0, len(schedules)),
+	}
+	for _, schedule := range schedules {
+		schedule.Route = schedule.Route.Localized(locale)
+		board.Entries = append(board.Entries, buildBoardEntry(req.Type, schedule, latestLogs[schedule.ID]))
+	}
+
+	// A page can always be left in the direction it came from; the other direction only if more trips remain
+	if len(schedules) > 0 {
+		first, last := schedules[0], schedules[len(schedules)-1]
+		if !query.Backward || hasMore {
+			board.PrevCursor = encodeBoardCursor(true, boardTime(req.Type, first), first.ID)
+		}
+		if query.Backward || hasMore {
+			board.NextCursor = encodeBoardCursor(false, boardTime(req.Type, last), last.ID)
+		}
+	} else if query.Backward {
+		board.NextCursor = encodeBoardCursor(false, query.From.Add(-time.Nanosecond), 0)
+	} else {
+		board.PrevCursor = encodeBoardCursor(true, query.From, query.FromID)
+	}
+
+	return board, nil
+}
+
+// buildBoardEntry combines a trip with its latest log into a board line
+func buildBoardEntry(boardType string, schedule *models.Schedule, latestLog *models.ScheduleLog) models.BoardEntry {
+	entry := models.BoardEntry{
+		ScheduleID:    schedule.ID,
+		RouteID:       schedule.RouteID,
+		Round:         schedule.Round,
+		ServiceDate:   schedule.ServiceDate,
+		Origin:        schedule.Route.StartStation.Name,
+		Destination:   schedule.Route.EndStation.Name,
+		ScheduledTime: boardTime(boardType, schedule),
+		Status:        schedule.Status,
+		Platform:      schedule.Platform,
+		VehicleID:     schedule.VehicleID,
+		LicensePlate:  schedule.Vehicle.LicensePlate,
+	}
+	entry.EstimatedTime = entry.ScheduledTime
+
+	if latestLog == nil {
+		return entry
+	}
+
+	entry.Notes = latestLog.Notes
+	if latestLog.Status != "" {
+		entry.Status = latestLog.Status
+	}
+
+	// Arrivals use the actual arrival once known, otherwise the departure delay carries through
+	var delay time.Duration
+	switch {
+	case boardType == models.BoardArrivals && latestLog.ActualArrival != nil:
+		entry.ActualTime = latestLog.ActualArrival
+		delay = latestLog.ActualArrival.Sub(schedule.ArrivalTime)
+		if latestLog.Status == "" {
+			entry.Status = BoardStatusArrived
+		}
+	case latestLog.ActualDeparture != nil:
+		delay = latestLog.ActualDeparture.Sub(schedule.DepartureTime)
+		if boardType == models.BoardDepartures {
+			entry.ActualTime = latestLog.ActualDeparture
+			if latestLog.Status == "" {
+				entry.Status = BoardStatusDeparted
+			}
+		}
+	}
+
+	entry.DelayMinutes = int(delay.Minutes())
+	entry.EstimatedTime = entry.ScheduledTime.Add(delay)
+	if entry.DelayMinutes > 0 && entry.Status == models.ScheduleStatusScheduled {
+		entry.Status = models.ScheduleStatusDelayed
+	}
+
+	return entry
+}
+
+// boardTime returns the time a trip is listed under on the given board
+func boardTime(boardType string, schedule *models.Schedule) time.Time {
+	if boardType == models.BoardArrivals {
+		return schedule.ArrivalTime
+	}
+	return schedule.DepartureTime
+}
+
+// encodeBoardCursor packs a paging direction and (time, schedule ID) position into an opaque string
+func encodeBoardCursor(backward bool, at time.Time, id uint) string {
+	direction := "n"
+	if backward {
+		direction = "p"
+	}
+	raw := fmt.Sprintf("%s:%d:%d", direction, at.UnixNano(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeBoardCursor unpacks a cursor produced by encodeBoardCursor
+func decodeBoardCursor(cursor string) (bool, time.Time, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return false, time.Time{}, 0, ErrInvalidBoardCursor
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 3 || (parts[0] != "n" && parts[0] != "p") {
+		return false, time.Time{}, 0, ErrInvalidBoardCursor
+	}
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return false, time.Time{}, 0, ErrInvalidBoardCursor
+	}
+	id, err := strconv.ParseUint(parts[2], 10, 32)
+	if err != nil {
+		return false, time.Time{}, 0, ErrInvalidBoardCursor
+	}
+
+	return parts[0] == "p", time.Unix(0, nanos), uint(id), nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"rota-api/i18n"
+	"rota-api/models"
+	"rota-api/repositories"
+)
+
+// Cache tags. Invalidating a tag drops every cached result stored under it
+const (
+	cacheTagStations  = "stations"
+	cacheTagRoutes    = "routes"
+	cacheTagSchedules = "schedules"
+)
+
+// cacheTagRoute tags cached results that embed a single route
+func cacheTagRoute(id uint) string {
+	return fmt.Sprintf("route:%d", id)
+}
+
+// pageCacheKey identifies one page of a cached list, as rendered in locale
+func pageCacheKey(prefix string, locale i18n.Locale, params models.SearchParams) string {
+	return fmt.Sprintf("%s:%s:page:%d:%d:%s:%t:%s:%s", prefix, locale, params.Page, params.PageSize, params.SortBy, params.SortDesc, params.Cursor, params.Filter)
+}
+
+// CacheConfig holds the cache used for read-through caching and how long results live in it
+type CacheConfig struct {
+	Repo repositories.CacheRepository
+	TTL  time.Duration
+}
+
+// readThrough returns the cached value for key, or loads it, caches it under the given tags and returns it.
+// Cache failures never fail the request; they only cost a trip to the database
+func readThrough[T any](ctx context.Context, cache CacheConfig, key string, tags []string, load func() (T, error)) (T, error) {
+	if cached, err := cache.Repo.Get(ctx, key); err == nil {
+		var value T
+		if err := json.Unmarshal(cached, &value); err == nil {
+			return value, nil
+		}
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	if encoded, err := json.Marshal(value); err == nil {
+		if err := cache.Repo.Set(ctx, key, encoded, cache.TTL, tags...); err != nil {
+			log.Printf("Cache: failed to set %s: %v", key, err)
+		}
+	}
+	return value, nil
+}
+
+// invalidateCache drops cached results for the given tags after a write
+func invalidateCache(ctx context.Context, cache CacheConfig, tags ...string) {
+	if err := cache.Repo.InvalidateTags(ctx, tags...); err != nil {
+		log.Printf("Cache: failed to invalidate %v: %v", tags, err)
+	}
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	apperrors "rota-api/errors"
+	"rota-api/models"
+	"rota-api/repositories"
+	"rota-api/utils/servicetime"
+)
+
+// ErrScheduleConflict is returned when a schedule would double-book a vehicle
+var ErrScheduleConflict = apperrors.Conflict(apperrors.SCHEDULE_CONFLICT, "schedule conflicts with another trip")
+
+// ConflictError carries the conflicts that caused a schedule to be rejected
+type ConflictError struct {
+	Conflicts []models.ScheduleConflict
+}
+
+func (e *ConflictError) Error() string {
+	if len(e.Conflicts) == 0 {
+		return ErrScheduleConflict.Error()
+	}
+	return fmt.Sprintf("%s: %s", ErrScheduleConflict, e.Conflicts[0].Message)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrScheduleConflict
+}
+
+// ProblemExtensions lists the conflicting trips in the error response
+func (e *ConflictError) ProblemExtensions() map[string]interface{} {
+	if len(e.Conflicts) == 0 {
+		return nil
+	}
+	return map[string]interface{}{"conflicts": e.Conflicts}
+}
+
+// DispatchRules holds the vehicle turnaround rules enforced when scheduling trips
+type DispatchRules struct {
+	VehicleTurnaround time.Duration
+}
+
+// ConflictService interface defines methods for detecting double-booked vehicles and drivers
+type ConflictService interface {
+	CheckSchedule(ctx context.Context, schedule *models.Schedule) ([]models.ScheduleConflict, error)
+	ValidateRange(ctx context.Context, from, to time.Time) (*models.ConflictReport, error)
+	CheckPlan(ctx context.Context, planned []*models.Schedule) ([]models.ScheduleConflict, error)
+}
+
+// conflictService implements ConflictService
+type conflictService struct {
+	scheduleRepo repositories.ScheduleRepository
+	rules        DispatchRules
+}
+
+// NewConflictService creates a new conflict service
+func NewConflictService(scheduleRepo repositories.ScheduleRepository, rules DispatchRules) ConflictService {
+	return &conflictService{
+		scheduleRepo: scheduleRepo,
+		rules:        rules,
+	}
+}
+
+// CheckSchedule finds the trips of the schedule's vehicle that overlap it or leave
+// less than the turnaround time at the end station before or after it
+func (s *conflictService) CheckSchedule(ctx context.Context, schedule *models.Schedule) ([]models.ScheduleConflict, error) {
+	if schedule.VehicleID == 0 || schedule.DepartureTime.IsZero() || schedule.ArrivalTime.IsZero() {
+		return nil, nil
+	}
+	if schedule.Status == models.ScheduleStatusCancelled {
+		return nil, nil
+	}
+
+	trips, err := s.scheduleRepo.FindByVehicleBetween(ctx, schedule.VehicleID,
+		schedule.DepartureTime.Add(-s.rules.VehicleTurnaround),
+		schedule.ArrivalTime.Add(s.rules.VehicleTurnaround))
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []models.ScheduleConflict
+	for _, trip := range trips {
+		if trip.ID == schedule.ID {
+			continue
+		}
+
+		first, second := trip, schedule
+		if schedule.DepartureTime.Before(trip.DepartureTime) {
+			first, second = schedule, trip
+		}
+		if conflict, ok := s.vehicleConflict(first, second); ok {
+			conflict.ScheduleID = schedule.ID
+			conflict.ConflictingScheduleID = trip.ID
+			conflicts = append(conflicts, conflict)
+		}
+	}
+
+	return conflicts, nil
+}
+
+// ValidateRange reports every vehicle and driver conflict among the trips departing in [from, to)
+func (s *conflictService) ValidateRange(ctx context.Context, from, to time.Time) (*models.ConflictReport, error) {
+	trips, err := s.scheduleRepo.FindBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ConflictReport{
+		From:             servicetime.In(from),
+		To:               servicetime.In(to),
+		SchedulesChecked: len(trips),
+		Conflicts:        s.conflictsAmong(trips, nil),
+	}
+	return report, nil
+}
+
+// CheckPlan reports the conflicts the planned trips would have once saved, among themselves and with the
+// stored trips. Planned trips with the ID of a stored trip replace it; new trips have no ID
+func (s *conflictService) CheckPlan(ctx context.Context, planned []*models.Schedule) ([]models.ScheduleConflict, error) {
+	inPlan := make(map[*models.Schedule]bool)
+	replaced := make(map[uint]bool)
+	var from, to time.Time
+	for _, trip := range planned {
+		if trip.Status == models.ScheduleStatusCancelled {
+			continue
+		}
+		inPlan[trip] = true
+		if trip.ID != 0 {
+			replaced[trip.ID] = true
+		}
+		if from.IsZero() || trip.DepartureTime.Before(from) {
+			from = trip.DepartureTime
+		}
+		if trip.ArrivalTime.After(to) {
+			to = trip.ArrivalTime
+		}
+	}
+	if len(inPlan) == 0 {
+		return []models.ScheduleConflict{}, nil
+	}
+
+	// Stored trips departing up to a day earlier may still be running when the first planned trip leaves
+	stored, err := s.scheduleRepo.FindBetween(ctx,
+		from.Add(-24*time.Hour-s.rules.VehicleTurnaround),
+		to.Add(s.rules.VehicleTurnaround))
+	if err != nil {
+		return nil, err
+	}
+
+	trips := make([]*models.Schedule, 0, len(stored)+len(inPlan))
+	for _, trip := range stored {
+		if !replaced[trip.ID] {
+			trips = append(trips, trip)
+		}
+	}
+	for _, trip := range planned {
+		if inPlan[trip] {
+			trips = append(trips, trip)
+		}
+	}
+	sort.SliceStable(trips, func(i, j int) bool {
+		return trips[i].DepartureTime.Before(trips[j].DepartureTime)
+	})
+
+	return s.conflictsAmong(trips, func(first, second *models.Schedule) bool {
+		return inPlan[first] || inPlan[second]
+	}), nil
+}
+
+// conflictsAmong reports the vehicle and driver conflicts among trips, which must be ordered by
+// departure. When keep is set, only pairs of trips it accepts are compared
+func (s *conflictService) conflictsAmong(trips []*models.Schedule, keep func(first, second *models.Schedule) bool) []models.ScheduleConflict {
+	conflicts := []models.ScheduleConflict{}
+
+	// Trips are ordered by departure, so grouping keeps each group ordered too
+	byVehicle := make(map[uint][]*models.Schedule)
+	byDriver := make(map[uint][]*models.Schedule)
+	var vehicleOrder, driverOrder []uint
+	for _, trip := range trips {
+		if _, ok := byVehicle[trip.VehicleID]; !ok {
+			vehicleOrder = append(vehicleOrder, trip.VehicleID)
+		}
+		byVehicle[trip.VehicleID] = append(byVehicle[trip.VehicleID], trip)
+
+		if trip.DriverID != nil {
+			if _, ok := byDriver[*trip.DriverID]; !ok {
+				driverOrder = append(driverOrder, *trip.DriverID)
+			}
+			byDriver[*trip.DriverID] = append(byDriver[*trip.DriverID], trip)
+		}
+	}
+
+	for _, vehicleID := range vehicleOrder {
+		group := byVehicle[vehicleID]
+		for i, first := range group {
+			for _, second := range group[i+1:] {
+				if !second.DepartureTime.Before(first.ArrivalTime.Add(s.rules.VehicleTurnaround)) {
+					break
+				}
+				if keep != nil && !keep(first, second) {
+					continue
+				}
+				if conflict, ok := s.vehicleConflict(first, second); ok {
+					conflicts = append(conflicts, conflict)
+				}
+			}
+		}
+	}
+
+	for _, driverID := range driverOrder {
+		group := byDriver[driverID]
+		for i, first := range group {
+			for _, second := range group[i+1:] {
+				if !second.DepartureTime.Before(first.ArrivalTime) {
+					break
+				}
+				if keep != nil && !keep(first, second) {
+					continue
+				}
+				conflicts = append(conflicts, models.ScheduleConflict{
+					Type:                  models.ConflictDriverOverlap,
+					ScheduleID:            first.ID,
+					ConflictingScheduleID: second.ID,
+					DriverID:              first.DriverID,
+					StartsAt:              second.DepartureTime,
+					Message:               fmt.Sprintf("driver %d is assigned to overlapping schedules %d and %d", driverID, first.ID, second.ID),
+				})
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// vehicleConflict compares two trips of the same vehicle, where first departs no later than second
+func (s *conflictService) vehicleConflict(first, second *models.Schedule) (models.ScheduleConflict, bool) {
+	conflict := models.ScheduleConflict{
+		ScheduleID:            first.ID,
+		ConflictingScheduleID: second.ID,
+		VehicleID:             first.VehicleID,
+		StartsAt:              second.DepartureTime,
+	}
+
+	gap := second.DepartureTime.Sub(first.ArrivalTime)
+	switch {
+	case gap < 0:
+		conflict.Type = models.ConflictVehicleOverlap
+		conflict.Message = fmt.Sprintf("vehicle %d is booked on overlapping schedules %d and %d", first.VehicleID, first.ID, second.ID)
+	case gap < s.rules.VehicleTurnaround:
+		conflict.Type = models.ConflictVehicleTurnaround
+		conflict.Message = fmt.Sprintf("vehicle %d has only %s turnaround between schedules %d and %d, minimum is %s",
+			first.VehicleID, gap, first.ID, second.ID, s.rules.VehicleTurnaround)
+	default:
+		return conflict, false
+	}
+
+	return conflict, true
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"time"
+
+	apperrors "rota-api/errors"
+	"rota-api/i18n"
+	"rota-api/models"
+	"rota-api/repositories"
+	"rota-api/utils/servicetime"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidTheme is returned when a display theme has malformed colours or scale
+var ErrInvalidTheme = apperrors.BadRequest(apperrors.DISPLAY_INVALID_THEME, "invalid display theme: colours must be hex (#rgb or #rrggbb) and font_scale between 0.5 and 3")
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// The signage board shows the next few trips each way within the coming day
+const (
+	displayWindow = 24 * time.Hour
+	displayRows   = 10
+)
+
+// DefaultDisplayTheme is used for stations without a theme of their own
+func DefaultDisplayTheme(stationID uint) models.StationDisplayTheme {
+	return models.StationDisplayTheme{
+		StationID:       stationID,
+		BackgroundColor: "#0b1f3a",
+		TextColor:       "#ffffff",
+		AccentColor:     "#ffcc00",
+		FontScale:       1,
+	}
+}
+
+// DisplayService interface defines methods for the station signage display
+type DisplayService interface {
+	GetStationDisplay(ctx context.Context, stationID uint) (*models.StationDisplay, error)
+	GetTheme(ctx context.Context, stationID uint) (models.StationDisplayTheme, error)
+	UpdateTheme(ctx context.Context, theme *models.StationDisplayTheme) error
+	ResetTheme(ctx context.Context, stationID uint) error
+}
+
+// displayService implements DisplayService
+type displayService struct {
+	boardService     BoardService
+	displayThemeRepo repositories.DisplayThemeRepository
+}
+
+// NewDisplayService creates a new display service
+func NewDisplayService(boardService BoardService, displayThemeRepo repositories.DisplayThemeRepository) DisplayService {
+	return &displayService{
+		boardService:     boardService,
+		displayThemeRepo: displayThemeRepo,
+	}
+}
+
+// GetStationDisplay gathers a station's next departures and arrivals, with their live status
+// and delay, in the locale of ctx. The result is fingerprinted so connected displays can tell
+// when they need to refresh
+func (s *displayService) GetStationDisplay(ctx context.Context, stationID uint) (*models.StationDisplay, error) {
+	locale := i18n.FromContext(ctx)
+	now := servicetime.Now()
+
+	departures, err := s.boardService.GetStationBoard(ctx, BoardRequest{
+		StationID: stationID, Type: models.BoardDepartures, At: now, Window: displayWindow, Limit: displayRows,
+	})
+	if err != nil {
+		return nil, err
+	}
+	arrivals, err := s.boardService.GetStationBoard(ctx, BoardRequest{
+		StationID: stationID, Type: models.BoardArrivals, At: now, Window: displayWindow, Limit: displayRows,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	theme, err := s.GetTheme(ctx, stationID)
+	if err != nil {
+		return nil, err
+	}
+
+	display := &models.StationDisplay{
+		Locale:         string(locale),
+		StationName:    departures.Station.Name,
+		StationDetails: departures.Station.Description(locale),
+		Departures:     displayEntries(locale, departures),
+		Arrivals:       displayEntries(locale, arrivals),
+		Theme:          theme,
+		GeneratedAt:    now,
+	}
+
+	fingerprint, err := json.Marshal(struct {
+		Locale         string
+		StationName    string
+		StationDetails string
+		Departures     []models.DisplayEntry
+		Arrivals       []models.DisplayEntry
+		Theme          models.StationDisplayTheme
+	}{display.Locale, display.StationName, display.StationDetails, display.Departures, display.Arrivals, theme})
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum(fingerprint)
+	display.Fingerprint = hex.EncodeToString(sum[:])
+
+	return display, nil
+}
+
+// displayEntries writes out a board's trips for the signage display in locale
+func displayEntries(locale i18n.Locale, board *models.StationBoard) []models.DisplayEntry {
+	entries := make([]models.DisplayEntry, 0, len(board.Entries))
+	for _, entry := range board.Entries {
+		line := models.DisplayEntry{
+			Time:         i18n.FormatTime(locale, entry.ScheduledTime),
+			Station:      entry.Destination,
+			Platform:     entry.Platform,
+			Status:       entry.Status,
+			StatusLabel:  i18n.T(locale, i18n.MsgTripStatus+entry.Status),
+			DelayMinutes: entry.DelayMinutes,
+		}
+		if board.Type == models.BoardArrivals {
+			line.Station = entry.Origin
+		}
+		if line.StatusLabel == i18n.MsgTripStatus+entry.Status {
+			// สถานะที่ยังไม่มีคำแปลจะแสดงตามค่าเดิม
+			line.StatusLabel = entry.Status
+		}
+		if entry.DelayMinutes > 0 {
+			line.Estimated = i18n.FormatTime(locale, entry.EstimatedTime)
+		}
+		entries = append(entries, line)
+	}
+	return entries
+}
+
+// GetTheme returns the station's display theme, or the default theme if it has none
+func (s *displayService) GetTheme(ctx context.Context, stationID uint) (models.StationDisplayTheme, error) {
+	theme, err := s.displayThemeRepo.FindByStation(ctx, stationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// สถานีที่ยังไม่ได้ตั้งค่าธีมจะใช้ธีมเริ่มต้น
+		return DefaultDisplayTheme(stationID), nil
+	}
+	if err != nil {
+		return models.StationDisplayTheme{}, err
+	}
+	return *theme, nil
+}
+
+// UpdateTheme validates and saves a station's display theme, filling unset fields from the default
+func (s *displayService) UpdateTheme(ctx context.Context, theme *models.StationDisplayTheme) error {
+	defaults := DefaultDisplayTheme(theme.StationID)
+	if theme.BackgroundColor == "" {
+		theme.BackgroundColor = defaults.BackgroundColor
+	}
+	if theme.TextColor == "" {
+		theme.TextColor = defaults.TextColor
+	}
+	if theme.AccentColor == "" {
+		theme.AccentColor = defaults.AccentColor
+	}
+	if theme.FontScale == 0 {
+		theme.FontScale = defaults.FontScale
+	}
+
+	if !hexColor.MatchString(theme.BackgroundColor) || !hexColor.MatchString(theme.TextColor) ||
+		!hexColor.MatchString(theme.AccentColor) || theme.FontScale < 0.5 || theme.FontScale > 3 {
+		return ErrInvalidTheme
+	}
+
+	return s.displayThemeRepo.Upsert(ctx, theme)
+}
+
+// ResetTheme removes a station's display theme so it uses the default again
+func (s *displayService) ResetTheme(ctx context.Context, stationID uint) error {
+	return s.displayThemeRepo.DeleteByStation(ctx, stationID)
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apperrors "rota-api/errors"
+	"rota-api/models"
+	"rota-api/repositories"
+	"rota-api/utils/servicetime"
+)
+
+// Roster errors
+var (
+	ErrDriverExists         = apperrors.Conflict(apperrors.DRIVER_EXISTS, "driver with this license number already exists")
+	ErrDriverInactive       = apperrors.Conflict(apperrors.DRIVER_INACTIVE, "driver is not active")
+	ErrDriverLicenseExpired = apperrors.Conflict(apperrors.DRIVER_LICENSE_EXPIRED, "driver license expires before the trip ends")
+	ErrDriverDoubleBooked   = apperrors.Conflict(apperrors.DRIVER_DOUBLE_BOOKED, "driver is already assigned to an overlapping trip")
+	ErrInsufficientRest     = apperrors.Conflict(apperrors.DRIVER_INSUFFICIENT_REST, "driver would not get the minimum rest between trips")
+	ErrDutyHoursExceeded    = apperrors.Conflict(apperrors.DRIVER_DUTY_HOURS_EXCEEDED, "driver would exceed the maximum daily duty hours")
+)
+
+// isRosterViolation reports whether err is a roster rule the assignment breaks
+func isRosterViolation(err error) bool {
+	for _, violation := range []error{ErrDriverInactive, ErrDriverLicenseExpired, ErrDriverDoubleBooked, ErrInsufficientRest, ErrDutyHoursExceeded} {
+		if errors.Is(err, violation) {
+			return true
+		}
+	}
+	return false
+}
+
+// RosterRules holds the labour rules enforced when rostering drivers
+type RosterRules struct {
+	MinRestBetweenTrips time.Duration
+	MaxDailyDuty        time.Duration
+}
+
+// DriverService interface defines methods for driver and roster service
+type DriverService interface {
+	GetDriverByID(ctx context.Context, id uint) (*models.Driver, error)
+	GetAllDrivers(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
+	CreateDriver(ctx context.Context, driver *models.Driver) error
+	UpdateDriver(ctx context.Context, driver *models.Driver) error
+	PatchDriver(ctx context.Context, id uint, apply func(*models.Driver) error) (*models.Driver, error)
+	DeleteDriver(ctx context.Context, id uint, version int) error
+
+	// Rostering
+	ValidateAssignment(ctx context.Context, driverID uint, schedule *models.Schedule, planned ...*models.Schedule) error
+	AssignDriver(ctx context.Context, scheduleID, driverID uint) (*models.Schedule, error)
+	UnassignDriver(ctx context.Context, scheduleID uint) (*models.Schedule, error)
+	GetRoster(ctx context.Context, date time.Time) (*models.DriverRoster, error)
+	GetDriverDuty(ctx context.Context, driverID uint, date time.Time) (*models.DriverDuty, error)
+}
+
+// driverService implements DriverService
+type driverService struct {
+	driverRepo   repositories.DriverRepository
+	scheduleRepo repositories.ScheduleRepository
+	rules        RosterRules
+	cache        CacheConfig
+}
+
+// NewDriverService creates a new driver service
+func NewDriverService(driverRepo repositories.DriverRepository, scheduleRepo repositories.ScheduleRepository, rules RosterRules, cache CacheConfig) DriverService {
+	return &driverService{
+		driverRepo:   driverRepo,
+		scheduleRepo: scheduleRepo,
+		rules:        rules,
+		cache:        cache,
+	}
+}
+
+// GetDriverByID retrieves a driver by ID
+func (s *driverService) GetDriverByID(ctx context.Context, id uint) (*models.Driver, error) {
+	return s.driverRepo.FindByID(ctx, id)
+}
+
+// GetAllDrivers retrieves one page of drivers
+func (s *driverService) GetAllDrivers(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	return s.driverRepo.FindPage(ctx, params)
+}
+
+// CreateDriver creates a new driver
+func (s *driverService) CreateDriver(ctx context.Context, driver *models.Driver) error {
+	existingDriver, err := s.driverRepo.FindByLicenseNumber(ctx, driver.LicenseNumber)
+	if err == nil && existingDriver != nil {
+		return ErrDriverExists
+	}
+
+	driver.Active = true
+	return s.driverRepo.Create(ctx, driver)
+}
+
+// UpdateDriver saves changes to an existing driver
+func (s *driverService) UpdateDriver(ctx context.Context, driver *models.Driver) error {
+	existingDriver, err := s.driverRepo.FindByLicenseNumber(ctx, driver.LicenseNumber)
+	if err == nil && existingDriver != nil && existingDriver.ID != driver.ID {
+		return ErrDriverExists
+	}
+
+	return s.driverRepo.Update(ctx, driver)
+}
+
+// PatchDriver applies a patch to the driver and saves the result
+func (s *driverService) PatchDriver(ctx context.Context, id uint, apply func(*models.Driver) error) (*models.Driver, error) {
+	driver, err := s.driverRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := apply(driver); err != nil {
+		return nil, err
+	}
+
+	driver.ID = id
+	if err := s.UpdateDriver(ctx, driver); err != nil {
+		return nil, err
+	}
+	return driver, nil
+}
+
+// DeleteDriver deletes a driver
+func (s *driverService) DeleteDriver(ctx context.Context, id uint, version int) error {
+	return s.driverRepo.Delete(ctx, id, version)
+}
+
+// ValidateAssignment checks that the driver may legally drive the given trip:
+// the driver is active and licensed, is not on another trip at the same time,
+// gets the minimum rest before and after the trip, and stays within the daily duty limit.
+// planned are schedules about to be saved together with this one, e.g. by a bulk shift; they
+// replace their stored versions, so the driver's other trips are checked as they will be
+func (s *driverService) ValidateAssignment(ctx context.Context, driverID uint, schedule *models.Schedule, planned ...*models.Schedule) error {
+	driver, err := s.driverRepo.FindByID(ctx, driverID)
+	if err != nil {
+		return err
+	}
+
+	if !driver.Active {
+		return ErrDriverInactive
+	}
+	if driver.LicenseExpiry.Before(schedule.ArrivalTime) {
+		return ErrDriverLicenseExpired
+	}
+
+	dayStart, dayEnd := servicetime.DayBounds(servicetime.ServiceDate(schedule.DepartureTime))
+
+	// Look far enough either side of the trip to catch rest violations across midnight
+	from := dayStart
+	if restStart := schedule.DepartureTime.Add(-s.rules.MinRestBetweenTrips); restStart.Before(from) {
+		from = restStart
+	}
+	to := dayEnd
+	if restEnd := schedule.ArrivalTime.Add(s.rules.MinRestBetweenTrips); restEnd.After(to) {
+		to = restEnd
+	}
+
+	trips, err := s.driverRepo.FindTrips(ctx, driverID, from, to)
+	if err != nil {
+		return err
+	}
+	trips = withPlannedTrips(trips, driverID, from, to, planned)
+
+	duty := schedule.ArrivalTime.Sub(schedule.DepartureTime)
+	for _, trip := range trips {
+		if trip == schedule || (trip.ID != 0 && trip.ID == schedule.ID) {
+			continue
+		}
+
+		if trip.DepartureTime.Before(schedule.ArrivalTime) && trip.ArrivalTime.After(schedule.DepartureTime) {
+			return fmt.Errorf("%w: schedule %d", ErrDriverDoubleBooked, trip.ID)
+		}
+		if !trip.ArrivalTime.After(schedule.DepartureTime) && schedule.DepartureTime.Sub(trip.ArrivalTime) < s.rules.MinRestBetweenTrips {
+			return fmt.Errorf("%w: only %s after schedule %d", ErrInsufficientRest, schedule.DepartureTime.Sub(trip.ArrivalTime), trip.ID)
+		}
+		if !trip.DepartureTime.Before(schedule.ArrivalTime) && trip.DepartureTime.Sub(schedule.ArrivalTime) < s.rules.MinRestBetweenTrips {
+			return fmt.Errorf("%w: only %s before schedule %d", ErrInsufficientRest, trip.DepartureTime.Sub(schedule.ArrivalTime), trip.ID)
+		}
+
+		if !trip.DepartureTime.Before(dayStart) && trip.DepartureTime.Before(dayEnd) {
+			duty += trip.ArrivalTime.Sub(trip.DepartureTime)
+		}
+	}
+
+	if duty > s.rules.MaxDailyDuty {
+		return fmt.Errorf("%w: %s scheduled, limit is %s", ErrDutyHoursExceeded, duty, s.rules.MaxDailyDuty)
+	}
+
+	return nil
+}
+
+// withPlannedTrips replaces the stored trips of a driver overlapping [from, to) by their planned versions
+// and adds the planned trips not stored yet
+func withPlannedTrips(stored []*models.Schedule, driverID uint, from, to time.Time, planned []*models.Schedule) []*models.Schedule {
+	if len(planned) == 0 {
+		return stored
+	}
+
+	replaced := make(map[uint]bool, len(planned))
+	for _, schedule := range planned {
+		if schedule.ID != 0 {
+			replaced[schedule.ID] = true
+		}
+	}
+
+	trips := make([]*models.Schedule, 0, len(stored)+len(planned))
+	for _, trip := range stored {
+		if !replaced[trip.ID] {
+			trips = append(trips, trip)
+		}
+	}
+	for _, schedule := range planned {
+		if schedule.DriverID == nil || *schedule.DriverID != driverID || schedule.Status == models.ScheduleStatusCancelled {
+			continue
+		}
+		if schedule.DepartureTime.Before(to) && schedule.ArrivalTime.After(from) {
+			trips = append(trips, schedule)
+		}
+	}
+	return trips
+}
+
+// AssignDriver rosters a driver onto a schedule trip after validating the labour rules
+func (s *driverService) AssignDriver(ctx context.Context, scheduleID, driverID uint) (*models.Schedule, error) {
+	schedule, err := s.scheduleRepo.FindByID(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ValidateAssignment(ctx, driverID, schedule); err != nil {
+		return nil, err
+	}
+
+	schedule.DriverID = &driverID
+	if err := s.scheduleRepo.Update(ctx, schedule); err != nil {
+		return nil, err
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules)
+
+	return s.scheduleRepo.FindByID(ctx, scheduleID)
+}
+
+// UnassignDriver removes the driver from a schedule trip
+func (s *driverService) UnassignDriver(ctx context.Context, scheduleID uint) (*models.Schedule, error) {
+	schedule, err := s.scheduleRepo.FindByID(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.DriverID = nil
+	schedule.Driver = nil
+	if err := s.scheduleRepo.Update(ctx, schedule); err != nil {
+		return nil, err
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules)
+
+	return schedule, nil
+}
+
+// GetRoster builds the duty roster of every driver with trips departing on the given service date
+func (s *driverService) GetRoster(ctx context.Context, date time.Time) (*models.DriverRoster, error) {
+	dayStart, dayEnd := servicetime.DayBounds(date)
+	trips, err := s.driverRepo.FindRosteredTrips(ctx, dayStart, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	roster := &models.DriverRoster{
+		Date:    date.Format(servicetime.DateLayout),
+		Drivers: []models.DriverDuty{},
+	}
+
+	// Trips are ordered by driver, so consecutive trips of the same driver form one duty
+	for i := 0; i < len(trips); {
+		j := i
+		for j < len(trips) && *trips[j].DriverID == *trips[i].DriverID {
+			j++
+		}
+
+		var driver models.Driver
+		if trips[i].Driver != nil {
+			driver = *trips[i].Driver
+		}
+		roster.Drivers = append(roster.Drivers, s.buildDuty(driver, trips[i:j]))
+		i = j
+	}
+
+	return roster, nil
+}
+
+// GetDriverDuty builds a single driver's duty for the given service date
+func (s *driverService) GetDriverDuty(ctx context.Context, driverID uint, date time.Time) (*models.DriverDuty, error) {
+	driver, err := s.driverRepo.FindByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	dayStart, dayEnd := servicetime.DayBounds(date)
+	trips, err := s.driverRepo.FindTrips(ctx, driverID, dayStart, dayEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	duty := s.buildDuty(*driver, trips)
+	return &duty, nil
+}
+
+// buildDuty summarises trips (ordered by departure) into duty time, rest periods and rule violations
+func (s *driverService) buildDuty(driver models.Driver, trips []*models.Schedule) models.DriverDuty {
+	duty := models.DriverDuty{
+		Driver:      driver,
+		Trips:       trips,
+		RestPeriods: []models.RestPeriod{},
+	}
+
+	var total time.Duration
+	for i, trip := range trips {
+		trip.Driver = nil
+		total += trip.ArrivalTime.Sub(trip.DepartureTime)
+
+		if i == 0 {
+			continue
+		}
+
+		previous := trips[i-1]
+		rest := trip.DepartureTime.Sub(previous.ArrivalTime)
+		if rest < 0 {
+			duty.Violations = append(duty.Violations, fmt.Sprintf("schedules %d and %d overlap", previous.ID, trip.ID))
+			continue
+		}
+
+		duty.RestPeriods = append(duty.RestPeriods, models.RestPeriod{
+			From:    previous.ArrivalTime,
+			To:      trip.DepartureTime,
+			Minutes: int(rest.Minutes()),
+		})
+		if rest < s.rules.MinRestBetweenTrips {
+			duty.Violations = append(duty.Violations, fmt.Sprintf("only %d minutes rest between schedules %d and %d", int(rest.Minutes()), previous.ID, trip.ID))
+		}
+	}
+
+	duty.DutyMinutes = int(total.Minutes())
+	if total > s.rules.MaxDailyDuty {
+		duty.Violations = append(duty.Violations, fmt.Sprintf("%d duty minutes exceeds the daily limit of %d", duty.DutyMinutes, int(s.rules.MaxDailyDuty.Minutes())))
+	}
+
+	return duty
+}
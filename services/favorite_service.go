@@ -5,10 +5,16 @@ import (
 	"fmt"
 	"time"
 
+	apperrors "rota-api/errors"
 	"rota-api/models"
 	"rota-api/repositories"
 )
 
+var (
+	ErrFavoriteExists   = apperrors.Conflict(apperrors.FAVORITE_EXISTS, "station is already a favorite")
+	ErrNotFavoriteOwner = apperrors.Forbidden(apperrors.FAVORITE_FORBIDDEN, "unauthorized to remove this favorite")
+)
+
 // FavoriteService interface defines methods for favorite service
 type FavoriteService interface {
 	AddFavorite(ctx context.Context, userID, stationID uint) (*models.Favorite, error)
@@ -16,20 +22,22 @@ type FavoriteService interface {
 	RemoveFavorite(ctx context.Context, id, userID uint) error
 	GetFavoriteByID(ctx context.Context, id uint) (*models.Favorite, error)
 	GetFavoriteByUserAndStation(ctx context.Context, userID, stationID uint) (*models.Favorite, error)
-	GetAllFavorites(ctx context.Context) ([]models.Favorite, error)
+	GetAllFavorites(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
 	CreateFavorite(ctx context.Context, favorite *models.Favorite) error
 	UpdateFavorite(ctx context.Context, favorite *models.Favorite) error
-	DeleteFavorite(ctx context.Context, id uint) error
+	PatchFavorite(ctx context.Context, id uint, apply func(*models.Favorite) error) (*models.Favorite, error)
+	DeleteFavorite(ctx context.Context, id uint, version int) error
 }
 
 // favoriteService implements FavoriteService
 type favoriteService struct {
 	favoriteRepo repositories.FavoriteRepository
+	ranking      RankingService
 }
 
 // NewFavoriteService creates a new favorite service
-func NewFavoriteService(favoriteRepo repositories.FavoriteRepository) FavoriteService {
-	return &favoriteService{favoriteRepo}
+func NewFavoriteService(favoriteRepo repositories.FavoriteRepository, ranking RankingService) FavoriteService {
+	return &favoriteService{favoriteRepo, ranking}
 }
 
 // AddFavorite adds a station to user's favorites
@@ -37,7 +45,7 @@ func (s *favoriteService) AddFavorite(ctx context.Context, userID, stationID uin
 	// Check if already a favorite
 	_, err := s.favoriteRepo.FindByUserAndStation(ctx, userID, stationID)
 	if err == nil {
-		return nil, fmt.Errorf("station is already a favorite")
+		return nil, ErrFavoriteExists
 	}
 
 	favorite := &models.Favorite{
@@ -49,6 +57,7 @@ func (s *favoriteService) AddFavorite(ctx context.Context, userID, stationID uin
 	if err := s.favoriteRepo.Create(ctx, favorite); err != nil {
 		return nil, fmt.Errorf("failed to add favorite: %w", err)
 	}
+	s.ranking.RecordFavorite(ctx, stationID)
 
 	// โหลดข้อมูล favorite ที่สร้างเสร็จแล้วจากฐานข้อมูล พร้อมความสัมพันธ์
 	completeFavorite, err := s.favoriteRepo.FindByID(ctx, favorite.ID)
@@ -73,30 +82,52 @@ func (s *favoriteService) RemoveFavorite(ctx context.Context, id, userID uint) e
 	}
 
 	if favorite.UserID != userID {
-		return fmt.Errorf("unauthorized to remove this favorite")
+		return ErrNotFavoriteOwner
 	}
 
-	return s.favoriteRepo.Delete(ctx, id)
+	return s.favoriteRepo.Delete(ctx, id, favorite.Version)
 }
 
 func (s *favoriteService) GetFavoriteByID(ctx context.Context, id uint) (*models.Favorite, error) {
 	return s.favoriteRepo.FindByID(ctx, id)
 }
 
-func (s *favoriteService) GetAllFavorites(ctx context.Context) ([]models.Favorite, error) {
-	return s.favoriteRepo.FindAll(ctx)
+// GetAllFavorites retrieves one page of favorites
+func (s *favoriteService) GetAllFavorites(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	return s.favoriteRepo.FindPage(ctx, params)
 }
 
 func (s *favoriteService) CreateFavorite(ctx context.Context, favorite *models.Favorite) error {
-	return s.favoriteRepo.Create(ctx, favorite)
+	if err := s.favoriteRepo.Create(ctx, favorite); err != nil {
+		return err
+	}
+	s.ranking.RecordFavorite(ctx, favorite.StationID)
+	return nil
 }
 
 func (s *favoriteService) UpdateFavorite(ctx context.Context, favorite *models.Favorite) error {
 	return s.favoriteRepo.Update(ctx, favorite)
 }
 
-func (s *favoriteService) DeleteFavorite(ctx context.Context, id uint) error {
-	return s.favoriteRepo.Delete(ctx, id)
+// PatchFavorite applies a patch to the favorite and saves the result
+func (s *favoriteService) PatchFavorite(ctx context.Context, id uint, apply func(*models.Favorite) error) (*models.Favorite, error) {
+	favorite, err := s.favoriteRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := apply(favorite); err != nil {
+		return nil, err
+	}
+
+	favorite.ID = id
+	if err := s.favoriteRepo.Update(ctx, favorite); err != nil {
+		return nil, err
+	}
+	return favorite, nil
+}
+
+func (s *favoriteService) DeleteFavorite(ctx context.Context, id uint, version int) error {
+	return s.favoriteRepo.Delete(ctx, id, version)
 }
 
 // GetFavoriteByUserAndStation ดึงรายการโปรดตามผู้ใช้และสถานี
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	apperrors "rota-api/errors"
+	"rota-api/models"
+	"rota-api/repositories"
+)
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key is sent again with a different request
+	ErrIdempotencyKeyReused = apperrors.New(http.StatusUnprocessableEntity, apperrors.IDEMPOTENCY_REUSED, "Idempotency-Key has already been used for a different request")
+	// ErrIdempotencyInProgress is returned when a key is sent again before the first request with it has finished
+	ErrIdempotencyInProgress = apperrors.Conflict(apperrors.IDEMPOTENCY_PENDING, "A request with this Idempotency-Key is still being processed")
+)
+
+// idempotencyPendingTTL is how long a key stays claimed by a request that has not finished. It is short so
+// a request that dies without releasing its key, e.g. when the process crashes, only holds it briefly
+const idempotencyPendingTTL = 2 * time.Minute
+
+// IdempotencyService remembers the responses of requests made with an Idempotency-Key,
+// so a client retrying a request gets the original response instead of a second effect
+type IdempotencyService interface {
+	// Begin claims key for the request identified by fingerprint. It returns the stored response when
+	// the request was already handled, or nil when the caller should handle it and then call Finish or Abandon
+	Begin(ctx context.Context, key, fingerprint string) (*models.IdempotencyRecord, error)
+	// Finish stores the response of record, to replay for its key
+	Finish(ctx context.Context, record *models.IdempotencyRecord)
+	// Abandon frees key without storing a response, so the request can be retried with it
+	Abandon(ctx context.Context, key string)
+}
+
+// idempotencyService implements IdempotencyService
+type idempotencyService struct {
+	idempotencyRepo repositories.IdempotencyRepository
+	ttl             time.Duration
+}
+
+// NewIdempotencyService creates an idempotency service that remembers responses for ttl
+func NewIdempotencyService(idempotencyRepo repositories.IdempotencyRepository, ttl time.Duration) IdempotencyService {
+	return &idempotencyService{idempotencyRepo: idempotencyRepo, ttl: ttl}
+}
+
+// Begin claims the key. If the store is unreachable the request is handled without
+// idempotency, so an outage of the store does not take the endpoints down with it
+func (s *idempotencyService) Begin(ctx context.Context, key, fingerprint string) (*models.IdempotencyRecord, error) {
+	existing, err := s.idempotencyRepo.Reserve(ctx, &models.IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   time.Now().Add(idempotencyPendingTTL),
+	})
+	if err != nil {
+		log.Printf("Idempotency: failed to reserve %s: %v", key, err)
+		return nil, nil
+	}
+
+	switch {
+	case existing == nil:
+		return nil, nil
+	case existing.Fingerprint != fingerprint:
+		return nil, ErrIdempotencyKeyReused
+	case !existing.Completed:
+		return nil, ErrIdempotencyInProgress
+	}
+	return existing, nil
+}
+
+// Finish stores the response, keeping the key for the full ttl from now
+func (s *idempotencyService) Finish(ctx context.Context, record *models.IdempotencyRecord) {
+	record.Completed = true
+	record.ExpiresAt = time.Now().Add(s.ttl)
+	if err := s.idempotencyRepo.Complete(ctx, record); err != nil {
+		log.Printf("Idempotency: failed to store the response for %s: %v", record.Key, err)
+	}
+}
+
+func (s *idempotencyService) Abandon(ctx context.Context, key string) {
+	if err := s.idempotencyRepo.Release(ctx, key); err != nil {
+		log.Printf("Idempotency: failed to release %s: %v", key, err)
+	}
+}
@@ -0,0 +1,502 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"rota-api/dto"
+	apperrors "rota-api/errors"
+	"rota-api/models"
+	"rota-api/passhash"
+	"rota-api/repositories"
+	"rota-api/tabular"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// Limits of imports and exports
+const (
+	maxExportRows = 10000
+	// maxReportedRows caps the invalid rows listed when an import is rejected
+	maxReportedRows = 100
+)
+
+var (
+	// ErrImportInvalidRows is returned when rows of an import are invalid. Nothing is imported then
+	ErrImportInvalidRows = apperrors.New(http.StatusUnprocessableEntity, apperrors.IMPORT_INVALID_ROWS, "One or more rows are invalid, nothing was imported")
+	// ErrExportTooManyRows is returned when more than maxExportRows records match an export
+	ErrExportTooManyRows = apperrors.BadRequest(apperrors.EXPORT_TOO_MANY_ROWS,
+		fmt.Sprintf("exports are limited to %d rows, narrow the filter", maxExportRows))
+)
+
+// ImportExportService imports admin data from spreadsheets and exports it to them. Imports update the
+// records that match a row by natural key and create the rest, all in one transaction; rows that fail
+// validation reject the whole import
+type ImportExportService interface {
+	ImportStations(ctx context.Context, sheet *tabular.Sheet, dryRun bool) (*models.ImportResult, error)
+	ImportVehicles(ctx context.Context, sheet *tabular.Sheet, dryRun bool) (*models.ImportResult, error)
+	ImportStaff(ctx context.Context, sheet *tabular.Sheet, dryRun bool) (*models.ImportResult, error)
+	ImportSchedules(ctx context.Context, sheet *tabular.Sheet, dryRun bool) (*models.ImportResult, error)
+	ExportStations(ctx context.Context, params models.SearchParams) ([]*models.Station, error)
+	ExportVehicles(ctx context.Context, params models.SearchParams) ([]*models.Vehicle, error)
+	ExportStaff(ctx context.Context, params models.SearchParams) ([]*models.Staff, error)
+	ExportSchedules(ctx context.Context, params models.ScheduleSearchParams) ([]*models.Schedule, error)
+}
+
+// importExportService implements ImportExportService
+type importExportService struct {
+	stationRepo        repositories.StationRepository
+	vehicleRepo        repositories.VehicleRepository
+	staffRepo          repositories.StaffRepository
+	scheduleRepo       repositories.ScheduleRepository
+	maintenanceService MaintenanceService
+	driverService      DriverService
+	conflictService    ConflictService
+	cache              CacheConfig
+}
+
+// NewImportExportService creates a new import and export service
+func NewImportExportService(
+	stationRepo repositories.StationRepository,
+	vehicleRepo repositories.VehicleRepository,
+	staffRepo repositories.StaffRepository,
+	scheduleRepo repositories.ScheduleRepository,
+	maintenanceService MaintenanceService,
+	driverService DriverService,
+	conflictService ConflictService,
+	cache CacheConfig,
+) ImportExportService {
+	return &importExportService{
+		stationRepo:        stationRepo,
+		vehicleRepo:        vehicleRepo,
+		staffRepo:          staffRepo,
+		scheduleRepo:       scheduleRepo,
+		maintenanceService: maintenanceService,
+		driverService:      driverService,
+		conflictService:    conflictService,
+		cache:              cache,
+	}
+}
+
+// ImportStations imports stations, matching them to stored stations by name
+func (s *importExportService) ImportStations(ctx context.Context, sheet *tabular.Sheet, dryRun bool) (*models.ImportResult, error) {
+	problems := importProblems{}
+	rows := decodeRows[dto.StationRow](sheet, problems)
+	names := uniqueKeys(rows, problems, "name", func(row dto.StationRow) string { return row.Name })
+
+	stored, err := s.stationRepo.FindByNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*models.Station, len(stored))
+	ambiguous := make(map[string]bool)
+	for _, station := range stored {
+		if _, ok := byName[station.Name]; ok {
+			ambiguous[station.Name] = true
+		}
+		byName[station.Name] = station
+	}
+
+	var created, updated []*models.Station
+	for _, row := range rows {
+		station, ok := byName[row.data.Name]
+		switch {
+		case ambiguous[row.data.Name]:
+			problems.add(row.number, "name", "matches more than one station")
+			continue
+		case ok:
+			updated = append(updated, station)
+		default:
+			station = &models.Station{}
+			created = append(created, station)
+		}
+		row.data.Apply(station)
+	}
+	if err := problems.err(); err != nil {
+		return nil, err
+	}
+
+	result := importResult("stations", sheet, dryRun, len(created), len(updated))
+	if dryRun {
+		return result, nil
+	}
+	if err := s.stationRepo.SaveImported(ctx, created, updated); err != nil {
+		return nil, err
+	}
+	invalidateCache(ctx, s.cache, cacheTagStations)
+	return result, nil
+}
+
+// ImportVehicles imports vehicles, matching them to stored vehicles by licence plate
+func (s *importExportService) ImportVehicles(ctx context.Context, sheet *tabular.Sheet, dryRun bool) (*models.ImportResult, error) {
+	problems := importProblems{}
+	rows := decodeRows[dto.VehicleRow](sheet, problems)
+	plates := uniqueKeys(rows, problems, "license_plate", func(row dto.VehicleRow) string { return row.LicensePlate })
+	if err := problems.err(); err != nil {
+		return nil, err
+	}
+
+	stored, err := s.vehicleRepo.FindByLicensePlates(ctx, plates)
+	if err != nil {
+		return nil, err
+	}
+	byPlate := make(map[string]*models.Vehicle, len(stored))
+	for _, vehicle := range stored {
+		byPlate[vehicle.LicensePlate] = vehicle
+	}
+
+	var created, updated []*models.Vehicle
+	for _, row := range rows {
+		vehicle, ok := byPlate[row.data.LicensePlate]
+		if ok {
+			updated = append(updated, vehicle)
+		} else {
+			vehicle = &models.Vehicle{}
+			created = append(created, vehicle)
+		}
+		row.data.Apply(vehicle)
+	}
+
+	result := importResult("vehicles", sheet, dryRun, len(created), len(updated))
+	if dryRun {
+		return result, nil
+	}
+	if err := s.vehicleRepo.SaveImported(ctx, created, updated); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ImportStaff imports staff accounts, matching them to stored accounts by email.
+// New accounts need a password; stored accounts keep theirs unless the row gives one
+func (s *importExportService) ImportStaff(ctx context.Context, sheet *tabular.Sheet, dryRun bool) (*models.ImportResult, error) {
+	problems := importProblems{}
+	rows := decodeRows[dto.StaffRow](sheet, problems)
+	emails := uniqueKeys(rows, problems, "email", func(row dto.StaffRow) string { return row.Email })
+	usernames := uniqueKeys(rows, problems, "username", func(row dto.StaffRow) string { return row.Username })
+
+	stored, err := s.staffRepo.FindByEmails(ctx, emails)
+	if err != nil {
+		return nil, err
+	}
+	byEmail := make(map[string]*models.Staff, len(stored))
+	for _, staff := range stored {
+		byEmail[staff.Email] = staff
+	}
+	taken, err := s.staffRepo.FindByUsernames(ctx, usernames)
+	if err != nil {
+		return nil, err
+	}
+	usernameOwner := make(map[string]string, len(taken))
+	for _, staff := range taken {
+		usernameOwner[staff.Username] = staff.Email
+	}
+
+	var created, updated []*models.Staff
+	passwords := make(map[*models.Staff]string)
+	for _, row := range rows {
+		if owner, ok := usernameOwner[row.data.Username]; ok && owner != row.data.Email {
+			problems.add(row.number, "username", "already taken by another account")
+		}
+		staff, ok := byEmail[row.data.Email]
+		if ok {
+			updated = append(updated, staff)
+		} else {
+			if row.data.Password == "" {
+				problems.add(row.number, "password", "required")
+			}
+			staff = &models.Staff{}
+			created = append(created, staff)
+		}
+		row.data.Apply(staff)
+		if row.data.Password != "" {
+			passwords[staff] = row.data.Password
+		}
+	}
+	if err := problems.err(); err != nil {
+		return nil, err
+	}
+
+	result := importResult("staff", sheet, dryRun, len(created), len(updated))
+	if dryRun {
+		return result, nil
+	}
+	for staff, password := range passwords {
+		hashedPassword, err := passhash.Hash(password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		staff.Password = hashedPassword
+	}
+	if err := s.staffRepo.SaveImported(ctx, created, updated); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ImportSchedules imports schedules, matching them to stored schedules by id, or else by route, station,
+// service date and round. The imported schedules must not double-book a vehicle or run while it is out of service
+func (s *importExportService) ImportSchedules(ctx context.Context, sheet *tabular.Sheet, dryRun bool) (*models.ImportResult, error) {
+	problems := importProblems{}
+	rows := decodeRows[dto.ScheduleRow](sheet, problems)
+
+	var ids []uint
+	for _, row := range rows {
+		if row.data.ID != 0 {
+			ids = append(ids, row.data.ID)
+		}
+	}
+	byID := make(map[uint]*models.Schedule)
+	if len(ids) > 0 {
+		stored, err := s.scheduleRepo.FindByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, schedule := range stored {
+			byID[schedule.ID] = schedule
+		}
+	}
+
+	// Rows without an id are keyed by their trip, which needs their service date resolved first
+	planned := make([]*models.Schedule, len(rows))
+	var keys []models.TripKey
+	for i, row := range rows {
+		schedule := &models.Schedule{}
+		if row.data.ID != 0 {
+			stored, ok := byID[row.data.ID]
+			if !ok {
+				problems.add(row.number, "id", "not found")
+				continue
+			}
+			schedule = stored
+		}
+		row.data.Apply(schedule)
+		if err := schedule.ResolveTimes(); err != nil {
+			problems.add(row.number, "times", err.Error())
+			continue
+		}
+		planned[i] = schedule
+		if row.data.ID == 0 {
+			keys = append(keys, schedule.TripKey())
+		}
+	}
+
+	byKey := make(map[models.TripKey]*models.Schedule)
+	if len(keys) > 0 {
+		stored, err := s.scheduleRepo.FindByTripKeys(ctx, keys)
+		if err != nil {
+			return nil, err
+		}
+		for _, schedule := range stored {
+			byKey[schedule.TripKey()] = schedule
+		}
+	}
+
+	var created, updated []*models.Schedule
+	firstRow := make(map[uint]int)
+	driverRows := make(map[*models.Schedule]int)
+	for i, row := range rows {
+		schedule := planned[i]
+		if schedule == nil {
+			continue
+		}
+		if schedule.ID == 0 {
+			if stored, ok := byKey[schedule.TripKey()]; ok {
+				row.data.Apply(stored)
+				if err := stored.ResolveTimes(); err != nil {
+					problems.add(row.number, "times", err.Error())
+					continue
+				}
+				schedule, planned[i] = stored, stored
+			}
+		}
+
+		if schedule.ID == 0 {
+			created = append(created, schedule)
+		} else if first, ok := firstRow[schedule.ID]; ok {
+			problems.add(row.number, "id", fmt.Sprintf("schedule %d is already imported by row %d", schedule.ID, first))
+			continue
+		} else {
+			firstRow[schedule.ID] = row.number
+			updated = append(updated, schedule)
+		}
+
+		if schedule.Status != models.ScheduleStatusCancelled {
+			if err := ensureVehicleAvailable(ctx, s.maintenanceService, schedule); err != nil {
+				if !errors.Is(err, ErrVehicleOutOfService) {
+					return nil, err
+				}
+				problems.add(row.number, "vehicle_id", err.Error())
+			}
+			if schedule.DriverID != nil {
+				driverRows[schedule] = row.number
+			}
+		}
+	}
+
+	// Drivers are checked once every row is planned, against their other imported trips as imported
+	all := append(append([]*models.Schedule{}, created...), updated...)
+	for _, schedule := range all {
+		number, ok := driverRows[schedule]
+		if !ok {
+			continue
+		}
+		err := s.driverService.ValidateAssignment(ctx, *schedule.DriverID, schedule, all...)
+		switch {
+		case err == nil:
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			problems.add(number, "driver_id", "not found")
+		case isRosterViolation(err):
+			problems.add(number, "driver_id", err.Error())
+		default:
+			return nil, err
+		}
+	}
+	if err := problems.err(); err != nil {
+		return nil, err
+	}
+
+	conflicts, err := s.conflictService.CheckPlan(ctx, all)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		return nil, &ConflictError{Conflicts: conflicts}
+	}
+
+	result := importResult("schedules", sheet, dryRun, len(created), len(updated))
+	if dryRun {
+		return result, nil
+	}
+	if err := translateScheduleOverlap(s.scheduleRepo.SaveImported(ctx, created, updated)); err != nil {
+		return nil, err
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules)
+	return result, nil
+}
+
+// ExportStations returns the stations the station list would show for params, on one page
+func (s *importExportService) ExportStations(ctx context.Context, params models.SearchParams) ([]*models.Station, error) {
+	return limitExport(s.stationRepo.FindFiltered(ctx, params, maxExportRows))
+}
+
+// ExportVehicles returns the vehicles the vehicle list would show for params, on one page
+func (s *importExportService) ExportVehicles(ctx context.Context, params models.SearchParams) ([]*models.Vehicle, error) {
+	return limitExport(s.vehicleRepo.FindFiltered(ctx, params, maxExportRows))
+}
+
+// ExportStaff returns the staff the staff list would show for params, on one page
+func (s *importExportService) ExportStaff(ctx context.Context, params models.SearchParams) ([]*models.Staff, error) {
+	return limitExport(s.staffRepo.FindFiltered(ctx, params, maxExportRows))
+}
+
+// ExportSchedules returns the schedules the schedule search would show for params, on one page
+func (s *importExportService) ExportSchedules(ctx context.Context, params models.ScheduleSearchParams) ([]*models.Schedule, error) {
+	return limitExport(s.scheduleRepo.FindFiltered(ctx, params, maxExportRows))
+}
+
+// limitExport refuses exports of more than maxExportRows records
+func limitExport[T any](records []T, err error) ([]T, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > maxExportRows {
+		return nil, ErrExportTooManyRows
+	}
+	return records, nil
+}
+
+// importRow is a row of an import that was read and validated as a T
+type importRow[T any] struct {
+	number int
+	data   T
+}
+
+// decodeRows reads every record of sheet as a T and validates it, noting the problems of the rows that fail
+func decodeRows[T any](sheet *tabular.Sheet, problems importProblems) []importRow[T] {
+	rows := make([]importRow[T], 0, len(sheet.Records))
+	for _, record := range sheet.Records {
+		var data T
+		fields := tabular.Decode(record.Values, &data)
+		var validationErrs validator.ValidationErrors
+		if err := dto.Validate(data); errors.As(err, &validationErrs) {
+			for field, message := range apperrors.ValidationFields(validationErrs).Fields {
+				if _, ok := fields[field]; !ok {
+					fields[field] = message
+				}
+			}
+		}
+
+		if len(fields) > 0 {
+			for field, message := range fields {
+				problems.add(record.Row, field, message)
+			}
+			continue
+		}
+		rows = append(rows, importRow[T]{number: record.Row, data: data})
+	}
+	return rows
+}
+
+// uniqueKeys returns the natural keys of rows, noting rows that repeat the key of an earlier row
+func uniqueKeys[T any](rows []importRow[T], problems importProblems, field string, key func(T) string) []string {
+	firstRow := make(map[string]int, len(rows))
+	keys := make([]string, 0, len(rows))
+	for _, row := range rows {
+		value := key(row.data)
+		if first, ok := firstRow[value]; ok {
+			problems.add(row.number, field, fmt.Sprintf("duplicate of row %d", first))
+			continue
+		}
+		firstRow[value] = row.number
+		keys = append(keys, value)
+	}
+	return keys
+}
+
+// importProblems collects what is wrong with the rows of an import, by row number and field
+type importProblems map[int]map[string]string
+
+func (p importProblems) add(row int, field, message string) {
+	if p[row] == nil {
+		p[row] = make(map[string]string)
+	}
+	p[row][field] = message
+}
+
+// err reports the problems as ErrImportInvalidRows listing the first rows, or nil when there are none
+func (p importProblems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	numbers := make([]int, 0, len(p))
+	for row := range p {
+		numbers = append(numbers, row)
+	}
+	sort.Ints(numbers)
+
+	rows := make([]models.ImportRowError, 0, maxReportedRows)
+	for _, row := range numbers {
+		if len(rows) == maxReportedRows {
+			break
+		}
+		rows = append(rows, models.ImportRowError{Row: row, Fields: p[row]})
+	}
+	return ErrImportInvalidRows.With("invalid_rows", len(p)).With("rows", rows)
+}
+
+// importResult summarises an import of sheet
+func importResult(resource string, sheet *tabular.Sheet, dryRun bool, created, updated int) *models.ImportResult {
+	return &models.ImportResult{
+		Resource:       resource,
+		DryRun:         dryRun,
+		Rows:           len(sheet.Records),
+		Created:        created,
+		Updated:        updated,
+		IgnoredColumns: sheet.Ignored,
+	}
+}
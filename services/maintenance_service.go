@@ -0,0 +1,312 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apperrors "rota-api/errors"
+	"rota-api/models"
+	"rota-api/repositories"
+)
+
+// Fleet errors
+var (
+	ErrVehicleOutOfService = apperrors.Conflict(apperrors.VEHICLE_OUT_OF_SERVICE, "vehicle is out of service")
+	ErrInvalidWindow       = apperrors.BadRequest(apperrors.MAINTENANCE_INVALID_WINDOW, "out-of-service window must end after it starts")
+	ErrInvalidInspection   = apperrors.BadRequest(apperrors.MAINTENANCE_INVALID_INSPECTION, "inspection requires a due date or a due odometer reading")
+	ErrWindowHasTrips      = apperrors.Conflict(apperrors.MAINTENANCE_TRIPS_SCHEDULED, "vehicle has trips scheduled during the out-of-service window, reassign or cancel them first")
+
+	// Records looked up through a vehicle are not found when they belong to another vehicle
+	ErrMaintenanceRecordNotFound = apperrors.NotFound(apperrors.MAINTENANCE_NOT_FOUND, "maintenance record not found")
+	ErrInspectionNotFound        = apperrors.NotFound(apperrors.INSPECTION_NOT_FOUND, "inspection not found")
+	ErrWindowNotFound            = apperrors.NotFound(apperrors.MAINTENANCE_WINDOW_NOT_FOUND, "out-of-service window not found")
+)
+
+// MaintenanceService interface defines methods for the fleet maintenance service
+type MaintenanceService interface {
+	// Maintenance records
+	GetMaintenanceRecords(ctx context.Context, vehicleID uint) ([]models.MaintenanceRecord, error)
+	CreateMaintenanceRecord(ctx context.Context, record *models.MaintenanceRecord) error
+	DeleteMaintenanceRecord(ctx context.Context, vehicleID, id uint) error
+
+	// Inspections
+	GetInspections(ctx context.Context, vehicleID uint) ([]models.VehicleInspection, error)
+	GetDueInspections(ctx context.Context, within time.Duration, odometerMargin int) ([]models.VehicleInspection, error)
+	ScheduleInspection(ctx context.Context, inspection *models.VehicleInspection) error
+	CompleteInspection(ctx context.Context, vehicleID, id uint, passed bool, notes string) (*models.VehicleInspection, error)
+	DeleteInspection(ctx context.Context, vehicleID, id uint) error
+
+	// Out-of-service windows
+	GetOutOfServiceWindows(ctx context.Context, vehicleID uint) ([]models.OutOfServiceWindow, error)
+	CreateOutOfServiceWindow(ctx context.Context, window *models.OutOfServiceWindow) error
+	CloseOutOfServiceWindow(ctx context.Context, vehicleID, id uint, endTime time.Time) (*models.OutOfServiceWindow, error)
+	DeleteOutOfServiceWindow(ctx context.Context, vehicleID, id uint) error
+
+	// Availability
+	GetAvailability(ctx context.Context, vehicleID uint, from, to time.Time) (*models.VehicleAvailability, error)
+	EnsureAvailable(ctx context.Context, vehicleID uint, from, to time.Time) error
+}
+
+// maintenanceService implements MaintenanceService
+type maintenanceService struct {
+	maintenanceRepo repositories.MaintenanceRepository
+	vehicleRepo     repositories.VehicleRepository
+	scheduleRepo    repositories.ScheduleRepository
+	cache           CacheConfig
+}
+
+// NewMaintenanceService creates a new maintenance service
+func NewMaintenanceService(maintenanceRepo repositories.MaintenanceRepository, vehicleRepo repositories.VehicleRepository, scheduleRepo repositories.ScheduleRepository, cache CacheConfig) MaintenanceService {
+	return &maintenanceService{
+		maintenanceRepo: maintenanceRepo,
+		vehicleRepo:     vehicleRepo,
+		scheduleRepo:    scheduleRepo,
+		cache:           cache,
+	}
+}
+
+// GetMaintenanceRecords retrieves the maintenance history of a vehicle
+func (s *maintenanceService) GetMaintenanceRecords(ctx context.Context, vehicleID uint) ([]models.MaintenanceRecord, error) {
+	return s.maintenanceRepo.FindRecordsByVehicle(ctx, vehicleID)
+}
+
+// CreateMaintenanceRecord records a maintenance job and advances the vehicle odometer if the job reports a higher reading
+func (s *maintenanceService) CreateMaintenanceRecord(ctx context.Context, record *models.MaintenanceRecord) error {
+	vehicle, err := s.vehicleRepo.FindByID(ctx, record.VehicleID)
+	if err != nil {
+		return err
+	}
+
+	if record.PerformedAt.IsZero() {
+		record.PerformedAt = time.Now()
+	}
+
+	if err := s.maintenanceRepo.CreateRecord(ctx, record); err != nil {
+		return err
+	}
+
+	if record.Odometer > vehicle.Odometer {
+		if err := s.vehicleRepo.UpdateOdometer(ctx, vehicle.ID, record.Odometer); err != nil {
+			return err
+		}
+		// Schedules embed their vehicle
+		invalidateCache(ctx, s.cache, cacheTagSchedules, cacheTagStations)
+	}
+
+	return nil
+}
+
+// DeleteMaintenanceRecord deletes a maintenance record of a vehicle
+func (s *maintenanceService) DeleteMaintenanceRecord(ctx context.Context, vehicleID, id uint) error {
+	record, err := s.maintenanceRepo.FindRecordByID(ctx, id)
+	if err != nil {
+		return apperrors.NotFoundIf(err, apperrors.MAINTENANCE_NOT_FOUND, "maintenance record not found")
+	}
+	if record.VehicleID != vehicleID {
+		return ErrMaintenanceRecordNotFound
+	}
+	return s.maintenanceRepo.DeleteRecord(ctx, id)
+}
+
+// GetInspections retrieves all inspections of a vehicle
+func (s *maintenanceService) GetInspections(ctx context.Context, vehicleID uint) ([]models.VehicleInspection, error) {
+	return s.maintenanceRepo.FindInspectionsByVehicle(ctx, vehicleID)
+}
+
+// GetDueInspections retrieves inspections falling due within the given period or odometer margin
+func (s *maintenanceService) GetDueInspections(ctx context.Context, within time.Duration, odometerMargin int) ([]models.VehicleInspection, error) {
+	return s.maintenanceRepo.FindDueInspections(ctx, time.Now().Add(within), odometerMargin)
+}
+
+// ScheduleInspection schedules a new inspection for a vehicle
+func (s *maintenanceService) ScheduleInspection(ctx context.Context, inspection *models.VehicleInspection) error {
+	if inspection.DueDate == nil && inspection.DueOdometer == nil {
+		return ErrInvalidInspection
+	}
+
+	if _, err := s.vehicleRepo.FindByID(ctx, inspection.VehicleID); err != nil {
+		return err
+	}
+
+	inspection.Status = models.InspectionStatusScheduled
+	inspection.CompletedAt = nil
+	return s.maintenanceRepo.CreateInspection(ctx, inspection)
+}
+
+// CompleteInspection records the outcome of an inspection
+// A failed inspection takes the vehicle out of service until the window is closed
+func (s *maintenanceService) CompleteInspection(ctx context.Context, vehicleID, id uint, passed bool, notes string) (*models.VehicleInspection, error) {
+	inspection, err := s.findInspection(ctx, vehicleID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	inspection.CompletedAt = &now
+	if notes != "" {
+		inspection.Notes = notes
+	}
+	if passed {
+		inspection.Status = models.InspectionStatusPassed
+	} else {
+		inspection.Status = models.InspectionStatusFailed
+	}
+
+	if err := s.maintenanceRepo.UpdateInspection(ctx, inspection); err != nil {
+		return nil, err
+	}
+
+	if !passed {
+		window := &models.OutOfServiceWindow{
+			VehicleID: inspection.VehicleID,
+			StartTime: now,
+			Reason:    fmt.Sprintf("failed %s inspection", inspection.Type),
+		}
+		if err := s.maintenanceRepo.CreateWindow(ctx, window); err != nil {
+			return nil, err
+		}
+		invalidateCache(ctx, s.cache, cacheTagSchedules, cacheTagStations)
+	}
+
+	return inspection, nil
+}
+
+// DeleteInspection deletes an inspection of a vehicle
+func (s *maintenanceService) DeleteInspection(ctx context.Context, vehicleID, id uint) error {
+	if _, err := s.findInspection(ctx, vehicleID, id); err != nil {
+		return err
+	}
+	return s.maintenanceRepo.DeleteInspection(ctx, id)
+}
+
+// findInspection retrieves an inspection, which must belong to the given vehicle
+func (s *maintenanceService) findInspection(ctx context.Context, vehicleID, id uint) (*models.VehicleInspection, error) {
+	inspection, err := s.maintenanceRepo.FindInspectionByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.NotFoundIf(err, apperrors.INSPECTION_NOT_FOUND, "inspection not found")
+	}
+	if inspection.VehicleID != vehicleID {
+		return nil, ErrInspectionNotFound
+	}
+	return inspection, nil
+}
+
+// GetOutOfServiceWindows retrieves all out-of-service windows of a vehicle
+func (s *maintenanceService) GetOutOfServiceWindows(ctx context.Context, vehicleID uint) ([]models.OutOfServiceWindow, error) {
+	return s.maintenanceRepo.FindWindowsByVehicle(ctx, vehicleID)
+}
+
+// openWindowEnd bounds the trips an out-of-service window without an end time covers
+var openWindowEnd = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// CreateOutOfServiceWindow takes a vehicle out of service for a period. It is refused while trips
+// are still scheduled on the vehicle during the period, listing them so they can be reassigned
+func (s *maintenanceService) CreateOutOfServiceWindow(ctx context.Context, window *models.OutOfServiceWindow) error {
+	if window.StartTime.IsZero() {
+		window.StartTime = time.Now()
+	}
+	if window.EndTime != nil && !window.EndTime.After(window.StartTime) {
+		return ErrInvalidWindow
+	}
+
+	if _, err := s.vehicleRepo.FindByID(ctx, window.VehicleID); err != nil {
+		return err
+	}
+
+	end := openWindowEnd
+	if window.EndTime != nil {
+		end = *window.EndTime
+	}
+	trips, err := s.scheduleRepo.FindByVehicleBetween(ctx, window.VehicleID, window.StartTime, end)
+	if err != nil {
+		return err
+	}
+	if len(trips) > 0 {
+		ids := make([]uint, len(trips))
+		for i, trip := range trips {
+			ids[i] = trip.ID
+		}
+		return ErrWindowHasTrips.With("schedule_ids", ids)
+	}
+
+	if err := s.maintenanceRepo.CreateWindow(ctx, window); err != nil {
+		return err
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules, cacheTagStations)
+	return nil
+}
+
+// CloseOutOfServiceWindow returns a vehicle to service at the given time
+func (s *maintenanceService) CloseOutOfServiceWindow(ctx context.Context, vehicleID, id uint, endTime time.Time) (*models.OutOfServiceWindow, error) {
+	window, err := s.findWindow(ctx, vehicleID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !endTime.After(window.StartTime) {
+		return nil, ErrInvalidWindow
+	}
+
+	window.EndTime = &endTime
+	if err := s.maintenanceRepo.UpdateWindow(ctx, window); err != nil {
+		return nil, err
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules, cacheTagStations)
+
+	return window, nil
+}
+
+// DeleteOutOfServiceWindow deletes an out-of-service window of a vehicle
+func (s *maintenanceService) DeleteOutOfServiceWindow(ctx context.Context, vehicleID, id uint) error {
+	if _, err := s.findWindow(ctx, vehicleID, id); err != nil {
+		return err
+	}
+	if err := s.maintenanceRepo.DeleteWindow(ctx, id); err != nil {
+		return err
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules, cacheTagStations)
+	return nil
+}
+
+// findWindow retrieves an out-of-service window, which must belong to the given vehicle
+func (s *maintenanceService) findWindow(ctx context.Context, vehicleID, id uint) (*models.OutOfServiceWindow, error) {
+	window, err := s.maintenanceRepo.FindWindowByID(ctx, id)
+	if err != nil {
+		return nil, apperrors.NotFoundIf(err, apperrors.MAINTENANCE_WINDOW_NOT_FOUND, "out-of-service window not found")
+	}
+	if window.VehicleID != vehicleID {
+		return nil, ErrWindowNotFound
+	}
+	return window, nil
+}
+
+// GetAvailability reports whether a vehicle can be dispatched in [from, to)
+func (s *maintenanceService) GetAvailability(ctx context.Context, vehicleID uint, from, to time.Time) (*models.VehicleAvailability, error) {
+	windows, err := s.maintenanceRepo.FindOverlappingWindows(ctx, vehicleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.VehicleAvailability{
+		VehicleID: vehicleID,
+		From:      from,
+		To:        to,
+		Available: len(windows) == 0,
+		Windows:   windows,
+	}, nil
+}
+
+// EnsureAvailable returns ErrVehicleOutOfService if the vehicle is out of service at any point in [from, to)
+func (s *maintenanceService) EnsureAvailable(ctx context.Context, vehicleID uint, from, to time.Time) error {
+	windows, err := s.maintenanceRepo.FindOverlappingWindows(ctx, vehicleID, from, to)
+	if err != nil {
+		return err
+	}
+
+	if len(windows) > 0 {
+		return fmt.Errorf("%w: %s", ErrVehicleOutOfService, windows[0].Reason)
+	}
+
+	return nil
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	apperrors "rota-api/errors"
+	"rota-api/i18n"
+	"rota-api/models"
+	"rota-api/repositories"
+)
+
+// ErrInvalidRankingPeriod is returned for a ranking period other than day or week
+var ErrInvalidRankingPeriod = apperrors.BadRequest(apperrors.RANKING_INVALID_PERIOD, "period must be day or week")
+
+// Ranking event weights: a favorite says more about a station than a single view
+const (
+	rankWeightView     = 1
+	rankWeightSearch   = 2
+	rankWeightFavorite = 5
+)
+
+// Rankings are kept in hourly buckets; a bucket's weight halves every half-life
+const (
+	rankBucketTTL      = 8 * 24 * time.Hour
+	rankKindStations   = "stations"
+	rankKindRoutes     = "routes"
+	rankBucketLayout   = "2006010215"
+	rankDefaultLimit   = 10
+	rankMaxLimit       = 50
+	rankScorePrecision = 100
+)
+
+// rankingPeriods maps each period to how many hourly buckets it covers and its decay half-life
+var rankingPeriods = map[string]struct {
+	buckets  int
+	halfLife time.Duration
+}{
+	models.RankingPeriodDay:  {buckets: 24, halfLife: 6 * time.Hour},
+	models.RankingPeriodWeek: {buckets: 7 * 24, halfLife: 48 * time.Hour},
+}
+
+// RankingService interface defines methods for popularity rankings of stations and routes
+type RankingService interface {
+	RecordStationView(ctx context.Context, stationID uint)
+	RecordRouteView(ctx context.Context, routeID uint)
+	RecordScheduleSearch(ctx context.Context, params models.ScheduleSearchParams)
+	RecordFavorite(ctx context.Context, stationID uint)
+	GetPopularStations(ctx context.Context, period string, limit int) ([]models.PopularStation, error)
+	GetPopularRoutes(ctx context.Context, period string, limit int) ([]models.PopularRoute, error)
+}
+
+// rankingService implements RankingService
+type rankingService struct {
+	redisRepo   repositories.RedisRepository
+	stationRepo repositories.StationRepository
+	routeRepo   repositories.RouteRepository
+	now         func() time.Time
+}
+
+// NewRankingService creates a new ranking service. Without Redis, events are not
+// recorded and rankings are empty
+func NewRankingService(redisRepo repositories.RedisRepository, stationRepo repositories.StationRepository, routeRepo repositories.RouteRepository) RankingService {
+	if redisRepo == nil {
+		log.Println("Ranking: Redis not available, popularity rankings are disabled")
+	}
+	return &rankingService{
+		redisRepo:   redisRepo,
+		stationRepo: stationRepo,
+		routeRepo:   routeRepo,
+		now:         time.Now,
+	}
+}
+
+// RecordStationView counts a view of a station's details
+func (s *rankingService) RecordStationView(ctx context.Context, stationID uint) {
+	s.record(ctx, rankKindStations, stationID, rankWeightView)
+}
+
+// RecordRouteView counts a view of a route's details
+func (s *rankingService) RecordRouteView(ctx context.Context, routeID uint) {
+	s.record(ctx, rankKindRoutes, routeID, rankWeightView)
+}
+
+// RecordScheduleSearch counts the station and route a schedule search was narrowed to
+func (s *rankingService) RecordScheduleSearch(ctx context.Context, params models.ScheduleSearchParams) {
+	if params.StationID != nil {
+		s.record(ctx, rankKindStations, *params.StationID, rankWeightSearch)
+	}
+	if params.RouteID != nil {
+		s.record(ctx, rankKindRoutes, *params.RouteID, rankWeightSearch)
+	}
+}
+
+// RecordFavorite counts a station being added to a user's favorites
+func (s *rankingService) RecordFavorite(ctx context.Context, stationID uint) {
+	s.record(ctx, rankKindStations, stationID, rankWeightFavorite)
+}
+
+// GetPopularStations returns the highest-scoring stations over the period, most popular first
+func (s *rankingService) GetPopularStations(ctx context.Context, period string, limit int) ([]models.PopularStation, error) {
+	limit = rankLimit(limit)
+	ranks, err := s.top(ctx, rankKindStations, period, limit)
+	if err != nil || len(ranks) == 0 {
+		return []models.PopularStation{}, err
+	}
+
+	stations, err := s.stationRepo.FindByIDs(ctx, rankIDs(ranks))
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.Station, len(stations))
+	for _, station := range stations {
+		byID[station.ID] = station
+	}
+
+	popular := make([]models.PopularStation, 0, len(ranks))
+	for _, rank := range ranks {
+		if len(popular) == limit {
+			break
+		}
+		// Stations deleted since they were ranked are skipped
+		station, ok := byID[rankID(rank)]
+		if !ok {
+			continue
+		}
+		popular = append(popular, models.PopularStation{
+			Rank:    len(popular) + 1,
+			Score:   roundScore(rank.Score),
+			Station: station.Localized(i18n.FromContext(ctx)),
+		})
+	}
+	return popular, nil
+}
+
+// GetPopularRoutes returns the highest-scoring routes over the period, most popular first
+func (s *rankingService) GetPopularRoutes(ctx context.Context, period string, limit int) ([]models.PopularRoute, error) {
+	limit = rankLimit(limit)
+	ranks, err := s.top(ctx, rankKindRoutes, period, limit)
+	if err != nil || len(ranks) == 0 {
+		return []models.PopularRoute{}, err
+	}
+
+	routes, err := s.routeRepo.FindByIDs(ctx, rankIDs(ranks))
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]*models.Route, len(routes))
+	for _, route := range routes {
+		byID[route.ID] = route
+	}
+
+	popular := make([]models.PopularRoute, 0, len(ranks))
+	for _, rank := range ranks {
+		if len(popular) == limit {
+			break
+		}
+		route, ok := byID[rankID(rank)]
+		if !ok {
+			continue
+		}
+		popular = append(popular, models.PopularRoute{
+			Rank:  len(popular) + 1,
+			Score: roundScore(rank.Score),
+			Route: route.Localized(i18n.FromContext(ctx)),
+		})
+	}
+	return popular, nil
+}
+
+// record adds weight to the member's score in the current hourly bucket.
+// Ranking is best effort, so failures are logged rather than returned
+func (s *rankingService) record(ctx context.Context, kind string, id uint, weight float64) {
+	if s.redisRepo == nil || id == 0 {
+		return
+	}
+	key := rankBucketKey(kind, s.now())
+	if err := s.redisRepo.IncrementRank(ctx, key, strconv.FormatUint(uint64(id), 10), weight, rankBucketTTL); err != nil {
+		log.Printf("Ranking: failed to record %s %d: %v", kind, id, err)
+	}
+}
+
+// top combines the period's hourly buckets, weighting each by its age, and returns the best members
+func (s *rankingService) top(ctx context.Context, kind, period string, limit int) ([]repositories.RankScore, error) {
+	if period == "" {
+		period = models.RankingPeriodDay
+	}
+	config, ok := rankingPeriods[period]
+	if !ok {
+		return nil, ErrInvalidRankingPeriod
+	}
+	if s.redisRepo == nil {
+		return nil, nil
+	}
+
+	now := s.now()
+	keys := make([]string, config.buckets)
+	weights := make([]float64, config.buckets)
+	for i := 0; i < config.buckets; i++ {
+		age := time.Duration(i) * time.Hour
+		keys[i] = rankBucketKey(kind, now.Add(-age))
+		weights[i] = math.Pow(0.5, float64(age)/float64(config.halfLife))
+	}
+
+	// Over-fetch a little so members that no longer exist do not shorten the list
+	return s.redisRepo.UnionRanks(ctx, keys, weights, limit+5)
+}
+
+// rankLimit clamps the requested number of ranked items
+func rankLimit(limit int) int {
+	if limit <= 0 {
+		return rankDefaultLimit
+	}
+	if limit > rankMaxLimit {
+		return rankMaxLimit
+	}
+	return limit
+}
+
+// rankBucketKey returns the sorted set holding the kind's scores for the hour containing t
+func rankBucketKey(kind string, t time.Time) string {
+	return fmt.Sprintf("rank:%s:%s", kind, t.UTC().Format(rankBucketLayout))
+}
+
+// rankIDs parses the ranked members back into IDs
+func rankIDs(ranks []repositories.RankScore) []uint {
+	ids := make([]uint, 0, len(ranks))
+	for _, rank := range ranks {
+		if id := rankID(rank); id != 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func rankID(rank repositories.RankScore) uint {
+	id, _ := strconv.ParseUint(rank.Member, 10, 32)
+	return uint(id)
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*rankScorePrecision) / rankScorePrecision
+}
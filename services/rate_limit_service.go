@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"rota-api/models"
+	"rota-api/repositories"
+)
+
+// RateLimiter interface defines methods for throttling requests per key
+type RateLimiter interface {
+	Allow(ctx context.Context, key string, policy models.RateLimitPolicy) models.RateLimitResult
+	Reset(ctx context.Context, key string)
+}
+
+// rateLimiter implements RateLimiter
+type rateLimiter struct {
+	rateLimitRepo repositories.RateLimitRepository
+}
+
+// NewRateLimiter creates a new rate limiter
+func NewRateLimiter(rateLimitRepo repositories.RateLimitRepository) RateLimiter {
+	return &rateLimiter{rateLimitRepo: rateLimitRepo}
+}
+
+// Allow counts a request against the policy. If the store is unreachable the request
+// is allowed, so an outage of Redis does not take the API down with it
+func (l *rateLimiter) Allow(ctx context.Context, key string, policy models.RateLimitPolicy) models.RateLimitResult {
+	if policy.Limit <= 0 || policy.Window <= 0 {
+		return models.RateLimitResult{Allowed: true}
+	}
+
+	result, err := l.rateLimitRepo.Hit(ctx, key, policy)
+	if err != nil {
+		log.Printf("Rate limit: failed to count %s: %v", key, err)
+		return models.RateLimitResult{Allowed: true, Limit: policy.Limit, Remaining: policy.Limit}
+	}
+	return result
+}
+
+// Reset clears the requests counted for a key
+func (l *rateLimiter) Reset(ctx context.Context, key string) {
+	if err := l.rateLimitRepo.Reset(ctx, key); err != nil {
+		log.Printf("Rate limit: failed to reset %s: %v", key, err)
+	}
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	apperrors "rota-api/errors"
+	"rota-api/models"
+	"rota-api/repositories"
+	"rota-api/utils/servicetime"
+)
+
+// maxReportDays caps the service dates one report covers
+const maxReportDays = 366
+
+// Report sources
+const (
+	reportSourceLive     = "live"
+	reportSourceSnapshot = "snapshot"
+)
+
+var (
+	ErrInvalidReportRange = apperrors.BadRequest(apperrors.REPORT_INVALID_RANGE,
+		fmt.Sprintf("from must not be after to, and a report covers at most %d days", maxReportDays))
+	ErrInvalidReportGroup = apperrors.BadRequest(apperrors.REPORT_INVALID_GROUP, "invalid report grouping")
+)
+
+// ReportRules holds the settings reports are computed with
+type ReportRules struct {
+	// LateThreshold is how late a departure can be and still count as on time, unless a report sets its own
+	LateThreshold time.Duration
+	// VehicleHoursPerDay is the service time a day that counts as full utilization of a vehicle
+	VehicleHoursPerDay time.Duration
+	// UseSnapshot reads trips from the trip_actuals materialized view instead of computing them live
+	UseSnapshot bool
+}
+
+// ReportService interface defines methods for the punctuality and utilization reports
+type ReportService interface {
+	GetPunctuality(ctx context.Context, query models.ReportQuery) (*models.PunctualityReport, error)
+	GetUtilization(ctx context.Context, query models.ReportQuery) (*models.UtilizationReport, error)
+	RefreshSnapshot(ctx context.Context) error
+}
+
+// reportService implements ReportService
+type reportService struct {
+	reportRepo repositories.ReportRepository
+	rules      ReportRules
+}
+
+// NewReportService creates a new report service
+func NewReportService(reportRepo repositories.ReportRepository, rules ReportRules) ReportService {
+	return &reportService{
+		reportRepo: reportRepo,
+		rules:      rules,
+	}
+}
+
+// GetPunctuality reports the on-time performance, delays and cancellations of the trips the query selects,
+// grouped by any of route, station and day, or as one total row when not grouped
+func (s *reportService) GetPunctuality(ctx context.Context, query models.ReportQuery) (*models.PunctualityReport, error) {
+	if err := s.resolveQuery(&query, models.ReportGroupRoute, models.ReportGroupStation, models.ReportGroupDay); err != nil {
+		return nil, err
+	}
+	if query.LateThreshold == nil {
+		query.LateThreshold = &s.rules.LateThreshold
+	}
+
+	rows, err := s.reportRepo.Punctuality(ctx, query, s.rules.UseSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		row := &rows[i]
+		row.OnTimeRate = percentage(float64(row.OnTime), float64(row.Reported))
+		row.CancellationRate = percentage(float64(row.Cancelled), float64(row.Trips))
+		for _, delay := range []*float64{row.AvgDepartureDelay, row.AvgArrivalDelay, row.MaxDepartureDelay} {
+			if delay != nil {
+				*delay = roundTo(*delay, 1)
+			}
+		}
+	}
+
+	return &models.PunctualityReport{
+		ReportRange:   s.reportRange(query),
+		LateThreshold: query.LateThreshold.Seconds(),
+		Rows:          rows,
+	}, nil
+}
+
+// GetUtilization reports the trips, service hours and seats offered of each vehicle, split by day when
+// grouped by day. Utilization is the vehicle's service time over VehicleHoursPerDay for each day covered
+func (s *reportService) GetUtilization(ctx context.Context, query models.ReportQuery) (*models.UtilizationReport, error) {
+	if err := s.resolveQuery(&query, models.ReportGroupDay); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.reportRepo.Utilization(ctx, query, s.rules.UseSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	days := 1
+	if len(query.GroupBy) == 0 {
+		days = reportDays(query)
+	}
+	available := float64(days) * s.rules.VehicleHoursPerDay.Seconds()
+	for i := range rows {
+		row := &rows[i]
+		row.ServiceHours = roundTo(row.ServiceSeconds/time.Hour.Seconds(), 2)
+		row.UtilizationRate = percentage(row.ServiceSeconds, available)
+	}
+
+	return &models.UtilizationReport{
+		ReportRange: s.reportRange(query),
+		HoursPerDay: s.rules.VehicleHoursPerDay.Hours(),
+		Rows:        rows,
+	}, nil
+}
+
+// RefreshSnapshot recomputes the trip_actuals view reports read when UseSnapshot is set
+func (s *reportService) RefreshSnapshot(ctx context.Context) error {
+	return s.reportRepo.RefreshSnapshot(ctx)
+}
+
+// resolveQuery fills in the default range, the seven service days up to today, and checks the range
+// and that the query only groups by the allowed groupings, each at most once
+func (s *reportService) resolveQuery(query *models.ReportQuery, allowed ...string) error {
+	if query.To == "" {
+		query.To = models.DateOf(servicetime.ServiceDate(servicetime.Now()))
+	}
+	if query.From == "" {
+		to, err := query.To.Time()
+		if err != nil {
+			return ErrInvalidReportRange
+		}
+		query.From = models.DateOf(to.AddDate(0, 0, -6))
+	}
+	if days := reportDays(*query); days < 1 || days > maxReportDays {
+		return ErrInvalidReportRange
+	}
+
+	seen := make(map[string]bool, len(query.GroupBy))
+	for _, group := range query.GroupBy {
+		if seen[group] || !slices.Contains(allowed, group) {
+			return ErrInvalidReportGroup.With("allowed", allowed)
+		}
+		seen[group] = true
+	}
+	if query.GroupBy == nil {
+		query.GroupBy = []string{}
+	}
+	return nil
+}
+
+// reportRange describes the range and grouping of a resolved query
+func (s *reportService) reportRange(query models.ReportQuery) models.ReportRange {
+	source := reportSourceLive
+	if s.rules.UseSnapshot {
+		source = reportSourceSnapshot
+	}
+	return models.ReportRange{From: query.From, To: query.To, GroupBy: query.GroupBy, Source: source}
+}
+
+// reportDays counts the service dates a query covers, or 0 when its range is invalid
+func reportDays(query models.ReportQuery) int {
+	from, err := query.From.Time()
+	if err != nil {
+		return 0
+	}
+	to, err := query.To.Time()
+	if err != nil || to.Before(from) {
+		return 0
+	}
+	return int(math.Round(to.Sub(from).Hours()/24)) + 1
+}
+
+// percentage is part of whole as a percentage with one decimal, or nil when whole is zero
+func percentage(part, whole float64) *float64 {
+	if whole <= 0 {
+		return nil
+	}
+	rate := roundTo(part/whole*100, 1)
+	return &rate
+}
+
+// roundTo rounds value to the given number of decimals
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
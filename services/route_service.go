@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"rota-api/i18n"
 	"rota-api/models"
 	"rota-api/repositories"
 )
@@ -11,43 +12,74 @@ import (
 // RouteService interface defines methods for route service
 type RouteService interface {
 	GetRouteByID(ctx context.Context, id uint) (*models.Route, error)
-	GetAllRoutes(ctx context.Context) ([]*models.Route, error)
+	GetAllRoutes(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
 	CreateRoute(ctx context.Context, route *models.Route) error
 	UpdateRoute(ctx context.Context, route *models.Route) error
-	DeleteRoute(ctx context.Context, id uint) error
+	PatchRoute(ctx context.Context, id uint, apply func(*models.Route) error) (*models.Route, error)
+	DeleteRoute(ctx context.Context, id uint, version int) error
 }
 
 // routeService implements RouteService
 type routeService struct {
 	routeRepo   repositories.RouteRepository
 	stationRepo repositories.StationRepository
+	cache       CacheConfig
+	ranking     RankingService
 }
 
 // NewRouteService creates a new route service
-func NewRouteService(routeRepo repositories.RouteRepository) RouteService {
-	return &routeService{routeRepo: routeRepo}
+func NewRouteService(routeRepo repositories.RouteRepository, cache CacheConfig, ranking RankingService) RouteService {
+	return &routeService{routeRepo: routeRepo, cache: cache, ranking: ranking}
 }
 
 // CreateRoute creates a new route
 func (s *routeService) CreateRoute(ctx context.Context, route *models.Route) error {
+	if err := validateTranslations(route.Translations, models.RouteTranslatableFields); err != nil {
+		return err
+	}
 	if err := s.routeRepo.Create(ctx, route); err != nil {
 		return fmt.Errorf("failed to create route: %w", err)
 	}
+	invalidateCache(ctx, s.cache, cacheTagRoutes)
 	return nil
 }
 
 // GetRouteByID retrieves a route by ID
+// Routes embed their start and end stations, so the result is tagged with stations as well
 func (s *routeService) GetRouteByID(ctx context.Context, id uint) (*models.Route, error) {
-	return s.routeRepo.FindByID(ctx, id)
+	key := fmt.Sprintf("route:%d", id)
+	route, err := readThrough(ctx, s.cache, key, []string{cacheTagRoute(id), cacheTagStations}, func() (*models.Route, error) {
+		return s.routeRepo.FindByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.ranking.RecordRouteView(ctx, id)
+
+	localized := route.Localized(i18n.FromContext(ctx))
+	return &localized, nil
 }
 
-// GetAllRoutes retrieves all routes
-func (s *routeService) GetAllRoutes(ctx context.Context) ([]*models.Route, error) {
-	return s.routeRepo.FindAll(ctx)
+// GetAllRoutes retrieves one page of routes
+func (s *routeService) GetAllRoutes(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	locale := i18n.FromContext(ctx)
+	return readThrough(ctx, s.cache, pageCacheKey("routes", locale, params), []string{cacheTagRoutes, cacheTagStations}, func() (models.PagedResult, error) {
+		result, err := s.routeRepo.FindPage(ctx, params)
+		if routes, ok := result.Data.([]*models.Route); ok {
+			for _, item := range routes {
+				*item = item.Localized(locale)
+			}
+		}
+		return result, err
+	})
 }
 
 // UpdateRoute updates a route
 func (s *routeService) UpdateRoute(ctx context.Context, route *models.Route) error {
+	if err := validateTranslations(route.Translations, models.RouteTranslatableFields); err != nil {
+		return err
+	}
+
 	// ดึงข้อมูลเดิมก่อน
 	existingRoute, err := s.routeRepo.FindByID(ctx, route.ID)
 	if err != nil {
@@ -67,12 +99,23 @@ func (s *routeService) UpdateRoute(ctx context.Context, route *models.Route) err
 	if route.Duration != "" {
 		existingRoute.Duration = route.Duration
 	}
+	if route.Description != "" {
+		existingRoute.Description = route.Description
+	}
+	if route.Translations != nil {
+		existingRoute.Translations = route.Translations
+	}
+	// บันทึกเฉพาะเมื่อยังเป็นเวอร์ชันที่ผู้เรียกอ่านไป
+	if route.Version != 0 {
+		existingRoute.Version = route.Version
+	}
 
 	// บันทึกการอัพเดท
 	err = s.routeRepo.Update(ctx, existingRoute)
 	if err != nil {
 		return fmt.Errorf("failed to update route: %w", err)
 	}
+	invalidateCache(ctx, s.cache, cacheTagRoutes, cacheTagRoute(route.ID))
 	
 	// คัดลอกข้อมูลที่อัพเดทแล้วกลับไปยังพารามิเตอร์
 	*route = *existingRoute
@@ -80,7 +123,32 @@ func (s *routeService) UpdateRoute(ctx context.Context, route *models.Route) err
 	return nil
 }
 
+// PatchRoute applies a patch to the route as stored, untranslated, and saves the result
+func (s *routeService) PatchRoute(ctx context.Context, id uint, apply func(*models.Route) error) (*models.Route, error) {
+	route, err := s.routeRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := apply(route); err != nil {
+		return nil, err
+	}
+	if err := validateTranslations(route.Translations, models.RouteTranslatableFields); err != nil {
+		return nil, err
+	}
+
+	route.ID = id
+	if err := s.routeRepo.Update(ctx, route); err != nil {
+		return nil, err
+	}
+	invalidateCache(ctx, s.cache, cacheTagRoutes, cacheTagRoute(id))
+	return route, nil
+}
+
 // DeleteRoute deletes a route
-func (s *routeService) DeleteRoute(ctx context.Context, id uint) error {
-	return s.routeRepo.Delete(ctx, id)
+func (s *routeService) DeleteRoute(ctx context.Context, id uint, version int) error {
+	if err := s.routeRepo.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	invalidateCache(ctx, s.cache, cacheTagRoutes, cacheTagRoute(id))
+	return nil
 }
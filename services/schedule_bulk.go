@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	apperrors "rota-api/errors"
+	"rota-api/models"
+)
+
+// maxBulkSchedules caps the schedules one bulk operation may change or create
+const maxBulkSchedules = 500
+
+var (
+	// ErrBulkCriteriaRequired is returned when a bulk operation is not narrowed by any criterion
+	ErrBulkCriteriaRequired = apperrors.BadRequest(apperrors.SCHEDULE_NO_CRITERIA, "at least one criterion is required to select schedules")
+	// ErrBulkTooManySchedules is returned when a bulk operation would touch more than maxBulkSchedules schedules
+	ErrBulkTooManySchedules = apperrors.BadRequest(apperrors.SCHEDULE_BULK_TOO_MANY,
+		fmt.Sprintf("bulk operations are limited to %d schedules, narrow the criteria", maxBulkSchedules))
+)
+
+// ShiftSchedules moves the departure and arrival of every schedule matching criteria that is not
+// cancelled by offset. Schedules keep their service date unless the shift moves them off it
+func (s *scheduleService) ShiftSchedules(ctx context.Context, criteria models.ScheduleCriteria, offset time.Duration, preview bool) (*models.ScheduleBulkResult, error) {
+	matching, err := s.findForBulk(ctx, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make([]*models.Schedule, 0, len(matching))
+	for _, schedule := range matching {
+		if schedule.Status == models.ScheduleStatusCancelled {
+			continue
+		}
+		schedule.DepartureTime = schedule.DepartureTime.Add(offset)
+		schedule.ArrivalTime = schedule.ArrivalTime.Add(offset)
+		schedule.DepartureLocal, schedule.ArrivalLocal = "", ""
+		if err := schedule.ResolveTimes(); errors.Is(err, models.ErrScheduleTimes) {
+			// เวลาที่เลื่อนออกนอกวันให้บริการเดิม ให้คำนวณวันที่ให้บริการใหม่จากเวลาออกเดินทาง
+			schedule.ServiceDate = ""
+			err = schedule.ResolveTimes()
+		}
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	// Drivers are checked against the shifted times of their other shifted trips, not the stored ones
+	for _, schedule := range schedules {
+		if err := s.checkVehicleAvailability(ctx, schedule); err != nil {
+			return nil, fmt.Errorf("schedule %d: %w", schedule.ID, err)
+		}
+		if err := s.checkDriverAssignment(ctx, schedule, schedules...); err != nil {
+			return nil, fmt.Errorf("schedule %d: %w", schedule.ID, err)
+		}
+	}
+
+	// Each update must clear the exclusion constraints on its own, so trips moving later are saved
+	// latest first and trips moving earlier earliest first, never onto a slot not yet vacated
+	if offset > 0 {
+		sort.SliceStable(schedules, func(i, j int) bool {
+			return schedules[i].DepartureTime.After(schedules[j].DepartureTime)
+		})
+	}
+
+	result, err := s.planBulk(ctx, models.ScheduleBulkShift, schedules, preview)
+	if err != nil || preview {
+		return result, err
+	}
+	if err := s.saveBulk(ctx, schedules, nil, nil); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CancelSchedules cancels every schedule matching criteria that is not cancelled yet,
+// and records the cancellation with reason in the log of each schedule on behalf of staffID
+func (s *scheduleService) CancelSchedules(ctx context.Context, criteria models.ScheduleCriteria, reason string, staffID uint, preview bool) (*models.ScheduleBulkResult, error) {
+	matching, err := s.findForBulk(ctx, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	schedules := make([]*models.Schedule, 0, len(matching))
+	logs := make([]*models.ScheduleLog, 0, len(matching))
+	for _, schedule := range matching {
+		if schedule.Status == models.ScheduleStatusCancelled {
+			continue
+		}
+		logs = append(logs, &models.ScheduleLog{
+			ScheduleID:        schedule.ID,
+			StaffID:           staffID,
+			ChangeDescription: fmt.Sprintf("Status changed from %s to %s", schedule.Status, models.ScheduleStatusCancelled),
+			Status:            models.ScheduleStatusCancelled,
+			Notes:             reason,
+			UpdatedAt:         &now,
+		})
+		schedule.Status = models.ScheduleStatusCancelled
+		schedules = append(schedules, schedule)
+	}
+
+	result := &models.ScheduleBulkResult{
+		Operation: models.ScheduleBulkCancel,
+		Preview:   preview,
+		Affected:  len(schedules),
+		Schedules: schedules,
+		Conflicts: []models.ScheduleConflict{},
+	}
+	if preview {
+		return result, nil
+	}
+	if err := s.saveBulk(ctx, schedules, nil, logs); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CopySchedules copies the schedules running on sourceDate that match criteria to each of targetDates,
+// at the same local times. Cancelled schedules are not copied
+func (s *scheduleService) CopySchedules(ctx context.Context, sourceDate models.Date, criteria models.ScheduleCriteria, targetDates []models.Date, preview bool) (*models.ScheduleBulkResult, error) {
+	criteria.ServiceDate = &sourceDate
+	sources, err := s.findForBulk(ctx, criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	var copies []*models.Schedule
+	for _, date := range targetDates {
+		if date == sourceDate {
+			continue
+		}
+		for _, source := range sources {
+			if source.Status == models.ScheduleStatusCancelled {
+				continue
+			}
+			copies = append(copies, &models.Schedule{
+				RouteID:        source.RouteID,
+				VehicleID:      source.VehicleID,
+				DriverID:       source.DriverID,
+				StationID:      source.StationID,
+				Round:          source.Round,
+				ServiceDate:    date,
+				DepartureLocal: source.DepartureLocal,
+				ArrivalLocal:   source.ArrivalLocal,
+				Status:         models.ScheduleStatusScheduled,
+				Platform:       source.Platform,
+			})
+		}
+	}
+	if len(copies) > maxBulkSchedules {
+		return nil, ErrBulkTooManySchedules
+	}
+
+	for _, schedule := range copies {
+		if err := schedule.ResolveTimes(); err != nil {
+			return nil, err
+		}
+		if err := s.checkVehicleAvailability(ctx, schedule); err != nil {
+			return nil, fmt.Errorf("copy to %s: %w", schedule.ServiceDate, err)
+		}
+		if err := s.checkDriverAssignment(ctx, schedule); err != nil {
+			return nil, fmt.Errorf("copy to %s: %w", schedule.ServiceDate, err)
+		}
+	}
+
+	result, err := s.planBulk(ctx, models.ScheduleBulkCopy, copies, preview)
+	if err != nil || preview {
+		return result, err
+	}
+	if err := s.saveBulk(ctx, nil, copies, nil); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// findForBulk loads the schedules matching criteria, refusing criteria that match every
+// schedule and matches too large to change in one transaction
+func (s *scheduleService) findForBulk(ctx context.Context, criteria models.ScheduleCriteria) ([]*models.Schedule, error) {
+	if criteria.IsEmpty() {
+		return nil, ErrBulkCriteriaRequired
+	}
+	schedules, err := s.scheduleRepo.FindMatching(ctx, criteria, maxBulkSchedules)
+	if err != nil {
+		return nil, err
+	}
+	if len(schedules) > maxBulkSchedules {
+		return nil, ErrBulkTooManySchedules
+	}
+	return schedules, nil
+}
+
+// planBulk checks the planned schedules for conflicts. A preview reports them, while an
+// operation that would cause conflicts is refused
+func (s *scheduleService) planBulk(ctx context.Context, operation string, planned []*models.Schedule, preview bool) (*models.ScheduleBulkResult, error) {
+	conflicts, err := s.conflictService.CheckPlan(ctx, planned)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 && !preview {
+		return nil, &ConflictError{Conflicts: conflicts}
+	}
+
+	schedules := make([]*models.Schedule, len(planned))
+	copy(schedules, planned)
+	sortByDeparture(schedules)
+	return &models.ScheduleBulkResult{
+		Operation: operation,
+		Preview:   preview,
+		Affected:  len(planned),
+		Schedules: schedules,
+		Conflicts: conflicts,
+	}, nil
+}
+
+// saveBulk writes the changes of a bulk operation in one transaction
+func (s *scheduleService) saveBulk(ctx context.Context, updated, created []*models.Schedule, logs []*models.ScheduleLog) error {
+	if len(updated) == 0 && len(created) == 0 {
+		return nil
+	}
+	if err := translateScheduleOverlap(s.scheduleRepo.SaveBatch(ctx, updated, created, logs)); err != nil {
+		return err
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules)
+	return nil
+}
+
+// sortByDeparture orders schedules by departure, then by ID
+func sortByDeparture(schedules []*models.Schedule) {
+	sort.SliceStable(schedules, func(i, j int) bool {
+		if !schedules[i].DepartureTime.Equal(schedules[j].DepartureTime) {
+			return schedules[i].DepartureTime.Before(schedules[j].DepartureTime)
+		}
+		return schedules[i].ID < schedules[j].ID
+	})
+}
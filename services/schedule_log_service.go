@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"time"
 
 	"rota-api/models"
 	"rota-api/repositories"
@@ -10,20 +11,23 @@ import (
 // ScheduleLogService interface defines methods for schedule log service
 type ScheduleLogService interface {
 	GetScheduleLogByID(ctx context.Context, id uint) (*models.ScheduleLog, error)
-	GetAllScheduleLogs(ctx context.Context) ([]*models.ScheduleLog, error)
+	GetAllScheduleLogs(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
 	CreateScheduleLog(ctx context.Context, scheduleLog *models.ScheduleLog) error
 	UpdateScheduleLog(ctx context.Context, scheduleLog *models.ScheduleLog) error
-	DeleteScheduleLog(ctx context.Context, id uint) error
+	PatchScheduleLog(ctx context.Context, id uint, apply func(*models.ScheduleLog) error) (*models.ScheduleLog, error)
+	DeleteScheduleLog(ctx context.Context, id uint, version int) error
 }
 
 // scheduleLogService implements ScheduleLogService
+// Logs carry the actual times and status of a trip, so cached schedules are dropped on every write
 type scheduleLogService struct {
 	scheduleLogRepo repositories.ScheduleLogRepository
+	cache           CacheConfig
 }
 
 // NewScheduleLogService creates a new schedule log service
-func NewScheduleLogService(scheduleLogRepo repositories.ScheduleLogRepository) ScheduleLogService {
-	return &scheduleLogService{scheduleLogRepo}
+func NewScheduleLogService(scheduleLogRepo repositories.ScheduleLogRepository, cache CacheConfig) ScheduleLogService {
+	return &scheduleLogService{scheduleLogRepo, cache}
 }
 
 // GetScheduleLogByID retrieves a schedule log by ID
@@ -31,14 +35,18 @@ func (s *scheduleLogService) GetScheduleLogByID(ctx context.Context, id uint) (*
 	return s.scheduleLogRepo.FindByID(ctx, id)
 }
 
-// GetAllScheduleLogs retrieves all schedule logs
-func (s *scheduleLogService) GetAllScheduleLogs(ctx context.Context) ([]*models.ScheduleLog, error) {
-	return s.scheduleLogRepo.FindAll(ctx)
+// GetAllScheduleLogs retrieves one page of schedule logs
+func (s *scheduleLogService) GetAllScheduleLogs(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	return s.scheduleLogRepo.FindPage(ctx, params)
 }
 
 // CreateScheduleLog creates a new schedule log
 func (s *scheduleLogService) CreateScheduleLog(ctx context.Context, scheduleLog *models.ScheduleLog) error {
-	return s.scheduleLogRepo.Create(ctx, scheduleLog)
+	if err := s.scheduleLogRepo.Create(ctx, scheduleLog); err != nil {
+		return err
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules, cacheTagStations)
+	return nil
 }
 
 // UpdateScheduleLog updates a schedule log
@@ -71,12 +79,17 @@ func (s *scheduleLogService) UpdateScheduleLog(ctx context.Context, scheduleLog
 	if scheduleLog.Notes != "" {
 		existingLog.Notes = scheduleLog.Notes
 	}
+	// บันทึกเฉพาะเมื่อยังเป็นเวอร์ชันที่ผู้เรียกอ่านไป
+	if scheduleLog.Version != 0 {
+		existingLog.Version = scheduleLog.Version
+	}
 
 	// บันทึกการอัพเดท
 	err = s.scheduleLogRepo.Update(ctx, existingLog)
 	if err != nil {
 		return err
 	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules, cacheTagStations)
 
 	// คัดลอกข้อมูลที่อัพเดทแล้วกลับไปยังพารามิเตอร์
 	*scheduleLog = *existingLog
@@ -84,7 +97,31 @@ func (s *scheduleLogService) UpdateScheduleLog(ctx context.Context, scheduleLog
 	return nil
 }
 
+// PatchScheduleLog applies a patch to the schedule log and saves the result, including cleared fields
+func (s *scheduleLogService) PatchScheduleLog(ctx context.Context, id uint, apply func(*models.ScheduleLog) error) (*models.ScheduleLog, error) {
+	scheduleLog, err := s.scheduleLogRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := apply(scheduleLog); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	scheduleLog.ID = id
+	scheduleLog.UpdatedAt = &now
+	if err := s.scheduleLogRepo.Update(ctx, scheduleLog); err != nil {
+		return nil, err
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules, cacheTagStations)
+	return scheduleLog, nil
+}
+
 // DeleteScheduleLog deletes a schedule log
-func (s *scheduleLogService) DeleteScheduleLog(ctx context.Context, id uint) error {
-	return s.scheduleLogRepo.Delete(ctx, id)
+func (s *scheduleLogService) DeleteScheduleLog(ctx context.Context, id uint, version int) error {
+	if err := s.scheduleLogRepo.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules, cacheTagStations)
+	return nil
 }
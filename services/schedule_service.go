@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"rota-api/i18n"
 	"rota-api/models"
 	"rota-api/repositories"
 )
@@ -10,23 +14,46 @@ import (
 // ScheduleService interface defines methods for schedule service
 type ScheduleService interface {
 	GetScheduleByID(ctx context.Context, id uint) (*models.Schedule, error)
-	GetAllSchedules(ctx context.Context) ([]*models.Schedule, error)
+	GetAllSchedules(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
 	SearchSchedules(ctx context.Context, params models.ScheduleSearchParams) (models.PagedResult, error)
 	CreateSchedule(ctx context.Context, schedule *models.Schedule) error
 	UpdateSchedule(ctx context.Context, schedule *models.Schedule) error
-	DeleteSchedule(ctx context.Context, id uint) error
+	PatchSchedule(ctx context.Context, id uint, apply func(*models.Schedule) error) (*models.Schedule, error)
+	DeleteSchedule(ctx context.Context, id uint, version int) error
 	GetSchedulesByStation(ctx context.Context, stationID uint, limit int) (models.StationSchedulesResponse, error)
 	GetSimpleSchedulesByStation(ctx context.Context, stationID uint) (*models.SimpleStationScheduleResponse, error)
+	ShiftSchedules(ctx context.Context, criteria models.ScheduleCriteria, offset time.Duration, preview bool) (*models.ScheduleBulkResult, error)
+	CancelSchedules(ctx context.Context, criteria models.ScheduleCriteria, reason string, staffID uint, preview bool) (*models.ScheduleBulkResult, error)
+	CopySchedules(ctx context.Context, sourceDate models.Date, criteria models.ScheduleCriteria, targetDates []models.Date, preview bool) (*models.ScheduleBulkResult, error)
 }
 
 // scheduleService implements ScheduleService
 type scheduleService struct {
-	scheduleRepo repositories.ScheduleRepository
+	scheduleRepo       repositories.ScheduleRepository
+	maintenanceService MaintenanceService
+	driverService      DriverService
+	conflictService    ConflictService
+	cache              CacheConfig
+	ranking            RankingService
 }
 
 // NewScheduleService creates a new schedule service
-func NewScheduleService(scheduleRepo repositories.ScheduleRepository) ScheduleService {
-	return &scheduleService{scheduleRepo}
+func NewScheduleService(
+	scheduleRepo repositories.ScheduleRepository,
+	maintenanceService MaintenanceService,
+	driverService DriverService,
+	conflictService ConflictService,
+	cache CacheConfig,
+	ranking RankingService,
+) ScheduleService {
+	return &scheduleService{
+		scheduleRepo:       scheduleRepo,
+		maintenanceService: maintenanceService,
+		driverService:      driverService,
+		conflictService:    conflictService,
+		cache:              cache,
+		ranking:            ranking,
+	}
 }
 
 // GetScheduleByID retrieves a schedule by ID
@@ -34,14 +61,30 @@ func (s *scheduleService) GetScheduleByID(ctx context.Context, id uint) (*models
 	return s.scheduleRepo.FindByID(ctx, id)
 }
 
-// GetAllSchedules retrieves all schedules
-func (s *scheduleService) GetAllSchedules(ctx context.Context) ([]*models.Schedule, error) {
-	return s.scheduleRepo.FindAll(ctx)
+// GetAllSchedules retrieves one page of schedules
+func (s *scheduleService) GetAllSchedules(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	return s.scheduleRepo.FindPage(ctx, params)
 }
 
 // CreateSchedule creates a new schedule
 func (s *scheduleService) CreateSchedule(ctx context.Context, schedule *models.Schedule) error {
-	return s.scheduleRepo.Create(ctx, schedule)
+	if err := schedule.ResolveTimes(); err != nil {
+		return err
+	}
+	if err := s.checkVehicleAvailability(ctx, schedule); err != nil {
+		return err
+	}
+	if err := s.checkDriverAssignment(ctx, schedule); err != nil {
+		return err
+	}
+	if err := s.checkConflicts(ctx, schedule); err != nil {
+		return err
+	}
+	if err := translateScheduleOverlap(s.scheduleRepo.Create(ctx, schedule)); err != nil {
+		return err
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules)
+	return nil
 }
 
 // UpdateSchedule updates a schedule
@@ -59,27 +102,69 @@ func (s *scheduleService) UpdateSchedule(ctx context.Context, schedule *models.S
 	if schedule.VehicleID != 0 {
 		existingSchedule.VehicleID = schedule.VehicleID
 	}
+	if schedule.DriverID != nil {
+		existingSchedule.DriverID = schedule.DriverID
+	}
 	if schedule.StationID != 0 {
 		existingSchedule.StationID = schedule.StationID
 	}
 	if schedule.Round != 0 {
 		existingSchedule.Round = schedule.Round
 	}
+	// เวลาที่ระบุพร้อม offset แทนเวลาท้องถิ่นเดิม ส่วนวันที่ให้บริการคำนวณใหม่จากเวลาออกเดินทางหากไม่ได้ระบุ
+	if !schedule.DepartureTime.IsZero() || !schedule.ArrivalTime.IsZero() {
+		existingSchedule.DepartureLocal, existingSchedule.ArrivalLocal = "", ""
+	}
 	if !schedule.DepartureTime.IsZero() {
 		existingSchedule.DepartureTime = schedule.DepartureTime
+		existingSchedule.ServiceDate = ""
 	}
 	if !schedule.ArrivalTime.IsZero() {
 		existingSchedule.ArrivalTime = schedule.ArrivalTime
 	}
+	// วันที่ให้บริการและเวลาท้องถิ่น (เช่น 25:10 สำหรับเที่ยวหลังเที่ยงคืน) เปลี่ยนวันโดยคงเวลาท้องถิ่นเดิมไว้
+	if schedule.ServiceDate != "" {
+		existingSchedule.ServiceDate = schedule.ServiceDate
+	}
+	if schedule.DepartureLocal != "" {
+		existingSchedule.DepartureLocal = schedule.DepartureLocal
+	}
+	if schedule.ArrivalLocal != "" {
+		existingSchedule.ArrivalLocal = schedule.ArrivalLocal
+	}
+	if err := existingSchedule.ResolveTimes(); err != nil {
+		return err
+	}
 	if schedule.Status != "" {
 		existingSchedule.Status = schedule.Status
 	}
+	if schedule.Platform != "" {
+		existingSchedule.Platform = schedule.Platform
+	}
+	// บันทึกเฉพาะเมื่อยังเป็นเวอร์ชันที่ผู้เรียกอ่านไป
+	if schedule.Version != 0 {
+		existingSchedule.Version = schedule.Version
+	}
+
+	// ตรวจสอบว่ารถไม่อยู่ระหว่างซ่อมบำรุงในช่วงเวลาเดินรถ
+	if err := s.checkVehicleAvailability(ctx, existingSchedule); err != nil {
+		return err
+	}
+	// ตรวจสอบเวลาพักและชั่วโมงทำงานของพนักงานขับรถ
+	if err := s.checkDriverAssignment(ctx, existingSchedule); err != nil {
+		return err
+	}
+	// ตรวจสอบว่ารถไม่ถูกจองซ้อนและมีเวลากลับรถเพียงพอ
+	if err := s.checkConflicts(ctx, existingSchedule); err != nil {
+		return err
+	}
 
 	// บันทึกการอัพเดท
-	err = s.scheduleRepo.Update(ctx, existingSchedule)
+	err = translateScheduleOverlap(s.scheduleRepo.Update(ctx, existingSchedule))
 	if err != nil {
 		return err
 	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules)
 
 	// คัดลอกข้อมูลที่อัพเดทแล้วกลับไปยังพารามิเตอร์
 	*schedule = *existingSchedule
@@ -89,29 +174,145 @@ func (s *scheduleService) UpdateSchedule(ctx context.Context, schedule *models.S
 
 // SearchSchedules searches for schedules with advanced filtering
 func (s *scheduleService) SearchSchedules(ctx context.Context, params models.ScheduleSearchParams) (models.PagedResult, error) {
-	return s.scheduleRepo.Search(ctx, params)
+	result, err := s.scheduleRepo.Search(ctx, params)
+	if err != nil {
+		return result, err
+	}
+	s.ranking.RecordScheduleSearch(ctx, params)
+	return result, nil
+}
+
+// PatchSchedule applies a patch to the schedule and saves the result after the same checks as an update
+func (s *scheduleService) PatchSchedule(ctx context.Context, id uint, apply func(*models.Schedule) error) (*models.Schedule, error) {
+	schedule, err := s.scheduleRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *schedule
+	if err := apply(schedule); err != nil {
+		return nil, err
+	}
+
+	// เวลาพร้อม offset ที่เปลี่ยนแทนเวลาท้องถิ่นเดิมที่ไม่ได้เปลี่ยน และคำนวณวันที่ให้บริการใหม่หากไม่ได้ระบุ
+	localsKept := schedule.DepartureLocal == before.DepartureLocal && schedule.ArrivalLocal == before.ArrivalLocal
+	instantsChanged := !schedule.DepartureTime.Equal(before.DepartureTime) || !schedule.ArrivalTime.Equal(before.ArrivalTime)
+	if localsKept && instantsChanged {
+		schedule.DepartureLocal, schedule.ArrivalLocal = "", ""
+		if !schedule.DepartureTime.Equal(before.DepartureTime) && schedule.ServiceDate == before.ServiceDate {
+			schedule.ServiceDate = ""
+		}
+	}
+	if err := schedule.ResolveTimes(); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkVehicleAvailability(ctx, schedule); err != nil {
+		return nil, err
+	}
+	if err := s.checkDriverAssignment(ctx, schedule); err != nil {
+		return nil, err
+	}
+	if err := s.checkConflicts(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	schedule.ID = id
+	if err := translateScheduleOverlap(s.scheduleRepo.Update(ctx, schedule)); err != nil {
+		return nil, err
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules)
+	return schedule, nil
 }
 
 // DeleteSchedule deletes a schedule
-func (s *scheduleService) DeleteSchedule(ctx context.Context, id uint) error {
-	return s.scheduleRepo.Delete(ctx, id)
+func (s *scheduleService) DeleteSchedule(ctx context.Context, id uint, version int) error {
+	if err := s.scheduleRepo.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules)
+	return nil
 }
 
 // GetSchedulesByStation retrieves schedules (both inbound and outbound) for a specific station
 // with a limit of schedules per direction
 func (s *scheduleService) GetSchedulesByStation(ctx context.Context, stationID uint, limit int) (models.StationSchedulesResponse, error) {
-	response, err := s.scheduleRepo.FindSchedulesByStation(ctx, stationID, limit)
-	
-	// If station details is empty, provide a default description based on station name
-	if response.StationDetails == "" && response.Station.ID > 0 {
-		response.StationDetails = "สถานีขนส่งผู้โดยสาร" + response.Station.Name + " ให้บริการเดินรถระหว่างเมือง ตั้งอยู่ที่ " + response.Station.Location
-	}
-	
-	return response, err
+	locale := i18n.FromContext(ctx)
+	key := fmt.Sprintf("station-schedules:%s:%d:%d", locale, stationID, limit)
+	return readThrough(ctx, s.cache, key, []string{cacheTagSchedules, cacheTagRoutes, cacheTagStations}, func() (models.StationSchedulesResponse, error) {
+		response, err := s.scheduleRepo.FindSchedulesByStation(ctx, stationID, limit)
+
+		// Describe the station in the reader's language
+		if response.Station.ID > 0 {
+			response.Localize(locale)
+		}
+
+		return response, err
+	})
 }
 
 // GetSimpleSchedulesByStation retrieves a simplified version of schedules for a station
 // with only departure times and destinations, limited to 10 schedules in each direction
 func (s *scheduleService) GetSimpleSchedulesByStation(ctx context.Context, stationID uint) (*models.SimpleStationScheduleResponse, error) {
-	return s.scheduleRepo.FindSimpleSchedulesByStation(ctx, stationID)
+	locale := i18n.FromContext(ctx)
+	key := fmt.Sprintf("station-schedules-simple:%s:%d", locale, stationID)
+	return readThrough(ctx, s.cache, key, []string{cacheTagSchedules, cacheTagRoutes, cacheTagStations}, func() (*models.SimpleStationScheduleResponse, error) {
+		response, err := s.scheduleRepo.FindSimpleSchedulesByStation(ctx, stationID)
+		if err != nil {
+			return nil, err
+		}
+		response.Localize(locale)
+		return response, nil
+	})
+}
+
+// checkVehicleAvailability refuses schedules whose vehicle is out of service during the trip
+func (s *scheduleService) checkVehicleAvailability(ctx context.Context, schedule *models.Schedule) error {
+	return ensureVehicleAvailable(ctx, s.maintenanceService, schedule)
+}
+
+// ensureVehicleAvailable returns ErrVehicleOutOfService if the schedule's vehicle is out of service during the trip.
+// Cancelled trips do not need the vehicle, so trips on a vehicle taken out of service can be cancelled
+func ensureVehicleAvailable(ctx context.Context, maintenanceService MaintenanceService, schedule *models.Schedule) error {
+	if schedule.VehicleID == 0 || schedule.Status == models.ScheduleStatusCancelled || schedule.DepartureTime.IsZero() {
+		return nil
+	}
+
+	end := schedule.ArrivalTime
+	if !end.After(schedule.DepartureTime) {
+		end = schedule.DepartureTime.Add(time.Minute)
+	}
+
+	return maintenanceService.EnsureAvailable(ctx, schedule.VehicleID, schedule.DepartureTime, end)
+}
+
+// checkDriverAssignment enforces the roster rules when a schedule has a driver. Cancelled trips are not
+// driven, so they never break the rules. planned are the other schedules saved together with it,
+// see DriverService.ValidateAssignment
+func (s *scheduleService) checkDriverAssignment(ctx context.Context, schedule *models.Schedule, planned ...*models.Schedule) error {
+	if schedule.DriverID == nil || schedule.Status == models.ScheduleStatusCancelled ||
+		schedule.DepartureTime.IsZero() || schedule.ArrivalTime.IsZero() {
+		return nil
+	}
+	return s.driverService.ValidateAssignment(ctx, *schedule.DriverID, schedule, planned...)
+}
+
+// checkConflicts refuses schedules that double-book their vehicle or cut its turnaround short
+func (s *scheduleService) checkConflicts(ctx context.Context, schedule *models.Schedule) error {
+	conflicts, err := s.conflictService.CheckSchedule(ctx, schedule)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+// translateScheduleOverlap reports exclusion constraint violations that slipped past
+// the service checks (e.g. concurrent writes) as schedule conflicts
+func translateScheduleOverlap(err error) error {
+	if errors.Is(err, repositories.ErrScheduleOverlap) {
+		return &ConflictError{}
+	}
+	return err
 }
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	apperrors "rota-api/errors"
+	"rota-api/i18n"
+	"rota-api/models"
+	"rota-api/repositories"
+	"rota-api/utils/thai"
+)
+
+// Custom errors
+var (
+	ErrEmptySearchQuery = apperrors.BadRequest(apperrors.SEARCH_QUERY_REQUIRED, "search query is required")
+	ErrSearchQueryLong  = apperrors.BadRequest(apperrors.SEARCH_QUERY_TOO_LONG, "search query is too long")
+)
+
+// Search limits
+const (
+	defaultSearchLimit  = 10
+	maxSearchLimit      = 50
+	defaultSuggestLimit = 8
+	maxSuggestLimit     = 20
+	maxSearchQueryRunes = 100
+	searchRouteLimit    = 20
+)
+
+// SearchService interface defines methods for searching stations and routes in Thai and English
+type SearchService interface {
+	Search(ctx context.Context, query string, limit int) (*models.SearchResult, error)
+	Suggest(ctx context.Context, query string, limit int) ([]models.SearchSuggestion, error)
+	RefreshSearchKeys(ctx context.Context) (int, error)
+}
+
+// searchService implements SearchService
+type searchService struct {
+	stationRepo repositories.StationRepository
+	routeRepo   repositories.RouteRepository
+}
+
+// NewSearchService creates a new search service
+func NewSearchService(stationRepo repositories.StationRepository, routeRepo repositories.RouteRepository) SearchService {
+	return &searchService{
+		stationRepo: stationRepo,
+		routeRepo:   routeRepo,
+	}
+}
+
+// Search finds stations by name or location and the routes that start or end at them
+func (s *searchService) Search(ctx context.Context, query string, limit int) (*models.SearchResult, error) {
+	terms, err := searchTerms(query, limit, defaultSearchLimit, maxSearchLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := s.stationRepo.Search(ctx, terms)
+	if err != nil {
+		return nil, err
+	}
+
+	locale := i18n.FromContext(ctx)
+	stationIDs := make([]uint, 0, len(matches))
+	for i, match := range matches {
+		stationIDs = append(stationIDs, match.Station.ID)
+		localized := match.Station.Localized(locale)
+		matches[i].Station = &localized
+	}
+	routes, err := s.routeRepo.FindByStations(ctx, stationIDs, searchRouteLimit)
+	if err != nil {
+		return nil, err
+	}
+	for i, route := range routes {
+		localized := route.Localized(locale)
+		routes[i] = &localized
+	}
+
+	return &models.SearchResult{
+		Query:    terms.Text,
+		Stations: matches,
+		Routes:   routes,
+	}, nil
+}
+
+// Suggest returns station names completing a partly typed query
+func (s *searchService) Suggest(ctx context.Context, query string, limit int) ([]models.SearchSuggestion, error) {
+	terms, err := searchTerms(query, limit, defaultSuggestLimit, maxSuggestLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	stations, err := s.stationRepo.Suggest(ctx, terms)
+	if err != nil {
+		return nil, err
+	}
+
+	locale := i18n.FromContext(ctx)
+	suggestions := make([]models.SearchSuggestion, 0, len(stations))
+	for _, station := range stations {
+		suggestions = append(suggestions, models.SearchSuggestion{
+			StationID: station.ID,
+			Name:      station.Localized(locale).Name,
+			NameEN:    station.NameEN,
+		})
+	}
+	return suggestions, nil
+}
+
+// RefreshSearchKeys brings the search keys of existing stations up to date
+func (s *searchService) RefreshSearchKeys(ctx context.Context) (int, error) {
+	return s.stationRepo.RefreshSearchKeys(ctx)
+}
+
+// searchTerms validates a query and prepares it for matching
+func searchTerms(query string, limit, defaultLimit, maxLimit int) (models.StationSearchTerms, error) {
+	text := strings.Join(strings.Fields(query), " ")
+	if text == "" {
+		return models.StationSearchTerms{}, ErrEmptySearchQuery
+	}
+	if utf8.RuneCountInString(text) > maxSearchQueryRunes {
+		return models.StationSearchTerms{}, ErrSearchQueryLong
+	}
+
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	words, compact := thai.Query(text)
+	return models.StationSearchTerms{
+		Text:    text,
+		Words:   words,
+		Compact: compact,
+		Limit:   limit,
+	}, nil
+}
@@ -2,22 +2,34 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
-
+	apperrors "rota-api/errors"
 	"rota-api/models"
+	"rota-api/passhash"
 	"rota-api/repositories"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrStaffExists is returned when another staff member already has the username or email
+	ErrStaffExists = apperrors.Conflict(apperrors.STAFF_EXISTS, "staff with this username or email already exists")
+	// ErrNotStaff is returned when a signed-in user acting as staff has no staff record
+	ErrNotStaff = apperrors.Forbidden(apperrors.STAFF_NOT_LINKED, "the signed-in user has no staff record")
 )
 
 // StaffService interface defines methods for staff service
 type StaffService interface {
 	GetStaffByID(ctx context.Context, id uint) (*models.Staff, error)
-	GetAllStaff(ctx context.Context) ([]*models.Staff, error)
+	GetStaffForUser(ctx context.Context, email string) (*models.Staff, error)
+	GetAllStaff(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
 	CreateStaff(ctx context.Context, staff *models.Staff) error
 	UpdateStaff(ctx context.Context, staff *models.Staff) error
-	DeleteStaff(ctx context.Context, id uint) error
+	PatchStaff(ctx context.Context, id uint, apply func(*models.Staff) error) (*models.Staff, error)
+	DeleteStaff(ctx context.Context, id uint, version int) error
 }
 
 // staffService implements StaffService
@@ -30,27 +42,39 @@ func NewStaffService(staffRepo repositories.StaffRepository) StaffService {
 	return &staffService{staffRepo}
 }
 
+// GetStaffForUser retrieves the staff record of a signed-in user, matched by email
+func (s *staffService) GetStaffForUser(ctx context.Context, email string) (*models.Staff, error) {
+	if email == "" {
+		return nil, ErrNotStaff
+	}
+	staff, err := s.staffRepo.FindByEmail(ctx, email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotStaff
+	}
+	return staff, err
+}
+
 // CreateStaff creates a new staff
 func (s *staffService) CreateStaff(ctx context.Context, staff *models.Staff) error {
 	// Check if email already exists
 	existingStaff, err := s.staffRepo.FindByEmail(ctx, staff.Email)
 	if err == nil && existingStaff != nil {
-		return fmt.Errorf("staff with email %s already exists", staff.Email)
+		return fmt.Errorf("%w: %s", ErrStaffExists, staff.Email)
 	}
 
 	// Check if username already exists
 	existingStaff, err = s.staffRepo.FindByUsername(ctx, staff.Username)
 	if err == nil && existingStaff != nil {
-		return fmt.Errorf("staff with username %s already exists", staff.Username)
+		return fmt.Errorf("%w: %s", ErrStaffExists, staff.Username)
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(staff.Password), bcrypt.DefaultCost)
+	hashedPassword, err := passhash.Hash(staff.Password)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	staff.Password = string(hashedPassword)
+	staff.Password = hashedPassword
 	staff.CreatedAt = time.Now()
 	staff.UpdatedAt = time.Now()
 
@@ -66,9 +90,9 @@ func (s *staffService) GetStaffByID(ctx context.Context, id uint) (*models.Staff
 	return s.staffRepo.FindByID(ctx, id)
 }
 
-// GetAllStaff retrieves all staff
-func (s *staffService) GetAllStaff(ctx context.Context) ([]*models.Staff, error) {
-	return s.staffRepo.FindAll(ctx)
+// GetAllStaff retrieves one page of staff
+func (s *staffService) GetAllStaff(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	return s.staffRepo.FindPage(ctx, params)
 }
 
 // UpdateStaff updates a staff
@@ -95,15 +119,19 @@ func (s *staffService) UpdateStaff(ctx context.Context, staff *models.Staff) err
 		existingStaff.Phone = staff.Phone
 	}
 	if staff.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(staff.Password), bcrypt.DefaultCost)
+		hashedPassword, err := passhash.Hash(staff.Password)
 		if err != nil {
 			return fmt.Errorf("failed to hash password: %w", err)
 		}
-		existingStaff.Password = string(hashedPassword)
+		existingStaff.Password = hashedPassword
 	}
 	if staff.StationID != 0 {
 		existingStaff.StationID = staff.StationID
 	}
+	// Save only over the version the caller read
+	if staff.Version != 0 {
+		existingStaff.Version = staff.Version
+	}
 	existingStaff.UpdatedAt = time.Now()
 
 	if err := s.staffRepo.Update(ctx, existingStaff); err != nil {
@@ -116,7 +144,37 @@ func (s *staffService) UpdateStaff(ctx context.Context, staff *models.Staff) err
 	return nil
 }
 
+// PatchStaff applies a patch to the staff member and saves the result. Passwords are not patchable
+func (s *staffService) PatchStaff(ctx context.Context, id uint, apply func(*models.Staff) error) (*models.Staff, error) {
+	staff, err := s.staffRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	username, email := staff.Username, staff.Email
+	if err := apply(staff); err != nil {
+		return nil, err
+	}
+
+	if staff.Email != email {
+		if other, err := s.staffRepo.FindByEmail(ctx, staff.Email); err == nil && other != nil && other.ID != id {
+			return nil, fmt.Errorf("%w: %s", ErrStaffExists, staff.Email)
+		}
+	}
+	if staff.Username != username {
+		if other, err := s.staffRepo.FindByUsername(ctx, staff.Username); err == nil && other != nil && other.ID != id {
+			return nil, fmt.Errorf("%w: %s", ErrStaffExists, staff.Username)
+		}
+	}
+
+	staff.ID = id
+	staff.UpdatedAt = time.Now()
+	if err := s.staffRepo.Update(ctx, staff); err != nil {
+		return nil, fmt.Errorf("failed to update staff: %w", err)
+	}
+	return staff, nil
+}
+
 // DeleteStaff deletes a staff
-func (s *staffService) DeleteStaff(ctx context.Context, id uint) error {
-	return s.staffRepo.Delete(ctx, id)
+func (s *staffService) DeleteStaff(ctx context.Context, id uint, version int) error {
+	return s.staffRepo.Delete(ctx, id, version)
 }
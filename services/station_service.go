@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"rota-api/i18n"
 	"rota-api/models"
 	"rota-api/repositories"
 )
@@ -11,49 +12,101 @@ import (
 // StationService interface defines methods for station service
 type StationService interface {
 	GetStationByID(ctx context.Context, id uint) (*models.Station, error)
-	GetAllStations(ctx context.Context) ([]*models.Station, error)
+	GetAllStations(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
 	CreateStation(ctx context.Context, station *models.Station) error
 	UpdateStation(ctx context.Context, station *models.Station) error
-	DeleteStation(ctx context.Context, id uint) error
+	PatchStation(ctx context.Context, id uint, apply func(*models.Station) error) (*models.Station, error)
+	DeleteStation(ctx context.Context, id uint, version int) error
 }
 
 // stationService implements StationService
 type stationService struct {
 	stationRepo repositories.StationRepository
+	cache       CacheConfig
+	ranking     RankingService
 }
 
 // NewStationService creates a new station service
-func NewStationService(stationRepo repositories.StationRepository) StationService {
-	return &stationService{stationRepo}
+func NewStationService(stationRepo repositories.StationRepository, cache CacheConfig, ranking RankingService) StationService {
+	return &stationService{stationRepo, cache, ranking}
 }
 
 // CreateStation creates a new station
 func (s *stationService) CreateStation(ctx context.Context, station *models.Station) error {
+	if err := validateTranslations(station.Translations, models.StationTranslatableFields); err != nil {
+		return err
+	}
 	if err := s.stationRepo.Create(ctx, station); err != nil {
 		return fmt.Errorf("failed to create station: %w", err)
 	}
+	invalidateCache(ctx, s.cache, cacheTagStations)
 	return nil
 }
 
 // GetStationByID retrieves a station by ID
+// Stations embed their schedules, so the result is tagged with schedules and routes as well
 func (s *stationService) GetStationByID(ctx context.Context, id uint) (*models.Station, error) {
-	return s.stationRepo.FindByID(ctx, id)
+	key := fmt.Sprintf("station:%d", id)
+	station, err := readThrough(ctx, s.cache, key, []string{cacheTagStations, cacheTagRoutes, cacheTagSchedules}, func() (*models.Station, error) {
+		return s.stationRepo.FindByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.ranking.RecordStationView(ctx, id)
+
+	localized := station.Localized(i18n.FromContext(ctx))
+	return &localized, nil
 }
 
-// GetAllStations retrieves all stations
-func (s *stationService) GetAllStations(ctx context.Context) ([]*models.Station, error) {
-	return s.stationRepo.FindAll(ctx)
+// GetAllStations retrieves one page of stations
+func (s *stationService) GetAllStations(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	locale := i18n.FromContext(ctx)
+	return readThrough(ctx, s.cache, pageCacheKey("stations", locale, params), []string{cacheTagStations, cacheTagRoutes, cacheTagSchedules}, func() (models.PagedResult, error) {
+		result, err := s.stationRepo.FindPage(ctx, params)
+		if stations, ok := result.Data.([]*models.Station); ok {
+			for _, item := range stations {
+				*item = item.Localized(locale)
+			}
+		}
+		return result, err
+	})
 }
 
 // UpdateStation updates a station
 func (s *stationService) UpdateStation(ctx context.Context, station *models.Station) error {
+	if err := validateTranslations(station.Translations, models.StationTranslatableFields); err != nil {
+		return err
+	}
 	if err := s.stationRepo.Update(ctx, station); err != nil {
 		return fmt.Errorf("failed to update station: %w", err)
 	}
+	invalidateCache(ctx, s.cache, cacheTagStations)
 	return nil
 }
 
+// PatchStation applies a patch to the station as stored, untranslated, and saves the result
+func (s *stationService) PatchStation(ctx context.Context, id uint, apply func(*models.Station) error) (*models.Station, error) {
+	station, err := s.stationRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := apply(station); err != nil {
+		return nil, err
+	}
+
+	station.ID = id
+	if err := s.UpdateStation(ctx, station); err != nil {
+		return nil, err
+	}
+	return station, nil
+}
+
 // DeleteStation deletes a station
-func (s *stationService) DeleteStation(ctx context.Context, id uint) error {
-	return s.stationRepo.Delete(ctx, id)
+func (s *stationService) DeleteStation(ctx context.Context, id uint, version int) error {
+	if err := s.stationRepo.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	invalidateCache(ctx, s.cache, cacheTagStations)
+	return nil
 }
@@ -0,0 +1,19 @@
+package services
+
+import (
+	"fmt"
+
+	apperrors "rota-api/errors"
+	"rota-api/models"
+)
+
+// ErrInvalidTranslation is returned when a record carries translations for an unsupported locale or field
+var ErrInvalidTranslation = apperrors.BadRequest(apperrors.INVALID_TRANSLATION, "invalid translation")
+
+// validateTranslations checks translations against the fields of the record that may be translated
+func validateTranslations(translations models.Translations, fields []string) error {
+	if err := translations.Validate(fields...); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTranslation, err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apperrors "rota-api/errors"
+	"rota-api/models"
+	"rota-api/repositories"
+	"rota-api/utils/servicetime"
+
+	"gorm.io/gorm"
+)
+
+// CO2 emitted per passenger-kilometre, after the UK government's greenhouse gas conversion factors:
+// an average petrol car with one occupant, and an average local bus. A trip saves the difference
+const (
+	carKgCO2PerKm      = 0.170
+	busKgCO2PerKm      = 0.097
+	tripTopStationsMax = 5
+)
+
+var (
+	ErrTripExists          = apperrors.Conflict(apperrors.TRIP_EXISTS, "trip is already in your history")
+	ErrNotTripOwner        = apperrors.Forbidden(apperrors.TRIP_FORBIDDEN, "unauthorized to access this trip")
+	ErrTripInFuture        = apperrors.BadRequest(apperrors.TRIP_IN_FUTURE, "only trips already taken can be saved")
+	ErrTripInvalidRoute    = apperrors.BadRequest(apperrors.TRIP_INVALID_ROUTE, "route does not exist")
+	ErrTripInvalidSchedule = apperrors.BadRequest(apperrors.TRIP_INVALID_SCHEDULE, "schedule does not exist or was cancelled")
+)
+
+// TripService interface defines methods for users' travel history
+type TripService interface {
+	SaveTrip(ctx context.Context, trip *models.Trip) (*models.Trip, error)
+	GetUserTrips(ctx context.Context, userID uint, params models.SearchParams) (models.PagedResult, error)
+	GetTripStats(ctx context.Context, userID uint, from, to *time.Time) (*models.TripStats, error)
+	RemoveTrip(ctx context.Context, id, userID uint) error
+	ClearHistory(ctx context.Context, userID uint) (int64, error)
+}
+
+// tripService implements TripService
+type tripService struct {
+	tripRepo     repositories.TripRepository
+	scheduleRepo repositories.ScheduleRepository
+	routeRepo    repositories.RouteRepository
+}
+
+// NewTripService creates a new trip service
+func NewTripService(
+	tripRepo repositories.TripRepository,
+	scheduleRepo repositories.ScheduleRepository,
+	routeRepo repositories.RouteRepository,
+) TripService {
+	return &tripService{
+		tripRepo:     tripRepo,
+		scheduleRepo: scheduleRepo,
+		routeRepo:    routeRepo,
+	}
+}
+
+// SaveTrip adds a trip the user has taken to their history. A trip saved from a schedule takes its
+// route and departure time from it, and a schedule is only saved once per user; other trips name
+// their route and when they were travelled. The distance is copied from the route
+func (s *tripService) SaveTrip(ctx context.Context, trip *models.Trip) (*models.Trip, error) {
+	if trip.ScheduleID != nil {
+		if _, err := s.tripRepo.FindByUserAndSchedule(ctx, trip.UserID, *trip.ScheduleID); err == nil {
+			return nil, ErrTripExists
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+
+		schedule, err := s.scheduleRepo.FindByID(ctx, *trip.ScheduleID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTripInvalidSchedule
+		}
+		if err != nil {
+			return nil, err
+		}
+		if schedule.Status == models.ScheduleStatusCancelled {
+			return nil, ErrTripInvalidSchedule
+		}
+		trip.RouteID = schedule.RouteID
+		trip.TravelledAt = schedule.DepartureTime
+	}
+	if trip.TravelledAt.After(time.Now()) {
+		return nil, ErrTripInFuture
+	}
+
+	route, err := s.routeRepo.FindByID(ctx, trip.RouteID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTripInvalidRoute
+	}
+	if err != nil {
+		return nil, err
+	}
+	trip.Distance = route.Distance
+	if trip.Source == "" {
+		trip.Source = models.TripSourceManual
+	}
+
+	if err := s.tripRepo.Create(ctx, trip); err != nil {
+		return nil, fmt.Errorf("failed to save trip: %w", err)
+	}
+	return s.tripRepo.FindByID(ctx, trip.ID)
+}
+
+// GetUserTrips retrieves one page of a user's trips
+func (s *tripService) GetUserTrips(ctx context.Context, userID uint, params models.SearchParams) (models.PagedResult, error) {
+	return s.tripRepo.FindPageByUser(ctx, userID, params)
+}
+
+// GetTripStats summarises the trips a user travelled between from and to, either of which may be open:
+// how many, how far, the CO2 saved against driving the same distance, and the stations used most
+func (s *tripService) GetTripStats(ctx context.Context, userID uint, from, to *time.Time) (*models.TripStats, error) {
+	stats, err := s.tripRepo.Totals(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	stats.TopStations, err = s.tripRepo.TopStations(ctx, userID, from, to, tripTopStationsMax)
+	if err != nil {
+		return nil, err
+	}
+	if stats.TopStations == nil {
+		stats.TopStations = []models.StationUsage{}
+	}
+
+	for _, t := range []*time.Time{stats.FirstTrip, stats.LastTrip} {
+		if t != nil {
+			*t = servicetime.In(*t)
+		}
+	}
+	stats.TotalDistance = roundTo(stats.TotalDistance, 1)
+	stats.CO2SavedKg = roundTo(stats.TotalDistance*(carKgCO2PerKm-busKgCO2PerKm), 1)
+	return stats, nil
+}
+
+// RemoveTrip removes one trip from the user's history
+func (s *tripService) RemoveTrip(ctx context.Context, id, userID uint) error {
+	trip, err := s.tripRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if trip.UserID != userID {
+		return ErrNotTripOwner
+	}
+	return s.tripRepo.Delete(ctx, id)
+}
+
+// ClearHistory erases the user's whole travel history and returns how many trips were removed
+func (s *tripService) ClearHistory(ctx context.Context, userID uint) (int64, error) {
+	return s.tripRepo.DeleteByUser(ctx, userID)
+}
@@ -4,28 +4,39 @@ import (
 	"context"
 	"fmt"
 
-	"golang.org/x/crypto/bcrypt"
-
 	"rota-api/models"
 	"rota-api/repositories"
+	"rota-api/utils/servicetime"
 )
 
 // UserService interface defines methods for user service
 type UserService interface {
 	GetUserByID(ctx context.Context, id int) (*models.User, error)
-	GetAllUsers(ctx context.Context) ([]*models.User, error)
+	GetAllUsers(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
 	UpdateUser(ctx context.Context, user *models.User) error
-	DeleteUser(ctx context.Context, id int) error
+	PatchUser(ctx context.Context, id int, apply func(*models.User) error) (*models.User, error)
+	DeleteUser(ctx context.Context, id int, version int) error
+	ExportUserData(ctx context.Context, id int) (*models.UserDataExport, error)
 }
 
 // userService implements UserService
 type userService struct {
-	userRepo repositories.UserRepository
+	userRepo     repositories.UserRepository
+	favoriteRepo repositories.FavoriteRepository
+	tripRepo     repositories.TripRepository
 }
 
 // NewUserService creates a new user service
-func NewUserService(userRepo repositories.UserRepository) UserService {
-	return &userService{userRepo}
+func NewUserService(
+	userRepo repositories.UserRepository,
+	favoriteRepo repositories.FavoriteRepository,
+	tripRepo repositories.TripRepository,
+) UserService {
+	return &userService{
+		userRepo:     userRepo,
+		favoriteRepo: favoriteRepo,
+		tripRepo:     tripRepo,
+	}
 }
 
 // GetUserByID retrieves a user by ID
@@ -35,23 +46,14 @@ func (s *userService) GetUserByID(ctx context.Context, id int) (*models.User, er
 	return s.userRepo.FindByID(ctx, strID)
 }
 
-// GetAllUsers retrieves all users
-func (s *userService) GetAllUsers(ctx context.Context) ([]*models.User, error) {
-	return s.userRepo.FindAll(ctx)
+// GetAllUsers retrieves one page of users
+func (s *userService) GetAllUsers(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	return s.userRepo.FindPage(ctx, params)
 }
 
-// UpdateUser updates a user with all provided fields
+// UpdateUser updates a user with all provided fields. The password is saved as it is: it is
+// already hashed, and changes only through AuthService.ChangePassword
 func (s *userService) UpdateUser(ctx context.Context, user *models.User) error {
-	// Make sure password is hashed if it's provided
-	if user.Password != nil && *user.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*user.Password), bcrypt.DefaultCost)
-		if err != nil {
-			return fmt.Errorf("failed to hash password: %w", err)
-		}
-		hashedPwdStr := string(hashedPassword)
-		user.Password = &hashedPwdStr
-	}
-
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return fmt.Errorf("failed to update user: %w", err)
 	}
@@ -59,9 +61,56 @@ func (s *userService) UpdateUser(ctx context.Context, user *models.User) error {
 	return nil
 }
 
-// DeleteUser deletes a user
-func (s *userService) DeleteUser(ctx context.Context, id int) error {
+// PatchUser applies a patch to the user and saves the result. Passwords are not patchable,
+// so the stored hash is saved as it is
+func (s *userService) PatchUser(ctx context.Context, id int, apply func(*models.User) error) (*models.User, error) {
+	user, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := apply(user); err != nil {
+		return nil, err
+	}
+
+	user.ID = id
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// DeleteUser deletes a user, anonymizing the account and erasing their favorites and trips
+func (s *userService) DeleteUser(ctx context.Context, id int, version int) error {
 	// Convert int to string as repository expects string ID
 	strID := fmt.Sprintf("%d", id)
-	return s.userRepo.Delete(ctx, strID)
+	return s.userRepo.Delete(ctx, strID, version)
+}
+
+// ExportUserData gathers the user's profile, favorites and trips into one archive
+func (s *userService) ExportUserData(ctx context.Context, id int) (*models.UserDataExport, error) {
+	user, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	favorites, err := s.favoriteRepo.FindByUser(ctx, uint(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to export favorites: %w", err)
+	}
+	trips, err := s.tripRepo.FindByUser(ctx, uint(id))
+	if err != nil {
+		return nil, err
+	}
+	if favorites == nil {
+		favorites = []models.Favorite{}
+	}
+	if trips == nil {
+		trips = []models.Trip{}
+	}
+
+	return &models.UserDataExport{
+		ExportedAt: servicetime.Now(),
+		Profile:    user,
+		Favorites:  favorites,
+		Trips:      trips,
+	}, nil
 }
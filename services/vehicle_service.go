@@ -4,27 +4,33 @@ import (
 	"context"
 	"fmt"
 
+	apperrors "rota-api/errors"
 	"rota-api/models"
 	"rota-api/repositories"
 )
 
+// ErrVehicleExists is returned when another vehicle already has the license plate
+var ErrVehicleExists = apperrors.Conflict(apperrors.VEHICLE_EXISTS, "vehicle with this license plate already exists")
+
 // VehicleService interface defines methods for vehicle service
 type VehicleService interface {
 	GetVehicleByID(ctx context.Context, id uint) (*models.Vehicle, error)
-	GetAllVehicles(ctx context.Context) ([]*models.Vehicle, error)
+	GetAllVehicles(ctx context.Context, params models.SearchParams) (models.PagedResult, error)
 	CreateVehicle(ctx context.Context, vehicle *models.Vehicle) error
 	UpdateVehicle(ctx context.Context, vehicle *models.Vehicle) error
-	DeleteVehicle(ctx context.Context, id uint) error
+	PatchVehicle(ctx context.Context, id uint, apply func(*models.Vehicle) error) (*models.Vehicle, error)
+	DeleteVehicle(ctx context.Context, id uint, version int) error
 }
 
 // vehicleService implements VehicleService
 type vehicleService struct {
 	vehicleRepo repositories.VehicleRepository
+	cache       CacheConfig
 }
 
 // NewVehicleService creates a new vehicle service
-func NewVehicleService(vehicleRepo repositories.VehicleRepository) VehicleService {
-	return &vehicleService{vehicleRepo}
+func NewVehicleService(vehicleRepo repositories.VehicleRepository, cache CacheConfig) VehicleService {
+	return &vehicleService{vehicleRepo, cache}
 }
 
 // CreateVehicle creates a new vehicle
@@ -32,7 +38,7 @@ func (s *vehicleService) CreateVehicle(ctx context.Context, vehicle *models.Vehi
 	// Check if license plate already exists
 	existingVehicle, err := s.vehicleRepo.FindByLicensePlate(ctx, vehicle.LicensePlate)
 	if err == nil && existingVehicle != nil {
-		return fmt.Errorf("vehicle with license plate %s already exists", vehicle.LicensePlate)
+		return fmt.Errorf("%w: %s", ErrVehicleExists, vehicle.LicensePlate)
 	}
 
 	if err := s.vehicleRepo.Create(ctx, vehicle); err != nil {
@@ -47,9 +53,9 @@ func (s *vehicleService) GetVehicleByID(ctx context.Context, id uint) (*models.V
 	return s.vehicleRepo.FindByID(ctx, id)
 }
 
-// GetAllVehicles retrieves all vehicles
-func (s *vehicleService) GetAllVehicles(ctx context.Context) ([]*models.Vehicle, error) {
-	return s.vehicleRepo.FindAll(ctx)
+// GetAllVehicles retrieves one page of vehicles
+func (s *vehicleService) GetAllVehicles(ctx context.Context, params models.SearchParams) (models.PagedResult, error) {
+	return s.vehicleRepo.FindPage(ctx, params)
 }
 
 // UpdateVehicle updates a vehicle
@@ -64,7 +70,7 @@ func (s *vehicleService) UpdateVehicle(ctx context.Context, vehicle *models.Vehi
 		// Check if license plate already exists
 		vehicleWithSamePlate, err := s.vehicleRepo.FindByLicensePlate(ctx, vehicle.LicensePlate)
 		if err == nil && vehicleWithSamePlate != nil && vehicleWithSamePlate.ID != vehicle.ID {
-			return fmt.Errorf("vehicle with license plate %s already exists", vehicle.LicensePlate)
+			return fmt.Errorf("%w: %s", ErrVehicleExists, vehicle.LicensePlate)
 		}
 		existingVehicle.LicensePlate = vehicle.LicensePlate
 	}
@@ -77,15 +83,55 @@ func (s *vehicleService) UpdateVehicle(ctx context.Context, vehicle *models.Vehi
 	if vehicle.RouteID != 0 {
 		existingVehicle.RouteID = vehicle.RouteID
 	}
+	if vehicle.Odometer != 0 {
+		existingVehicle.Odometer = vehicle.Odometer
+	}
+	// Save only over the version the caller read
+	if vehicle.Version != 0 {
+		existingVehicle.Version = vehicle.Version
+	}
 
 	if err := s.vehicleRepo.Update(ctx, existingVehicle); err != nil {
 		return fmt.Errorf("failed to update vehicle: %w", err)
 	}
+	*vehicle = *existingVehicle
+	// Schedules embed their vehicle
+	invalidateCache(ctx, s.cache, cacheTagSchedules, cacheTagStations)
 
 	return nil
 }
 
+// PatchVehicle applies a patch to the vehicle and saves the result
+func (s *vehicleService) PatchVehicle(ctx context.Context, id uint, apply func(*models.Vehicle) error) (*models.Vehicle, error) {
+	vehicle, err := s.vehicleRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	plate := vehicle.LicensePlate
+	if err := apply(vehicle); err != nil {
+		return nil, err
+	}
+
+	if vehicle.LicensePlate != plate {
+		other, err := s.vehicleRepo.FindByLicensePlate(ctx, vehicle.LicensePlate)
+		if err == nil && other != nil && other.ID != id {
+			return nil, fmt.Errorf("%w: %s", ErrVehicleExists, vehicle.LicensePlate)
+		}
+	}
+
+	vehicle.ID = id
+	if err := s.vehicleRepo.Update(ctx, vehicle); err != nil {
+		return nil, fmt.Errorf("failed to update vehicle: %w", err)
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules, cacheTagStations)
+	return vehicle, nil
+}
+
 // DeleteVehicle deletes a vehicle
-func (s *vehicleService) DeleteVehicle(ctx context.Context, id uint) error {
-	return s.vehicleRepo.Delete(ctx, id)
+func (s *vehicleService) DeleteVehicle(ctx context.Context, id uint, version int) error {
+	if err := s.vehicleRepo.Delete(ctx, id, version); err != nil {
+		return err
+	}
+	invalidateCache(ctx, s.cache, cacheTagSchedules, cacheTagStations)
+	return nil
 }
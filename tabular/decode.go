@@ -0,0 +1,184 @@
+package tabular
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"rota-api/utils/servicetime"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Fields lists the field names of the struct v, its JSON names, in declaration order.
+// Fields without a JSON name and struct fields other than times are left out
+func Fields(v interface{}) []string {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := jsonName(field)
+		if name == "" || !decodable(field.Type) {
+			continue
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// Decode sets the fields of the struct dst points to from values, keyed by JSON name. Missing
+// values leave their fields unset. Values that cannot be read as their field's type are reported
+// by field name, so they can be listed alongside the validation errors of the record
+func Decode(values map[string]string, dst interface{}) map[string]string {
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+
+	failures := make(map[string]string)
+	for i := 0; i < t.NumField(); i++ {
+		name := jsonName(t.Field(i))
+		value, ok := values[name]
+		if name == "" || !ok || !decodable(t.Field(i).Type) {
+			continue
+		}
+		if message := set(v.Field(i), value); message != "" {
+			failures[name] = message
+		}
+	}
+	return failures
+}
+
+// set parses value into field and returns what was expected when it cannot
+func set(field reflect.Value, value string) string {
+	if field.Kind() == reflect.Ptr {
+		target := reflect.New(field.Type().Elem())
+		if message := set(target.Elem(), value); message != "" {
+			return message
+		}
+		field.Set(target)
+		return ""
+	}
+
+	if field.Type() == timeType {
+		t, err := servicetime.ParseTime(value)
+		if err != nil {
+			return "datetime"
+		}
+		field.Set(reflect.ValueOf(t))
+		return ""
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return "integer"
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return "integer"
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return "number"
+		}
+		field.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "boolean"
+		}
+		field.SetBool(b)
+	}
+	return ""
+}
+
+// decodable reports whether a spreadsheet cell can be read into a field of type t
+func decodable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// jsonName is the name a struct field is written under in JSON, or "" for fields never written
+func jsonName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// Encode writes the fields of the struct v as a row, in the order Fields lists them.
+// Unset pointers and zero times are written as empty cells
+func Encode(v interface{}) []string {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	t := value.Type()
+
+	var row []string
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) == "" || !decodable(t.Field(i).Type) {
+			continue
+		}
+		row = append(row, format(value.Field(i)))
+	}
+	return row
+}
+
+// format writes a field the way set reads it back
+func format(field reflect.Value) string {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return ""
+		}
+		field = field.Elem()
+	}
+
+	if field.Type() == timeType {
+		t := field.Interface().(time.Time)
+		if t.IsZero() {
+			return ""
+		}
+		return servicetime.In(t).Format(time.RFC3339)
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		return field.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'f', -1, field.Type().Bits())
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool())
+	}
+	return ""
+}
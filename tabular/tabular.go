@@ -0,0 +1,242 @@
+// Package tabular reads and writes the spreadsheets used to import and export admin data, as CSV or
+// XLSX. The first row of a sheet holds the column headers, and every following row one record.
+// Headers are matched to the fields of a record through a column mapping, or by name
+package tabular
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	apperrors "rota-api/errors"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format is the file format of a spreadsheet
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+// Limits that keep an import cheap to read and to validate
+const (
+	MaxRows = 5000
+	// maxUnzipSize caps the uncompressed size of an XLSX file, which is a zip archive
+	maxUnzipSize = 64 << 20
+)
+
+var (
+	// ErrInvalidFile is wrapped by every error reading a spreadsheet
+	ErrInvalidFile = apperrors.BadRequest(apperrors.IMPORT_INVALID_FILE, "invalid spreadsheet")
+	// ErrInvalidMapping is returned when a column mapping names a field a record does not have
+	ErrInvalidMapping = apperrors.BadRequest(apperrors.IMPORT_INVALID_MAPPING, "invalid column mapping")
+	// ErrTooManyRows is returned when a spreadsheet holds more than MaxRows records
+	ErrTooManyRows = apperrors.New(http.StatusRequestEntityTooLarge, apperrors.IMPORT_TOO_MANY_ROWS, fmt.Sprintf("spreadsheets are limited to %d rows", MaxRows))
+)
+
+// utf8BOM marks CSV files as UTF-8, which spreadsheet programs need to show Thai text
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// ParseFormat reads a format name, "csv" or "xlsx"
+func ParseFormat(name string) (Format, bool) {
+	switch Format(strings.ToLower(strings.TrimSpace(name))) {
+	case CSV:
+		return CSV, true
+	case XLSX:
+		return XLSX, true
+	}
+	return "", false
+}
+
+// FormatOf tells the format of a file by its extension
+func FormatOf(filename string) (Format, bool) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(filename), "."))
+}
+
+// ContentType is the media type of files in the format
+func (f Format) ContentType() string {
+	if f == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Record is one row of a spreadsheet, with its values keyed by field name
+type Record struct {
+	// Row is the row number shown by spreadsheet programs; the header is row 1
+	Row    int
+	Values map[string]string
+}
+
+// Sheet is the content of a spreadsheet read for import
+type Sheet struct {
+	Records []Record
+	// Ignored lists the headers of columns that matched no field
+	Ignored []string
+}
+
+// Read reads the first sheet of a spreadsheet. Each column is matched to one of fields through
+// mapping, which maps headers to field names, or else by its header, compared case-insensitively
+// with spaces and dashes read as underscores. Rows with no values are skipped
+func Read(r io.Reader, format Format, fields []string, mapping map[string]string) (*Sheet, error) {
+	rows, err := readRows(r, format)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the header row is missing", ErrInvalidFile)
+	}
+
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field] = true
+	}
+	mapped := make(map[string]string, len(mapping))
+	for header, field := range mapping {
+		if !known[field] {
+			return nil, fmt.Errorf("%w: unknown field %q, fields are: %s", ErrInvalidMapping, field, strings.Join(fields, ", "))
+		}
+		mapped[normalize(header)] = field
+	}
+
+	sheet := &Sheet{}
+	columns := make([]string, len(rows[0]))
+	seen := make(map[string]bool)
+	for i, header := range rows[0] {
+		field, ok := mapped[normalize(header)]
+		if !ok {
+			field = normalize(header)
+		}
+		if !known[field] {
+			if strings.TrimSpace(header) != "" {
+				sheet.Ignored = append(sheet.Ignored, header)
+			}
+			continue
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("%w: more than one column holds %q", ErrInvalidFile, field)
+		}
+		seen[field] = true
+		columns[i] = field
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("%w: no column matches a field, fields are: %s", ErrInvalidFile, strings.Join(fields, ", "))
+	}
+
+	for n, row := range rows[1:] {
+		record := Record{Row: n + 2, Values: make(map[string]string, len(seen))}
+		for i, value := range row {
+			if i < len(columns) && columns[i] != "" {
+				if value = strings.TrimSpace(value); value != "" {
+					record.Values[columns[i]] = value
+				}
+			}
+		}
+		if len(record.Values) == 0 {
+			continue
+		}
+		if len(sheet.Records) == MaxRows {
+			return nil, ErrTooManyRows
+		}
+		sheet.Records = append(sheet.Records, record)
+	}
+	return sheet, nil
+}
+
+// readRows reads every row of the first sheet, header included
+func readRows(r io.Reader, format Format) ([][]string, error) {
+	switch format {
+	case CSV:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, utf8BOM)))
+		reader.FieldsPerRecord = -1
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		return rows, nil
+
+	case XLSX:
+		file, err := excelize.OpenReader(r, excelize.Options{UnzipSizeLimit: maxUnzipSize})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		defer file.Close()
+
+		sheets := file.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("%w: the workbook has no sheets", ErrInvalidFile)
+		}
+		rows, err := file.GetRows(sheets[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("%w: unsupported format %q", ErrInvalidFile, format)
+}
+
+// Write writes a spreadsheet with a header row and rows. XLSX files name their only sheet after name
+func Write(w io.Writer, format Format, name string, header []string, rows [][]string) error {
+	switch format {
+	case CSV:
+		if _, err := w.Write(utf8BOM); err != nil {
+			return err
+		}
+		writer := csv.NewWriter(w)
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return err
+		}
+		return writer.Error()
+
+	case XLSX:
+		file := excelize.NewFile()
+		defer file.Close()
+
+		if err := file.SetSheetName("Sheet1", name); err != nil {
+			return err
+		}
+		stream, err := file.NewStreamWriter(name)
+		if err != nil {
+			return err
+		}
+		for i, row := range append([][]string{header}, rows...) {
+			cell, err := excelize.CoordinatesToCellName(1, i+1)
+			if err != nil {
+				return err
+			}
+			values := make([]interface{}, len(row))
+			for j, value := range row {
+				values[j] = value
+			}
+			if err := stream.SetRow(cell, values); err != nil {
+				return err
+			}
+		}
+		if err := stream.Flush(); err != nil {
+			return err
+		}
+		return file.Write(w)
+	}
+	return errors.New("tabular: unsupported format " + string(format))
+}
+
+// normalize reduces a header to the form field names are written in, e.g. "License Plate" to "license_plate"
+func normalize(header string) string {
+	header = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header, string(utf8BOM))))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(header)
+}
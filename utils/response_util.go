@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"rota-api/response"
+
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -13,10 +15,7 @@ func SuccessResponse(c *fiber.Ctx, statusCode int, message string, data interfac
 	})
 }
 
-// ErrorResponse creates a standardized error response format
+// ErrorResponse sends a problem document with the generic error code of statusCode
 func ErrorResponse(c *fiber.Ctx, statusCode int, message string) error {
-	return c.Status(statusCode).JSON(fiber.Map{
-		"success": false,
-		"message": message,
-	})
+	return response.Problem(c, fiber.NewError(statusCode, message))
 }
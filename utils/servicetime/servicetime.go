@@ -0,0 +1,189 @@
+package servicetime
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	// Embed the zone database so the agency timezone loads on hosts and images without one
+	_ "time/tzdata"
+)
+
+// DefaultTimezone is the agency timezone used until Configure is called
+const DefaultTimezone = "Asia/Bangkok"
+
+// DateLayout is how service dates are written
+const DateLayout = "2006-01-02"
+
+// MaxLocalTime bounds local times of trips that run past midnight into the next calendar day
+const MaxLocalTime = 48 * time.Hour
+
+var (
+	ErrInvalidDate      = errors.New("invalid service date, expected YYYY-MM-DD")
+	ErrInvalidLocalTime = errors.New("invalid local time, expected HH:MM or HH:MM:SS")
+	ErrInvalidTime      = errors.New("invalid time, expected RFC 3339 or a local date and time")
+)
+
+// settings are the agency clock, configured once at startup and read everywhere times are interpreted
+var settings = struct {
+	sync.RWMutex
+	location *time.Location
+	dayStart time.Duration
+}{dayStart: 3 * time.Hour}
+
+func init() {
+	location, err := time.LoadLocation(DefaultTimezone)
+	if err != nil {
+		location = time.FixedZone("ICT", 7*60*60)
+	}
+	settings.location = location
+}
+
+// Configure sets the agency timezone and the local time at which a service day begins.
+// Trips departing after midnight but before dayStart belong to the previous service date
+func Configure(location *time.Location, dayStart time.Duration) {
+	settings.Lock()
+	defer settings.Unlock()
+	settings.location = location
+	settings.dayStart = dayStart
+}
+
+// Location returns the agency timezone
+func Location() *time.Location {
+	settings.RLock()
+	defer settings.RUnlock()
+	return settings.location
+}
+
+// DayStart returns the local time at which a service day begins
+func DayStart() time.Duration {
+	settings.RLock()
+	defer settings.RUnlock()
+	return settings.dayStart
+}
+
+// In returns t in the agency timezone, leaving the zero time alone
+func In(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.In(Location())
+}
+
+// Now returns the current time in the agency timezone
+func Now() time.Time {
+	return time.Now().In(Location())
+}
+
+// StartOfDay returns midnight at the start of t's calendar day in the agency timezone
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.In(Location()).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, Location())
+}
+
+// ServiceDate returns the service date t belongs to, as midnight in the agency timezone
+func ServiceDate(t time.Time) time.Time {
+	return StartOfDay(t.In(Location()).Add(-DayStart()))
+}
+
+// DayBounds returns the span [from, to) of instants that belong to a service date
+func DayBounds(serviceDate time.Time) (time.Time, time.Time) {
+	return origin(serviceDate).Add(DayStart()), origin(serviceDate.AddDate(0, 0, 1)).Add(DayStart())
+}
+
+// ParseDate reads a YYYY-MM-DD date as midnight in the agency timezone
+func ParseDate(value string) (time.Time, error) {
+	date, err := time.ParseInLocation(DateLayout, strings.TrimSpace(value), Location())
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+	return date, nil
+}
+
+// ParseTime reads an RFC 3339 time, or a local date and time without an offset
+// (YYYY-MM-DD, YYYY-MM-DDTHH:MM or YYYY-MM-DDTHH:MM:SS) in the agency timezone
+func ParseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", DateLayout} {
+		if t, err := time.ParseInLocation(layout, value, Location()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrInvalidTime
+}
+
+// origin is the instant local times on a service date are measured from: noon minus 12 hours,
+// which is midnight except on days a daylight saving change moves the clocks
+func origin(serviceDate time.Time) time.Time {
+	year, month, day := serviceDate.Date()
+	return time.Date(year, month, day, 12, 0, 0, 0, Location()).Add(-12 * time.Hour)
+}
+
+// Combine returns the instant a local time falls on for a service date.
+// Local times may exceed 24:00 for trips that run past midnight
+func Combine(serviceDate time.Time, localTime string) (time.Time, error) {
+	offset, err := ParseLocalTime(localTime)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return origin(serviceDate).Add(offset).In(Location()), nil
+}
+
+// Offset returns how far t is from the start of serviceDate
+func Offset(serviceDate, t time.Time) time.Duration {
+	return t.Sub(origin(serviceDate))
+}
+
+// LocalTime writes t as a local time on serviceDate, e.g. "25:10" for 01:10 the next morning
+func LocalTime(serviceDate, t time.Time) string {
+	return FormatLocalTime(Offset(serviceDate, t))
+}
+
+// ParseLocalTime reads HH:MM or HH:MM:SS as the time since the start of the service date
+func ParseLocalTime(value string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, ErrInvalidLocalTime
+	}
+
+	var fields [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || part == "" || (i > 0 && (len(part) != 2 || n > 59)) {
+			return 0, ErrInvalidLocalTime
+		}
+		fields[i] = n
+	}
+
+	offset := time.Duration(fields[0])*time.Hour + time.Duration(fields[1])*time.Minute + time.Duration(fields[2])*time.Second
+	if offset >= MaxLocalTime {
+		return 0, ErrInvalidLocalTime
+	}
+	return offset, nil
+}
+
+// FormatLocalTime writes the time since the start of a service date as HH:MM, adding seconds only when set
+func FormatLocalTime(offset time.Duration) string {
+	negative := offset < 0
+	if negative {
+		offset = -offset
+	}
+	hours := int(offset / time.Hour)
+	minutes := int(offset % time.Hour / time.Minute)
+	seconds := int(offset % time.Minute / time.Second)
+
+	sign := ""
+	if negative {
+		sign = "-"
+	}
+	if seconds != 0 {
+		return fmt.Sprintf("%s%02d:%02d:%02d", sign, hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%s%02d:%02d", sign, hours, minutes)
+}
@@ -0,0 +1,263 @@
+// Package thai provides the Thai text handling used by search: word segmentation,
+// romanization close to the Royal Thai General System (RTGS), and normalization
+package thai
+
+import "strings"
+
+// initials maps consonants to their RTGS spelling at the start of a syllable
+var initials = map[rune]string{
+	'ก': "k", 'ข': "kh", 'ฃ': "kh", 'ค': "kh", 'ฅ': "kh", 'ฆ': "kh", 'ง': "ng",
+	'จ': "ch", 'ฉ': "ch", 'ช': "ch", 'ซ': "s", 'ฌ': "ch", 'ญ': "y",
+	'ฎ': "d", 'ฏ': "t", 'ฐ': "th", 'ฑ': "th", 'ฒ': "th", 'ณ': "n",
+	'ด': "d", 'ต': "t", 'ถ': "th", 'ท': "th", 'ธ': "th", 'น': "n",
+	'บ': "b", 'ป': "p", 'ผ': "ph", 'ฝ': "f", 'พ': "ph", 'ฟ': "f", 'ภ': "ph", 'ม': "m",
+	'ย': "y", 'ร': "r", 'ล': "l", 'ว': "w", 'ศ': "s", 'ษ': "s", 'ส': "s",
+	'ห': "h", 'ฬ': "l", 'อ': "", 'ฮ': "h",
+}
+
+// finals maps consonants to their RTGS spelling at the end of a syllable
+var finals = map[rune]string{
+	'ก': "k", 'ข': "k", 'ค': "k", 'ฆ': "k", 'ง': "ng",
+	'จ': "t", 'ช': "t", 'ซ': "t", 'ฌ': "t", 'ญ': "n",
+	'ฎ': "t", 'ฏ': "t", 'ฐ': "t", 'ฑ': "t", 'ฒ': "t", 'ณ': "n",
+	'ด': "t", 'ต': "t", 'ถ': "t", 'ท': "t", 'ธ': "t", 'น': "n",
+	'บ': "p", 'ป': "p", 'พ': "p", 'ฟ': "p", 'ภ': "p", 'ม': "m",
+	'ย': "i", 'ร': "n", 'ล': "n", 'ว': "o", 'ศ': "t", 'ษ': "t", 'ส': "t", 'ฬ': "n",
+}
+
+const thanthakhat = '์'
+
+func isConsonant(r rune) bool    { return r >= 'ก' && r <= 'ฮ' && r != 'ฤ' && r != 'ฦ' }
+func isLeadingVowel(r rune) bool { return r >= 'เ' && r <= 'ไ' }
+func isThaiDigit(r rune) bool    { return r >= '๐' && r <= '๙' }
+
+// isVowelMark reports vowels written after, above or below a consonant
+func isVowelMark(r rune) bool {
+	switch r {
+	case 'ะ', 'ั', 'า', 'ำ', 'ิ', 'ี', 'ึ', 'ื', 'ุ', 'ู', '็':
+		return true
+	}
+	return false
+}
+
+// isThai reports whether r is in the Thai block
+func isThai(r rune) bool { return r >= 0x0E00 && r <= 0x0E7F }
+
+// Romanize spells Thai text in Latin letters following the RTGS rules that matter for matching
+// place names. Tone marks are dropped and syllables are written without spaces, so
+// "เชียงใหม่" becomes "chiangmai". Non-Thai text is lowercased and kept
+func Romanize(text string) string {
+	var b strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if !isThai(runes[i]) {
+			b.WriteString(strings.ToLower(string(runes[i])))
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && isThai(runes[i]) {
+			i++
+		}
+		b.WriteString(romanizeRun(runes[start:i]))
+	}
+	return b.String()
+}
+
+// romanizeRun romanizes a run of Thai characters syllable by syllable
+func romanizeRun(run []rune) string {
+	rs := clean(run)
+	n := len(rs)
+	var b strings.Builder
+
+	for i := 0; i < n; {
+		r := rs[i]
+		switch {
+		case isThaiDigit(r):
+			b.WriteRune('0' + (r - '๐'))
+			i++
+			continue
+		case r == 'ฤ' || r == 'ฦ':
+			if r == 'ฤ' {
+				b.WriteString("rue")
+			} else {
+				b.WriteString("lue")
+			}
+			i++
+			if i < n && rs[i] == 'ๅ' {
+				i++
+			}
+			continue
+		}
+
+		var lead rune
+		if isLeadingVowel(r) {
+			lead = r
+			i++
+		}
+		if i >= n || !isConsonant(rs[i]) {
+			i++
+			continue
+		}
+
+		initial, next := readInitial(rs, i, lead != 0)
+		i = next
+		vowel, next, hasVowel := readVowel(rs, i, lead)
+		i = next
+
+		final := ""
+		if i < n && isConsonant(rs[i]) && !startsSyllable(rs, i) {
+			final = finals[rs[i]]
+			i++
+			// A trailing ร after a final consonant is silent, as in จักร
+			if i < n && rs[i] == 'ร' && !startsSyllable(rs, i) && (i+1 == n || !isConsonant(rs[i+1])) {
+				i++
+			}
+		}
+
+		if !hasVowel {
+			// Unwritten vowels: "o" in closed syllables, "a" in open ones
+			if final != "" {
+				vowel = "o"
+			} else {
+				vowel = "a"
+			}
+		}
+		if strings.HasSuffix(vowel, "i") && final == "i" {
+			final = ""
+		}
+
+		b.WriteString(initial + vowel + final)
+	}
+	return b.String()
+}
+
+// clean drops tone marks and silent letters, which do not change the romanized spelling
+func clean(run []rune) []rune {
+	out := make([]rune, 0, len(run))
+	for _, r := range run {
+		switch {
+		case r >= '่' && r <= '๋', r == 'ํ', r == 'ฯ', r == 'ๆ':
+			continue
+		case r == thanthakhat:
+			// The letter carrying the thanthakhat is silent
+			if len(out) > 0 {
+				out = out[:len(out)-1]
+			}
+			continue
+		default:
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// startsSyllable reports whether the consonant at j begins a new syllable rather than closing the current one
+func startsSyllable(rs []rune, j int) bool {
+	if j+1 >= len(rs) {
+		return false
+	}
+	next := rs[j+1]
+	return isVowelMark(next) || (next == 'อ' && (j+2 >= len(rs) || !isVowelMark(rs[j+2])))
+}
+
+// readInitial reads the initial consonant of a syllable, including clusters such as "กร" and
+// silent leading letters such as the ห in "หมอ"
+func readInitial(rs []rune, i int, hasLead bool) (string, int) {
+	c := rs[i]
+	if i+1 < len(rs) {
+		second := rs[i+1]
+		vowelFollows := i+2 < len(rs) && (isVowelMark(rs[i+2]) || rs[i+2] == 'อ')
+
+		switch {
+		case c == 'ห' && strings.ContainsRune("งญนมยรลว", second) && (hasLead || vowelFollows):
+			return initials[second], i + 2
+		case c == 'อ' && second == 'ย' && (hasLead || vowelFollows):
+			return "y", i + 2
+		case strings.ContainsRune("กขคตปผพบดท", c) && strings.ContainsRune("รลว", second) && vowelFollows:
+			return initials[c] + initials[second], i + 2
+		}
+	}
+	return initials[c], i + 1
+}
+
+// readVowel reads the vowel after the initial consonant, combined with any leading vowel
+func readVowel(rs []rune, i int, lead rune) (string, int, bool) {
+	at := func(k int) rune {
+		if k < len(rs) {
+			return rs[k]
+		}
+		return 0
+	}
+
+	switch lead {
+	case 'เ':
+		switch {
+		case at(i) == '็':
+			return "e", i + 1, true
+		case at(i) == 'ี' && at(i+1) == 'ย':
+			if at(i+2) == 'ะ' {
+				return "ia", i + 3, true
+			}
+			return "ia", i + 2, true
+		case at(i) == 'ื' && at(i+1) == 'อ':
+			return "uea", i + 2, true
+		case at(i) == 'ิ':
+			return "oe", i + 1, true
+		case at(i) == 'า' && at(i+1) == 'ะ':
+			return "o", i + 2, true
+		case at(i) == 'า':
+			return "ao", i + 1, true
+		case at(i) == 'อ':
+			return "oe", i + 1, true
+		case at(i) == 'ะ':
+			return "e", i + 1, true
+		}
+		return "e", i, true
+	case 'แ':
+		if at(i) == '็' || at(i) == 'ะ' {
+			return "ae", i + 1, true
+		}
+		return "ae", i, true
+	case 'โ':
+		if at(i) == 'ะ' {
+			return "o", i + 1, true
+		}
+		return "o", i, true
+	case 'ใ', 'ไ':
+		return "ai", i, true
+	}
+
+	switch at(i) {
+	case 'ั':
+		if at(i+1) == 'ว' {
+			return "ua", i + 2, true
+		}
+		return "a", i + 1, true
+	case 'ะ', 'า':
+		return "a", i + 1, true
+	case 'ำ':
+		return "am", i + 1, true
+	case 'ิ', 'ี':
+		return "i", i + 1, true
+	case 'ึ':
+		return "ue", i + 1, true
+	case 'ื':
+		if at(i+1) == 'อ' {
+			return "ue", i + 2, true
+		}
+		return "ue", i + 1, true
+	case 'ุ', 'ู':
+		return "u", i + 1, true
+	case 'อ':
+		if !startsSyllable(rs, i) {
+			return "o", i + 1, true
+		}
+	case 'ว':
+		// ว between two consonants is the vowel "ua", as in สวน
+		if isConsonant(at(i+1)) && !startsSyllable(rs, i+1) {
+			return "ua", i + 1, true
+		}
+	}
+	return "", i, false
+}
@@ -0,0 +1,133 @@
+package thai
+
+import (
+	"strings"
+	"unicode"
+)
+
+// dictionary holds common words in station names and Thai addresses. Thai is written without
+// spaces, so these are used to split names into words before romanizing them
+var dictionary = []string{
+	"สถานี", "ขนส่ง", "ผู้โดยสาร", "ถนน", "แขวง", "เขต", "ตำบล", "อำเภอ", "จังหวัด",
+	"กรุงเทพมหานคร", "กรุงเทพฯ", "กรุงเทพ", "ซอย", "หมู่บ้าน", "ตลาด", "วัด", "สนามบิน",
+	"อนุสาวรีย์", "ท่ารถ", "ป้าย", "หน้า", "แยก", "สาย", "ใหม่", "เก่า", "ใต้", "เหนือ",
+}
+
+// stopWords are words too common in station names and addresses to tell stations apart
+var stopWords = map[string]bool{
+	"สถานี": true, "ขนส่ง": true, "ผู้โดยสาร": true, "ถนน": true, "แขวง": true, "เขต": true,
+	"ตำบล": true, "อำเภอ": true, "จังหวัด": true, "ซอย": true,
+	"station": true, "terminal": true, "bus": true, "road": true, "rd": true,
+}
+
+var maxWordLength = func() int {
+	longest := 0
+	for _, word := range dictionary {
+		if n := len([]rune(word)); n > longest {
+			longest = n
+		}
+	}
+	return longest
+}()
+
+// Segment splits text into words. Latin text splits on spaces and punctuation; Thai runs are
+// split by longest match against the dictionary, with unknown stretches kept as one word
+func Segment(text string) []string {
+	var words []string
+	runes := []rune(text)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case isThai(r) && r != 'ฯ':
+			start := i
+			for i < len(runes) && isThai(runes[i]) {
+				i++
+			}
+			words = append(words, segmentThai(runes[start:i])...)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && !isThai(runes[i]) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			words = append(words, strings.ToLower(string(runes[start:i])))
+		default:
+			i++
+		}
+	}
+	return words
+}
+
+func segmentThai(run []rune) []string {
+	var words []string
+	unknown := 0 // start of the pending stretch not matched by the dictionary
+
+	for i := 0; i < len(run); {
+		match := longestMatch(run[i:])
+		if match == 0 {
+			i++
+			continue
+		}
+		if unknown < i {
+			words = append(words, string(run[unknown:i]))
+		}
+		words = append(words, string(run[i:i+match]))
+		i += match
+		unknown = i
+	}
+	if unknown < len(run) {
+		words = append(words, string(run[unknown:]))
+	}
+	return words
+}
+
+func longestMatch(run []rune) int {
+	limit := maxWordLength
+	if len(run) < limit {
+		limit = len(run)
+	}
+	for n := limit; n > 0; n-- {
+		candidate := string(run[:n])
+		for _, word := range dictionary {
+			if word == candidate {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// Words segments text, drops stop words and returns the remaining words romanized
+func Words(text string) []string {
+	var words []string
+	for _, word := range Segment(text) {
+		if stopWords[word] {
+			continue
+		}
+		if romanized := strings.TrimSpace(Romanize(word)); romanized != "" && !stopWords[romanized] {
+			words = append(words, romanized)
+		}
+	}
+	return words
+}
+
+// SearchKey builds the text stored for trigram matching. Each text contributes its romanized
+// words and the same words run together, so "chiang mai", "chiangmai" and "เชียงใหม่" all match
+func SearchKey(texts ...string) string {
+	var parts []string
+	for _, text := range texts {
+		words := Words(text)
+		parts = append(parts, words...)
+		if len(words) > 1 {
+			parts = append(parts, strings.Join(words, ""))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// Query returns a search query romanized and normalized like a search key, both as
+// space-separated words and as the words run together
+func Query(text string) (words string, compact string) {
+	romanized := Words(text)
+	return strings.Join(romanized, " "), strings.Join(romanized, "")
+}